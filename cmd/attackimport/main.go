@@ -0,0 +1,50 @@
+// attackimport — загрузка версии MITRE ATT&CK Enterprise (STIX 2.1, enterprise-attack.json).
+//
+// Каждая версия сохраняется отдельно, ранее загруженные версии и сопоставления не изменяются:
+//
+//	go run ./cmd/attackimport -file enterprise-attack.json
+//	go run ./cmd/attackimport -file enterprise-attack-14.1.json -version 14.1
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"ib-integrator/internal/attack"
+	"ib-integrator/internal/database"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	_ = godotenv.Load()
+
+	file := flag.String("file", "", "путь к enterprise-attack.json")
+	version := flag.String("version", "", "версия ATT&CK (по умолчанию — из x-mitre-collection бандла)")
+	dsn := flag.String("dsn", os.Getenv("DB_DSN"), "строка подключения к БД (по умолчанию DB_DSN)")
+	flag.Parse()
+
+	if *file == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *dsn == "" {
+		log.Fatal("DB_DSN is not set")
+	}
+
+	bundle, err := attack.ParseFile(*file)
+	if err != nil {
+		log.Fatalf("failed to parse %s: %v", *file, err)
+	}
+	log.Printf("parsed %d techniques from %s (version %q)", len(bundle.Techniques), *file, bundle.Version)
+
+	database.Init(*dsn)
+
+	release, err := attack.Import(database.DB, bundle, *version)
+	if err != nil {
+		log.Fatalf("failed to import: %v", err)
+	}
+	fmt.Printf("Загружена версия ATT&CK %s: техник %d\n", release.Version, len(bundle.Techniques))
+}
@@ -0,0 +1,81 @@
+// bduimport — импорт перечня угроз БДУ ФСТЭК (thrlist.xlsx / XML) в каталог угроз.
+//
+// По умолчанию только показывает разницу с каталогом; изменения пишутся с флагом -apply:
+//
+//	go run ./cmd/bduimport -file thrlist.xlsx
+//	go run ./cmd/bduimport -file thrlist.xlsx -apply
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"ib-integrator/internal/bdu"
+	"ib-integrator/internal/database"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	_ = godotenv.Load()
+
+	file := flag.String("file", "", "путь к thrlist.xlsx или XML-выгрузке БДУ")
+	dsn := flag.String("dsn", os.Getenv("DB_DSN"), "строка подключения к БД (по умолчанию DB_DSN)")
+	apply := flag.Bool("apply", false, "применить изменения (без флага — только показать разницу)")
+	flag.Parse()
+
+	if *file == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *dsn == "" {
+		log.Fatal("DB_DSN is not set")
+	}
+
+	records, err := bdu.ParseThreatsFile(*file)
+	if err != nil {
+		log.Fatalf("failed to parse %s: %v", *file, err)
+	}
+	log.Printf("parsed %d threats from %s", len(records), *file)
+
+	database.Init(*dsn)
+
+	plan, err := bdu.BuildPlan(database.DB, records)
+	if err != nil {
+		log.Fatalf("failed to compare with catalogue: %v", err)
+	}
+
+	printPlan(plan)
+
+	if plan.Empty() {
+		fmt.Println("Каталог уже соответствует выгрузке.")
+		return
+	}
+	if !*apply {
+		fmt.Println("Изменения не применены. Для записи запустите с флагом -apply.")
+		return
+	}
+
+	if err := bdu.ApplyPlan(database.DB, plan); err != nil {
+		log.Fatalf("failed to apply import: %v", err)
+	}
+	fmt.Println("Импорт применён: " + plan.Summary())
+}
+
+func printPlan(plan *bdu.Plan) {
+	for _, r := range plan.Added {
+		fmt.Printf("+ %s %s\n", r.Code, r.Name)
+	}
+	for _, ch := range plan.Changed {
+		fmt.Printf("~ %s %s\n", ch.Record.Code, ch.Record.Name)
+		for _, f := range ch.Changes {
+			fmt.Printf("    %s: %q -> %q\n", f.Field, f.Old, f.New)
+		}
+	}
+	for _, w := range plan.Withdrawn {
+		fmt.Printf("- %s %s (%s)\n", w.Threat.Code, w.Threat.Name, w.Reason)
+	}
+	fmt.Println("Итого: " + plan.Summary())
+}
@@ -0,0 +1,56 @@
+// certregimport — загрузка выгрузки государственного реестра сертифицированных средств защиты
+// информации ФСТЭК России (.xlsx или .csv) и сверка с ним сертификатов СЗИ из каталога:
+//
+//	go run ./cmd/certregimport -file reestr_sszi.xlsx
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"ib-integrator/internal/certreg"
+	"ib-integrator/internal/database"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	_ = godotenv.Load()
+
+	file := flag.String("file", "", "путь к выгрузке реестра (.xlsx или .csv)")
+	dsn := flag.String("dsn", os.Getenv("DB_DSN"), "строка подключения к БД (по умолчанию DB_DSN)")
+	flag.Parse()
+
+	if *file == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *dsn == "" {
+		log.Fatal("DB_DSN is not set")
+	}
+
+	database.Init(*dsn)
+
+	entries, err := certreg.ParseFile(*file)
+	if err != nil {
+		log.Fatalf("failed to parse %s: %v", *file, err)
+	}
+	log.Printf("parsed %d certificates from %s", len(entries), *file)
+
+	res, err := certreg.Import(database.DB, entries, time.Now())
+	if err != nil {
+		log.Fatalf("failed to import %s: %v", *file, err)
+	}
+	for _, ch := range res.Changes {
+		fmt.Printf("сертификат № %s (%s): %s → %s, установок: %d\n",
+			ch.Tool.CertificateNumber, ch.Tool.Title(), ch.Old.Label(), ch.New.Label(), ch.Installations)
+	}
+	for _, number := range res.Missing {
+		log.Printf("warning: сертификат № %s из каталога СЗИ не найден в реестре", number)
+	}
+	fmt.Printf("%s: добавлено %d, обновлено %d, без изменений %d; СЗИ сверено %d, новых уведомлений %d\n",
+		*file, res.Added, res.Updated, res.Unchanged, res.ToolsChecked, res.Alerts)
+}
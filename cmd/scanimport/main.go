@@ -0,0 +1,120 @@
+// scanimport — загрузка отчёта сканера в инвентаризацию узлов клиента.
+//
+// Без -apply только показывает, что будет записано:
+//
+//	go run ./cmd/scanimport -client 3 -file nmap.xml
+//	go run ./cmd/scanimport -client 3 -asset 12 -file openvas.xml -apply -user admin@ib.local
+//	go run ./cmd/scanimport -client 3 -file redcheck.csv -mapping redcheck -apply -user admin@ib.local
+//
+// Для CSV другого сканера -mapping принимает путь к JSON-файлу сопоставления колонок.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"ib-integrator/internal/database"
+	"ib-integrator/internal/models"
+	"ib-integrator/internal/scan"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	_ = godotenv.Load()
+
+	file := flag.String("file", "", "отчёт Nmap / OpenVAS (.xml) или выгрузка сканера (.csv)")
+	clientID := flag.Uint("client", 0, "ID клиента")
+	assetID := flag.Uint("asset", 0, "ID объекта защиты для новых и несопоставленных узлов")
+	mappingName := flag.String("mapping", "", "сопоставление колонок CSV: имя встроенного или путь к .json")
+	apply := flag.Bool("apply", false, "записать изменения (по умолчанию — только предпросмотр)")
+	username := flag.String("user", "", "пользователь, от имени которого импорт попадёт в журнал аудита")
+	dsn := flag.String("dsn", os.Getenv("DB_DSN"), "строка подключения к БД (по умолчанию DB_DSN)")
+	flag.Parse()
+
+	if *file == "" || *clientID == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *dsn == "" {
+		log.Fatal("DB_DSN is not set")
+	}
+
+	var mapping *scan.CSVMapping
+	if *mappingName != "" {
+		var m scan.CSVMapping
+		var err error
+		if strings.HasSuffix(strings.ToLower(*mappingName), ".json") {
+			m, err = scan.LoadCSVMapping(*mappingName)
+		} else if found, ok := scan.CSVMappingByName(*mappingName); ok {
+			m = found
+		} else {
+			err = fmt.Errorf("unknown mapping %q", *mappingName)
+		}
+		if err != nil {
+			log.Fatalf("failed to load mapping: %v", err)
+		}
+		mapping = &m
+	}
+
+	report, err := scan.ParseFile(*file, mapping)
+	if err != nil {
+		log.Fatalf("failed to parse %s: %v", *file, err)
+	}
+
+	database.Init(*dsn)
+
+	var client models.Client
+	if err := database.DB.First(&client, *clientID).Error; err != nil {
+		log.Fatalf("client %d not found", *clientID)
+	}
+
+	var user models.User
+	if *apply {
+		if *username == "" {
+			log.Fatal("-user is required with -apply")
+		}
+		if err := database.DB.Where("username = ?", *username).First(&user).Error; err != nil {
+			log.Fatalf("user %s not found", *username)
+		}
+	}
+
+	var asset *uint
+	if *assetID != 0 {
+		id := *assetID
+		asset = &id
+	}
+	plan, err := scan.BuildPlan(database.DB, report, client.ID, asset)
+	if err != nil {
+		log.Fatalf("failed to build plan: %v", err)
+	}
+
+	for _, h := range plan.Hosts {
+		state := "обновление"
+		if h.New() {
+			state = "новый"
+		}
+		target := "без объекта"
+		if h.Matched() {
+			target = h.AssetName
+		}
+		fmt.Printf("%-16s %-10s %-30s портов %d, находок %d (новых %d), в реестр %d\n",
+			h.Record.Address, state, target, len(h.Record.Services), len(h.Findings), h.NewFindings(), len(h.Register))
+	}
+	fmt.Printf("%s: %s\n", *file, plan.Summary())
+
+	if !*apply {
+		fmt.Println("предпросмотр: изменения не записаны, для записи добавьте -apply")
+		return
+	}
+
+	if err := scan.Apply(database.DB, plan); err != nil {
+		log.Fatalf("failed to apply: %v", err)
+	}
+	database.CreateAuditLog(user.ID, "client", client.ID, "scan_import",
+		fmt.Sprintf("Импорт отчёта сканера (%s): %s", report.Format, plan.Summary()))
+	fmt.Println("изменения записаны")
+}
@@ -1,23 +1,41 @@
-package main
-
-import (
-	"fmt"
-	"log"
-
-	"ib-integrator/internal/config"
-	"ib-integrator/internal/database"
-	"ib-integrator/internal/server"
-)
-
-func main() {
-	cfg := config.Load()
-	database.Init(cfg.DBDSN)
-
-	r := server.NewRouter(cfg)
-
-	addr := fmt.Sprintf(":%s", cfg.ServerPort)
-	log.Printf("starting server on %s", addr)
-	if err := r.Run(addr); err != nil {
-		log.Fatalf("server error: %v", err)
-	}
-}
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"ib-integrator/internal/config"
+	"ib-integrator/internal/database"
+	"ib-integrator/internal/lockout"
+	"ib-integrator/internal/passpolicy"
+	"ib-integrator/internal/server"
+)
+
+func main() {
+	cfg := config.Load()
+	database.Init(cfg.DBDSN)
+
+	if err := passpolicy.Configure(passpolicy.Policy{
+		MinLength:    cfg.PasswordMinLength,
+		MinClasses:   cfg.PasswordMinClasses,
+		History:      cfg.PasswordHistory,
+		BreachedFile: cfg.PasswordBreachedFile,
+	}); err != nil {
+		log.Fatalf("failed to load password policy: %v", err)
+	}
+	lockout.Configure(lockout.Policy{
+		MaxFailures:   cfg.LoginMaxFailures,
+		IPMaxFailures: cfg.LoginIPMaxFailures,
+		Window:        cfg.LoginFailureWindow,
+		Base:          cfg.LoginLockout,
+		Max:           cfg.LoginLockoutMax,
+	})
+
+	r := server.NewRouter(cfg)
+
+	addr := fmt.Sprintf(":%s", cfg.ServerPort)
+	log.Printf("starting server on %s", addr)
+	if err := r.Run(addr); err != nil {
+		log.Fatalf("server error: %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+// vulnimport — загрузка уязвимостей в каталог: vulnlist БДУ ФСТЭК (.xlsx / XML) или фиды NVD JSON 2.0.
+//
+// Фиды NVD разбиты по годам, файлов можно передать несколько:
+//
+//	go run ./cmd/vulnimport -file vulnlist.xlsx
+//	go run ./cmd/vulnimport -file nvdcve-2.0-2023.json -file nvdcve-2.0-2024.json
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"ib-integrator/internal/database"
+	"ib-integrator/internal/vuln"
+
+	"github.com/joho/godotenv"
+)
+
+type fileList []string
+
+func (f *fileList) String() string { return strings.Join(*f, ", ") }
+
+func (f *fileList) Set(s string) error {
+	*f = append(*f, s)
+	return nil
+}
+
+func main() {
+	_ = godotenv.Load()
+
+	var files fileList
+	flag.Var(&files, "file", "путь к vulnlist.xlsx, XML-выгрузке БДУ или фиду NVD .json (можно несколько)")
+	dsn := flag.String("dsn", os.Getenv("DB_DSN"), "строка подключения к БД (по умолчанию DB_DSN)")
+	flag.Parse()
+
+	if len(files) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *dsn == "" {
+		log.Fatal("DB_DSN is not set")
+	}
+
+	database.Init(*dsn)
+
+	for _, file := range files {
+		records, err := vuln.ParseFile(file)
+		if err != nil {
+			log.Fatalf("failed to parse %s: %v", file, err)
+		}
+		log.Printf("parsed %d vulnerabilities from %s", len(records), file)
+
+		res, err := vuln.Import(database.DB, records)
+		if err != nil {
+			log.Fatalf("failed to import %s: %v", file, err)
+		}
+		for _, w := range res.Warnings {
+			log.Printf("warning: %s", w)
+		}
+		fmt.Printf("%s: добавлено %d, обновлено %d, без изменений %d\n", file, res.Added, res.Updated, res.Unchanged)
+	}
+}
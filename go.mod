@@ -1,12 +1,37 @@
-module ib-integrator
-
-go 1.23
-
-require (
-	github.com/gin-contrib/sessions v0.0.5
-	github.com/gin-gonic/gin v1.10.0
-	github.com/joho/godotenv v1.5.1
-	golang.org/x/crypto v0.30.0
-	gorm.io/driver/postgres v1.5.7
-	gorm.io/gorm v1.25.7
-)
+module ib-integrator
+
+go 1.23
+
+require (
+	github.com/gin-contrib/sessions v0.0.5
+	github.com/gin-gonic/gin v1.10.0
+	github.com/joho/godotenv v1.5.1
+	golang.org/x/crypto v0.30.0
+	gorm.io/driver/postgres v1.5.7
+	gorm.io/gorm v1.25.7
+)
+
+require (
+	github.com/gabriel-vasile/mimetype v1.4.3 // indirect
+	github.com/gin-contrib/sse v0.1.0 // indirect
+	github.com/go-playground/locales v0.14.1 // indirect
+	github.com/go-playground/universal-translator v0.18.1 // indirect
+	github.com/go-playground/validator/v10 v10.20.0 // indirect
+	github.com/gorilla/context v1.1.1 // indirect
+	github.com/gorilla/securecookie v1.1.1 // indirect
+	github.com/gorilla/sessions v1.2.1 // indirect
+	github.com/jackc/pgpassfile v1.0.0 // indirect
+	github.com/jackc/pgservicefile v0.0.0-20221227161230-091c0ba34f0a // indirect
+	github.com/jackc/pgx/v5 v5.4.3 // indirect
+	github.com/jinzhu/inflection v1.0.0 // indirect
+	github.com/jinzhu/now v1.1.5 // indirect
+	github.com/leodido/go-urn v1.4.0 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/pelletier/go-toml/v2 v2.2.2 // indirect
+	github.com/ugorji/go/codec v1.2.12 // indirect
+	golang.org/x/net v0.25.0 // indirect
+	golang.org/x/sys v0.28.0 // indirect
+	golang.org/x/text v0.21.0 // indirect
+	google.golang.org/protobuf v1.34.1 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
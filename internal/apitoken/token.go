@@ -0,0 +1,104 @@
+// Package apitoken выпускает и проверяет токены доступа к API.
+// Токен — случайная строка с префиксом "ibi_"; в базе хранится только её SHA-256,
+// поэтому утечка базы не даёт доступа к API.
+package apitoken
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"ib-integrator/internal/database"
+	"ib-integrator/internal/models"
+)
+
+const (
+	tokenPrefix = "ibi_"
+	prefixLen   = 12 // сколько первых символов токена показывать в списках
+)
+
+var (
+	ErrInvalid = errors.New("токен недействителен")
+	ErrExpired = errors.New("срок действия токена истёк")
+	ErrRevoked = errors.New("токен отозван")
+)
+
+// Generate — новый токен и данные для хранения: начало токена и его хеш
+func Generate() (plain, prefix, hash string, err error) {
+	buf := make([]byte, 32)
+	if _, err = rand.Read(buf); err != nil {
+		return "", "", "", err
+	}
+	plain = tokenPrefix + base64.RawURLEncoding.EncodeToString(buf)
+	return plain, plain[:prefixLen], Hash(plain), nil
+}
+
+// Hash — SHA-256 токена в hex. Токен случайный и длинный, соль и bcrypt не нужны,
+// а быстрый хеш позволяет искать токен по индексу на каждом запросе.
+func Hash(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
+
+// Issue выпускает токен для пользователя и сохраняет его хеш. Возвращает сам токен —
+// больше его нигде не получить.
+func Issue(token *models.APIToken) (string, error) {
+	plain, prefix, hash, err := Generate()
+	if err != nil {
+		return "", err
+	}
+	token.Prefix = prefix
+	token.TokenHash = hash
+	if err := database.DB.Create(token).Error; err != nil {
+		return "", err
+	}
+	return plain, nil
+}
+
+// Authenticate находит действующий токен и его владельца, отмечает время и адрес использования.
+// Роль токена перепроверяется по текущей роли владельца: если владельца понизили,
+// выданные им токены с более высокой ролью перестают действовать; токены отключённого
+// пользователя не действуют вовсе.
+func Authenticate(plain, ip string) (models.APIToken, error) {
+	var token models.APIToken
+	if !strings.HasPrefix(plain, tokenPrefix) {
+		return token, ErrInvalid
+	}
+	if err := database.DB.Preload("User").Where("token_hash = ?", Hash(plain)).First(&token).Error; err != nil {
+		return token, ErrInvalid
+	}
+	if token.User.ID == 0 || !token.User.Active() || !models.CanDelegate(token.User.Role, token.Role) {
+		return token, ErrInvalid
+	}
+
+	now := time.Now()
+	switch {
+	case token.RevokedAt != nil:
+		return token, ErrRevoked
+	case token.Expired(now):
+		return token, ErrExpired
+	}
+
+	database.DB.Model(&token).UpdateColumns(map[string]interface{}{
+		"last_used_at": now,
+		"last_used_ip": ip,
+	})
+	token.LastUsedAt = &now
+	token.LastUsedIP = ip
+	return token, nil
+}
+
+// Revoke отзывает токен; повторный отзыв ничего не меняет
+func Revoke(token *models.APIToken, by uint) error {
+	if token.RevokedAt != nil {
+		return nil
+	}
+	now := time.Now()
+	token.RevokedAt = &now
+	token.RevokedByID = &by
+	return database.DB.Model(token).Select("RevokedAt", "RevokedByID").Updates(token).Error
+}
@@ -0,0 +1,79 @@
+package attack
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ib-integrator/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrReleaseExists — версия уже загружена; загруженные версии не перезаписываются
+var ErrReleaseExists = errors.New("эта версия ATT&CK уже загружена")
+
+// Import сохраняет бандл как новую версию ATT&CK. version переопределяет версию из бандла.
+// Ранее загруженные версии и сопоставления угроз с их техниками не изменяются.
+func Import(db *gorm.DB, b *Bundle, version string) (*models.AttackRelease, error) {
+	version = strings.TrimSpace(version)
+	if version == "" {
+		version = b.Version
+	}
+	if version == "" {
+		return nil, errors.New("в бандле не указана версия — задайте её явно")
+	}
+
+	var count int64
+	if err := db.Model(&models.AttackRelease{}).Where("version = ?", version).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrReleaseExists
+	}
+
+	release := &models.AttackRelease{
+		Version:    version,
+		Name:       b.Name,
+		ImportedAt: time.Now(),
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(release).Error; err != nil {
+			return err
+		}
+
+		techniques := make([]models.AttackTechnique, 0, len(b.Techniques))
+		for _, r := range b.Techniques {
+			techniques = append(techniques, models.AttackTechnique{
+				ReleaseID:      release.ID,
+				ExternalID:     r.ExternalID,
+				STIXID:         r.STIXID,
+				Name:           r.Name,
+				Tactics:        strings.Join(r.Tactics, ","),
+				Platforms:      strings.Join(r.Platforms, ", "),
+				Description:    r.Description,
+				IsSubtechnique: r.IsSubtechnique,
+				Deprecated:     r.Deprecated,
+			})
+		}
+		if err := tx.CreateInBatches(techniques, 200).Error; err != nil {
+			return fmt.Errorf("запись техник: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return release, nil
+}
+
+// LatestRelease — последняя загруженная версия; ok=false, если ATT&CK ещё не загружался
+func LatestRelease(db *gorm.DB) (models.AttackRelease, bool) {
+	var r models.AttackRelease
+	if err := db.Order("imported_at desc, id desc").First(&r).Error; err != nil {
+		return models.AttackRelease{}, false
+	}
+	return r, true
+}
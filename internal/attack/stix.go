@@ -0,0 +1,133 @@
+// Package attack — разбор бандла MITRE ATT&CK Enterprise в формате STIX 2.1
+// (enterprise-attack.json из github.com/mitre-attack/attack-stix-data) и загрузка версии в каталог.
+package attack
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// TechniqueRecord — техника из бандла
+type TechniqueRecord struct {
+	ExternalID     string
+	STIXID         string
+	Name           string
+	Description    string
+	Tactics        []string
+	Platforms      []string
+	IsSubtechnique bool
+	Deprecated     bool
+}
+
+// Bundle — результат разбора: версия коллекции и техники
+type Bundle struct {
+	Version    string
+	Name       string
+	Techniques []TechniqueRecord
+}
+
+type stixBundle struct {
+	Type    string            `json:"type"`
+	Objects []json.RawMessage `json:"objects"`
+}
+
+type stixObject struct {
+	Type         string   `json:"type"`
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	Description  string   `json:"description"`
+	Revoked      bool     `json:"revoked"`
+	Deprecated   bool     `json:"x_mitre_deprecated"`
+	Version      string   `json:"x_mitre_version"`
+	Subtechnique bool     `json:"x_mitre_is_subtechnique"`
+	Platforms    []string `json:"x_mitre_platforms"`
+
+	KillChain []struct {
+		KillChainName string `json:"kill_chain_name"`
+		PhaseName     string `json:"phase_name"`
+	} `json:"kill_chain_phases"`
+
+	ExternalRefs []struct {
+		SourceName string `json:"source_name"`
+		ExternalID string `json:"external_id"`
+	} `json:"external_references"`
+}
+
+func (o stixObject) attackID() string {
+	for _, r := range o.ExternalRefs {
+		if r.SourceName == "mitre-attack" {
+			return r.ExternalID
+		}
+	}
+	return ""
+}
+
+// ParseFile читает бандл с диска
+func ParseFile(filename string) (*Bundle, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Parse разбирает бандл. Версия берётся из объекта x-mitre-collection;
+// если его нет (старые выгрузки), Version остаётся пустой и задаётся при загрузке.
+func Parse(r io.Reader) (*Bundle, error) {
+	var raw stixBundle
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("некорректный JSON: %w", err)
+	}
+	if raw.Type != "bundle" {
+		return nil, errors.New("файл не является STIX-бандлом")
+	}
+
+	b := &Bundle{}
+	seen := make(map[string]bool)
+	for _, msg := range raw.Objects {
+		var o stixObject
+		if err := json.Unmarshal(msg, &o); err != nil {
+			return nil, fmt.Errorf("некорректный объект STIX: %w", err)
+		}
+
+		switch o.Type {
+		case "x-mitre-collection":
+			b.Version = o.Version
+			b.Name = o.Name
+		case "attack-pattern":
+			id := o.attackID()
+			if id == "" || seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			rec := TechniqueRecord{
+				ExternalID:     id,
+				STIXID:         o.ID,
+				Name:           o.Name,
+				Description:    o.Description,
+				Platforms:      o.Platforms,
+				IsSubtechnique: o.Subtechnique || strings.Contains(id, "."),
+				Deprecated:     o.Revoked || o.Deprecated,
+			}
+			for _, k := range o.KillChain {
+				if k.KillChainName == "mitre-attack" {
+					rec.Tactics = append(rec.Tactics, k.PhaseName)
+				}
+			}
+			b.Techniques = append(b.Techniques, rec)
+		}
+	}
+
+	if len(b.Techniques) == 0 {
+		return nil, errors.New("в бандле нет техник ATT&CK (attack-pattern)")
+	}
+	sort.Slice(b.Techniques, func(i, j int) bool { return b.Techniques[i].ExternalID < b.Techniques[j].ExternalID })
+	return b, nil
+}
@@ -0,0 +1,193 @@
+package bdu
+
+import (
+	"fmt"
+	"strings"
+
+	"ib-integrator/internal/catalog"
+	"ib-integrator/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// FieldChange — изменение одного поля угрозы
+type FieldChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// ThreatChange — угроза, которая уже есть в каталоге, но отличается от выгрузки
+type ThreatChange struct {
+	ThreatID uint
+	Record   ThreatRecord
+	Changes  []FieldChange
+	Draft    bool // у угрозы есть черновик: он будет перенесён на импортированную версию
+}
+
+// WithdrawnThreat — угроза каталога, которая больше не действует в БДУ
+type WithdrawnThreat struct {
+	Threat models.Threat
+	Reason string
+}
+
+// Plan — разница между каталогом и выгрузкой; показывается пользователю до применения
+type Plan struct {
+	Added     []ThreatRecord
+	Changed   []ThreatChange
+	Withdrawn []WithdrawnThreat
+	Unchanged int
+}
+
+func (p *Plan) Empty() bool {
+	return len(p.Added) == 0 && len(p.Changed) == 0 && len(p.Withdrawn) == 0
+}
+
+func (p *Plan) Summary() string {
+	return fmt.Sprintf("добавлено %d, изменено %d, исключено %d, без изменений %d",
+		len(p.Added), len(p.Changed), len(p.Withdrawn), p.Unchanged)
+}
+
+// BuildPlan сравнивает выгрузку с текущим каталогом угроз (по коду УБИ.xxx).
+// Повторный запуск на той же выгрузке даёт пустой план.
+func BuildPlan(db *gorm.DB, records []ThreatRecord) (*Plan, error) {
+	var existing []models.Threat
+	if err := db.Where("code LIKE ?", ThreatCodePrefix+"%").Find(&existing).Error; err != nil {
+		return nil, err
+	}
+
+	byCode := make(map[string]models.Threat, len(existing))
+	for _, t := range existing {
+		byCode[t.Code] = t
+	}
+
+	plan := &Plan{}
+	inFile := make(map[string]bool, len(records))
+
+	for _, r := range records {
+		inFile[r.Code] = true
+		cur, ok := byCode[r.Code]
+
+		switch {
+		case !ok && r.Withdrawn:
+			// исключённые угрозы, которых у нас не было, не заводим
+			continue
+		case !ok:
+			plan.Added = append(plan.Added, r)
+		case r.Withdrawn && !cur.Withdrawn:
+			plan.Withdrawn = append(plan.Withdrawn, WithdrawnThreat{Threat: cur, Reason: "помечена исключённой в БДУ"})
+		case r.Withdrawn:
+			plan.Unchanged++
+		default:
+			if changes := diffThreat(cur, r); len(changes) > 0 {
+				_, draft := catalog.ThreatDraft(db, cur.ID)
+				plan.Changed = append(plan.Changed, ThreatChange{ThreatID: cur.ID, Record: r, Changes: changes, Draft: draft})
+			} else {
+				plan.Unchanged++
+			}
+		}
+	}
+
+	for _, t := range existing {
+		if !inFile[t.Code] && !t.Withdrawn {
+			plan.Withdrawn = append(plan.Withdrawn, WithdrawnThreat{Threat: t, Reason: "отсутствует в выгрузке"})
+		}
+	}
+
+	return plan, nil
+}
+
+// ApplyPlan записывает план в каталог одной транзакцией.
+// Угрозы не удаляются: исключённые только помечаются, поэтому связи AssetThreat остаются валидными.
+func ApplyPlan(db *gorm.DB, plan *Plan) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, r := range plan.Added {
+			th := models.Threat{Code: r.Code, Category: ThreatCategory, Status: models.CatalogDraft}
+			fillThreat(&th, r)
+			if err := tx.Create(&th).Error; err != nil {
+				return fmt.Errorf("%s: %w", r.Code, err)
+			}
+			if err := catalog.PublishThreat(tx, &th, importVersion(th)); err != nil {
+				return fmt.Errorf("%s: %w", r.Code, err)
+			}
+		}
+
+		// изменённые угрозы получают новую версию, оценки объектов остаются на прежней;
+		// черновик инженера переносится на неё и сохраняет только собственные правки
+		for _, ch := range plan.Changed {
+			var th models.Threat
+			if err := tx.First(&th, ch.ThreatID).Error; err != nil {
+				return fmt.Errorf("%s: %w", ch.Record.Code, err)
+			}
+			next := th
+			fillThreat(&next, ch.Record)
+			th.Withdrawn = false
+			if err := catalog.PublishThreat(tx, &th, importVersion(next)); err != nil {
+				return fmt.Errorf("%s: %w", ch.Record.Code, err)
+			}
+		}
+
+		for _, w := range plan.Withdrawn {
+			if err := tx.Model(&models.Threat{}).
+				Where("id = ?", w.Threat.ID).
+				Update("withdrawn", true).Error; err != nil {
+				return fmt.Errorf("%s: %w", w.Threat.Code, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func importVersion(th models.Threat) models.ThreatVersion {
+	v := models.NewThreatVersion(th)
+	v.Comment = "Импорт БДУ ФСТЭК"
+	return v
+}
+
+func fillThreat(th *models.Threat, r ThreatRecord) {
+	th.Name = r.Name
+	th.Description = r.Description
+	th.Source = r.Source
+	th.ViolatorType = r.ViolatorType
+	th.Object = r.Object
+	th.Confidentiality = r.Confidentiality
+	th.Integrity = r.Integrity
+	th.Availability = r.Availability
+	th.Withdrawn = false
+}
+
+func diffThreat(cur models.Threat, r ThreatRecord) []FieldChange {
+	var changes []FieldChange
+	str := func(field, old, val string) {
+		if strings.TrimSpace(old) != val {
+			changes = append(changes, FieldChange{Field: field, Old: old, New: val})
+		}
+	}
+	flag := func(field string, old, val bool) {
+		if old != val {
+			changes = append(changes, FieldChange{Field: field, Old: yesNo(old), New: yesNo(val)})
+		}
+	}
+
+	str("Наименование", cur.Name, r.Name)
+	str("Описание", cur.Description, r.Description)
+	str("Источник угрозы", cur.Source, r.Source)
+	str("Тип нарушителя", cur.ViolatorType, r.ViolatorType)
+	str("Объект воздействия", cur.Object, r.Object)
+	flag("Конфиденциальность", cur.Confidentiality, r.Confidentiality)
+	flag("Целостность", cur.Integrity, r.Integrity)
+	flag("Доступность", cur.Availability, r.Availability)
+	if cur.Withdrawn {
+		changes = append(changes, FieldChange{Field: "Статус", Old: "исключена", New: "действует"})
+	}
+
+	return changes
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "да"
+	}
+	return "нет"
+}
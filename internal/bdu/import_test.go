@@ -0,0 +1,88 @@
+package bdu
+
+import (
+	"os"
+	"testing"
+
+	"ib-integrator/internal/catalog"
+	"ib-integrator/internal/models"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// testDB — транзакция в PostgreSQL из TEST_DB_DSN, откатываемая после теста; без DSN тест пропускается
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DB_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DB_DSN не задан")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := db.Begin()
+	t.Cleanup(func() { tx.Rollback() })
+	if err := tx.AutoMigrate(&models.User{}, &models.Threat{}, &models.ThreatVersion{}); err != nil {
+		t.Fatal(err)
+	}
+	return tx
+}
+
+// Черновик → импорт БДУ → публикация черновика: поля из БДУ не затираются,
+// правки инженера сохраняются
+func TestApplyPlanKeepsDraft(t *testing.T) {
+	db := testDB(t)
+
+	th := models.Threat{Code: ThreatCodePrefix + "999", Category: ThreatCategory, Status: models.CatalogDraft,
+		Name: "Угроза", Description: "Старое описание", Source: "Внешний нарушитель", Integrity: true}
+	if err := db.Create(&th).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := catalog.PublishThreat(db, &th, importVersion(th)); err != nil {
+		t.Fatal(err)
+	}
+
+	fields := models.NewThreatVersion(th)
+	fields.ViolatorType = "Внутренний нарушитель"
+	if _, err := catalog.SaveThreatDraft(db, th, fields, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := ThreatRecord{Code: th.Code, Name: th.Name, Description: "Новое описание", Source: th.Source, Integrity: true}
+	plan, err := BuildPlan(db, []ThreatRecord{rec})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Changed) != 1 || !plan.Changed[0].Draft {
+		t.Fatalf("в плане нет изменённой угрозы с черновиком: %+v", plan.Changed)
+	}
+	if err := ApplyPlan(db, plan); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.First(&th, th.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	draft, ok := catalog.ThreatDraft(db, th.ID)
+	if !ok {
+		t.Fatal("черновик потерян при импорте")
+	}
+	if draft.Version != th.Version+1 {
+		t.Errorf("черновик версии %d, действующая %d", draft.Version, th.Version)
+	}
+	if err := catalog.PublishThreat(db, &th, draft); err != nil {
+		t.Fatal(err)
+	}
+
+	if th.Description != "Новое описание" {
+		t.Errorf("описание из БДУ затёрто черновиком: %q", th.Description)
+	}
+	if th.ViolatorType != "Внутренний нарушитель" {
+		t.Errorf("правка черновика потеряна: %q", th.ViolatorType)
+	}
+	if th.Version != 3 {
+		t.Errorf("версия %d, ожидается 3", th.Version)
+	}
+}
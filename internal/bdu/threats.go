@@ -0,0 +1,250 @@
+// Package bdu — разбор выгрузок Банка данных угроз ФСТЭК России (bdu.fstec.ru).
+package bdu
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"ib-integrator/internal/xlsx"
+)
+
+// ThreatCategory — категория, под которой угрозы БДУ попадают в каталог
+const ThreatCategory = "УБИ"
+
+// ThreatCodePrefix — коды угроз БДУ в каталоге имеют вид УБИ.001
+const ThreatCodePrefix = "УБИ."
+
+// ThreatRecord — одна угроза из выгрузки thrlist
+type ThreatRecord struct {
+	Code            string
+	Name            string
+	Description     string
+	Source          string
+	ViolatorType    string
+	Object          string
+	Confidentiality bool
+	Integrity       bool
+	Availability    bool
+	Withdrawn       bool
+}
+
+// ParseThreatsFile разбирает thrlist.xlsx или XML-выгрузку с теми же полями
+func ParseThreatsFile(filename string) ([]ThreatRecord, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".xlsx":
+		rows, err := xlsx.ReadFile(filename)
+		if err != nil {
+			return nil, fmt.Errorf("чтение xlsx: %w", err)
+		}
+		return parseThreatRows(rows)
+	case ".xml":
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		return parseThreatsXML(data)
+	}
+	return nil, errors.New("поддерживаются только файлы .xlsx и .xml")
+}
+
+// колонки thrlist.xlsx ищутся по подстроке в заголовке, порядок колонок не важен
+const (
+	colID = iota
+	colName
+	colDescription
+	colSource
+	colObject
+	colConf
+	colIntegrity
+	colAvail
+	colStatus
+	colCount
+)
+
+var headerKeys = []struct {
+	col int
+	key string
+}{
+	// порядок важен: "нарушение конфиденциальности" не должно попасть в другие колонки
+	{colConf, "конфиденциальност"},
+	{colIntegrity, "целостност"},
+	{colAvail, "доступност"},
+	{colID, "идентификатор"},
+	{colName, "наименование"},
+	{colDescription, "описание"},
+	{colSource, "источник"},
+	{colObject, "объект"},
+	{colStatus, "статус"},
+}
+
+func parseThreatRows(rows [][]string) ([]ThreatRecord, error) {
+	header := -1
+	var cols [colCount]int
+	for i := range cols {
+		cols[i] = -1
+	}
+
+	// в официальной выгрузке первая строка — общий заголовок, ищем строку с названиями колонок
+	for i, row := range rows {
+		for _, cell := range row {
+			if strings.Contains(strings.ToLower(cell), "идентификатор") {
+				header = i
+				break
+			}
+		}
+		if header >= 0 {
+			break
+		}
+	}
+	if header < 0 {
+		return nil, errors.New("не найдена строка заголовков (колонка «Идентификатор УБИ»)")
+	}
+
+	for j, cell := range rows[header] {
+		title := strings.ToLower(cell)
+		for _, hk := range headerKeys {
+			if cols[hk.col] < 0 && strings.Contains(title, hk.key) {
+				cols[hk.col] = j
+				break
+			}
+		}
+	}
+	if cols[colID] < 0 || cols[colName] < 0 {
+		return nil, errors.New("в выгрузке нет колонок идентификатора и наименования угрозы")
+	}
+
+	get := func(row []string, col int) string {
+		if col < 0 || col >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[col])
+	}
+
+	var records []ThreatRecord
+	for i := header + 1; i < len(rows); i++ {
+		row := rows[i]
+		rawID := get(row, cols[colID])
+		if rawID == "" {
+			continue
+		}
+		code, err := NormalizeThreatCode(rawID)
+		if err != nil {
+			return nil, fmt.Errorf("строка %d: %w", i+1, err)
+		}
+
+		source := get(row, cols[colSource])
+		records = append(records, ThreatRecord{
+			Code:            code,
+			Name:            get(row, cols[colName]),
+			Description:     get(row, cols[colDescription]),
+			Source:          source,
+			ViolatorType:    violatorType(source),
+			Object:          get(row, cols[colObject]),
+			Confidentiality: parseFlag(get(row, cols[colConf])),
+			Integrity:       parseFlag(get(row, cols[colIntegrity])),
+			Availability:    parseFlag(get(row, cols[colAvail])),
+			Withdrawn:       isWithdrawn(get(row, cols[colStatus])),
+		})
+	}
+
+	return records, checkDuplicates(records)
+}
+
+type xmlThreats struct {
+	Items []struct {
+		ID              string `xml:"id"`
+		Name            string `xml:"name"`
+		Description     string `xml:"description"`
+		Source          string `xml:"source"`
+		Object          string `xml:"object"`
+		Confidentiality string `xml:"confidentiality"`
+		Integrity       string `xml:"integrity"`
+		Availability    string `xml:"availability"`
+		Status          string `xml:"status"`
+	} `xml:"threat"`
+}
+
+func parseThreatsXML(data []byte) ([]ThreatRecord, error) {
+	var doc xmlThreats
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("разбор xml: %w", err)
+	}
+
+	records := make([]ThreatRecord, 0, len(doc.Items))
+	for i, it := range doc.Items {
+		code, err := NormalizeThreatCode(it.ID)
+		if err != nil {
+			return nil, fmt.Errorf("угроза #%d: %w", i+1, err)
+		}
+		source := strings.TrimSpace(it.Source)
+		records = append(records, ThreatRecord{
+			Code:            code,
+			Name:            strings.TrimSpace(it.Name),
+			Description:     strings.TrimSpace(it.Description),
+			Source:          source,
+			ViolatorType:    violatorType(source),
+			Object:          strings.TrimSpace(it.Object),
+			Confidentiality: parseFlag(it.Confidentiality),
+			Integrity:       parseFlag(it.Integrity),
+			Availability:    parseFlag(it.Availability),
+			Withdrawn:       isWithdrawn(it.Status),
+		})
+	}
+
+	return records, checkDuplicates(records)
+}
+
+// NormalizeThreatCode приводит идентификатор к виду УБИ.001 ("1", "1.0", "УБИ.1" -> "УБИ.001")
+func NormalizeThreatCode(raw string) (string, error) {
+	s := strings.TrimSpace(raw)
+	s = strings.TrimPrefix(s, ThreatCodePrefix)
+	s = strings.TrimPrefix(s, "UBI.")
+
+	// числовые ячейки Excel могут прийти как "1.0"
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || f < 1 || f != float64(int(f)) {
+		return "", fmt.Errorf("некорректный идентификатор угрозы %q", raw)
+	}
+	return fmt.Sprintf("%s%03d", ThreatCodePrefix, int(f)), nil
+}
+
+func parseFlag(s string) bool {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "1", "1.0", "да", "true", "+":
+		return true
+	}
+	return false
+}
+
+func isWithdrawn(status string) bool {
+	return strings.Contains(strings.ToLower(status), "исключ")
+}
+
+// violatorType — тип нарушителя по тексту "Источник угрозы"
+func violatorType(source string) string {
+	s := strings.ToLower(source)
+	var types []string
+	if strings.Contains(s, "внешний") {
+		types = append(types, "внешний")
+	}
+	if strings.Contains(s, "внутренний") {
+		types = append(types, "внутренний")
+	}
+	return strings.Join(types, ", ")
+}
+
+func checkDuplicates(records []ThreatRecord) error {
+	seen := make(map[string]bool, len(records))
+	for _, r := range records {
+		if seen[r.Code] {
+			return fmt.Errorf("угроза %s встречается в выгрузке несколько раз", r.Code)
+		}
+		seen[r.Code] = true
+	}
+	return nil
+}
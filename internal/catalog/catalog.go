@@ -0,0 +1,269 @@
+// Package catalog — версии записей каталога угроз и мер защиты.
+//
+// Действующие поля хранятся в самой записи (Threat, ControlMeasure), каждая опубликованная
+// редакция — в ThreatVersion/MeasureVersion. Правка создаёт черновик следующей версии,
+// публикация переносит его в запись и выводит предыдущую версию из действия.
+package catalog
+
+import (
+	"errors"
+	"time"
+
+	"ib-integrator/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// --- угрозы ---
+
+// CurrentThreatVersion — действующая версия угрозы. Угрозам, заведённым до версионирования
+// или напрямую (сиды, смоук), версия создаётся из текущих полей.
+func CurrentThreatVersion(tx *gorm.DB, th models.Threat) (models.ThreatVersion, error) {
+	var v models.ThreatVersion
+	err := tx.Where("threat_id = ? AND version = ?", th.ID, th.Version).First(&v).Error
+	if err == nil || !errors.Is(err, gorm.ErrRecordNotFound) {
+		return v, err
+	}
+
+	v = models.NewThreatVersion(th)
+	v.Version = th.Version
+	v.Status = th.Status
+	v.Comment = "Исходная версия"
+	if th.Status != models.CatalogDraft {
+		from := th.CreatedAt
+		v.EffectiveFrom = &from
+	}
+	return v, tx.Create(&v).Error
+}
+
+// ThreatDraft — черновик следующей версии; ok=false, если черновика нет
+func ThreatDraft(tx *gorm.DB, threatID uint) (models.ThreatVersion, bool) {
+	var v models.ThreatVersion
+	if err := tx.Where("threat_id = ? AND status = ?", threatID, models.CatalogDraft).First(&v).Error; err != nil {
+		return models.ThreatVersion{}, false
+	}
+	return v, true
+}
+
+// SaveThreatDraft записывает поля в черновик угрозы, создавая его при необходимости
+func SaveThreatDraft(tx *gorm.DB, th models.Threat, fields models.ThreatVersion, authorID *uint) (models.ThreatVersion, error) {
+	draft, ok := ThreatDraft(tx, th.ID)
+	if !ok {
+		// у ещё не опубликованной записи черновик — её первая версия
+		cur, err := CurrentThreatVersion(tx, th)
+		if err != nil {
+			return draft, err
+		}
+		draft = cur
+		if cur.Status != models.CatalogDraft {
+			next, err := nextVersion(tx, &models.ThreatVersion{}, "threat_id", th.ID)
+			if err != nil {
+				return draft, err
+			}
+			draft = models.ThreatVersion{ThreatID: th.ID, Version: next, Status: models.CatalogDraft}
+		}
+	}
+
+	id, version, created := draft.ID, draft.Version, draft.CreatedAt
+	draft = fields
+	draft.ID, draft.ThreatID, draft.Version, draft.Status = id, th.ID, version, models.CatalogDraft
+	draft.CreatedAt = created
+	draft.AuthorID = authorID
+	return draft, tx.Save(&draft).Error
+}
+
+// PublishThreat делает версию v действующей: поля переносятся в угрозу, предыдущая
+// версия выводится из действия. Версия без ID (импорт) создаётся следующей по номеру;
+// если у угрозы есть черновик, он переносится на эту версию (rebaseThreatDraft).
+func PublishThreat(tx *gorm.DB, th *models.Threat, v models.ThreatVersion) error {
+	now := time.Now()
+	var cur models.ThreatVersion
+	if th.Status != models.CatalogDraft {
+		var err error
+		if cur, err = CurrentThreatVersion(tx, *th); err != nil {
+			return err
+		}
+		if err := retire(tx, &models.ThreatVersion{}, cur.ID, now); err != nil {
+			return err
+		}
+	}
+
+	if v.ID == 0 {
+		next, err := nextVersion(tx, &models.ThreatVersion{}, "threat_id", th.ID)
+		if err != nil {
+			return err
+		}
+		v.ThreatID, v.Version = th.ID, next
+
+		if draft, ok := ThreatDraft(tx, th.ID); ok && th.Status != models.CatalogDraft {
+			// версия встаёт перед черновиком, черновик получает следующий номер
+			draftVersion := next + 1
+			if draft.Version == next-1 {
+				v.Version, draftVersion = draft.Version, next
+			}
+			rebaseThreatDraft(&draft, cur, v)
+			draft.Version = draftVersion
+			if err := tx.Save(&draft).Error; err != nil {
+				return err
+			}
+		}
+	}
+	v.Status = models.CatalogPublished
+	v.EffectiveFrom = &now
+	v.EffectiveTo = nil
+	if err := tx.Save(&v).Error; err != nil {
+		return err
+	}
+
+	v.ApplyTo(th)
+	th.Status = models.CatalogPublished
+	return tx.Save(th).Error
+}
+
+// rebaseThreatDraft переносит черновик с версии base, от которой он создан, на версию next,
+// опубликованную в обход него: поля, не изменённые в черновике, берутся из next, правки
+// сохраняются. Иначе публикация черновика вернула бы поля base и затёрла next.
+func rebaseThreatDraft(draft *models.ThreatVersion, base, next models.ThreatVersion) {
+	rebaseField(&draft.Name, base.Name, next.Name)
+	rebaseField(&draft.Category, base.Category, next.Category)
+	rebaseField(&draft.Description, base.Description, next.Description)
+	rebaseField(&draft.Source, base.Source, next.Source)
+	rebaseField(&draft.ViolatorType, base.ViolatorType, next.ViolatorType)
+	rebaseField(&draft.Object, base.Object, next.Object)
+	rebaseField(&draft.Confidentiality, base.Confidentiality, next.Confidentiality)
+	rebaseField(&draft.Integrity, base.Integrity, next.Integrity)
+	rebaseField(&draft.Availability, base.Availability, next.Availability)
+}
+
+// DeprecateThreat выводит угрозу из каталога: новые оценки по ней не заводятся,
+// существующие сохраняются вместе с оценённой версией
+func DeprecateThreat(tx *gorm.DB, th *models.Threat) error {
+	cur, err := CurrentThreatVersion(tx, *th)
+	if err != nil {
+		return err
+	}
+	if err := retire(tx, &models.ThreatVersion{}, cur.ID, time.Now()); err != nil {
+		return err
+	}
+	th.Status = models.CatalogDeprecated
+	return tx.Model(th).Update("status", th.Status).Error
+}
+
+// --- меры ---
+
+func CurrentMeasureVersion(tx *gorm.DB, m models.ControlMeasure) (models.MeasureVersion, error) {
+	var v models.MeasureVersion
+	err := tx.Where("measure_id = ? AND version = ?", m.ID, m.Version).First(&v).Error
+	if err == nil || !errors.Is(err, gorm.ErrRecordNotFound) {
+		return v, err
+	}
+
+	v = models.NewMeasureVersion(m)
+	v.Version = m.Version
+	v.Status = m.Status
+	v.Comment = "Исходная версия"
+	if m.Status != models.CatalogDraft {
+		from := m.CreatedAt
+		v.EffectiveFrom = &from
+	}
+	return v, tx.Create(&v).Error
+}
+
+func MeasureDraft(tx *gorm.DB, measureID uint) (models.MeasureVersion, bool) {
+	var v models.MeasureVersion
+	if err := tx.Where("measure_id = ? AND status = ?", measureID, models.CatalogDraft).First(&v).Error; err != nil {
+		return models.MeasureVersion{}, false
+	}
+	return v, true
+}
+
+func SaveMeasureDraft(tx *gorm.DB, m models.ControlMeasure, fields models.MeasureVersion, authorID *uint) (models.MeasureVersion, error) {
+	draft, ok := MeasureDraft(tx, m.ID)
+	if !ok {
+		// у ещё не опубликованной записи черновик — её первая версия
+		cur, err := CurrentMeasureVersion(tx, m)
+		if err != nil {
+			return draft, err
+		}
+		draft = cur
+		if cur.Status != models.CatalogDraft {
+			next, err := nextVersion(tx, &models.MeasureVersion{}, "measure_id", m.ID)
+			if err != nil {
+				return draft, err
+			}
+			draft = models.MeasureVersion{MeasureID: m.ID, Version: next, Status: models.CatalogDraft}
+		}
+	}
+
+	id, version, created := draft.ID, draft.Version, draft.CreatedAt
+	draft = fields
+	draft.ID, draft.MeasureID, draft.Version, draft.Status = id, m.ID, version, models.CatalogDraft
+	draft.CreatedAt = created
+	draft.AuthorID = authorID
+	return draft, tx.Save(&draft).Error
+}
+
+func PublishMeasure(tx *gorm.DB, m *models.ControlMeasure, v models.MeasureVersion) error {
+	now := time.Now()
+	if m.Status != models.CatalogDraft {
+		cur, err := CurrentMeasureVersion(tx, *m)
+		if err != nil {
+			return err
+		}
+		if err := retire(tx, &models.MeasureVersion{}, cur.ID, now); err != nil {
+			return err
+		}
+	}
+
+	if v.ID == 0 {
+		next, err := nextVersion(tx, &models.MeasureVersion{}, "measure_id", m.ID)
+		if err != nil {
+			return err
+		}
+		v.MeasureID, v.Version = m.ID, next
+	}
+	v.Status = models.CatalogPublished
+	v.EffectiveFrom = &now
+	v.EffectiveTo = nil
+	if err := tx.Save(&v).Error; err != nil {
+		return err
+	}
+
+	v.ApplyTo(m)
+	m.Status = models.CatalogPublished
+	return tx.Save(m).Error
+}
+
+func DeprecateMeasure(tx *gorm.DB, m *models.ControlMeasure) error {
+	cur, err := CurrentMeasureVersion(tx, *m)
+	if err != nil {
+		return err
+	}
+	if err := retire(tx, &models.MeasureVersion{}, cur.ID, time.Now()); err != nil {
+		return err
+	}
+	m.Status = models.CatalogDeprecated
+	return tx.Model(m).Update("status", m.Status).Error
+}
+
+// --- общее ---
+
+func rebaseField[T comparable](field *T, base, next T) {
+	if *field == base {
+		*field = next
+	}
+}
+
+func nextVersion(tx *gorm.DB, model interface{}, column string, id uint) (int, error) {
+	var max int
+	err := tx.Model(model).Where(column+" = ?", id).Select("COALESCE(MAX(version), 0)").Scan(&max).Error
+	return max + 1, err
+}
+
+// retire выводит версию из действия; дата окончания ставится один раз
+func retire(tx *gorm.DB, model interface{}, versionID uint, at time.Time) error {
+	if err := tx.Model(model).Where("id = ? AND effective_to IS NULL", versionID).Update("effective_to", at).Error; err != nil {
+		return err
+	}
+	return tx.Model(model).Where("id = ?", versionID).Update("status", models.CatalogDeprecated).Error
+}
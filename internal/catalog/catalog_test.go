@@ -0,0 +1,32 @@
+package catalog
+
+import (
+	"testing"
+
+	"ib-integrator/internal/models"
+)
+
+// Черновик, перенесённый на импортированную версию, сохраняет правки инженера,
+// а неизменённые в нём поля получает из импорта
+func TestRebaseThreatDraft(t *testing.T) {
+	base := models.ThreatVersion{Name: "Угроза", Description: "Старое описание", ViolatorType: "Внешний", Integrity: true}
+	next := base
+	next.Description = "Новое описание"
+	next.Availability = true
+
+	draft := base
+	draft.ViolatorType = "Внутренний"
+	draft.Integrity = false
+
+	rebaseThreatDraft(&draft, base, next)
+
+	if draft.Description != "Новое описание" || !draft.Availability {
+		t.Errorf("поля импорта потеряны: %q, доступность %v", draft.Description, draft.Availability)
+	}
+	if draft.ViolatorType != "Внутренний" || draft.Integrity {
+		t.Errorf("правки черновика потеряны: %q, целостность %v", draft.ViolatorType, draft.Integrity)
+	}
+	if draft.Name != "Угроза" {
+		t.Errorf("наименование %q", draft.Name)
+	}
+}
@@ -0,0 +1,189 @@
+package certreg
+
+import (
+	"fmt"
+	"time"
+
+	"ib-integrator/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ToolChange — СЗИ каталога, сертификат которого по реестру стал недействительным
+type ToolChange struct {
+	Tool          models.SecurityTool
+	Old, New      models.CertificateStatus
+	Installations int
+}
+
+// Result — итог загрузки выгрузки реестра
+type Result struct {
+	Added     int
+	Updated   int
+	Unchanged int
+
+	ToolsChecked int          // СЗИ каталога, найденные в реестре
+	Changes      []ToolChange // СЗИ с приостановленным, прекращённым или истёкшим сертификатом
+	Alerts       int          // новых уведомлений по установкам
+	Missing      []string     // сертификаты ФСТЭК из каталога, которых нет в реестре
+}
+
+// effectiveStatus — отметка из реестра, а для действующих — проверка срока
+func effectiveStatus(e Entry, now time.Time) models.CertificateStatus {
+	if e.Status == models.CertificateValid && e.ValidUntil != nil && e.ValidUntil.Before(now) {
+		return models.CertificateExpired
+	}
+	return e.Status
+}
+
+// Import обновляет записи реестра по номеру сертификата и сверяет с ними СЗИ каталога:
+// срок действия, окончание поддержки и состояние сертификата. По установкам СЗИ, сертификат
+// которых стал недействительным, создаются уведомления для инженеров.
+func Import(db *gorm.DB, entries []Entry, now time.Time) (Result, error) {
+	var res Result
+	byNumber := make(map[string]Entry, len(entries))
+	for _, e := range entries {
+		byNumber[e.Number] = e
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for number, e := range byNumber {
+			var rec models.RegisterCertificate
+			err := tx.Where("number = ?", number).First(&rec).Error
+			if err != nil && err != gorm.ErrRecordNotFound {
+				return err
+			}
+			isNew := err == gorm.ErrRecordNotFound
+
+			updated := models.RegisterCertificate{
+				Model:           rec.Model,
+				Number:          number,
+				Product:         e.Product,
+				Applicant:       e.Applicant,
+				Documents:       e.Documents,
+				ProtectionClass: e.ProtectionClass,
+				ValidUntil:      e.ValidUntil,
+				SupportUntil:    e.SupportUntil,
+				Status:          effectiveStatus(e, now),
+				StatusNote:      e.StatusNote,
+				ImportedAt:      now,
+			}
+			switch {
+			case isNew:
+				res.Added++
+			case sameRecord(rec, updated):
+				res.Unchanged++
+			default:
+				res.Updated++
+			}
+			if err := tx.Save(&updated).Error; err != nil {
+				return err
+			}
+		}
+		return syncTools(tx, byNumber, now, &res)
+	})
+	return res, err
+}
+
+func sameRecord(a, b models.RegisterCertificate) bool {
+	return a.Product == b.Product && a.Applicant == b.Applicant && a.Documents == b.Documents &&
+		a.ProtectionClass == b.ProtectionClass && sameDate(a.ValidUntil, b.ValidUntil) &&
+		sameDate(a.SupportUntil, b.SupportUntil) && a.Status == b.Status && a.StatusNote == b.StatusNote
+}
+
+func sameDate(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Equal(*b)
+}
+
+func syncTools(tx *gorm.DB, byNumber map[string]Entry, now time.Time, res *Result) error {
+	var tools []models.SecurityTool
+	if err := tx.Where("regulator = ?", models.RegulatorFSTEC).Order("id asc").Find(&tools).Error; err != nil {
+		return err
+	}
+
+	for _, tool := range tools {
+		e, ok := byNumber[tool.CertificateNumber]
+		if !ok {
+			res.Missing = append(res.Missing, tool.CertificateNumber)
+			continue
+		}
+		res.ToolsChecked++
+
+		old := tool.CertificateStatus
+		tool.CertificateStatus = effectiveStatus(e, now)
+		tool.RegisterCheckedAt = &now
+		if e.ValidUntil != nil {
+			tool.CertificateValidUntil = e.ValidUntil
+		}
+		if e.SupportUntil != nil {
+			tool.SupportUntil = e.SupportUntil
+		}
+		if tool.ProtectionClass == 0 {
+			tool.ProtectionClass = e.ProtectionClass
+		}
+		if err := tx.Save(&tool).Error; err != nil {
+			return err
+		}
+
+		if !tool.CertificateStatus.Problem() {
+			continue
+		}
+		alerts, installations, err := raiseAlerts(tx, tool, old, e)
+		if err != nil {
+			return err
+		}
+		res.Alerts += alerts
+		if old != tool.CertificateStatus {
+			res.Changes = append(res.Changes, ToolChange{Tool: tool, Old: old, New: tool.CertificateStatus, Installations: installations})
+		}
+	}
+	return nil
+}
+
+// raiseAlerts — уведомление по каждой установке СЗИ. Если состояние сертификата до загрузки
+// (old) было тем же, уведомляются только установки без уведомления об этом состоянии:
+// принятое уведомление при повторной загрузке реестра не создаётся заново. Новое
+// ухудшение — в том числе повторная приостановка после возобновления — уведомляется всегда.
+func raiseAlerts(tx *gorm.DB, tool models.SecurityTool, old models.CertificateStatus, e Entry) (int, int, error) {
+	var installations []models.SecurityToolInstallation
+	if err := tx.Preload("Asset").Where("tool_id = ?", tool.ID).Find(&installations).Error; err != nil {
+		return 0, 0, err
+	}
+
+	created := 0
+	for _, inst := range installations {
+		var last models.CertificateAlert
+		found := tx.Where("installation_id = ?", inst.ID).Order("id desc").Limit(1).Find(&last)
+		if found.Error != nil {
+			return 0, 0, found.Error
+		}
+		if old == tool.CertificateStatus && found.RowsAffected > 0 && last.Status == tool.CertificateStatus {
+			continue
+		}
+
+		msg := fmt.Sprintf("Сертификат ФСТЭК № %s на %s: %s", tool.CertificateNumber, tool.Title(), tool.CertificateStatus.Label())
+		if tool.CertificateStatus == models.CertificateExpired && tool.CertificateValidUntil != nil {
+			msg += " " + tool.CertificateValidUntil.Format("02.01.2006")
+		}
+		if e.StatusNote != "" && tool.CertificateStatus != models.CertificateExpired {
+			msg += " (" + e.StatusNote + ")"
+		}
+		alert := models.CertificateAlert{
+			ClientID:          inst.Asset.ClientID,
+			AssetID:           inst.AssetID,
+			InstallationID:    inst.ID,
+			ToolID:            tool.ID,
+			CertificateNumber: tool.CertificateNumber,
+			Status:            tool.CertificateStatus,
+			Message:           msg,
+		}
+		if err := tx.Omit("Client", "Asset", "Tool", "AcknowledgedBy").Create(&alert).Error; err != nil {
+			return 0, 0, err
+		}
+		created++
+	}
+	return created, len(installations), nil
+}
@@ -0,0 +1,259 @@
+// Package certreg — государственный реестр сертифицированных средств защиты информации
+// ФСТЭК России: разбор выгрузки (CSV или XLSX), загрузка в БД и сверка сертификатов СЗИ
+// из каталога.
+package certreg
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"ib-integrator/internal/models"
+	"ib-integrator/internal/textenc"
+	"ib-integrator/internal/xlsx"
+)
+
+// Entry — строка реестра
+type Entry struct {
+	Number          string
+	Product         string
+	Applicant       string
+	Documents       string
+	ProtectionClass int
+	ValidUntil      *time.Time
+	SupportUntil    *time.Time
+	Status          models.CertificateStatus // по отметкам в выгрузке; истечение срока проверяется при загрузке
+	StatusNote      string
+}
+
+// ParseFile определяет формат по расширению: .xlsx или .csv
+func ParseFile(filename string) ([]Entry, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(filename, data)
+}
+
+// Parse разбирает выгрузку; name — имя файла, по нему определяется формат
+func Parse(name string, data []byte) ([]Entry, error) {
+	var rows [][]string
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".xlsx":
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, fmt.Errorf("чтение xlsx: %w", err)
+		}
+		if rows, err = xlsx.Read(zr); err != nil {
+			return nil, fmt.Errorf("чтение xlsx: %w", err)
+		}
+	case ".csv":
+		data = textenc.ToUTF8(data)
+		comma := ';'
+		if first, _, _ := bytes.Cut(data, []byte("\n")); bytes.Count(first, []byte(",")) > bytes.Count(first, []byte(";")) {
+			comma = ','
+		}
+		r := csv.NewReader(bytes.NewReader(data))
+		r.Comma = comma
+		r.FieldsPerRecord = -1
+		r.LazyQuotes = true
+		var err error
+		if rows, err = r.ReadAll(); err != nil {
+			return nil, fmt.Errorf("разбор csv: %w", err)
+		}
+	default:
+		return nil, errors.New("поддерживаются выгрузки реестра в форматах .xlsx и .csv")
+	}
+	return parseRows(rows)
+}
+
+const (
+	colNumber = iota
+	colProduct
+	colDocuments
+	colValid
+	colSupport
+	colApplicant
+	colStatus
+	colIgnore
+	colCount
+)
+
+// колонки ищутся по подстроке в заголовке; порядок важен: «Реквизиты заявителя» не должны
+// попасть в колонку заявителя, «Наименования документов» — в колонку наименования средства
+var headerKeys = []struct {
+	col int
+	key string
+}{
+	{colIgnore, "реквизит"},
+	{colIgnore, "дата внесения"},
+	{colSupport, "поддержк"},
+	{colDocuments, "документ"},
+	{colNumber, "№ сертификата"},
+	{colNumber, "номер сертификата"},
+	{colValid, "срок действия"},
+	{colProduct, "наименование средства"},
+	{colProduct, "наименование"},
+	{colApplicant, "заявител"},
+	{colStatus, "статус"},
+	{colStatus, "примечани"},
+	{colStatus, "приостанов"},
+}
+
+func parseRows(rows [][]string) ([]Entry, error) {
+	header := -1
+	var cols [colCount]int
+	for i, row := range rows {
+		for j := range cols {
+			cols[j] = -1
+		}
+		for j, cell := range row {
+			title := strings.ToLower(strings.Join(strings.Fields(cell), " "))
+			for _, hk := range headerKeys {
+				if strings.Contains(title, hk.key) {
+					if cols[hk.col] < 0 {
+						cols[hk.col] = j
+					}
+					break
+				}
+			}
+		}
+		// первые строки выгрузки — общий заголовок реестра
+		if cols[colNumber] >= 0 && cols[colValid] >= 0 {
+			header = i
+			break
+		}
+	}
+	if header < 0 {
+		return nil, errors.New("не найдена строка заголовков (колонки «№ сертификата» и «Срок действия сертификата»)")
+	}
+
+	get := func(row []string, col int) string {
+		if col < 0 || col >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[col])
+	}
+
+	var entries []Entry
+	for i := header + 1; i < len(rows); i++ {
+		row := rows[i]
+		number := get(row, cols[colNumber])
+		if number == "" {
+			continue
+		}
+
+		// дата в отметке «приостановлено с …» — не срок действия
+		validRaw := get(row, cols[colValid])
+		var validUntil *time.Time
+		if statusFromText(validRaw) == "" {
+			var ok bool
+			validUntil, ok = parseDate(validRaw)
+			if !ok && validRaw != "" && !strings.Contains(strings.ToLower(validRaw), "бессроч") {
+				return nil, fmt.Errorf("строка %d: некорректный срок действия сертификата %s: %q", i+1, number, validRaw)
+			}
+		}
+		support, _ := parseDate(get(row, cols[colSupport]))
+
+		// отметка о приостановке бывает и в отдельной колонке, и вместо срока действия
+		note := get(row, cols[colStatus])
+		status := statusFromText(note)
+		if status == "" {
+			if status = statusFromText(validRaw); status != "" {
+				note = validRaw
+			}
+		}
+		if status == "" {
+			status = models.CertificateValid
+		}
+
+		documents := get(row, cols[colDocuments])
+		entries = append(entries, Entry{
+			Number:          number,
+			Product:         get(row, cols[colProduct]),
+			Applicant:       get(row, cols[colApplicant]),
+			Documents:       documents,
+			ProtectionClass: protectionClass(documents),
+			ValidUntil:      validUntil,
+			SupportUntil:    support,
+			Status:          status,
+			StatusNote:      note,
+		})
+	}
+	if len(entries) == 0 {
+		return nil, errors.New("в выгрузке нет записей о сертификатах")
+	}
+	return entries, nil
+}
+
+func statusFromText(s string) models.CertificateStatus {
+	s = strings.ToLower(s)
+	switch {
+	case strings.Contains(s, "приостановл"):
+		return models.CertificateSuspended
+	case strings.Contains(s, "прекращ"), strings.Contains(s, "аннулир"):
+		return models.CertificateTerminated
+	case strings.Contains(s, "истек"), strings.Contains(s, "истёк"):
+		return models.CertificateExpired
+	}
+	return ""
+}
+
+var dateRe = regexp.MustCompile(`\d{2}\.\d{2}\.\d{4}|\d{4}-\d{2}-\d{2}`)
+
+// excelEpoch — нулевой день дат Excel (с учётом ошибки 1900 года)
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// parseDate — первая дата из ячейки: 31.12.2026, 2026-12-31 или порядковый номер дня Excel
+func parseDate(s string) (*time.Time, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, false
+	}
+	if n, err := strconv.ParseFloat(s, 64); err == nil && n > 20000 && n < 80000 {
+		t := excelEpoch.AddDate(0, 0, int(n))
+		return &t, true
+	}
+	m := dateRe.FindString(s)
+	if m == "" {
+		return nil, false
+	}
+	layout := "02.01.2006"
+	if strings.Contains(m, "-") {
+		layout = "2006-01-02"
+	}
+	t, err := time.Parse(layout, m)
+	if err != nil {
+		return nil, false
+	}
+	return &t, true
+}
+
+var classRes = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)требования\s+доверия\s*\(\s*([1-6])\s*\)`),
+	regexp.MustCompile(`(?i)([1-6])\s*уров\S*\s+доверия`),
+	regexp.MustCompile(`(?i)уров\S*\s+доверия\s*([1-6])`),
+	regexp.MustCompile(`(?i)([1-6])\s*класс\S*\s+защиты`),
+	regexp.MustCompile(`\.[А-ЯA-Z]+([1-6])\.ПЗ`), // шифр профиля защиты: ИТ.МЭ.А4.ПЗ
+}
+
+// protectionClass — класс защиты (уровень доверия) из перечня документов; при нескольких — наивысший
+func protectionClass(documents string) int {
+	best := 0
+	for _, re := range classRes {
+		for _, m := range re.FindAllStringSubmatch(documents, -1) {
+			if n, _ := strconv.Atoi(m[1]); n > 0 && (best == 0 || n < best) {
+				best = n
+			}
+		}
+	}
+	return best
+}
@@ -1,36 +1,108 @@
-package config
-
-import (
-	"log"
-	"os"
-
-	"github.com/joho/godotenv"
-)
-
-type Config struct {
-	DBDSN         string
-	ServerPort    string
-	SessionSecret string
-}
-
-func Load() *Config {
-	_ = godotenv.Load()
-
-	cfg := &Config{
-		DBDSN:         os.Getenv("DB_DSN"),
-		ServerPort:    os.Getenv("SERVER_PORT"),
-		SessionSecret: os.Getenv("SESSION_SECRET"),
-	}
-
-	if cfg.DBDSN == "" {
-		log.Fatal("DB_DSN is not set")
-	}
-	if cfg.ServerPort == "" {
-		cfg.ServerPort = "8080"
-	}
-	if cfg.SessionSecret == "" {
-		log.Fatal("SESSION_SECRET is not set")
-	}
-
-	return cfg
-}
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+type Config struct {
+	DBDSN         string
+	ServerPort    string
+	SessionSecret string
+
+	// адреса обратных прокси, которым доверяем X-Forwarded-For; без них адрес клиента —
+	// адрес соединения (от него зависят блокировка входа и журнал аудита)
+	TrustedProxies []string
+
+	// парольная политика
+	PasswordMinLength    int    // минимальная длина
+	PasswordMinClasses   int    // сколько видов символов из четырёх: строчные, заглавные, цифры, знаки
+	PasswordHistory      int    // сколько последних паролей нельзя использовать повторно
+	PasswordBreachedFile string // список утёкших паролей: по одному в строке или SHA-1 в hex
+
+	// блокировка входа после неудачных попыток
+	LoginMaxFailures   int           // неудачных попыток на учётную запись до блокировки
+	LoginIPMaxFailures int           // неудачных попыток с одного адреса до блокировки
+	LoginFailureWindow time.Duration // за какой период считаются попытки
+	LoginLockout       time.Duration // первая блокировка; каждая следующая вдвое дольше
+	LoginLockoutMax    time.Duration // предел блокировки
+}
+
+func Load() *Config {
+	_ = godotenv.Load()
+
+	cfg := &Config{
+		DBDSN:         os.Getenv("DB_DSN"),
+		ServerPort:    os.Getenv("SERVER_PORT"),
+		SessionSecret: os.Getenv("SESSION_SECRET"),
+
+		TrustedProxies: envList("TRUSTED_PROXIES"),
+
+		PasswordMinLength:    envInt("PASSWORD_MIN_LENGTH", 8),
+		PasswordMinClasses:   envInt("PASSWORD_MIN_CLASSES", 3),
+		PasswordHistory:      envInt("PASSWORD_HISTORY", 5),
+		PasswordBreachedFile: os.Getenv("PASSWORD_BREACHED_FILE"),
+
+		LoginMaxFailures:   envInt("LOGIN_MAX_FAILURES", 5),
+		LoginIPMaxFailures: envInt("LOGIN_IP_MAX_FAILURES", 20),
+		LoginFailureWindow: envDuration("LOGIN_FAILURE_WINDOW", 15*time.Minute),
+		LoginLockout:       envDuration("LOGIN_LOCKOUT", time.Minute),
+		LoginLockoutMax:    envDuration("LOGIN_LOCKOUT_MAX", time.Hour),
+	}
+
+	if cfg.DBDSN == "" {
+		log.Fatal("DB_DSN is not set")
+	}
+	if cfg.ServerPort == "" {
+		cfg.ServerPort = "8080"
+	}
+	if cfg.SessionSecret == "" {
+		log.Fatal("SESSION_SECRET is not set")
+	}
+	if cfg.PasswordMinClasses > 4 {
+		log.Fatal("PASSWORD_MIN_CLASSES must be between 0 and 4")
+	}
+
+	return cfg
+}
+
+// envList — список через запятую
+func envList(name string) []string {
+	var out []string
+	for _, v := range strings.Split(os.Getenv(name), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func envInt(name string, def int) int {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return def
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 {
+		log.Fatalf("%s must be a non-negative integer", name)
+	}
+	return n
+}
+
+// envDuration — длительность в формате Go: 30s, 15m, 1h
+func envDuration(name string, def time.Duration) time.Duration {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return def
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		log.Fatalf("%s must be a positive duration, e.g. 15m", name)
+	}
+	return d
+}
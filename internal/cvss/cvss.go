@@ -0,0 +1,158 @@
+// Package cvss — разбор векторов CVSS v3.0/v3.1/v4.0 и расчёт базовой оценки.
+//
+// Базовая оценка v3.x считается по формулам спецификации FIRST, v4.0 — по таблице
+// MacroVector и интерполяции внутри класса, как в калькуляторе FIRST (cvss4.go).
+package cvss
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	V30 = "3.0"
+	V31 = "3.1"
+	V40 = "4.0"
+)
+
+// Vector — разобранный вектор CVSS
+type Vector struct {
+	Version string
+	Metrics map[string]string
+}
+
+// допустимые значения базовых метрик
+var baseV3 = map[string]string{
+	"AV": "NALP", "AC": "LH", "PR": "NLH", "UI": "NR", "S": "UC", "C": "HLN", "I": "HLN", "A": "HLN",
+}
+
+var baseV4 = map[string]string{
+	"AV": "NALP", "AC": "LH", "AT": "NP", "PR": "NLH", "UI": "NPA",
+	"VC": "HLN", "VI": "HLN", "VA": "HLN", "SC": "HLN", "SI": "HLN", "SA": "HLN",
+}
+
+// Parse разбирает вектор вида CVSS:3.1/AV:N/AC:L/... . Вектор без префикса
+// (так он записан в выгрузке БДУ) считается вектором v3.0.
+func Parse(s string) (Vector, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Vector{}, errors.New("пустой вектор CVSS")
+	}
+
+	v := Vector{Version: V30, Metrics: make(map[string]string)}
+	parts := strings.Split(s, "/")
+	if strings.HasPrefix(parts[0], "CVSS:") {
+		v.Version = strings.TrimPrefix(parts[0], "CVSS:")
+		parts = parts[1:]
+	}
+
+	var base map[string]string
+	switch v.Version {
+	case V30, V31:
+		base = baseV3
+	case V40:
+		base = baseV4
+	default:
+		return Vector{}, fmt.Errorf("неподдерживаемая версия CVSS %q", v.Version)
+	}
+
+	for _, p := range parts {
+		key, val, ok := strings.Cut(p, ":")
+		if !ok || key == "" || val == "" {
+			return Vector{}, fmt.Errorf("некорректная метрика %q", p)
+		}
+		if _, dup := v.Metrics[key]; dup {
+			return Vector{}, fmt.Errorf("метрика %s указана дважды", key)
+		}
+		if allowed, isBase := base[key]; isBase && (len(val) != 1 || !strings.Contains(allowed, val)) {
+			return Vector{}, fmt.Errorf("недопустимое значение %s:%s", key, val)
+		}
+		v.Metrics[key] = val
+	}
+	for key := range base {
+		if _, ok := v.Metrics[key]; !ok {
+			return Vector{}, fmt.Errorf("нет базовой метрики %s", key)
+		}
+	}
+	return v, nil
+}
+
+// String — вектор с префиксом версии и базовыми метриками в порядке спецификации
+func (v Vector) String() string {
+	order := []string{"AV", "AC", "PR", "UI", "S", "C", "I", "A"}
+	if v.Version == V40 {
+		order = []string{"AV", "AC", "AT", "PR", "UI", "VC", "VI", "VA", "SC", "SI", "SA"}
+	}
+	parts := []string{"CVSS:" + v.Version}
+	for _, k := range order {
+		if val, ok := v.Metrics[k]; ok {
+			parts = append(parts, k+":"+val)
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// BaseScore — базовая оценка; ok=false для неизвестной версии
+func (v Vector) BaseScore() (float64, bool) {
+	if v.Version == V40 {
+		return v.score4(), true
+	}
+	if v.Version != V30 && v.Version != V31 {
+		return 0, false
+	}
+	m := v.Metrics
+	changed := m["S"] == "C"
+
+	av := map[string]float64{"N": 0.85, "A": 0.62, "L": 0.55, "P": 0.2}[m["AV"]]
+	ac := map[string]float64{"L": 0.77, "H": 0.44}[m["AC"]]
+	ui := map[string]float64{"N": 0.85, "R": 0.62}[m["UI"]]
+	pr := map[string]float64{"N": 0.85, "L": 0.62, "H": 0.27}[m["PR"]]
+	if changed {
+		pr = map[string]float64{"N": 0.85, "L": 0.68, "H": 0.5}[m["PR"]]
+	}
+	cia := map[string]float64{"H": 0.56, "L": 0.22, "N": 0}
+
+	iss := 1 - (1-cia[m["C"]])*(1-cia[m["I"]])*(1-cia[m["A"]])
+	impact := 6.42 * iss
+	if changed {
+		impact = 7.52*(iss-0.029) - 3.25*math.Pow(iss-0.02, 15)
+	}
+	if impact <= 0 {
+		return 0, true
+	}
+
+	exploitability := 8.22 * av * ac * pr * ui
+	if changed {
+		return roundUp(math.Min(1.08*(impact+exploitability), 10), v.Version), true
+	}
+	return roundUp(math.Min(impact+exploitability, 10), v.Version), true
+}
+
+// roundUp — округление вверх до десятых; в v3.1 с поправкой на погрешность float
+func roundUp(x float64, version string) float64 {
+	if version == V30 {
+		return math.Ceil(x*10) / 10
+	}
+	i := int64(math.Round(x * 100000))
+	if i%10000 == 0 {
+		return float64(i) / 100000
+	}
+	return float64(i/10000+1) / 10
+}
+
+// Severity — качественная оценка по шкале CVSS: none, low, medium, high, critical
+func Severity(score float64) string {
+	switch {
+	case score >= 9.0:
+		return "critical"
+	case score >= 7.0:
+		return "high"
+	case score >= 4.0:
+		return "medium"
+	case score > 0:
+		return "low"
+	}
+	return "none"
+}
@@ -0,0 +1,330 @@
+package cvss
+
+import (
+	"math"
+	"strings"
+)
+
+// ====== CVSS v4.0: расчёт по MacroVector (спецификация FIRST, раздел 8) ======
+//
+// Вектор относится к одному из 270 классов (MacroVector) по шести эквивалентностям EQ1–EQ6.
+// Оценка класса берётся из таблицы FIRST и уменьшается пропорционально тому, насколько
+// вектор «ниже» самого тяжёлого вектора своего класса — так же, как в калькуляторе FIRST.
+// Считается базовая оценка (CVSS-B): метрики угроз и среды не учитываются.
+
+// оценки классов MacroVector из калькулятора FIRST (cvss_lookup.js); ключ — EQ1…EQ6
+var macroVectorScores = map[string]float64{
+	"000000": 10.0, "000001": 9.9, "000010": 9.8, "000011": 9.5, "000020": 9.5, "000021": 9.2,
+	"000100": 10.0, "000101": 9.6, "000110": 9.3, "000111": 8.7, "000120": 9.1, "000121": 8.1,
+	"000200": 9.3, "000201": 9.0, "000210": 8.9, "000211": 8.0, "000220": 8.1, "000221": 6.8,
+	"001000": 9.8, "001001": 9.5, "001010": 9.5, "001011": 9.2, "001020": 9.0, "001021": 8.4,
+	"001100": 9.3, "001101": 9.2, "001110": 8.9, "001111": 8.1, "001120": 8.1, "001121": 6.5,
+	"001200": 8.8, "001201": 8.0, "001210": 7.8, "001211": 7.0, "001220": 6.9, "001221": 4.8,
+	"002001": 9.2, "002011": 8.2, "002021": 7.2, "002101": 7.9, "002111": 6.9, "002121": 5.0,
+	"002201": 6.9, "002211": 5.5, "002221": 2.7, "010000": 9.9, "010001": 9.7, "010010": 9.5,
+	"010011": 9.2, "010020": 9.2, "010021": 8.5, "010100": 9.5, "010101": 9.1, "010110": 9.0,
+	"010111": 8.3, "010120": 8.4, "010121": 7.1, "010200": 9.2, "010201": 8.1, "010210": 8.2,
+	"010211": 7.1, "010220": 7.2, "010221": 5.3, "011000": 9.5, "011001": 9.3, "011010": 9.2,
+	"011011": 8.5, "011020": 8.5, "011021": 7.3, "011100": 9.2, "011101": 8.2, "011110": 8.0,
+	"011111": 7.2, "011120": 7.0, "011121": 5.9, "011200": 8.4, "011201": 7.0, "011210": 7.1,
+	"011211": 5.2, "011220": 5.0, "011221": 3.0, "012001": 8.6, "012011": 7.5, "012021": 5.2,
+	"012101": 7.1, "012111": 5.2, "012121": 2.9, "012201": 6.3, "012211": 2.9, "012221": 1.7,
+	"100000": 9.8, "100001": 9.5, "100010": 9.4, "100011": 8.7, "100020": 9.1, "100021": 8.1,
+	"100100": 9.4, "100101": 8.9, "100110": 8.6, "100111": 7.4, "100120": 7.7, "100121": 6.4,
+	"100200": 8.7, "100201": 7.5, "100210": 7.4, "100211": 6.3, "100220": 6.3, "100221": 4.9,
+	"101000": 9.4, "101001": 8.9, "101010": 8.8, "101011": 7.7, "101020": 7.6, "101021": 6.7,
+	"101100": 8.6, "101101": 7.6, "101110": 7.4, "101111": 5.8, "101120": 5.9, "101121": 5.0,
+	"101200": 7.2, "101201": 5.7, "101210": 5.7, "101211": 5.2, "101220": 5.2, "101221": 2.5,
+	"102001": 8.3, "102011": 7.0, "102021": 5.4, "102101": 6.5, "102111": 5.8, "102121": 2.6,
+	"102201": 5.3, "102211": 2.1, "102221": 1.3, "110000": 9.5, "110001": 9.0, "110010": 8.8,
+	"110011": 7.6, "110020": 7.6, "110021": 7.0, "110100": 9.0, "110101": 7.7, "110110": 7.5,
+	"110111": 6.2, "110120": 6.1, "110121": 5.3, "110200": 7.7, "110201": 6.6, "110210": 6.8,
+	"110211": 5.9, "110220": 5.2, "110221": 3.0, "111000": 8.9, "111001": 7.8, "111010": 7.6,
+	"111011": 6.7, "111020": 6.2, "111021": 5.8, "111100": 7.4, "111101": 5.9, "111110": 5.7,
+	"111111": 5.7, "111120": 4.7, "111121": 2.3, "111200": 6.1, "111201": 5.2, "111210": 5.7,
+	"111211": 2.9, "111220": 2.4, "111221": 1.6, "112001": 7.1, "112011": 5.9, "112021": 3.0,
+	"112101": 5.8, "112111": 2.6, "112121": 1.5, "112201": 2.3, "112211": 1.3, "112221": 0.6,
+	"200000": 9.3, "200001": 8.7, "200010": 8.6, "200011": 7.2, "200020": 7.5, "200021": 5.8,
+	"200100": 8.6, "200101": 7.4, "200110": 7.4, "200111": 6.1, "200120": 5.6, "200121": 3.4,
+	"200200": 7.0, "200201": 5.4, "200210": 5.2, "200211": 4.0, "200220": 4.0, "200221": 2.2,
+	"201000": 8.5, "201001": 7.5, "201010": 7.4, "201011": 5.5, "201020": 6.2, "201021": 5.1,
+	"201100": 7.2, "201101": 5.7, "201110": 5.5, "201111": 4.1, "201120": 4.6, "201121": 1.9,
+	"201200": 5.3, "201201": 3.6, "201210": 3.4, "201211": 1.9, "201220": 1.9, "201221": 0.8,
+	"202001": 6.4, "202011": 5.1, "202021": 2.0, "202101": 4.7, "202111": 2.1, "202121": 1.1,
+	"202201": 2.4, "202211": 0.9, "202221": 0.4, "210000": 8.8, "210001": 7.5, "210010": 7.3,
+	"210011": 5.3, "210020": 6.0, "210021": 5.0, "210100": 7.3, "210101": 5.5, "210110": 5.9,
+	"210111": 4.0, "210120": 4.1, "210121": 2.0, "210200": 5.4, "210201": 4.3, "210210": 4.5,
+	"210211": 2.2, "210220": 2.0, "210221": 1.1, "211000": 7.5, "211001": 5.5, "211010": 5.8,
+	"211011": 4.5, "211020": 4.0, "211021": 2.1, "211100": 6.1, "211101": 5.1, "211110": 4.8,
+	"211111": 1.8, "211120": 2.0, "211121": 0.9, "211200": 4.6, "211201": 1.8, "211210": 1.7,
+	"211211": 0.7, "211220": 0.8, "211221": 0.2, "212001": 5.3, "212011": 2.4, "212021": 1.4,
+	"212101": 2.4, "212111": 1.2, "212121": 0.5, "212201": 1.0, "212211": 0.3, "212221": 0.1,
+}
+
+// самые тяжёлые векторы каждого класса по эквивалентностям
+var (
+	maxEQ1 = [][]string{
+		{"AV:N/PR:N/UI:N"},
+		{"AV:A/PR:N/UI:N", "AV:N/PR:L/UI:N", "AV:N/PR:N/UI:P"},
+		{"AV:P/PR:N/UI:N", "AV:A/PR:L/UI:P"},
+	}
+	maxEQ2 = [][]string{
+		{"AC:L/AT:N"},
+		{"AC:H/AT:N", "AC:L/AT:P"},
+	}
+	// EQ3 и EQ6 связаны, поэтому индекс — [EQ3][EQ6]
+	maxEQ3EQ6 = [][][]string{
+		{
+			{"VC:H/VI:H/VA:H/CR:H/IR:H/AR:H"},
+			{"VC:H/VI:H/VA:L/CR:M/IR:M/AR:H", "VC:H/VI:H/VA:H/CR:M/IR:M/AR:M"},
+		},
+		{
+			{"VC:L/VI:H/VA:H/CR:H/IR:H/AR:H", "VC:H/VI:L/VA:H/CR:H/IR:H/AR:H"},
+			{"VC:L/VI:H/VA:H/CR:H/IR:M/AR:M", "VC:H/VI:L/VA:H/CR:M/IR:H/AR:M", "VC:L/VI:H/VA:L/CR:H/IR:M/AR:H", "VC:H/VI:L/VA:L/CR:M/IR:H/AR:H", "VC:L/VI:L/VA:H/CR:H/IR:H/AR:M"},
+		},
+		{
+			nil,
+			{"VC:L/VI:L/VA:L/CR:H/IR:H/AR:H"},
+		},
+	}
+	maxEQ4 = [][]string{
+		{"SC:H/SI:S/SA:S"},
+		{"SC:H/SI:H/SA:H"},
+		{"SC:L/SI:L/SA:L"},
+	}
+
+	// глубина класса — число шагов от самого тяжёлого вектора до самого лёгкого
+	depthEQ1    = []float64{1, 4, 5}
+	depthEQ2    = []float64{1, 2}
+	depthEQ3EQ6 = [][]float64{{7, 6}, {8, 8}, {0, 10}}
+	depthEQ4    = []float64{6, 5, 4}
+)
+
+// уровни тяжести значений метрик: чем больше, тем дальше от худшего случая
+var severityLevels = map[string]map[string]float64{
+	"AV": {"N": 0.0, "A": 0.1, "L": 0.2, "P": 0.3},
+	"PR": {"N": 0.0, "L": 0.1, "H": 0.2},
+	"UI": {"N": 0.0, "P": 0.1, "A": 0.2},
+	"AC": {"L": 0.0, "H": 0.1},
+	"AT": {"N": 0.0, "P": 0.1},
+	"VC": {"H": 0.0, "L": 0.1, "N": 0.2},
+	"VI": {"H": 0.0, "L": 0.1, "N": 0.2},
+	"VA": {"H": 0.0, "L": 0.1, "N": 0.2},
+	"SC": {"H": 0.1, "L": 0.2, "N": 0.3},
+	"SI": {"S": 0.0, "H": 0.1, "L": 0.2, "N": 0.3},
+	"SA": {"S": 0.0, "H": 0.1, "L": 0.2, "N": 0.3},
+	"CR": {"H": 0.0, "M": 0.1, "L": 0.2},
+	"IR": {"H": 0.0, "M": 0.1, "L": 0.2},
+	"AR": {"H": 0.0, "M": 0.1, "L": 0.2},
+}
+
+// metric4 — значение метрики для базовой оценки: требования к безопасности (CR, IR, AR)
+// по умолчанию высокие, зрелость эксплойта (E) — «атакуется»
+func (v Vector) metric4(key string) string {
+	switch key {
+	case "CR", "IR", "AR":
+		return "H"
+	case "E":
+		return "A"
+	}
+	return v.Metrics[key]
+}
+
+// macroVector — классы эквивалентности EQ1…EQ6
+func (v Vector) macroVector() [6]int {
+	m := v.metric4
+	var eq [6]int
+
+	switch {
+	case m("AV") == "N" && m("PR") == "N" && m("UI") == "N":
+		eq[0] = 0
+	case (m("AV") == "N" || m("PR") == "N" || m("UI") == "N") && m("AV") != "P":
+		eq[0] = 1
+	default:
+		eq[0] = 2
+	}
+
+	if m("AC") != "L" || m("AT") != "N" {
+		eq[1] = 1
+	}
+
+	switch {
+	case m("VC") == "H" && m("VI") == "H":
+		eq[2] = 0
+	case m("VC") == "H" || m("VI") == "H" || m("VA") == "H":
+		eq[2] = 1
+	default:
+		eq[2] = 2
+	}
+
+	// EQ4 = 0 только при модифицированных SI/SA «Safety» — в базовой оценке их нет
+	switch {
+	case m("SI") == "S" || m("SA") == "S":
+		eq[3] = 0
+	case m("SC") == "H" || m("SI") == "H" || m("SA") == "H":
+		eq[3] = 1
+	default:
+		eq[3] = 2
+	}
+
+	switch m("E") {
+	case "P":
+		eq[4] = 1
+	case "U":
+		eq[4] = 2
+	}
+
+	if !(m("CR") == "H" && m("VC") == "H" || m("IR") == "H" && m("VI") == "H" || m("AR") == "H" && m("VA") == "H") {
+		eq[5] = 1
+	}
+	return eq
+}
+
+func macroKey(eq [6]int) string {
+	b := make([]byte, len(eq))
+	for i, e := range eq {
+		b[i] = byte('0' + e)
+	}
+	return string(b)
+}
+
+// macroScore — оценка класса; NaN, если такого класса нет
+func macroScore(eq [6]int) float64 {
+	if s, ok := macroVectorScores[macroKey(eq)]; ok {
+		return s
+	}
+	return math.NaN()
+}
+
+// parseMetrics — "AV:N/PR:N/UI:N" в карту метрик
+func parseMetrics(s string, into map[string]string) {
+	for _, part := range strings.Split(s, "/") {
+		if k, val, ok := strings.Cut(part, ":"); ok {
+			into[k] = val
+		}
+	}
+}
+
+// score4 — базовая оценка v4.0
+func (v Vector) score4() float64 {
+	m := v.metric4
+	impact := []string{"VC", "VI", "VA", "SC", "SI", "SA"}
+	none := true
+	for _, k := range impact {
+		if m(k) != "N" {
+			none = false
+			break
+		}
+	}
+	if none {
+		return 0
+	}
+
+	eq := v.macroVector()
+	value := macroScore(eq)
+
+	// оценки соседних, менее тяжёлых классов по каждой эквивалентности
+	lower := func(i int) float64 {
+		next := eq
+		next[i]++
+		return macroScore(next)
+	}
+	nextEQ1, nextEQ2, nextEQ4, nextEQ5 := lower(0), lower(1), lower(3), lower(4)
+
+	var nextEQ3EQ6 float64
+	eq3, eq6 := eq[2], eq[5]
+	switch {
+	case eq3 == 0 && eq6 == 0:
+		// 00 → 01 или 10, берётся более тяжёлый
+		left, right := eq, eq
+		left[5]++
+		right[2]++
+		l, r := macroScore(left), macroScore(right)
+		if l > r {
+			nextEQ3EQ6 = l
+		} else {
+			nextEQ3EQ6 = r
+		}
+	case eq3 == 1 && eq6 == 0:
+		next := eq
+		next[5]++
+		nextEQ3EQ6 = macroScore(next)
+	case eq3 == 2:
+		nextEQ3EQ6 = math.NaN()
+	default: // 01 → 11, 11 → 21
+		next := eq
+		next[2]++
+		nextEQ3EQ6 = macroScore(next)
+	}
+
+	// ближайший из самых тяжёлых векторов класса, не легче оцениваемого по всем метрикам
+	var dist map[string]float64
+	for _, a := range maxEQ1[eq[0]] {
+		for _, b := range maxEQ2[eq[1]] {
+			for _, c := range maxEQ3EQ6[eq3][eq6] {
+				for _, d := range maxEQ4[eq[3]] {
+					max := map[string]string{}
+					for _, part := range []string{a, b, c, d} {
+						parseMetrics(part, max)
+					}
+					candidate := make(map[string]float64, len(severityLevels))
+					ok := true
+					for k, levels := range severityLevels {
+						candidate[k] = levels[m(k)] - levels[max[k]]
+						if candidate[k] < 0 {
+							ok = false
+							break
+						}
+					}
+					if ok && dist == nil {
+						dist = candidate
+					}
+				}
+			}
+		}
+	}
+	if dist == nil {
+		// не бывает для корректного вектора: самый тяжёлый вектор класса всегда найдётся
+		return round4(value)
+	}
+
+	const step = 0.1
+	current := []float64{
+		dist["AV"] + dist["PR"] + dist["UI"],
+		dist["AC"] + dist["AT"],
+		dist["VC"] + dist["VI"] + dist["VA"] + dist["CR"] + dist["IR"] + dist["AR"],
+		dist["SC"] + dist["SI"] + dist["SA"],
+	}
+	depth := []float64{
+		depthEQ1[eq[0]] * step,
+		depthEQ2[eq[1]] * step,
+		depthEQ3EQ6[eq3][eq6] * step,
+		depthEQ4[eq[3]] * step,
+	}
+	available := []float64{value - nextEQ1, value - nextEQ2, value - nextEQ3EQ6, value - nextEQ4}
+
+	n := 0
+	sum := 0.0
+	for i, avail := range available {
+		if math.IsNaN(avail) || avail < 0 {
+			continue
+		}
+		n++
+		sum += avail * (current[i] / depth[i])
+	}
+	// у EQ5 одна метрика, расстояние внутри класса всегда 0 — но класс учитывается в среднем
+	if avail := value - nextEQ5; !math.IsNaN(avail) && avail >= 0 {
+		n++
+	}
+
+	if n > 0 {
+		value -= sum / float64(n)
+	}
+	return round4(value)
+}
+
+// round4 — округление до десятых, как в калькуляторе FIRST (с поправкой на погрешность float)
+func round4(x float64) float64 {
+	x = math.Max(0, math.Min(10, x))
+	return math.Round((x+1e-6)*10) / 10
+}
@@ -1,18 +1,34 @@
-package database
-
-import "ib-integrator/internal/models"
-
-// helper для записи в журнал аудита
-func CreateAuditLog(userID uint, entity string, entityID uint, action, details string) {
-	if DB == nil {
-		return
-	}
-	record := models.AuditLog{
-		UserID:   userID,
-		Entity:   entity,
-		EntityID: entityID,
-		Action:   action,
-		Details:  details,
-	}
-	_ = DB.Create(&record).Error
-}
+package database
+
+import "ib-integrator/internal/models"
+
+// helper для записи в журнал аудита
+func CreateAuditLog(userID uint, entity string, entityID uint, action, details string) {
+	CreateRequestAuditLog(userID, entity, entityID, action, details, "", "")
+}
+
+// CreateRequestAuditLog — запись в журнал аудита с адресом и User-Agent клиента.
+// userID == 0 — пользователь неизвестен (например, вход под несуществующим логином).
+func CreateRequestAuditLog(userID uint, entity string, entityID uint, action, details, ip, userAgent string) {
+	if DB == nil {
+		return
+	}
+	if r := []rune(userAgent); len(r) > 255 {
+		userAgent = string(r[:255])
+	}
+	record := models.AuditLog{
+		UserID:    userID,
+		Entity:    entity,
+		EntityID:  entityID,
+		Action:    action,
+		Details:   details,
+		IP:        ip,
+		UserAgent: userAgent,
+	}
+	query := DB
+	if userID == 0 {
+		// user_id ссылается на users — без пользователя пишем NULL, а не 0
+		query = query.Omit("UserID")
+	}
+	_ = query.Create(&record).Error
+}
@@ -0,0 +1,98 @@
+package database
+
+import (
+	_ "embed"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"ib-integrator/internal/models"
+)
+
+// baselines.csv — каталоги мер приказов ФСТЭК и базовые наборы, формат описан в шапке файла
+//
+//go:embed baselines.csv
+var baselinesCSV string
+
+// BaselineMeasures — меры базового набора приказа для уровня в порядке приказа
+func BaselineMeasures(regulation string, level int) ([]models.ControlMeasure, error) {
+	var measures []models.ControlMeasure
+	err := DB.
+		Joins("JOIN baseline_measures ON baseline_measures.measure_id = control_measures.id").
+		Where("control_measures.regulation = ? AND baseline_measures.level = ?", regulation, level).
+		Order("control_measures.id asc").
+		Find(&measures).Error
+	return measures, err
+}
+
+// seedBaselines загружает каталоги мер приказов №21, 17, 31 и 239 и отметки базовых наборов.
+// Недостающие меры и отметки досоздаются, правки пользователей в названиях не затираются.
+func seedBaselines() error {
+	var existing []models.ControlMeasure
+	if err := DB.Where("regulation <> ?", "").Find(&existing).Error; err != nil {
+		return err
+	}
+	ids := make(map[string]uint, len(existing))
+	for _, m := range existing {
+		ids[m.Regulation+"|"+m.Code] = m.ID
+	}
+
+	var marks []models.BaselineMeasure
+	if err := DB.Find(&marks).Error; err != nil {
+		return err
+	}
+	marked := make(map[string]bool, len(marks))
+	for _, b := range marks {
+		marked[fmt.Sprintf("%d|%d", b.MeasureID, b.Level)] = true
+	}
+
+	for n, line := range strings.Split(baselinesCSV, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		f := strings.Split(line, ";")
+		if len(f) != 4 {
+			return fmt.Errorf("baselines.csv:%d: ожидается 4 поля", n+1)
+		}
+		reg, code, name := f[0], f[1], f[2]
+		r, ok := models.FindRegulation(reg)
+		if !ok {
+			return fmt.Errorf("baselines.csv:%d: неизвестный приказ %q", n+1, reg)
+		}
+
+		id, ok := ids[reg+"|"+code]
+		if !ok {
+			m := models.ControlMeasure{
+				Regulation: reg,
+				Code:       code,
+				GroupCode:  strings.SplitN(code, ".", 2)[0],
+				Name:       name,
+				Standard:   fmt.Sprintf("Приказ ФСТЭК России №%s", reg),
+			}
+			if err := DB.Create(&m).Error; err != nil {
+				return err
+			}
+			id = m.ID
+			ids[reg+"|"+code] = id
+		}
+
+		if f[3] == "" {
+			continue
+		}
+		for _, l := range strings.Split(f[3], ",") {
+			level, err := strconv.Atoi(l)
+			if err != nil || !r.ValidLevel(level) {
+				return fmt.Errorf("baselines.csv:%d: некорректный уровень %q", n+1, l)
+			}
+			if marked[fmt.Sprintf("%d|%d", id, level)] {
+				continue
+			}
+			if err := DB.Create(&models.BaselineMeasure{MeasureID: id, Level: level}).Error; err != nil {
+				return err
+			}
+			marked[fmt.Sprintf("%d|%d", id, level)] = true
+		}
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package database
+
+import (
+	"ib-integrator/internal/catalog"
+	"ib-integrator/internal/models"
+)
+
+// migrateCatalogVersions заводит исходные версии записям каталога без истории
+// (созданным до версионирования или сидами) и привязывает к ним ранее сделанные оценки объектов.
+func migrateCatalogVersions() error {
+	var threats []models.Threat
+	if err := DB.Where("id NOT IN (SELECT threat_id FROM threat_versions)").Find(&threats).Error; err != nil {
+		return err
+	}
+	for _, th := range threats {
+		if _, err := catalog.CurrentThreatVersion(DB, th); err != nil {
+			return err
+		}
+	}
+
+	var measures []models.ControlMeasure
+	if err := DB.Where("id NOT IN (SELECT measure_id FROM measure_versions)").Find(&measures).Error; err != nil {
+		return err
+	}
+	for _, m := range measures {
+		if _, err := catalog.CurrentMeasureVersion(DB, m); err != nil {
+			return err
+		}
+	}
+
+	return DB.Exec(`UPDATE asset_threats SET threat_version_id = (
+		SELECT threat_versions.id FROM threat_versions
+		JOIN threats ON threats.id = threat_versions.threat_id AND threats.version = threat_versions.version
+		WHERE threats.id = asset_threats.threat_id
+	) WHERE threat_version_id IS NULL`).Error
+}
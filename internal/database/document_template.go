@@ -0,0 +1,33 @@
+package database
+
+import (
+	_ "embed"
+
+	"ib-integrator/internal/models"
+)
+
+// DefaultThreatModelTemplate — шаблон модели угроз по методике ФСТЭК 2021 г.;
+// им же заполняется форма нового шаблона
+//
+//go:embed threat_model.tmpl
+var DefaultThreatModelTemplate string
+
+// seedDocumentTemplates создаёт шаблон по умолчанию, если шаблонов ещё нет
+func seedDocumentTemplates() error {
+	var count int64
+	if err := DB.Model(&models.DocumentTemplate{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	return DB.Create(&models.DocumentTemplate{
+		Name:        "Модель угроз (методика ФСТЭК 2021)",
+		Description: "Типовая структура: описание систем, актуальные угрозы, меры, приложения",
+		Body:        DefaultThreatModelTemplate,
+		FontFamily:  "Times New Roman",
+		FontSize:    14,
+		IsDefault:   true,
+	}).Error
+}
@@ -0,0 +1,132 @@
+package database
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+
+	"ib-integrator/internal/models"
+)
+
+// frameworks.csv — каталоги требований стандартов, формат описан в шапке файла
+//
+//go:embed frameworks.csv
+var frameworksCSV string
+
+// crosswalk.csv — начальное сопоставление требований с мерами каталога
+//
+//go:embed crosswalk.csv
+var crosswalkCSV string
+
+// seedFrameworks загружает каталоги требований ISO/IEC 27001, ГОСТ Р 57580.1, NIST CSF
+// и начальные сопоставления с мерами. Досоздаётся только недостающее: правки
+// и удалённые пользователем сопоставления не возвращаются, если стандарт уже был загружен.
+func seedFrameworks() error {
+	var frameworks []models.Framework
+	if err := DB.Find(&frameworks).Error; err != nil {
+		return err
+	}
+	frameworkIDs := make(map[string]uint, len(frameworks))
+	for _, f := range frameworks {
+		frameworkIDs[f.Code] = f.ID
+	}
+	// сопоставления заводим только для стандартов, появившихся в этом запуске
+	fresh := make(map[string]bool)
+
+	var reqs []models.FrameworkRequirement
+	if err := DB.Find(&reqs).Error; err != nil {
+		return err
+	}
+	reqIDs := make(map[string]uint, len(reqs))
+	for _, r := range reqs {
+		reqIDs[fmt.Sprintf("%d|%s", r.FrameworkID, r.Code)] = r.ID
+	}
+
+	var current string
+	for n, line := range strings.Split(frameworksCSV, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "@") {
+			f := strings.Split(strings.TrimPrefix(line, "@"), ";")
+			if len(f) != 3 {
+				return fmt.Errorf("frameworks.csv:%d: ожидается 3 поля", n+1)
+			}
+			current = f[0]
+			if _, ok := frameworkIDs[current]; ok {
+				continue
+			}
+			fw := models.Framework{Code: f[0], Name: f[1], Levels: f[2]}
+			if err := DB.Create(&fw).Error; err != nil {
+				return err
+			}
+			frameworkIDs[current] = fw.ID
+			fresh[current] = true
+			continue
+		}
+
+		if current == "" {
+			return fmt.Errorf("frameworks.csv:%d: требование до объявления стандарта", n+1)
+		}
+		f := strings.Split(line, ";")
+		if len(f) != 4 {
+			return fmt.Errorf("frameworks.csv:%d: ожидается 4 поля", n+1)
+		}
+		key := fmt.Sprintf("%d|%s", frameworkIDs[current], f[0])
+		if _, ok := reqIDs[key]; ok {
+			continue
+		}
+		r := models.FrameworkRequirement{
+			FrameworkID: frameworkIDs[current],
+			Code:        f[0],
+			Section:     f[1],
+			Name:        f[2],
+			Levels:      f[3],
+		}
+		if err := DB.Create(&r).Error; err != nil {
+			return err
+		}
+		reqIDs[key] = r.ID
+	}
+
+	if len(fresh) == 0 {
+		return nil
+	}
+
+	var measures []models.ControlMeasure
+	if err := DB.Find(&measures).Error; err != nil {
+		return err
+	}
+	measureIDs := make(map[string]uint, len(measures))
+	for _, m := range measures {
+		measureIDs[m.Regulation+"|"+m.Code] = m.ID
+	}
+
+	for n, line := range strings.Split(crosswalkCSV, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		f := strings.Split(line, ";")
+		if len(f) != 4 {
+			return fmt.Errorf("crosswalk.csv:%d: ожидается 4 поля", n+1)
+		}
+		if !fresh[f[0]] {
+			continue
+		}
+		reqID, ok := reqIDs[fmt.Sprintf("%d|%s", frameworkIDs[f[0]], f[1])]
+		if !ok {
+			return fmt.Errorf("crosswalk.csv:%d: неизвестное требование %s %s", n+1, f[0], f[1])
+		}
+		measureID, ok := measureIDs[f[2]+"|"+f[3]]
+		if !ok {
+			return fmt.Errorf("crosswalk.csv:%d: неизвестная мера %q", n+1, f[3])
+		}
+		if err := DB.Create(&models.RequirementMeasure{RequirementID: reqID, MeasureID: measureID}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package database
+
+import (
+	"errors"
+
+	"ib-integrator/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// seedIntruders заполняет каталог видов нарушителей по Методике оценки угроз ФСТЭК 2021 г.
+// Существующие записи не изменяются.
+func seedIntruders() error {
+	intruders := []models.Intruder{
+		{Code: "НР.1", Name: "Специальные службы иностранных государств", Kind: models.IntruderExternal, Level: 4,
+			Motivation: "Нанесение ущерба государству, дестабилизация деятельности органов власти и организаций, получение конкурентных преимуществ на уровне государства"},
+		{Code: "НР.2", Name: "Террористические, экстремистские группировки", Kind: models.IntruderExternal, Level: 3,
+			Motivation: "Совершение террористических актов, угроза жизни граждан, нанесение ущерба отдельным сферам деятельности, создание внутриполитического кризиса"},
+		{Code: "НР.3", Name: "Преступные группы (криминальные структуры)", Kind: models.IntruderExternal, Level: 3,
+			Motivation: "Получение финансовой или иной материальной выгоды, желание самореализации (подтверждение статуса)"},
+		{Code: "НР.4", Name: "Отдельные физические лица (хакеры)", Kind: models.IntruderExternal, Level: 2,
+			Motivation: "Получение финансовой или иной материальной выгоды, любопытство или желание самореализации"},
+		{Code: "НР.5", Name: "Конкурирующие организации", Kind: models.IntruderExternal, Level: 2,
+			Motivation: "Получение конкурентных преимуществ, получение финансовой или иной материальной выгоды"},
+		{Code: "НР.6", Name: "Разработчики программных, программно-аппаратных средств", Kind: models.IntruderInternal, Level: 3,
+			Motivation: "Внедрение дополнительных функциональных возможностей в ПО на этапе разработки, получение конкурентных преимуществ, непреднамеренные ошибки"},
+		{Code: "НР.7", Name: "Лица, обеспечивающие поставку программных, программно-аппаратных средств, обеспечивающих систем", Kind: models.IntruderExternal, Level: 2,
+			Motivation: "Получение финансовой или иной материальной выгоды, непреднамеренные действия"},
+		{Code: "НР.8", Name: "Поставщики услуг связи, вычислительных услуг", Kind: models.IntruderExternal, Level: 2,
+			Motivation: "Получение финансовой или иной материальной выгоды, непреднамеренные или неправомерные действия"},
+		{Code: "НР.9", Name: "Лица, привлекаемые для установки, настройки, испытаний, пусконаладочных и иных работ", Kind: models.IntruderInternal, Level: 2,
+			Motivation: "Получение финансовой или иной материальной выгоды, непреднамеренные или неправомерные действия"},
+		{Code: "НР.10", Name: "Лица, обеспечивающие функционирование систем и сетей или обеспечивающих систем (администрация, охрана, уборщики и т.д.)", Kind: models.IntruderInternal, Level: 1,
+			Motivation: "Получение финансовой или иной материальной выгоды, непреднамеренные действия"},
+		{Code: "НР.11", Name: "Авторизованные пользователи систем и сетей", Kind: models.IntruderInternal, Level: 1,
+			Motivation: "Получение финансовой или иной материальной выгоды, любопытство, месть за ранее совершённые действия, непреднамеренные действия"},
+		{Code: "НР.12", Name: "Системные администраторы и администраторы безопасности", Kind: models.IntruderInternal, Level: 2,
+			Motivation: "Получение финансовой или иной материальной выгоды, любопытство, месть, непреднамеренные действия"},
+		{Code: "НР.13", Name: "Бывшие (уволенные) работники (пользователи)", Kind: models.IntruderExternal, Level: 1,
+			Motivation: "Получение финансовой или иной материальной выгоды, месть за ранее совершённые действия"},
+	}
+
+	for _, in := range intruders {
+		var existing models.Intruder
+		err := DB.Where("code = ?", in.Code).First(&existing).Error
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err := DB.Create(&in).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
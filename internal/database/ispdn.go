@@ -0,0 +1,48 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"ib-integrator/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// recalculateISPDnLevels пересчитывает сохранённые анкеты ИСПДн по текущим правилам
+// ПП №1119: если уровень или ход расчёта изменились, обновляются анкета и уровень
+// объекта (если его не меняли вручную), смена уровня пишется в журнал аудита.
+func recalculateISPDnLevels() error {
+	var list []models.ISPDnAssessment
+	if err := DB.Find(&list).Error; err != nil {
+		return err
+	}
+	for _, a := range list {
+		if a.Validate() != nil {
+			continue
+		}
+		level, trail := a.Calculate()
+		joined := strings.Join(trail, "\n")
+		if level == a.Level && joined == a.Trail {
+			continue
+		}
+		oldLabel, newLabel := models.ISPDnLevelLabel(a.Level), models.ISPDnLevelLabel(level)
+		err := DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&a).Updates(map[string]interface{}{"level": level, "trail": joined}).Error; err != nil {
+				return err
+			}
+			return tx.Model(&models.Asset{}).
+				Where("id = ? AND category = ?", a.AssetID, oldLabel).
+				Update("category", newLabel).Error
+		})
+		if err != nil {
+			return err
+		}
+		if level != a.Level {
+			CreateAuditLog(0, "asset", a.AssetID, "ispdn_level",
+				fmt.Sprintf("Уровень защищённости ПДн пересчитан по уточнённым правилам ПП №1119: %s → %s. %s",
+					oldLabel, newLabel, strings.Join(trail, "; ")))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package database
+
+import (
+	"fmt"
+
+	"ib-integrator/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// LoadRiskMatrix — текущая матрица рисков вместе с ячейками
+func LoadRiskMatrix() (*models.RiskMatrix, error) {
+	var m models.RiskMatrix
+	if err := DB.Preload("Cells").Order("id asc").First(&m).Error; err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// RecalculateRiskLevels пересчитывает выведенные уровни риска всех оценок по матрице.
+// Вызывается после изменения матрицы, чтобы уровни не расходились с ячейками.
+func RecalculateRiskLevels(tx *gorm.DB, m *models.RiskMatrix) error {
+	var links []models.AssetThreat
+	if err := tx.Find(&links).Error; err != nil {
+		return err
+	}
+
+	for _, l := range links {
+		level := m.Level(l.Likelihood, l.Impact)
+		residual := m.Level(l.ResidualLikelihood, l.ResidualImpact)
+		if level == l.RiskLevel && residual == l.ResidualLevel {
+			continue
+		}
+		if err := tx.Model(&models.AssetThreat{}).Where("id = ?", l.ID).
+			Updates(map[string]interface{}{"risk_level": level, "residual_level": residual}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// seedRiskMatrix создаёт матрицу 5×5 по умолчанию, если её ещё нет
+func seedRiskMatrix() error {
+	var count int64
+	if err := DB.Model(&models.RiskMatrix{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	m := models.RiskMatrix{
+		LikelihoodScale:  5,
+		ImpactScale:      5,
+		LikelihoodLabels: "Очень низкая\nНизкая\nСредняя\nВысокая\nОчень высокая",
+		ImpactLabels:     "Незначительный\nНизкий\nСредний\nВысокий\nКритический",
+	}
+	for l := 1; l <= m.LikelihoodScale; l++ {
+		for i := 1; i <= m.ImpactScale; i++ {
+			m.Cells = append(m.Cells, models.RiskMatrixCell{
+				Likelihood: l,
+				Impact:     i,
+				Level:      models.DefaultRiskLevel(l, i, m.LikelihoodScale, m.ImpactScale),
+			})
+		}
+	}
+	return DB.Create(&m).Error
+}
+
+// migrateAssetThreatRisk переносит старые оценки (только RiskLevel low/medium/high)
+// в модель "вероятность × ущерб": берётся типовая ячейка матрицы для этого уровня.
+func migrateAssetThreatRisk() error {
+	m, err := LoadRiskMatrix()
+	if err != nil {
+		return err
+	}
+
+	var links []models.AssetThreat
+	if err := DB.Where("likelihood = 0 OR likelihood IS NULL").Find(&links).Error; err != nil {
+		return err
+	}
+
+	for _, l := range links {
+		lk, im, ok := m.Representative(l.RiskLevel)
+		if !ok {
+			// неизвестный уровень — оцениваем как средний, инженер уточнит
+			lk, im, _ = m.Representative(models.RiskMedium)
+		}
+		note := fmt.Sprintf("Перенесено из прежней оценки «%s»", l.RiskLevel)
+
+		err := DB.Model(&models.AssetThreat{}).Where("id = ?", l.ID).Updates(map[string]interface{}{
+			"likelihood":          lk,
+			"impact":              im,
+			"likelihood_notes":    note,
+			"impact_notes":        note,
+			"risk_level":          m.Level(lk, im),
+			"residual_likelihood": lk,
+			"residual_impact":     im,
+			"residual_level":      m.Level(lk, im),
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package database
+
+import "ib-integrator/internal/models"
+
+// GetSetting — значение настройки или def, если она не задана
+func GetSetting(key, def string) string {
+	var s models.Setting
+	if err := DB.Where(&models.Setting{Key: key}).First(&s).Error; err != nil {
+		return def
+	}
+	return s.Value
+}
+
+func SetSetting(key, value string) error {
+	return DB.Save(&models.Setting{Key: key, Value: value}).Error
+}
+
+// RegistrationMode — текущий режим самостоятельной регистрации; по умолчанию открытая
+func RegistrationMode() models.RegistrationMode {
+	mode := models.RegistrationMode(GetSetting(models.SettingRegistrationMode, string(models.RegistrationOpen)))
+	if !mode.Valid() {
+		return models.RegistrationOpen
+	}
+	return mode
+}
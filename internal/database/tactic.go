@@ -0,0 +1,85 @@
+package database
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+
+	"ib-integrator/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// tactics.csv — тактики и техники из приложения 11 к Методике, формат описан в шапке файла
+//
+//go:embed tactics.csv
+var tacticsCSV string
+
+// seedTactics загружает справочник тактик и техник. Недостающие записи досоздаются,
+// существующие не изменяются.
+func seedTactics() error {
+	var tactics []models.Tactic
+	if err := DB.Find(&tactics).Error; err != nil {
+		return err
+	}
+	tacticIDs := make(map[string]uint, len(tactics))
+	for _, t := range tactics {
+		tacticIDs[t.Code] = t.ID
+	}
+
+	var codes []string
+	if err := DB.Model(&models.Technique{}).Pluck("code", &codes).Error; err != nil {
+		return err
+	}
+	known := make(map[string]bool, len(codes))
+	for _, c := range codes {
+		known[c] = true
+	}
+
+	for n, line := range strings.Split(tacticsCSV, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		f := strings.SplitN(line, ";", 2)
+		if len(f) != 2 {
+			return fmt.Errorf("tactics.csv:%d: ожидается 2 поля", n+1)
+		}
+		code, name := f[0], f[1]
+
+		tacticCode, _, isTechnique := strings.Cut(code, ".")
+		if !isTechnique {
+			if _, ok := tacticIDs[code]; ok {
+				continue
+			}
+			t := models.Tactic{Code: code, Name: name}
+			if err := DB.Create(&t).Error; err != nil {
+				return err
+			}
+			tacticIDs[code] = t.ID
+			continue
+		}
+
+		if known[code] {
+			continue
+		}
+		tacticID, ok := tacticIDs[tacticCode]
+		if !ok {
+			return fmt.Errorf("tactics.csv:%d: техника %s до тактики %s", n+1, code, tacticCode)
+		}
+		if err := DB.Create(&models.Technique{TacticID: tacticID, Code: code, Name: name}).Error; err != nil {
+			return err
+		}
+		known[code] = true
+	}
+	return nil
+}
+
+// Tactics — справочник тактик с техниками в порядке Методики
+func Tactics() ([]models.Tactic, error) {
+	var tactics []models.Tactic
+	err := DB.Preload("Techniques", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id asc")
+	}).Order("id asc").Find(&tactics).Error
+	return tactics, err
+}
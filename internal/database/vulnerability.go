@@ -0,0 +1,33 @@
+package database
+
+import (
+	"ib-integrator/internal/cvss"
+	"ib-integrator/internal/models"
+)
+
+// rescoreCVSS4 пересчитывает оценки v4.0 по сохранённым векторам: раньше оценка
+// бралась из источника, теперь считается по методике FIRST, как и для v3.x
+func rescoreCVSS4() error {
+	var list []models.Vulnerability
+	if err := DB.Where("cvss4_vector <> ''").Find(&list).Error; err != nil {
+		return err
+	}
+	for _, v := range list {
+		vec, err := cvss.Parse(v.CVSS4Vector)
+		if err != nil || vec.Version != cvss.V40 {
+			continue
+		}
+		score, _ := vec.BaseScore()
+		if score == v.CVSS4Score {
+			continue
+		}
+		v.CVSS4Score = score
+		if err := DB.Model(&v).Updates(map[string]interface{}{
+			"cvss4_score": score,
+			"severity":    cvss.Severity(v.Score()),
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
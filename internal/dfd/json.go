@@ -0,0 +1,153 @@
+package dfd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"ib-integrator/internal/models"
+)
+
+// FormatName — метка формата в файле обмена
+const FormatName = "ib-integrator-dfd"
+
+// Document — диаграмма в формате обмена. Ссылки — по наименованиям, а не по ID,
+// чтобы диаграмму можно было перенести на другой объект или в другую установку.
+type Document struct {
+	Format     string        `json:"format"`
+	Version    int           `json:"version"`
+	Asset      string        `json:"asset,omitempty"`
+	Boundaries []DocBoundary `json:"boundaries"`
+	Elements   []DocElement  `json:"elements"`
+	Flows      []DocFlow     `json:"flows"`
+}
+
+type DocBoundary struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+type DocElement struct {
+	Name        string `json:"name"`
+	Kind        string `json:"kind"`
+	Boundary    string `json:"boundary,omitempty"`
+	Component   string `json:"component,omitempty"` // наименование компонента из состава объекта
+	Description string `json:"description,omitempty"`
+}
+
+type DocFlow struct {
+	From          string `json:"from"`
+	To            string `json:"to"`
+	Name          string `json:"name"`
+	Protocol      string `json:"protocol,omitempty"`
+	Encrypted     bool   `json:"encrypted"`
+	Authenticated bool   `json:"authenticated"`
+}
+
+// CheckFlow — правило DFD: данные не перемещаются между хранилищами и внешними
+// сущностями напрямую, хотя бы один конец потока — процесс
+func CheckFlow(source, target models.DFDElementKind) error {
+	if source != models.DFDProcess && target != models.DFDProcess {
+		return errors.New("поток данных должен начинаться или заканчиваться процессом")
+	}
+	return nil
+}
+
+// Export собирает документ из диаграммы; у элементов должны быть загружены Boundary и Component,
+// у потоков — Source и Target
+func Export(assetName string, boundaries []models.DFDBoundary, elements []models.DFDElement, flows []models.DFDFlow) Document {
+	doc := Document{
+		Format:     FormatName,
+		Version:    1,
+		Asset:      assetName,
+		Boundaries: make([]DocBoundary, 0, len(boundaries)),
+		Elements:   make([]DocElement, 0, len(elements)),
+		Flows:      make([]DocFlow, 0, len(flows)),
+	}
+	for _, b := range boundaries {
+		doc.Boundaries = append(doc.Boundaries, DocBoundary{Name: b.Name, Description: b.Description})
+	}
+	for _, el := range elements {
+		item := DocElement{Name: el.Name, Kind: string(el.Kind), Description: el.Description}
+		if el.Boundary != nil {
+			item.Boundary = el.Boundary.Name
+		}
+		if el.Component != nil {
+			item.Component = el.Component.Name
+		}
+		doc.Elements = append(doc.Elements, item)
+	}
+	for _, f := range flows {
+		doc.Flows = append(doc.Flows, DocFlow{
+			From: f.Source.Name, To: f.Target.Name, Name: f.Name, Protocol: f.Protocol,
+			Encrypted: f.Encrypted, Authenticated: f.Authenticated,
+		})
+	}
+	return doc
+}
+
+// Parse разбирает и проверяет документ: уникальность имён, типы элементов, ссылки
+func Parse(data []byte) (Document, error) {
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return doc, fmt.Errorf("разбор json: %w", err)
+	}
+	if doc.Format != FormatName {
+		return doc, fmt.Errorf("ожидается файл формата %s", FormatName)
+	}
+	if doc.Version != 1 {
+		return doc, fmt.Errorf("неподдерживаемая версия формата: %d", doc.Version)
+	}
+
+	boundaries := make(map[string]bool)
+	for i := range doc.Boundaries {
+		b := &doc.Boundaries[i]
+		b.Name = strings.TrimSpace(b.Name)
+		if b.Name == "" {
+			return doc, fmt.Errorf("граница доверия №%d без наименования", i+1)
+		}
+		if boundaries[b.Name] {
+			return doc, fmt.Errorf("граница доверия «%s» указана дважды", b.Name)
+		}
+		boundaries[b.Name] = true
+	}
+
+	kinds := make(map[string]models.DFDElementKind)
+	for i := range doc.Elements {
+		el := &doc.Elements[i]
+		el.Name = strings.TrimSpace(el.Name)
+		if el.Name == "" {
+			return doc, fmt.Errorf("элемент №%d без наименования", i+1)
+		}
+		if _, ok := kinds[el.Name]; ok {
+			return doc, fmt.Errorf("элемент «%s» указан дважды", el.Name)
+		}
+		kind := models.DFDElementKind(el.Kind)
+		if !kind.Valid() {
+			return doc, fmt.Errorf("элемент «%s»: неизвестный тип %q", el.Name, el.Kind)
+		}
+		if el.Boundary != "" && !boundaries[el.Boundary] {
+			return doc, fmt.Errorf("элемент «%s»: граница доверия «%s» не описана", el.Name, el.Boundary)
+		}
+		kinds[el.Name] = kind
+	}
+
+	for i, f := range doc.Flows {
+		src, ok1 := kinds[f.From]
+		dst, ok2 := kinds[f.To]
+		if !ok1 || !ok2 {
+			return doc, fmt.Errorf("поток №%d ссылается на неописанный элемент", i+1)
+		}
+		if f.From == f.To {
+			return doc, fmt.Errorf("поток №%d начинается и заканчивается в одном элементе", i+1)
+		}
+		if strings.TrimSpace(f.Name) == "" {
+			return doc, fmt.Errorf("поток №%d: не указаны передаваемые данные", i+1)
+		}
+		if err := CheckFlow(src, dst); err != nil {
+			return doc, fmt.Errorf("поток №%d (%s → %s): %w", i+1, f.From, f.To, err)
+		}
+	}
+	return doc, nil
+}
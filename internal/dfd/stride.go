@@ -0,0 +1,113 @@
+// Package dfd — диаграммы потоков данных объектов: правила подбора угроз STRIDE
+// и формат обмена диаграммами в JSON.
+package dfd
+
+import (
+	"fmt"
+
+	"ib-integrator/internal/models"
+)
+
+// категории STRIDE; угроза каталога — "STRIDE-" + буква
+const (
+	Spoofing              = 'S'
+	Tampering             = 'T'
+	Repudiation           = 'R'
+	InformationDisclosure = 'I'
+	DenialOfService       = 'D'
+	ElevationOfPrivilege  = 'E'
+)
+
+// ThreatCode — код угрозы каталога для категории STRIDE
+func ThreatCode(category byte) string {
+	return "STRIDE-" + string(category)
+}
+
+// Suggestion — предложение угрозы по элементу или потоку диаграммы
+type Suggestion struct {
+	Category  byte
+	Key       string // правило + элемент; не меняется, пока не переименован элемент
+	Target    string
+	Reason    string
+	ElementID uint // элемент, к которому относится угроза; 0 — поток
+}
+
+// применимость STRIDE к типам элементов (STRIDE-per-element)
+var elementRules = map[models.DFDElementKind][]struct {
+	category byte
+	reason   string
+}{
+	models.DFDExternal: {
+		{Spoofing, "внешняя сущность может быть подменена нарушителем"},
+		{Repudiation, "внешняя сущность может отрицать совершённые действия"},
+	},
+	models.DFDProcess: {
+		{Spoofing, "нарушитель может выдать себя за процесс"},
+		{Tampering, "возможна модификация процесса или его конфигурации"},
+		{Repudiation, "действия процесса могут не фиксироваться в журнале"},
+		{InformationDisclosure, "процесс обрабатывает защищаемую информацию"},
+		{DenialOfService, "процесс может быть выведен из строя"},
+		{ElevationOfPrivilege, "нарушитель может получить привилегии процесса"},
+	},
+	models.DFDDataStore: {
+		{Tampering, "возможна модификация хранимых данных"},
+		{InformationDisclosure, "возможен несанкционированный доступ к хранимым данным"},
+		{DenialOfService, "хранилище может стать недоступным или переполниться"},
+	},
+}
+
+// Analyze подбирает угрозы STRIDE: по типу каждого элемента и по потокам,
+// пересекающим границы доверия. У потоков должны быть загружены Source и Target.
+func Analyze(elements []models.DFDElement, flows []models.DFDFlow) []Suggestion {
+	var out []Suggestion
+	for _, el := range elements {
+		for _, r := range elementRules[el.Kind] {
+			out = append(out, Suggestion{
+				Category:  r.category,
+				Key:       fmt.Sprintf("%c|element|%s", r.category, el.Name),
+				Target:    el.Kind.Label() + " «" + el.Name + "»",
+				Reason:    r.reason,
+				ElementID: el.ID,
+			})
+		}
+	}
+
+	for _, f := range flows {
+		if !f.Crosses() {
+			continue
+		}
+		target := fmt.Sprintf("Поток «%s»: %s → %s", f.Name, f.Source.Name, f.Target.Name)
+		key := func(c byte) string {
+			return fmt.Sprintf("%c|flow|%s|%s|%s", c, f.Source.Name, f.Target.Name, f.Name)
+		}
+		crossing := "поток пересекает границу доверия (" + boundaryName(f.Source) + " → " + boundaryName(f.Target) + ")"
+
+		channel := crossing
+		if !f.Encrypted {
+			channel += ", данные передаются без шифрования"
+		}
+		out = append(out,
+			Suggestion{Category: Tampering, Key: key(Tampering), Target: target, Reason: channel + ": возможна подмена данных в канале"},
+			Suggestion{Category: InformationDisclosure, Key: key(InformationDisclosure), Target: target, Reason: channel + ": возможен перехват данных"},
+			Suggestion{Category: DenialOfService, Key: key(DenialOfService), Target: target, Reason: crossing + ": канал может быть заблокирован"},
+		)
+		if !f.Authenticated {
+			out = append(out, Suggestion{Category: Spoofing, Key: key(Spoofing), Target: target,
+				Reason: crossing + ", источник не аутентифицируется: возможна подмена отправителя"})
+		}
+		// процесс, принимающий данные из другой зоны доверия, — точка повышения привилегий
+		if f.Target.Kind == models.DFDProcess {
+			out = append(out, Suggestion{Category: ElevationOfPrivilege, Key: key(ElevationOfPrivilege), Target: target,
+				Reason:    crossing + ": процесс «" + f.Target.Name + "» принимает данные из менее доверенной зоны",
+				ElementID: f.Target.ID})
+		}
+	}
+	return out
+}
+
+func boundaryName(el models.DFDElement) string {
+	if el.Boundary != nil {
+		return el.Boundary.Name
+	}
+	return "вне границ"
+}
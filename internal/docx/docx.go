@@ -0,0 +1,267 @@
+// Package docx — минимальная запись .docx без сторонних зависимостей.
+// Документ описывается простой построчной разметкой (см. Parse); по тем же блокам
+// строится HTML-предпросмотр, поэтому файл и страница не расходятся.
+package docx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Виды блоков документа
+const (
+	Heading1  = "h1"
+	Heading2  = "h2"
+	Heading3  = "h3"
+	Paragraph = "p"
+	ListItem  = "li"
+	Table     = "table"
+	PageBreak = "pagebreak"
+)
+
+type Block struct {
+	Kind string
+	Text string
+	Rows [][]string // строки таблицы, первая — шапка
+}
+
+// Parse разбирает разметку:
+//
+//	# , ## , ###   — заголовки 1–3 уровня
+//	- текст        — элемент списка
+//	| a | b |      — строка таблицы; первая из подряд идущих строк — шапка
+//	---            — разрыв страницы
+//
+// Остальные непустые строки — абзацы, пустые строки пропускаются.
+func Parse(markup string) []Block {
+	var blocks []Block
+	var table *Block
+
+	flush := func() {
+		if table != nil {
+			blocks = append(blocks, *table)
+			table = nil
+		}
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(markup, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+
+		if strings.HasPrefix(line, "|") {
+			if table == nil {
+				table = &Block{Kind: Table}
+			}
+			table.Rows = append(table.Rows, splitRow(line))
+			continue
+		}
+		flush()
+
+		switch {
+		case line == "":
+		case line == "---":
+			blocks = append(blocks, Block{Kind: PageBreak})
+		case strings.HasPrefix(line, "### "):
+			blocks = append(blocks, Block{Kind: Heading3, Text: strings.TrimSpace(line[4:])})
+		case strings.HasPrefix(line, "## "):
+			blocks = append(blocks, Block{Kind: Heading2, Text: strings.TrimSpace(line[3:])})
+		case strings.HasPrefix(line, "# "):
+			blocks = append(blocks, Block{Kind: Heading1, Text: strings.TrimSpace(line[2:])})
+		case strings.HasPrefix(line, "- "):
+			blocks = append(blocks, Block{Kind: ListItem, Text: strings.TrimSpace(line[2:])})
+		default:
+			blocks = append(blocks, Block{Kind: Paragraph, Text: line})
+		}
+	}
+	flush()
+	return blocks
+}
+
+func splitRow(line string) []string {
+	line = strings.TrimPrefix(line, "|")
+	line = strings.TrimSuffix(line, "|")
+	cells := strings.Split(line, "|")
+	for i := range cells {
+		cells[i] = strings.TrimSpace(cells[i])
+	}
+	return cells
+}
+
+// Style — оформление документа: шрифт и кегль основного текста
+type Style struct {
+	FontFamily string
+	FontSize   int // пт
+}
+
+// Write собирает .docx из блоков
+func Write(w io.Writer, blocks []Block, st Style) error {
+	if st.FontFamily == "" {
+		st.FontFamily = "Times New Roman"
+	}
+	if st.FontSize <= 0 {
+		st.FontSize = 12
+	}
+
+	zw := zip.NewWriter(w)
+	parts := []struct {
+		name string
+		body string
+	}{
+		{"[Content_Types].xml", contentTypes},
+		{"_rels/.rels", rootRels},
+		{"word/_rels/document.xml.rels", documentRels},
+		{"word/styles.xml", styles(st)},
+		{"word/document.xml", document(blocks)},
+	}
+	for _, p := range parts {
+		f, err := zw.Create(p.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, p.body); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+const contentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>
+<Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/>
+</Types>`
+
+const rootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>
+</Relationships>`
+
+const documentRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+</Relationships>`
+
+const wordNS = `xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"`
+
+func styles(st Style) string {
+	font := escape(st.FontFamily)
+	heading := func(id, name string, level, size int) string {
+		return fmt.Sprintf(`<w:style w:type="paragraph" w:styleId="%s"><w:name w:val="%s"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/>`+
+			`<w:pPr><w:keepNext/><w:spacing w:before="240" w:after="120"/><w:outlineLvl w:val="%d"/></w:pPr>`+
+			`<w:rPr><w:b/><w:sz w:val="%d"/></w:rPr></w:style>`, id, name, level, size*2)
+	}
+
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	b.WriteString(`<w:styles ` + wordNS + `>`)
+	fmt.Fprintf(&b, `<w:docDefaults><w:rPrDefault><w:rPr><w:rFonts w:ascii="%s" w:hAnsi="%s" w:cs="%s" w:eastAsia="%s"/>`+
+		`<w:sz w:val="%d"/><w:szCs w:val="%d"/><w:lang w:val="ru-RU"/></w:rPr></w:rPrDefault>`+
+		`<w:pPrDefault><w:pPr><w:spacing w:after="120"/><w:jc w:val="both"/></w:pPr></w:pPrDefault></w:docDefaults>`,
+		font, font, font, font, st.FontSize*2, st.FontSize*2)
+	b.WriteString(`<w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/></w:style>`)
+	b.WriteString(heading("Heading1", "heading 1", 0, st.FontSize+4))
+	b.WriteString(heading("Heading2", "heading 2", 1, st.FontSize+2))
+	b.WriteString(heading("Heading3", "heading 3", 2, st.FontSize))
+	b.WriteString(`<w:style w:type="table" w:styleId="TableGrid"><w:name w:val="Table Grid"/><w:tblPr><w:tblBorders>` +
+		`<w:top w:val="single" w:sz="4" w:space="0" w:color="000000"/><w:left w:val="single" w:sz="4" w:space="0" w:color="000000"/>` +
+		`<w:bottom w:val="single" w:sz="4" w:space="0" w:color="000000"/><w:right w:val="single" w:sz="4" w:space="0" w:color="000000"/>` +
+		`<w:insideH w:val="single" w:sz="4" w:space="0" w:color="000000"/><w:insideV w:val="single" w:sz="4" w:space="0" w:color="000000"/>` +
+		`</w:tblBorders><w:tblCellMar><w:left w:w="80" w:type="dxa"/><w:right w:w="80" w:type="dxa"/></w:tblCellMar></w:tblPr></w:style>`)
+	b.WriteString(`</w:styles>`)
+	return b.String()
+}
+
+// ширина текста на листе A4 с полями ГОСТ Р 7.0.97, в twips
+const textWidth = 9355
+
+func document(blocks []Block) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	b.WriteString(`<w:document ` + wordNS + `><w:body>`)
+
+	for _, bl := range blocks {
+		switch bl.Kind {
+		case Heading1, Heading2, Heading3:
+			style := map[string]string{Heading1: "Heading1", Heading2: "Heading2", Heading3: "Heading3"}[bl.Kind]
+			fmt.Fprintf(&b, `<w:p><w:pPr><w:pStyle w:val="%s"/></w:pPr>%s</w:p>`, style, run(bl.Text, false))
+		case ListItem:
+			fmt.Fprintf(&b, `<w:p><w:pPr><w:ind w:left="720" w:hanging="360"/></w:pPr>%s</w:p>`, run("–\t"+bl.Text, false))
+		case PageBreak:
+			b.WriteString(`<w:p><w:r><w:br w:type="page"/></w:r></w:p>`)
+		case Table:
+			writeTable(&b, bl.Rows)
+		default:
+			fmt.Fprintf(&b, `<w:p><w:pPr><w:ind w:firstLine="709"/></w:pPr>%s</w:p>`, run(bl.Text, false))
+		}
+	}
+
+	b.WriteString(`<w:sectPr><w:pgSz w:w="11906" w:h="16838"/>` +
+		`<w:pgMar w:top="1134" w:right="850" w:bottom="1134" w:left="1701" w:header="709" w:footer="709" w:gutter="0"/></w:sectPr>`)
+	b.WriteString(`</w:body></w:document>`)
+	return b.String()
+}
+
+func writeTable(b *strings.Builder, rows [][]string) {
+	cols := 0
+	for _, r := range rows {
+		if len(r) > cols {
+			cols = len(r)
+		}
+	}
+	if cols == 0 {
+		return
+	}
+
+	b.WriteString(`<w:tbl><w:tblPr><w:tblStyle w:val="TableGrid"/><w:tblW w:w="5000" w:type="pct"/></w:tblPr><w:tblGrid>`)
+	for i := 0; i < cols; i++ {
+		fmt.Fprintf(b, `<w:gridCol w:w="%d"/>`, textWidth/cols)
+	}
+	b.WriteString(`</w:tblGrid>`)
+
+	for i, r := range rows {
+		b.WriteString(`<w:tr>`)
+		if i == 0 {
+			b.WriteString(`<w:trPr><w:tblHeader/></w:trPr>`)
+		}
+		for j := 0; j < cols; j++ {
+			var text string
+			if j < len(r) {
+				text = r[j]
+			}
+			fmt.Fprintf(b, `<w:tc><w:tcPr><w:tcW w:w="%d" w:type="dxa"/></w:tcPr><w:p><w:pPr><w:jc w:val="left"/></w:pPr>%s</w:p></w:tc>`,
+				textWidth/cols, run(text, i == 0))
+		}
+		b.WriteString(`</w:tr>`)
+	}
+	b.WriteString(`</w:tbl><w:p/>`)
+}
+
+// run — фрагмент текста; табуляции переносятся в w:tab
+func run(text string, bold bool) string {
+	var b strings.Builder
+	b.WriteString(`<w:r>`)
+	if bold {
+		b.WriteString(`<w:rPr><w:b/></w:rPr>`)
+	}
+	for i, part := range strings.Split(text, "\t") {
+		if i > 0 {
+			b.WriteString(`<w:tab/>`)
+		}
+		if part != "" {
+			b.WriteString(`<w:t xml:space="preserve">` + escape(part) + `</w:t>`)
+		}
+	}
+	b.WriteString(`</w:r>`)
+	return b.String()
+}
+
+func escape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
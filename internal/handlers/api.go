@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"ib-integrator/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ====== JSON API v1: ОБЩЕЕ ======
+//
+// Ответы: {"data": ...} для записи, {"data": [...], "meta": {...}} для списка,
+// ошибки — {"error": {"status", "code", "message"}} (см. middleware.AbortAPI).
+// Проверки и запись в журнал аудита — те же функции, что и у HTML-форм.
+
+const (
+	apiDefaultPerPage = 50
+	apiMaxPerPage     = 200
+)
+
+type apiItem struct {
+	Data interface{} `json:"data"`
+}
+
+type apiList struct {
+	Data interface{} `json:"data"`
+	Meta apiMeta     `json:"meta"`
+}
+
+type apiMeta struct {
+	Page       int    `json:"page"`
+	PerPage    int    `json:"per_page"`
+	Total      int64  `json:"total"`
+	TotalPages int    `json:"total_pages"`
+	Sort       string `json:"sort"`
+}
+
+func apiBadRequest(c *gin.Context, msg string) {
+	middleware.AbortAPI(c, http.StatusBadRequest, middleware.ErrBadRequest, msg)
+}
+
+func apiValidation(c *gin.Context, msg string) {
+	middleware.AbortAPI(c, http.StatusUnprocessableEntity, middleware.ErrValidation, msg)
+}
+
+func apiNotFound(c *gin.Context, msg string) {
+	middleware.AbortAPI(c, http.StatusNotFound, middleware.ErrNotFound, msg)
+}
+
+func apiConflict(c *gin.Context, msg string) {
+	middleware.AbortAPI(c, http.StatusConflict, middleware.ErrConflict, msg)
+}
+
+func apiInternal(c *gin.Context, msg string) {
+	middleware.AbortAPI(c, http.StatusInternalServerError, middleware.ErrInternal, msg)
+}
+
+// apiParamID — ID из пути запроса
+func apiParamID(c *gin.Context, name, what string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 64)
+	if err != nil || id == 0 {
+		apiBadRequest(c, "Некорректный ID: "+what)
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// apiBind разбирает тело запроса в JSON; неизвестные поля — ошибка, чтобы опечатка
+// в имени поля не превращалась в молча пустое значение
+func apiBind(c *gin.Context, dest interface{}) bool {
+	dec := json.NewDecoder(c.Request.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dest); err != nil {
+		if errors.Is(err, io.EOF) {
+			apiBadRequest(c, "Пустое тело запроса, ожидается JSON")
+		} else {
+			apiBadRequest(c, "Некорректный JSON: "+err.Error())
+		}
+		return false
+	}
+	return true
+}
+
+// apiQueryID — необязательный фильтр по ID: ?client_id=3
+func apiQueryID(c *gin.Context, name string) (uint, bool) {
+	raw := strings.TrimSpace(c.Query(name))
+	if raw == "" {
+		return 0, true
+	}
+	id, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil || id == 0 {
+		apiBadRequest(c, fmt.Sprintf("Некорректное значение параметра %s", name))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// apiQueryBool — необязательный фильтр true/false; nil — не задан
+func apiQueryBool(c *gin.Context, name string) (*bool, bool) {
+	raw := strings.TrimSpace(c.Query(name))
+	if raw == "" {
+		return nil, true
+	}
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		apiBadRequest(c, fmt.Sprintf("Параметр %s: ожидается true или false", name))
+		return nil, false
+	}
+	return &v, true
+}
+
+// apiSortFields — допустимые значения ?sort= и соответствующие колонки
+type apiSortFields map[string]string
+
+// apiPaginate применяет к запросу ?page=, ?per_page= и ?sort= (поле или -поле для убывания),
+// считает общее число записей и загружает страницу в dest
+func apiPaginate(c *gin.Context, query *gorm.DB, fields apiSortFields, defaultSort string, dest interface{}) (apiMeta, bool) {
+	meta := apiMeta{Page: 1, PerPage: apiDefaultPerPage, Sort: defaultSort}
+
+	if raw := c.Query("page"); raw != "" {
+		p, err := strconv.Atoi(raw)
+		if err != nil || p < 1 {
+			apiBadRequest(c, "Параметр page: ожидается номер страницы начиная с 1")
+			return meta, false
+		}
+		meta.Page = p
+	}
+	if raw := c.Query("per_page"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > apiMaxPerPage {
+			apiBadRequest(c, fmt.Sprintf("Параметр per_page: от 1 до %d", apiMaxPerPage))
+			return meta, false
+		}
+		meta.PerPage = n
+	}
+	if raw := strings.TrimSpace(c.Query("sort")); raw != "" {
+		meta.Sort = raw
+	}
+
+	field, desc := strings.CutPrefix(meta.Sort, "-")
+	column, ok := fields[field]
+	if !ok {
+		names := make([]string, 0, len(fields))
+		for name := range fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		apiBadRequest(c, "Параметр sort: допустимые поля — "+strings.Join(names, ", "))
+		return meta, false
+	}
+	order := column + " asc"
+	if desc {
+		order = column + " desc"
+	}
+
+	if err := query.Count(&meta.Total).Error; err != nil {
+		apiInternal(c, "Ошибка БД")
+		return meta, false
+	}
+	meta.TotalPages = int((meta.Total + int64(meta.PerPage) - 1) / int64(meta.PerPage))
+
+	// при равных значениях порядок стабилен по ID
+	if err := query.Order(order).Order("id asc").
+		Limit(meta.PerPage).Offset((meta.Page - 1) * meta.PerPage).
+		Find(dest).Error; err != nil {
+		apiInternal(c, "Ошибка БД")
+		return meta, false
+	}
+	return meta, true
+}
@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"ib-integrator/internal/database"
+	"ib-integrator/internal/middleware"
+	"ib-integrator/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ====== API v1: УГРОЗЫ ОБЪЕКТА ЗАЩИТЫ И ОЦЕНКА РИСКА ======
+
+type apiAssetThreat struct {
+	ID                 uint   `json:"id"`
+	AssetID            uint   `json:"asset_id"`
+	ThreatID           uint   `json:"threat_id"`
+	ThreatCode         string `json:"threat_code"`
+	ThreatName         string `json:"threat_name"`
+	ThreatVersionID    *uint  `json:"threat_version_id"`
+	Likelihood         int    `json:"likelihood"`
+	Impact             int    `json:"impact"`
+	LikelihoodNotes    string `json:"likelihood_notes"`
+	ImpactNotes        string `json:"impact_notes"`
+	RiskLevel          string `json:"risk_level"`
+	ResidualLikelihood int    `json:"residual_likelihood"`
+	ResidualImpact     int    `json:"residual_impact"`
+	ResidualNotes      string `json:"residual_notes"`
+	ResidualLevel      string `json:"residual_level"`
+	Notes              string `json:"notes"`
+	Excluded           bool   `json:"excluded"`
+	ExclusionReason    string `json:"exclusion_reason"`
+	MeasureIDs         []uint `json:"measure_ids"`
+	ComponentIDs       []uint `json:"component_ids"`
+}
+
+func newAPIAssetThreat(l models.AssetThreat) apiAssetThreat {
+	a := apiAssetThreat{
+		ID:                 l.ID,
+		AssetID:            l.AssetID,
+		ThreatID:           l.ThreatID,
+		ThreatCode:         l.Threat.Code,
+		ThreatName:         l.Threat.Name,
+		ThreatVersionID:    l.ThreatVersionID,
+		Likelihood:         l.Likelihood,
+		Impact:             l.Impact,
+		LikelihoodNotes:    l.LikelihoodNotes,
+		ImpactNotes:        l.ImpactNotes,
+		RiskLevel:          l.RiskLevel,
+		ResidualLikelihood: l.ResidualLikelihood,
+		ResidualImpact:     l.ResidualImpact,
+		ResidualNotes:      l.ResidualNotes,
+		ResidualLevel:      l.ResidualLevel,
+		Notes:              l.Notes,
+		Excluded:           l.Excluded,
+		ExclusionReason:    l.ExclusionReason,
+		MeasureIDs:         []uint{},
+		ComponentIDs:       []uint{},
+	}
+	for _, m := range l.Measures {
+		a.MeasureIDs = append(a.MeasureIDs, m.ID)
+	}
+	for _, comp := range l.Components {
+		a.ComponentIDs = append(a.ComponentIDs, comp.ID)
+	}
+	return a
+}
+
+var apiAssetThreatSort = apiSortFields{"id": "id", "threat_id": "threat_id", "risk_level": "risk_level",
+	"residual_level": "residual_level", "likelihood": "likelihood", "impact": "impact"}
+
+// APIListAssetThreats — GET /api/v1/assets/:id/threats?risk_level=&residual_level=&excluded=
+func APIListAssetThreats(c *gin.Context) {
+	asset, ok := apiLoadAsset(c)
+	if !ok {
+		return
+	}
+
+	query := database.DB.Model(&models.AssetThreat{}).Where("asset_id = ?", asset.ID)
+	for _, param := range []string{"risk_level", "residual_level"} {
+		if v := strings.TrimSpace(c.Query(param)); v != "" {
+			if !isRiskLevel(v) {
+				apiBadRequest(c, fmt.Sprintf("Параметр %s: %s", param, strings.Join(models.RiskLevels, ", ")))
+				return
+			}
+			query = query.Where(param+" = ?", v)
+		}
+	}
+	excluded, ok := apiQueryBool(c, "excluded")
+	if !ok {
+		return
+	}
+	if excluded != nil {
+		query = query.Where("excluded = ?", *excluded)
+	}
+
+	var links []models.AssetThreat
+	query = query.Preload("Threat").Preload("Measures").Preload("Components")
+	meta, ok := apiPaginate(c, query, apiAssetThreatSort, "id", &links)
+	if !ok {
+		return
+	}
+
+	data := make([]apiAssetThreat, 0, len(links))
+	for _, l := range links {
+		data = append(data, newAPIAssetThreat(l))
+	}
+	c.JSON(http.StatusOK, apiList{Data: data, Meta: meta})
+}
+
+func apiLoadAssetThreat(c *gin.Context) (models.AssetThreat, bool) {
+	assetID, ok := apiParamID(c, "id", "объект защиты")
+	if !ok {
+		return models.AssetThreat{}, false
+	}
+	linkID, ok := apiParamID(c, "link_id", "угроза объекта")
+	if !ok {
+		return models.AssetThreat{}, false
+	}
+
+	var link models.AssetThreat
+	if err := database.DB.
+		Preload("Threat").Preload("Measures").Preload("Components").
+		Joins("JOIN assets ON assets.id = asset_threats.asset_id AND assets.deleted_at IS NULL").
+		Where("asset_threats.id = ? AND asset_threats.asset_id = ?", linkID, assetID).
+		First(&link).Error; err != nil {
+		apiNotFound(c, "Угроза объекта не найдена")
+		return models.AssetThreat{}, false
+	}
+	return link, true
+}
+
+func APIGetAssetThreat(c *gin.Context) {
+	link, ok := apiLoadAssetThreat(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, apiItem{Data: newAPIAssetThreat(link)})
+}
+
+// APIAddAssetThreat привязывает угрозу: нужны threat_id, likelihood и impact;
+// остаточный риск до применения мер равен исходному
+func APIAddAssetThreat(c *gin.Context) {
+	asset, ok := apiLoadAsset(c)
+	if !ok {
+		return
+	}
+
+	var in assetThreatInput
+	if !apiBind(c, &in) {
+		return
+	}
+	trimAssetThreatInput(&in)
+
+	matrix, err := database.LoadRiskMatrix()
+	if err != nil {
+		apiInternal(c, "Матрица рисков не настроена")
+		return
+	}
+
+	link, msg, err := addAssetThreat(asset.ID, in, matrix, middleware.APIUserID(c))
+	if msg != "" {
+		apiValidation(c, msg)
+		return
+	}
+	if err != nil {
+		apiInternal(c, "Ошибка сохранения угрозы для объекта")
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("/api/v1/assets/%d/threats/%d", asset.ID, link.ID))
+	c.JSON(http.StatusCreated, apiItem{Data: newAPIAssetThreat(link)})
+}
+
+// APIUpdateAssetThreat — PUT: оценка целиком, включая остаточный риск, меры и компоненты
+func APIUpdateAssetThreat(c *gin.Context) {
+	link, ok := apiLoadAssetThreat(c)
+	if !ok {
+		return
+	}
+
+	var in assetThreatInput
+	if !apiBind(c, &in) {
+		return
+	}
+	trimAssetThreatInput(&in)
+	if in.ThreatID != 0 && in.ThreatID != link.ThreatID {
+		apiValidation(c, "Угрозу связи изменить нельзя — удалите связь и привяжите другую угрозу")
+		return
+	}
+
+	matrix, err := database.LoadRiskMatrix()
+	if err != nil {
+		apiInternal(c, "Матрица рисков не настроена")
+		return
+	}
+
+	msg, err := updateAssetThreat(&link, in, matrix, middleware.APIUserID(c))
+	if msg != "" {
+		apiValidation(c, msg)
+		return
+	}
+	if err != nil {
+		apiInternal(c, "Ошибка сохранения оценки риска")
+		return
+	}
+	c.JSON(http.StatusOK, apiItem{Data: newAPIAssetThreat(link)})
+}
+
+func APIDeleteAssetThreat(c *gin.Context) {
+	link, ok := apiLoadAssetThreat(c)
+	if !ok {
+		return
+	}
+	if err := deleteAssetThreat(link, middleware.APIUserID(c)); err != nil {
+		apiInternal(c, "Ошибка удаления связи угрозы")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func trimAssetThreatInput(in *assetThreatInput) {
+	for _, f := range []*string{&in.LikelihoodNotes, &in.ImpactNotes, &in.ResidualNotes, &in.Notes} {
+		*f = strings.TrimSpace(*f)
+	}
+}
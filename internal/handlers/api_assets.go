@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"ib-integrator/internal/database"
+	"ib-integrator/internal/middleware"
+	"ib-integrator/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ====== API v1: ОБЪЕКТЫ ЗАЩИТЫ ======
+
+type apiAsset struct {
+	ID          uint             `json:"id"`
+	ClientID    uint             `json:"client_id"`
+	Name        string           `json:"name"`
+	AssetType   models.AssetType `json:"asset_type"`
+	Category    string           `json:"category"`
+	Description string           `json:"description"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+}
+
+func newAPIAsset(a models.Asset) apiAsset {
+	return apiAsset{
+		ID:          a.ID,
+		ClientID:    a.ClientID,
+		Name:        a.Name,
+		AssetType:   a.AssetType,
+		Category:    a.Category,
+		Description: a.Description,
+		CreatedAt:   a.CreatedAt,
+		UpdatedAt:   a.UpdatedAt,
+	}
+}
+
+var apiAssetSort = apiSortFields{"id": "id", "name": "name", "client_id": "client_id", "asset_type": "asset_type",
+	"created_at": "created_at", "updated_at": "updated_at"}
+
+// APIListAssets — GET /api/v1/assets?client_id=&asset_type=&q=
+func APIListAssets(c *gin.Context) {
+	query := database.DB.Model(&models.Asset{})
+	clientID, ok := apiQueryID(c, "client_id")
+	if !ok {
+		return
+	}
+	if clientID != 0 {
+		query = query.Where("client_id = ?", clientID)
+	}
+	if v := strings.TrimSpace(c.Query("asset_type")); v != "" {
+		query = query.Where("asset_type = ?", v)
+	}
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		like := "%" + strings.ToLower(q) + "%"
+		query = query.Where("LOWER(name) LIKE ? OR LOWER(description) LIKE ?", like, like)
+	}
+
+	var assets []models.Asset
+	meta, ok := apiPaginate(c, query, apiAssetSort, "name", &assets)
+	if !ok {
+		return
+	}
+
+	data := make([]apiAsset, 0, len(assets))
+	for _, a := range assets {
+		data = append(data, newAPIAsset(a))
+	}
+	c.JSON(http.StatusOK, apiList{Data: data, Meta: meta})
+}
+
+func apiLoadAsset(c *gin.Context) (models.Asset, bool) {
+	id, ok := apiParamID(c, "id", "объект защиты")
+	if !ok {
+		return models.Asset{}, false
+	}
+	var asset models.Asset
+	if err := database.DB.First(&asset, id).Error; err != nil {
+		apiNotFound(c, "Объект защиты не найден")
+		return models.Asset{}, false
+	}
+	return asset, true
+}
+
+func APIGetAsset(c *gin.Context) {
+	asset, ok := apiLoadAsset(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, apiItem{Data: newAPIAsset(asset)})
+}
+
+// APICreateAsset — УЗ ИСПДн и класс ГИС из запроса не берутся: они рассчитываются по анкете
+func APICreateAsset(c *gin.Context) {
+	var in assetInput
+	if !apiBind(c, &in) {
+		return
+	}
+	in.trim()
+
+	asset, _, msg, err := prepareAsset(in, models.Asset{})
+	if msg != "" || err != nil {
+		apiValidation(c, msg)
+		return
+	}
+	if err := saveAsset(&asset, nil, "", middleware.APIUserID(c)); err != nil {
+		apiInternal(c, "Ошибка сохранения объекта защиты в БД")
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("/api/v1/assets/%d", asset.ID))
+	c.JSON(http.StatusCreated, apiItem{Data: newAPIAsset(asset)})
+}
+
+// APIUpdateAsset — PUT: объект целиком. Изменение рассчитанного класса ГИС требует
+// category_justification, как и в форме.
+func APIUpdateAsset(c *gin.Context) {
+	asset, ok := apiLoadAsset(c)
+	if !ok {
+		return
+	}
+
+	var in assetInput
+	if !apiBind(c, &in) {
+		return
+	}
+	in.trim()
+
+	updated, override, msg, err := prepareAsset(in, asset)
+	if err != nil {
+		apiInternal(c, "Ошибка загрузки классификации ГИС")
+		return
+	}
+	if msg != "" {
+		apiValidation(c, msg)
+		return
+	}
+	if err := saveAsset(&updated, override, asset.Category, middleware.APIUserID(c)); err != nil {
+		apiInternal(c, "Ошибка сохранения объекта защиты в БД")
+		return
+	}
+	c.JSON(http.StatusOK, apiItem{Data: newAPIAsset(updated)})
+}
+
+// APIDeleteAsset — объект помечается удалённым; описание состава, оценки и документы
+// сохраняются в БД, но в отчётах и списках не участвуют
+func APIDeleteAsset(c *gin.Context) {
+	asset, ok := apiLoadAsset(c)
+	if !ok {
+		return
+	}
+
+	if err := database.DB.Delete(&asset).Error; err != nil {
+		apiInternal(c, "Ошибка удаления объекта защиты")
+		return
+	}
+	database.CreateAuditLog(middleware.APIUserID(c), "asset", asset.ID, "delete", "Удалён объект защиты: "+asset.Name)
+	c.Status(http.StatusNoContent)
+}
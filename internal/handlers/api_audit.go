@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"ib-integrator/internal/database"
+	"ib-integrator/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ====== API v1: ЖУРНАЛ АУДИТА (только чтение) ======
+
+type apiAuditLog struct {
+	ID        uint      `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UserID    uint      `json:"user_id"` // 0 — пользователь неизвестен (неудачный вход)
+	Username  string    `json:"username"`
+	Entity    string    `json:"entity"`
+	EntityID  uint      `json:"entity_id"`
+	Action    string    `json:"action"`
+	Details   string    `json:"details"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+}
+
+var apiAuditSort = apiSortFields{"id": "id", "created_at": "created_at", "entity": "entity", "action": "action", "user_id": "user_id"}
+
+// APIListAuditLogs — GET /api/v1/audit-logs?entity=&entity_id=&action=&user_id=&from=&to=
+// (даты — ГГГГ-ММ-ДД, to включительно)
+func APIListAuditLogs(c *gin.Context) {
+	query := database.DB.Model(&models.AuditLog{})
+	for _, param := range []string{"entity", "action"} {
+		if v := strings.TrimSpace(c.Query(param)); v != "" {
+			query = query.Where(param+" = ?", v)
+		}
+	}
+	for _, param := range []string{"entity_id", "user_id"} {
+		id, ok := apiQueryID(c, param)
+		if !ok {
+			return
+		}
+		if id != 0 {
+			query = query.Where(param+" = ?", id)
+		}
+	}
+	if raw := strings.TrimSpace(c.Query("from")); raw != "" {
+		from, err := time.ParseInLocation(dateLayout, raw, time.Local)
+		if err != nil {
+			apiBadRequest(c, "Параметр from: дата в формате ГГГГ-ММ-ДД")
+			return
+		}
+		query = query.Where("created_at >= ?", from)
+	}
+	if raw := strings.TrimSpace(c.Query("to")); raw != "" {
+		to, err := time.ParseInLocation(dateLayout, raw, time.Local)
+		if err != nil {
+			apiBadRequest(c, "Параметр to: дата в формате ГГГГ-ММ-ДД")
+			return
+		}
+		query = query.Where("created_at < ?", to.AddDate(0, 0, 1))
+	}
+
+	var logs []models.AuditLog
+	meta, ok := apiPaginate(c, query.Preload("User"), apiAuditSort, "-created_at", &logs)
+	if !ok {
+		return
+	}
+
+	data := make([]apiAuditLog, 0, len(logs))
+	for _, l := range logs {
+		data = append(data, apiAuditLog{
+			ID:        l.ID,
+			CreatedAt: l.CreatedAt,
+			UserID:    l.UserID,
+			Username:  l.User.Username,
+			Entity:    l.Entity,
+			EntityID:  l.EntityID,
+			Action:    l.Action,
+			Details:   l.Details,
+			IP:        l.IP,
+			UserAgent: l.UserAgent,
+		})
+	}
+	c.JSON(http.StatusOK, apiList{Data: data, Meta: meta})
+}
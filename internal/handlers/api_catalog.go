@@ -0,0 +1,365 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"ib-integrator/internal/catalog"
+	"ib-integrator/internal/database"
+	"ib-integrator/internal/middleware"
+	"ib-integrator/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ====== API v1: КАТАЛОГ УГРОЗ И МЕР ======
+//
+// Изменение записи каталога — новая версия: PUT сохраняет черновик (с "publish": true —
+// сразу публикует), DELETE выводит запись из каталога. Оценки объектов ссылаются на версии,
+// поэтому физически записи каталога не удаляются.
+
+type apiThreat struct {
+	ID              uint                 `json:"id"`
+	Code            string               `json:"code"`
+	Name            string               `json:"name"`
+	Category        string               `json:"category"`
+	Description     string               `json:"description"`
+	Source          string               `json:"source"`
+	ViolatorType    string               `json:"violator_type"`
+	Object          string               `json:"object"`
+	Confidentiality bool                 `json:"confidentiality"`
+	Integrity       bool                 `json:"integrity"`
+	Availability    bool                 `json:"availability"`
+	Withdrawn       bool                 `json:"withdrawn"`
+	Status          models.CatalogStatus `json:"status"`
+	Version         int                  `json:"version"`
+	DraftVersion    int                  `json:"draft_version,omitempty"` // неопубликованный черновик
+	CreatedAt       time.Time            `json:"created_at"`
+	UpdatedAt       time.Time            `json:"updated_at"`
+}
+
+func newAPIThreat(th models.Threat) apiThreat {
+	t := apiThreat{
+		ID:              th.ID,
+		Code:            th.Code,
+		Name:            th.Name,
+		Category:        th.Category,
+		Description:     th.Description,
+		Source:          th.Source,
+		ViolatorType:    th.ViolatorType,
+		Object:          th.Object,
+		Confidentiality: th.Confidentiality,
+		Integrity:       th.Integrity,
+		Availability:    th.Availability,
+		Withdrawn:       th.Withdrawn,
+		Status:          th.Status,
+		Version:         th.Version,
+		CreatedAt:       th.CreatedAt,
+		UpdatedAt:       th.UpdatedAt,
+	}
+	if draft, ok := catalog.ThreatDraft(database.DB, th.ID); ok {
+		t.DraftVersion = draft.Version
+	}
+	return t
+}
+
+type apiMeasure struct {
+	ID           uint                 `json:"id"`
+	Regulation   string               `json:"regulation"` // пусто — собственная мера
+	Code         string               `json:"code"`
+	GroupCode    string               `json:"group_code"`
+	Name         string               `json:"name"`
+	Standard     string               `json:"standard"`
+	Description  string               `json:"description"`
+	Status       models.CatalogStatus `json:"status"`
+	Version      int                  `json:"version"`
+	DraftVersion int                  `json:"draft_version,omitempty"`
+	CreatedAt    time.Time            `json:"created_at"`
+	UpdatedAt    time.Time            `json:"updated_at"`
+}
+
+func newAPIMeasure(m models.ControlMeasure) apiMeasure {
+	a := apiMeasure{
+		ID:          m.ID,
+		Regulation:  m.Regulation,
+		Code:        m.Code,
+		GroupCode:   m.GroupCode,
+		Name:        m.Name,
+		Standard:    m.Standard,
+		Description: m.Description,
+		Status:      m.Status,
+		Version:     m.Version,
+		CreatedAt:   m.CreatedAt,
+		UpdatedAt:   m.UpdatedAt,
+	}
+	if draft, ok := catalog.MeasureDraft(database.DB, m.ID); ok {
+		a.DraftVersion = draft.Version
+	}
+	return a
+}
+
+var apiCatalogSort = apiSortFields{"id": "id", "code": "code", "name": "name", "created_at": "created_at", "updated_at": "updated_at"}
+
+// apiCatalogFilter — общие фильтры каталога: ?q= по коду и названию, ?status=
+func apiCatalogFilter(c *gin.Context, query *gorm.DB) (*gorm.DB, bool) {
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		like := "%" + strings.ToLower(q) + "%"
+		query = query.Where("LOWER(code) LIKE ? OR LOWER(name) LIKE ?", like, like)
+	}
+	if v := models.CatalogStatus(strings.TrimSpace(c.Query("status"))); v != "" {
+		if v != models.CatalogDraft && v != models.CatalogPublished && v != models.CatalogDeprecated {
+			apiBadRequest(c, "Параметр status: draft, published или deprecated")
+			return query, false
+		}
+		query = query.Where("status = ?", v)
+	}
+	return query, true
+}
+
+// ---------- угрозы ----------
+
+// APIListThreats — GET /api/v1/threats?q=&category=&status=&withdrawn=
+func APIListThreats(c *gin.Context) {
+	query, ok := apiCatalogFilter(c, database.DB.Model(&models.Threat{}))
+	if !ok {
+		return
+	}
+	if v := strings.TrimSpace(c.Query("category")); v != "" {
+		query = query.Where("category = ?", v)
+	}
+	withdrawn, ok := apiQueryBool(c, "withdrawn")
+	if !ok {
+		return
+	}
+	if withdrawn != nil {
+		query = query.Where("withdrawn = ?", *withdrawn)
+	}
+
+	var threats []models.Threat
+	meta, ok := apiPaginate(c, query, apiCatalogSort, "code", &threats)
+	if !ok {
+		return
+	}
+
+	data := make([]apiThreat, 0, len(threats))
+	for _, th := range threats {
+		data = append(data, newAPIThreat(th))
+	}
+	c.JSON(http.StatusOK, apiList{Data: data, Meta: meta})
+}
+
+func apiLoadThreat(c *gin.Context) (models.Threat, bool) {
+	id, ok := apiParamID(c, "id", "угроза")
+	if !ok {
+		return models.Threat{}, false
+	}
+	var th models.Threat
+	if err := database.DB.First(&th, id).Error; err != nil {
+		apiNotFound(c, "Угроза не найдена")
+		return models.Threat{}, false
+	}
+	return th, true
+}
+
+func APIGetThreat(c *gin.Context) {
+	th, ok := apiLoadThreat(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, apiItem{Data: newAPIThreat(th)})
+}
+
+func APICreateThreat(c *gin.Context) {
+	var in threatInput
+	if !apiBind(c, &in) {
+		return
+	}
+	in.Code = strings.TrimSpace(in.Code)
+	in.Name = strings.TrimSpace(in.Name)
+	in.Category = strings.TrimSpace(in.Category)
+	in.Description = strings.TrimSpace(in.Description)
+	if msg := validateThreat(in); msg != "" {
+		apiValidation(c, msg)
+		return
+	}
+
+	uid := middleware.APIUserID(c)
+	th, err := createThreat(in, &uid)
+	if err != nil {
+		apiInternal(c, "Ошибка сохранения угрозы в БД")
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("/api/v1/threats/%d", th.ID))
+	c.JSON(http.StatusCreated, apiItem{Data: newAPIThreat(th)})
+}
+
+// APIUpdateThreat сохраняет черновик следующей версии угрозы
+func APIUpdateThreat(c *gin.Context) {
+	th, ok := apiLoadThreat(c)
+	if !ok {
+		return
+	}
+
+	var in threatVersionInput
+	if !apiBind(c, &in) {
+		return
+	}
+	form, msg := in.version()
+	if msg != "" {
+		apiValidation(c, msg)
+		return
+	}
+
+	uid := middleware.APIUserID(c)
+	if _, err := saveThreatVersion(&th, form, in.Publish, &uid); err != nil {
+		apiInternal(c, "Ошибка сохранения версии угрозы")
+		return
+	}
+	c.JSON(http.StatusOK, apiItem{Data: newAPIThreat(th)})
+}
+
+// APIDeleteThreat выводит угрозу из каталога
+func APIDeleteThreat(c *gin.Context) {
+	th, ok := apiLoadThreat(c)
+	if !ok {
+		return
+	}
+
+	uid := middleware.APIUserID(c)
+	msg, err := deprecateThreat(&th, &uid)
+	if msg != "" {
+		apiConflict(c, msg)
+		return
+	}
+	if err != nil {
+		apiInternal(c, "Ошибка вывода угрозы из каталога")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ---------- меры ----------
+
+// APIListMeasures — GET /api/v1/measures?q=&status=&regulation=&group_code=.
+// regulation=own — только собственные меры (без приказов ФСТЭК).
+func APIListMeasures(c *gin.Context) {
+	query, ok := apiCatalogFilter(c, database.DB.Model(&models.ControlMeasure{}))
+	if !ok {
+		return
+	}
+	switch v := strings.TrimSpace(c.Query("regulation")); v {
+	case "":
+	case "own":
+		query = query.Where("regulation = ?", "")
+	default:
+		query = query.Where("regulation = ?", v)
+	}
+	if v := strings.TrimSpace(c.Query("group_code")); v != "" {
+		query = query.Where("group_code = ?", v)
+	}
+
+	var measures []models.ControlMeasure
+	meta, ok := apiPaginate(c, query, apiCatalogSort, "code", &measures)
+	if !ok {
+		return
+	}
+
+	data := make([]apiMeasure, 0, len(measures))
+	for _, m := range measures {
+		data = append(data, newAPIMeasure(m))
+	}
+	c.JSON(http.StatusOK, apiList{Data: data, Meta: meta})
+}
+
+func apiLoadMeasure(c *gin.Context) (models.ControlMeasure, bool) {
+	id, ok := apiParamID(c, "id", "мера защиты")
+	if !ok {
+		return models.ControlMeasure{}, false
+	}
+	var m models.ControlMeasure
+	if err := database.DB.First(&m, id).Error; err != nil {
+		apiNotFound(c, "Мера защиты не найдена")
+		return models.ControlMeasure{}, false
+	}
+	return m, true
+}
+
+func APIGetMeasure(c *gin.Context) {
+	m, ok := apiLoadMeasure(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, apiItem{Data: newAPIMeasure(m)})
+}
+
+func APICreateMeasure(c *gin.Context) {
+	var in measureInput
+	if !apiBind(c, &in) {
+		return
+	}
+	in.Code = strings.TrimSpace(in.Code)
+	in.Name = strings.TrimSpace(in.Name)
+	in.Standard = strings.TrimSpace(in.Standard)
+	in.Description = strings.TrimSpace(in.Description)
+	if msg := validateMeasure(in); msg != "" {
+		apiValidation(c, msg)
+		return
+	}
+
+	uid := middleware.APIUserID(c)
+	m, err := createMeasure(in, &uid)
+	if err != nil {
+		apiInternal(c, "Ошибка сохранения меры защиты в БД")
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("/api/v1/measures/%d", m.ID))
+	c.JSON(http.StatusCreated, apiItem{Data: newAPIMeasure(m)})
+}
+
+func APIUpdateMeasure(c *gin.Context) {
+	m, ok := apiLoadMeasure(c)
+	if !ok {
+		return
+	}
+
+	var in measureVersionInput
+	if !apiBind(c, &in) {
+		return
+	}
+	form, msg := in.version(m)
+	if msg != "" {
+		apiValidation(c, msg)
+		return
+	}
+
+	uid := middleware.APIUserID(c)
+	if _, err := saveMeasureVersion(&m, form, in.Publish, &uid); err != nil {
+		apiInternal(c, "Ошибка сохранения версии меры")
+		return
+	}
+	c.JSON(http.StatusOK, apiItem{Data: newAPIMeasure(m)})
+}
+
+func APIDeleteMeasure(c *gin.Context) {
+	m, ok := apiLoadMeasure(c)
+	if !ok {
+		return
+	}
+
+	uid := middleware.APIUserID(c)
+	msg, err := deprecateMeasure(&m, &uid)
+	if msg != "" {
+		apiConflict(c, msg)
+		return
+	}
+	if err != nil {
+		apiInternal(c, "Ошибка вывода меры из каталога")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
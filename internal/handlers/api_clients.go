@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"ib-integrator/internal/database"
+	"ib-integrator/internal/middleware"
+	"ib-integrator/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ====== API v1: КЛИЕНТЫ ======
+
+type apiClient struct {
+	ID           uint      `json:"id"`
+	Name         string    `json:"name"`
+	OrgType      string    `json:"org_type"`
+	INN          string    `json:"inn"`
+	Industry     string    `json:"industry"`
+	ContactName  string    `json:"contact_name"`
+	ContactPost  string    `json:"contact_post"`
+	ContactEmail string    `json:"contact_email"`
+	ContactPhone string    `json:"contact_phone"`
+	Notes        string    `json:"notes"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func newAPIClient(cl models.Client) apiClient {
+	return apiClient{
+		ID:           cl.ID,
+		Name:         cl.Name,
+		OrgType:      cl.OrgType,
+		INN:          cl.INN,
+		Industry:     cl.Industry,
+		ContactName:  cl.ContactName,
+		ContactPost:  cl.ContactPost,
+		ContactEmail: cl.ContactEmail,
+		ContactPhone: cl.ContactPhone,
+		Notes:        cl.Notes,
+		CreatedAt:    cl.CreatedAt,
+		UpdatedAt:    cl.UpdatedAt,
+	}
+}
+
+var apiClientSort = apiSortFields{"id": "id", "name": "name", "inn": "inn", "created_at": "created_at", "updated_at": "updated_at"}
+
+// APIListClients — GET /api/v1/clients?q=&industry=&org_type=. Контакты маскируются,
+// как в списке клиентов; полностью — в карточке клиента.
+func APIListClients(c *gin.Context) {
+	query := database.DB.Model(&models.Client{})
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		like := "%" + strings.ToLower(q) + "%"
+		query = query.Where("LOWER(name) LIKE ? OR inn LIKE ?", like, like)
+	}
+	if v := strings.TrimSpace(c.Query("industry")); v != "" {
+		query = query.Where("industry = ?", v)
+	}
+	if v := strings.TrimSpace(c.Query("org_type")); v != "" {
+		query = query.Where("org_type = ?", v)
+	}
+
+	var clients []models.Client
+	meta, ok := apiPaginate(c, query, apiClientSort, "name", &clients)
+	if !ok {
+		return
+	}
+
+	data := make([]apiClient, 0, len(clients))
+	for _, cl := range clients {
+		item := newAPIClient(cl)
+		if item.ContactEmail != "" {
+			item.ContactEmail = models.MaskEmail(item.ContactEmail)
+		}
+		if item.ContactPhone != "" {
+			item.ContactPhone = models.MaskPhone(item.ContactPhone)
+		}
+		data = append(data, item)
+	}
+	c.JSON(http.StatusOK, apiList{Data: data, Meta: meta})
+}
+
+func apiLoadClient(c *gin.Context) (models.Client, bool) {
+	id, ok := apiParamID(c, "id", "клиент")
+	if !ok {
+		return models.Client{}, false
+	}
+	var client models.Client
+	if err := database.DB.First(&client, id).Error; err != nil {
+		apiNotFound(c, "Клиент не найден")
+		return models.Client{}, false
+	}
+	return client, true
+}
+
+func APIGetClient(c *gin.Context) {
+	client, ok := apiLoadClient(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, apiItem{Data: newAPIClient(client)})
+}
+
+func APICreateClient(c *gin.Context) {
+	var in clientInput
+	if !apiBind(c, &in) {
+		return
+	}
+	in.trim()
+	if msg := validateClient(in, 0); msg != "" {
+		apiValidation(c, msg)
+		return
+	}
+
+	var client models.Client
+	in.apply(&client)
+	if err := saveClient(&client, middleware.APIUserID(c)); err != nil {
+		apiInternal(c, "Ошибка сохранения клиента в БД")
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("/api/v1/clients/%d", client.ID))
+	c.JSON(http.StatusCreated, apiItem{Data: newAPIClient(client)})
+}
+
+// APIUpdateClient — PUT: передаётся клиент целиком, отсутствующие поля очищаются
+func APIUpdateClient(c *gin.Context) {
+	client, ok := apiLoadClient(c)
+	if !ok {
+		return
+	}
+
+	var in clientInput
+	if !apiBind(c, &in) {
+		return
+	}
+	in.trim()
+	if msg := validateClient(in, client.ID); msg != "" {
+		apiValidation(c, msg)
+		return
+	}
+
+	in.apply(&client)
+	if err := saveClient(&client, middleware.APIUserID(c)); err != nil {
+		apiInternal(c, "Ошибка сохранения клиента")
+		return
+	}
+	c.JSON(http.StatusOK, apiItem{Data: newAPIClient(client)})
+}
+
+// APIDeleteClient удаляет клиента, у которого нет объектов защиты, проектов и узлов.
+// Удаление физическое: иначе уникальные индексы не дадут снова завести клиента с тем же ИНН.
+func APIDeleteClient(c *gin.Context) {
+	client, ok := apiLoadClient(c)
+	if !ok {
+		return
+	}
+
+	for _, dep := range []struct {
+		model interface{}
+		what  string
+	}{
+		{&models.Asset{}, "объекты защиты"},
+		{&models.Project{}, "проекты"},
+		{&models.Host{}, "узлы инвентаризации"},
+		{&models.CertificateAlert{}, "уведомления по сертификатам СЗИ"},
+	} {
+		var count int64
+		database.DB.Unscoped().Model(dep.model).Where("client_id = ?", client.ID).Count(&count)
+		if count > 0 {
+			apiConflict(c, "У клиента есть "+dep.what+" — удаление невозможно")
+			return
+		}
+	}
+
+	if err := database.DB.Unscoped().Delete(&client).Error; err != nil {
+		apiInternal(c, "Ошибка удаления клиента")
+		return
+	}
+	database.CreateAuditLog(middleware.APIUserID(c), "client", client.ID, "delete", "Удалён клиент: "+client.Name)
+	c.Status(http.StatusNoContent)
+}
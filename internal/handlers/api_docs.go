@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"sort"
+
+	"ib-integrator/internal/middleware"
+	"ib-integrator/internal/models"
+	"ib-integrator/internal/openapi"
+)
+
+// ====== JSON API v1: ОПИСАНИЕ ДЛЯ OPENAPI ======
+//
+// Маршруты и роли берутся из таблицы маршрутов сервера, здесь — только то, чего из неё
+// не узнать: назначение операции, фильтры и типы тела запроса и ответа.
+// Тест в internal/server падает, если маршрут и описание разошлись.
+
+const apiBasePath = "/api/v1"
+
+func (f apiSortFields) names() []string {
+	out := make([]string, 0, len(f))
+	for name := range f {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func apiOp(method, path string) string {
+	return openapi.Key(method, apiBasePath+path)
+}
+
+func riskLevelParam(name, what string) openapi.Param {
+	return openapi.Param{Name: name, Description: what, Enum: models.RiskLevels}
+}
+
+// catalogQuery — фильтры apiCatalogFilter, общие для угроз и мер
+func catalogQuery(extra ...openapi.Param) []openapi.Param {
+	return append([]openapi.Param{
+		{Name: "q", Description: "Поиск по коду и названию"},
+		{Name: "status", Description: "Состояние записи каталога", Enum: catalogStatusValues()},
+	}, extra...)
+}
+
+func catalogStatusValues() []string {
+	out := make([]string, 0, len(models.CatalogStatuses))
+	for _, s := range models.CatalogStatuses {
+		out = append(out, string(s))
+	}
+	return out
+}
+
+func assetTypeValues() []string {
+	out := make([]string, 0, len(models.AssetTypes))
+	for _, t := range models.AssetTypes {
+		out = append(out, string(t))
+	}
+	return out
+}
+
+// apiOperations — описания операций /api/v1 по ключу "МЕТОД путь"
+func apiOperations() map[string]openapi.Op {
+	return map[string]openapi.Op{
+		// клиенты
+		apiOp("GET", "/clients"): {
+			Tag: "clients", Summary: "Список клиентов",
+			Description: "Контакты маскируются, как в списке клиентов; полностью — в карточке клиента.",
+			Sort:        apiClientSort.names(),
+			Query: []openapi.Param{
+				{Name: "q", Description: "Поиск по названию и ИНН"},
+				{Name: "industry", Description: "Отрасль"},
+				{Name: "org_type", Description: "Тип организации"},
+			},
+			Response: apiClient{},
+		},
+		apiOp("POST", "/clients"): {
+			Tag: "clients", Summary: "Создать клиента",
+			Body: clientInput{}, Response: apiClient{}, Created: true,
+		},
+		apiOp("GET", "/clients/:id"): {
+			Tag: "clients", Summary: "Карточка клиента", Response: apiClient{},
+		},
+		apiOp("PUT", "/clients/:id"): {
+			Tag: "clients", Summary: "Изменить клиента",
+			Description: "Запись заменяется целиком: незаданные поля очищаются.",
+			Body:        clientInput{}, Response: apiClient{},
+		},
+		apiOp("DELETE", "/clients/:id"): {
+			Tag: "clients", Summary: "Удалить клиента",
+			Conflict: "У клиента есть объекты защиты, проекты, узлы или уведомления",
+		},
+
+		// объекты защиты
+		apiOp("GET", "/assets"): {
+			Tag: "assets", Summary: "Список объектов защиты",
+			Sort: apiAssetSort.names(),
+			Query: []openapi.Param{
+				{Name: "client_id", Type: "integer", Description: "Клиент"},
+				{Name: "asset_type", Description: "Тип объекта", Enum: assetTypeValues()},
+				{Name: "q", Description: "Поиск по названию"},
+			},
+			Response: apiAsset{},
+		},
+		apiOp("POST", "/assets"): {
+			Tag: "assets", Summary: "Создать объект защиты",
+			Description: "Категория (класс) ГИС не задаётся вручную — она определяется по классификации.",
+			Body:        assetInput{}, Response: apiAsset{}, Created: true,
+		},
+		apiOp("GET", "/assets/:id"): {
+			Tag: "assets", Summary: "Объект защиты", Response: apiAsset{},
+		},
+		apiOp("PUT", "/assets/:id"): {
+			Tag: "assets", Summary: "Изменить объект защиты",
+			Description: "Ручная смена класса ГИС требует обоснования (category_justification).",
+			Body:        assetInput{}, Response: apiAsset{},
+		},
+		apiOp("DELETE", "/assets/:id"): {
+			Tag: "assets", Summary: "Удалить объект защиты",
+		},
+
+		// угрозы объекта
+		apiOp("GET", "/assets/:id/threats"): {
+			Tag: "asset-threats", Summary: "Угрозы объекта и оценка риска",
+			Sort: apiAssetThreatSort.names(),
+			Query: []openapi.Param{
+				riskLevelParam("risk_level", "Уровень исходного риска"),
+				riskLevelParam("residual_level", "Уровень остаточного риска"),
+				{Name: "excluded", Type: "boolean", Description: "Исключена из модели угроз"},
+			},
+			Response: apiAssetThreat{},
+		},
+		apiOp("POST", "/assets/:id/threats"): {
+			Tag: "asset-threats", Summary: "Привязать угрозу к объекту",
+			Description: "Вероятность и ущерб оцениваются по шкале матрицы рисков.",
+			Body:        assetThreatInput{}, Response: apiAssetThreat{}, Created: true,
+		},
+		apiOp("GET", "/assets/:id/threats/:link_id"): {
+			Tag: "asset-threats", Summary: "Угроза объекта", Response: apiAssetThreat{},
+		},
+		apiOp("PUT", "/assets/:id/threats/:link_id"): {
+			Tag: "asset-threats", Summary: "Переоценить угрозу объекта",
+			Description: "threat_id менять нельзя. Снижение остаточного риска требует мер защиты (measure_ids).",
+			Body:        assetThreatInput{}, Response: apiAssetThreat{},
+		},
+		apiOp("DELETE", "/assets/:id/threats/:link_id"): {
+			Tag: "asset-threats", Summary: "Отвязать угрозу от объекта",
+		},
+
+		// каталог угроз
+		apiOp("GET", "/threats"): {
+			Tag: "threats", Summary: "Каталог угроз",
+			Sort: apiCatalogSort.names(),
+			Query: catalogQuery(
+				openapi.Param{Name: "category", Description: "Категория угрозы"},
+				openapi.Param{Name: "withdrawn", Type: "boolean", Description: "Исключена из БДУ ФСТЭК"},
+			),
+			Response: apiThreat{},
+		},
+		apiOp("POST", "/threats"): {
+			Tag: "threats", Summary: "Добавить угрозу в каталог",
+			Description: "Создаётся версия 1 — черновиком или сразу опубликованной (publish).",
+			Body:        threatInput{}, Response: apiThreat{}, Created: true,
+		},
+		apiOp("GET", "/threats/:id"): {
+			Tag: "threats", Summary: "Угроза каталога", Response: apiThreat{},
+		},
+		apiOp("PUT", "/threats/:id"): {
+			Tag: "threats", Summary: "Новая версия угрозы",
+			Description: "Сохраняет черновик следующей версии; с publish — сразу публикует его.",
+			Body:        threatVersionInput{}, Response: apiThreat{},
+		},
+		apiOp("DELETE", "/threats/:id"): {
+			Tag: "threats", Summary: "Вывести угрозу из употребления",
+			Conflict: "Угроза не опубликована или уже выведена",
+		},
+
+		// каталог мер
+		apiOp("GET", "/measures"): {
+			Tag: "measures", Summary: "Каталог мер защиты",
+			Sort: apiCatalogSort.names(),
+			Query: catalogQuery(
+				openapi.Param{Name: "regulation", Description: "Приказ ФСТЭК (21, 17, 31, 239) или own — собственные меры"},
+				openapi.Param{Name: "group_code", Description: "Группа мер приказа"},
+			),
+			Response: apiMeasure{},
+		},
+		apiOp("POST", "/measures"): {
+			Tag: "measures", Summary: "Добавить меру в каталог",
+			Body: measureInput{}, Response: apiMeasure{}, Created: true,
+		},
+		apiOp("GET", "/measures/:id"): {
+			Tag: "measures", Summary: "Мера каталога", Response: apiMeasure{},
+		},
+		apiOp("PUT", "/measures/:id"): {
+			Tag: "measures", Summary: "Новая версия меры",
+			Body: measureVersionInput{}, Response: apiMeasure{},
+		},
+		apiOp("DELETE", "/measures/:id"): {
+			Tag: "measures", Summary: "Вывести меру из употребления",
+			Conflict: "Мера не опубликована или уже выведена",
+		},
+
+		// журнал аудита
+		apiOp("GET", "/audit-logs"): {
+			Tag: "audit", Summary: "Журнал аудита",
+			Description: "Действия через API, использование токенов и события входа (entity=auth) пишутся в тот же журнал.",
+			Sort:        apiAuditSort.names(),
+			Query: []openapi.Param{
+				{Name: "entity", Description: "Тип записи: client, asset, threat, api_token, auth…"},
+				{Name: "action", Description: "Действие: create, update, delete, use, login_failed, lockout…"},
+				{Name: "entity_id", Type: "integer", Description: "Запись"},
+				{Name: "user_id", Type: "integer", Description: "Пользователь"},
+				{Name: "from", Format: "date", Description: "С даты, ГГГГ-ММ-ДД"},
+				{Name: "to", Format: "date", Description: "По дату включительно, ГГГГ-ММ-ДД"},
+			},
+			Response: apiAuditLog{},
+		},
+	}
+}
+
+// APISpec собирает спецификацию OpenAPI по маршрутам /api/v1. Второй результат —
+// расхождения между маршрутами и описаниями (пусто, если всё сходится).
+func APISpec(routes []openapi.Route) (*openapi.Document, []string) {
+	b := openapi.NewBuilder(openapi.Info{
+		Title:   "IB Integrator API",
+		Version: "1",
+		Description: "JSON API для интеграций и внутренних сервисов. Ответы: {\"data\": ...} для записи, " +
+			"{\"data\": [...], \"meta\": {...}} для списка, ошибки — {\"error\": {\"status\", \"code\", \"message\"}}. " +
+			"Проверки и журнал аудита — те же, что у веб-интерфейса.",
+	}, apiBasePath)
+
+	b.Security("bearerAuth", openapi.SecurityScheme{
+		Type: "http", Scheme: "bearer",
+		Description: "Персональный токен или токен сервисной учётной записи (раздел «Токены API»)",
+	})
+	b.Security("cookieAuth", openapi.SecurityScheme{
+		Type: "apiKey", In: "cookie", Name: "ib_session",
+		Description: "Сессия браузера после входа в веб-интерфейс",
+	})
+
+	b.Enum(models.AssetType(""), assetTypeValues()...)
+	b.Enum(models.CatalogStatus(""), catalogStatusValues()...)
+	b.Required(clientInput{}, "name")
+	b.Required(assetInput{}, "client_id", "name", "asset_type")
+	b.Required(assetThreatInput{}, "threat_id", "likelihood", "impact")
+	b.Required(threatInput{}, "name")
+	b.Required(threatVersionInput{}, "name")
+	b.Required(measureInput{}, "name")
+	b.Required(measureVersionInput{}, "name")
+	b.Errors(middleware.APIErrorBody{})
+	b.Meta(apiMeta{})
+
+	b.Tag("clients", "Клиенты")
+	b.Tag("assets", "Объекты защиты")
+	b.Tag("asset-threats", "Угрозы объекта и оценка риска")
+	b.Tag("threats", "Каталог угроз")
+	b.Tag("measures", "Каталог мер защиты")
+	b.Tag("audit", "Журнал аудита")
+
+	return b.Build(apiBasePath, routes, apiOperations())
+}
@@ -0,0 +1,342 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ib-integrator/internal/apitoken"
+	"ib-integrator/internal/database"
+	"ib-integrator/internal/models"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+// ====== ТОКЕНЫ API И СЕРВИСНЫЕ УЧЁТНЫЕ ЗАПИСИ ======
+
+// сроки действия токена на выбор, в днях; 0 — бессрочный, только для сервисных учётных записей
+var tokenExpiryDays = []int{7, 30, 90, 180, 365}
+
+var tokenRoles = []models.UserRole{models.RoleAdmin, models.RoleEngineer, models.RoleSales, models.RoleViewer}
+
+// delegableRoles — роли, которые можно выдать токену владельца с ролью owner
+func delegableRoles(owner models.UserRole) []models.UserRole {
+	var out []models.UserRole
+	for _, r := range tokenRoles {
+		if models.CanDelegate(owner, r) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+type tokenForm struct {
+	Name        string `form:"name"`
+	Role        string `form:"role"`
+	Scope       string `form:"scope"`
+	ExpiresDays string `form:"expires_days"`
+}
+
+// buildToken проверяет форму выпуска токена для владельца owner; allowUnlimited — можно ли бессрочный
+func buildToken(form tokenForm, owner models.User, allowUnlimited bool) (models.APIToken, string) {
+	token := models.APIToken{
+		UserID: owner.ID,
+		Name:   strings.TrimSpace(form.Name),
+		Role:   models.UserRole(form.Role),
+		Scope:  models.TokenScope(form.Scope),
+	}
+	if len([]rune(token.Name)) < 3 || len([]rune(token.Name)) > 100 {
+		return token, "Название токена — от 3 до 100 символов"
+	}
+	if !models.CanDelegate(owner.Role, token.Role) {
+		return token, "Эту роль нельзя выдать токену"
+	}
+	if !token.Scope.Valid() {
+		return token, "Укажите, разрешена ли токену запись"
+	}
+
+	days, err := strconv.Atoi(form.ExpiresDays)
+	if err != nil {
+		return token, "Укажите срок действия токена"
+	}
+	if days == 0 && allowUnlimited {
+		return token, ""
+	}
+	valid := false
+	for _, d := range tokenExpiryDays {
+		if d == days {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return token, "Недопустимый срок действия токена"
+	}
+	expires := time.Now().AddDate(0, 0, days)
+	token.ExpiresAt = &expires
+	return token, ""
+}
+
+// issueToken сохраняет токен и пишет выпуск в журнал аудита
+func issueToken(token *models.APIToken, owner models.User, by uint) (string, error) {
+	token.CreatedByID = by
+	plain, err := apitoken.Issue(token)
+	if err != nil {
+		return "", err
+	}
+	expires := "бессрочно"
+	if token.ExpiresAt != nil {
+		expires = "до " + token.ExpiresAt.Format("02.01.2006")
+	}
+	database.CreateAuditLog(by, "api_token", token.ID, "create",
+		fmt.Sprintf("Выпущен токен «%s» (%s…) для %s: роль %s, %s, %s",
+			token.Name, token.Prefix, owner.Username, token.Role, token.Scope.Label(), expires))
+	return plain, nil
+}
+
+func renderTokens(c *gin.Context, status int, extra gin.H) {
+	sess := sessions.Default(c)
+	uid, _ := sess.Get("user_id").(uint)
+	roleStr, _ := sess.Get("role").(string)
+
+	var tokens []models.APIToken
+	database.DB.Where("user_id = ?", uid).Order("created_at desc").Find(&tokens)
+
+	data := gin.H{
+		"role":        roleStr,
+		"tokens":      tokens,
+		"roles":       delegableRoles(models.UserRole(roleStr)),
+		"expiryDays":  tokenExpiryDays,
+		"defaultDays": 90,
+		"now":         time.Now(),
+		"form":        tokenForm{Role: roleStr, Scope: string(models.TokenScopeRead)},
+	}
+	for k, v := range extra {
+		data[k] = v
+	}
+	render(c, status, "api_tokens.html", data)
+}
+
+// ShowTokens — персональные токены текущего пользователя
+func ShowTokens(c *gin.Context) {
+	renderTokens(c, http.StatusOK, nil)
+}
+
+// CreateToken — выпуск персонального токена; сам токен показывается один раз
+func CreateToken(c *gin.Context) {
+	uid, _ := sessions.Default(c).Get("user_id").(uint)
+	var owner models.User
+	if err := database.DB.First(&owner, uid).Error; err != nil {
+		c.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+
+	var form tokenForm
+	_ = c.ShouldBind(&form)
+	token, msg := buildToken(form, owner, false)
+	if msg != "" {
+		renderTokens(c, http.StatusBadRequest, gin.H{"error": msg, "form": form})
+		return
+	}
+	plain, err := issueToken(&token, owner, uid)
+	if err != nil {
+		renderTokens(c, http.StatusInternalServerError, gin.H{"error": "Ошибка выпуска токена"})
+		return
+	}
+	renderTokens(c, http.StatusOK, gin.H{"newToken": plain, "newTokenName": token.Name})
+}
+
+// RevokeToken — отзыв токена: владелец отзывает свои, администратор — любые
+func RevokeToken(c *gin.Context) {
+	sess := sessions.Default(c)
+	uid, _ := sess.Get("user_id").(uint)
+	roleStr, _ := sess.Get("role").(string)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.String(http.StatusBadRequest, "Некорректный ID токена")
+		return
+	}
+	var token models.APIToken
+	if err := database.DB.Preload("User").First(&token, id).Error; err != nil {
+		c.String(http.StatusNotFound, "Токен не найден")
+		return
+	}
+	if token.UserID != uid && models.UserRole(roleStr) != models.RoleAdmin {
+		c.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+
+	if token.RevokedAt == nil {
+		if err := apitoken.Revoke(&token, uid); err != nil {
+			c.String(http.StatusInternalServerError, "Ошибка отзыва токена")
+			return
+		}
+		database.CreateAuditLog(uid, "api_token", token.ID, "revoke",
+			fmt.Sprintf("Отозван токен «%s» (%s…) пользователя %s", token.Name, token.Prefix, token.User.Username))
+	}
+
+	back := c.PostForm("back")
+	if !strings.HasPrefix(back, "/") || strings.HasPrefix(back, "//") {
+		back = "/tokens"
+	}
+	c.Redirect(http.StatusFound, back)
+}
+
+// ---------- сервисные учётные записи (только админ) ----------
+
+type serviceAccountView struct {
+	Account models.User
+	Tokens  []models.APIToken
+	Roles   []models.UserRole // роли, которые можно выдать токенам этой учётной записи
+}
+
+func renderServiceAccounts(c *gin.Context, status int, extra gin.H) {
+	var accounts []models.User
+	database.DB.Where("is_service = ?", true).Order("username").Find(&accounts)
+
+	ids := make([]uint, 0, len(accounts))
+	for _, a := range accounts {
+		ids = append(ids, a.ID)
+	}
+	byUser := map[uint][]models.APIToken{}
+	if len(ids) > 0 {
+		var tokens []models.APIToken
+		database.DB.Where("user_id IN ?", ids).Order("created_at desc").Find(&tokens)
+		for _, t := range tokens {
+			byUser[t.UserID] = append(byUser[t.UserID], t)
+		}
+	}
+	views := make([]serviceAccountView, 0, len(accounts))
+	for _, a := range accounts {
+		views = append(views, serviceAccountView{Account: a, Tokens: byUser[a.ID], Roles: delegableRoles(a.Role)})
+	}
+
+	// действующие персональные токены сотрудников — чтобы администратор мог отозвать любой
+	var personal []models.APIToken
+	database.DB.Preload("User").
+		Joins("JOIN users ON users.id = api_tokens.user_id AND users.is_service = ?", false).
+		Where("api_tokens.revoked_at IS NULL").
+		Where("api_tokens.expires_at IS NULL OR api_tokens.expires_at > ?", time.Now()).
+		Order("api_tokens.created_at desc").
+		Find(&personal)
+
+	roleStr, _ := sessions.Default(c).Get("role").(string)
+	data := gin.H{
+		"role":       roleStr,
+		"accounts":   views,
+		"personal":   personal,
+		"roles":      tokenRoles,
+		"expiryDays": tokenExpiryDays,
+		"now":        time.Now(),
+	}
+	for k, v := range extra {
+		data[k] = v
+	}
+	render(c, status, "service_accounts.html", data)
+}
+
+func ShowServiceAccounts(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+	renderServiceAccounts(c, http.StatusOK, nil)
+}
+
+type serviceAccountForm struct {
+	Username    string `form:"username"`
+	Role        string `form:"role"`
+	Description string `form:"description"`
+}
+
+// CreateServiceAccount — учётная запись для интеграции: без пароля, доступ только по токенам
+func CreateServiceAccount(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+	uid, _ := sessions.Default(c).Get("user_id").(uint)
+
+	var form serviceAccountForm
+	_ = c.ShouldBind(&form)
+	form.Username = strings.TrimSpace(form.Username)
+	form.Description = strings.TrimSpace(form.Description)
+
+	role := models.UserRole(form.Role)
+	var msg string
+	switch {
+	case len(form.Username) < 3 || len(form.Username) > 50:
+		msg = "Имя учётной записи — от 3 до 50 символов"
+	case !models.CanDelegate(models.RoleAdmin, role):
+		msg = "Неверная роль"
+	case len([]rune(form.Description)) > 255:
+		msg = "Слишком длинное описание"
+	}
+	if msg == "" {
+		var count int64
+		database.DB.Unscoped().Model(&models.User{}).Where("username = ?", form.Username).Count(&count)
+		if count > 0 {
+			msg = "Пользователь с таким именем уже существует"
+		}
+	}
+	if msg != "" {
+		renderServiceAccounts(c, http.StatusBadRequest, gin.H{"error": msg, "accountForm": form})
+		return
+	}
+
+	account := models.User{
+		Username: form.Username,
+		// пароля нет: "!" не является bcrypt-хешем, вход по паролю невозможен
+		PasswordHash: "!",
+		Role:         role,
+		Status:       models.UserActive,
+		IsService:    true,
+		Description:  form.Description,
+	}
+	if err := database.DB.Create(&account).Error; err != nil {
+		renderServiceAccounts(c, http.StatusInternalServerError, gin.H{"error": "Ошибка сохранения учётной записи"})
+		return
+	}
+	database.CreateAuditLog(uid, "user", account.ID, "create",
+		fmt.Sprintf("Создана сервисная учётная запись %s (роль %s)", account.Username, account.Role))
+
+	c.Redirect(http.StatusFound, "/service-accounts")
+}
+
+// IssueServiceToken — токен для сервисной учётной записи; может быть бессрочным
+func IssueServiceToken(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+	uid, _ := sessions.Default(c).Get("user_id").(uint)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.String(http.StatusBadRequest, "Некорректный ID учётной записи")
+		return
+	}
+	var account models.User
+	if err := database.DB.Where("is_service = ?", true).First(&account, id).Error; err != nil {
+		c.String(http.StatusNotFound, "Сервисная учётная запись не найдена")
+		return
+	}
+
+	var form tokenForm
+	_ = c.ShouldBind(&form)
+	token, msg := buildToken(form, account, true)
+	if msg != "" {
+		renderServiceAccounts(c, http.StatusBadRequest, gin.H{"error": account.Username + ": " + msg})
+		return
+	}
+	plain, err := issueToken(&token, account, uid)
+	if err != nil {
+		renderServiceAccounts(c, http.StatusInternalServerError, gin.H{"error": "Ошибка выпуска токена"})
+		return
+	}
+	renderServiceAccounts(c, http.StatusOK, gin.H{
+		"newToken":     plain,
+		"newTokenName": account.Username + " / " + token.Name,
+	})
+}
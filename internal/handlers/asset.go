@@ -1,203 +1,291 @@
-package handlers
-
-import (
-	"net/http"
-	"strings"
-
-	"ib-integrator/internal/database"
-	"ib-integrator/internal/models"
-
-	"github.com/gin-contrib/sessions"
-	"github.com/gin-gonic/gin"
-)
-
-// СПИСОК ОБЪЕКТОВ ЗАЩИТЫ
-
-func ListAssets(c *gin.Context) {
-	sess := sessions.Default(c)
-	roleStr, _ := sess.Get("role").(string)
-
-	var assets []models.Asset
-	database.DB.Preload("Client").Order("client_id asc, name asc").Find(&assets)
-
-	render(c, http.StatusOK, "assets_list.html", gin.H{
-		"assets": assets,
-		"role":   roleStr,
-	})
-}
-
-// СОЗДАНИЕ НОВОГО ОБЪЕКТА
-
-func ShowNewAsset(c *gin.Context) {
-	var clients []models.Client
-	database.DB.Order("name asc").Find(&clients)
-
-	render(c, http.StatusOK, "assets_new.html", gin.H{
-		"clients": clients,
-		"error":   "",
-	})
-}
-
-func CreateAsset(c *gin.Context) {
-	name := strings.TrimSpace(c.PostForm("name"))
-	clientIDStr := c.PostForm("client_id")
-	aTypeStr := strings.TrimSpace(c.PostForm("asset_type"))
-	category := strings.TrimSpace(c.PostForm("category"))
-	description := strings.TrimSpace(c.PostForm("description"))
-
-	if len(name) < 3 {
-		renderAssetError(c, "Название объекта защиты должно быть не короче 3 символов")
-		return
-	}
-
-	if aTypeStr == "" {
-		renderAssetError(c, "Укажите тип объекта защиты")
-		return
-	}
-
-	var client models.Client
-	if err := database.DB.First(&client, clientIDStr).Error; err != nil {
-		renderAssetError(c, "Клиент не найден")
-		return
-	}
-
-	// Для ИСПДн/ГИС — требуем указать класс/уровень защищённости
-	upperType := strings.ToUpper(aTypeStr)
-	if (strings.Contains(upperType, "ИСПД") || strings.Contains(upperType, "ГИС")) && category == "" {
-		renderAssetError(c, "Для ИСПДн/ГИС необходимо указать класс/уровень защищённости")
-		return
-	}
-
-	asset := models.Asset{
-		ClientID:    client.ID,
-		Name:        name,
-		AssetType:   models.AssetType(aTypeStr),
-		Category:    category,
-		Description: description,
-	}
-
-	if err := database.DB.Create(&asset).Error; err != nil {
-		renderAssetError(c, "Ошибка сохранения объекта защиты в БД")
-		return
-	}
-
-	sess := sessions.Default(c)
-	if uid, ok := sess.Get("user_id").(uint); ok {
-		database.CreateAuditLog(uid, "asset", asset.ID, "create", "Создан объект защиты: "+asset.Name)
-	}
-
-	c.Redirect(http.StatusFound, "/assets")
-}
-
-func renderAssetError(c *gin.Context, msg string) {
-	var clients []models.Client
-	database.DB.Order("name asc").Find(&clients)
-
-	render(c, http.StatusBadRequest, "assets_new.html", gin.H{
-		"error":   msg,
-		"clients": clients,
-	})
-}
-
-// РЕДАКТИРОВАНИЕ ОБЪЕКТА
-
-func ShowEditAsset(c *gin.Context) {
-	sess := sessions.Default(c)
-	roleStr, _ := sess.Get("role").(string)
-	role := models.UserRole(roleStr)
-	if role != models.RoleAdmin {
-		c.AbortWithStatus(http.StatusForbidden)
-		return
-	}
-
-	id := c.Param("id")
-
-	var asset models.Asset
-	if err := database.DB.Preload("Client").First(&asset, id).Error; err != nil {
-		c.String(http.StatusNotFound, "Объект защиты не найден")
-		return
-	}
-
-	var clients []models.Client
-	database.DB.Order("name asc").Find(&clients)
-
-	render(c, http.StatusOK, "assets_edit.html", gin.H{
-		"asset":   asset,
-		"clients": clients,
-		"error":   "",
-	})
-}
-
-func UpdateAsset(c *gin.Context) {
-	sess := sessions.Default(c)
-	roleStr, _ := sess.Get("role").(string)
-	role := models.UserRole(roleStr)
-	if role != models.RoleAdmin {
-		c.AbortWithStatus(http.StatusForbidden)
-		return
-	}
-
-	id := c.Param("id")
-
-	var asset models.Asset
-	if err := database.DB.First(&asset, id).Error; err != nil {
-		c.String(http.StatusNotFound, "Объект защиты не найден")
-		return
-	}
-
-	name := strings.TrimSpace(c.PostForm("name"))
-	clientIDStr := c.PostForm("client_id")
-	aTypeStr := strings.TrimSpace(c.PostForm("asset_type"))
-	category := strings.TrimSpace(c.PostForm("category"))
-	description := strings.TrimSpace(c.PostForm("description"))
-
-	if len(name) < 3 {
-		renderAssetEditError(c, asset, "Название объекта защиты должно быть не короче 3 символов")
-		return
-	}
-
-	if aTypeStr == "" {
-		renderAssetEditError(c, asset, "Укажите тип объекта защиты")
-		return
-	}
-
-	var client models.Client
-	if err := database.DB.First(&client, clientIDStr).Error; err != nil {
-		renderAssetEditError(c, asset, "Клиент не найден")
-		return
-	}
-
-	upperType := strings.ToUpper(aTypeStr)
-	if (strings.Contains(upperType, "ИСПД") || strings.Contains(upperType, "ГИС")) && category == "" {
-		renderAssetEditError(c, asset, "Для ИСПДн/ГИС необходимо указать класс/уровень защищённости")
-		return
-	}
-
-	asset.ClientID = client.ID
-	asset.Name = name
-	asset.AssetType = models.AssetType(aTypeStr)
-	asset.Category = category
-	asset.Description = description
-
-	if err := database.DB.Save(&asset).Error; err != nil {
-		renderAssetEditError(c, asset, "Ошибка сохранения объекта защиты в БД")
-		return
-	}
-
-	if uid, ok := sess.Get("user_id").(uint); ok {
-		database.CreateAuditLog(uid, "asset", asset.ID, "update", "Изменён объект защиты: "+asset.Name)
-	}
-
-	c.Redirect(http.StatusFound, "/assets")
-}
-
-func renderAssetEditError(c *gin.Context, asset models.Asset, msg string) {
-	var clients []models.Client
-	database.DB.Order("name asc").Find(&clients)
-
-	render(c, http.StatusBadRequest, "assets_edit.html", gin.H{
-		"error":   msg,
-		"asset":   asset,
-		"clients": clients,
-	})
-}
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"ib-integrator/internal/database"
+	"ib-integrator/internal/models"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// СПИСОК ОБЪЕКТОВ ЗАЩИТЫ
+
+func ListAssets(c *gin.Context) {
+	sess := sessions.Default(c)
+	roleStr, _ := sess.Get("role").(string)
+
+	var assets []models.Asset
+	database.DB.Preload("Client").Order("client_id asc, name asc").Find(&assets)
+
+	render(c, http.StatusOK, "assets_list.html", gin.H{
+		"assets": assets,
+		"role":   roleStr,
+	})
+}
+
+// СОЗДАНИЕ НОВОГО ОБЪЕКТА
+
+func ShowNewAsset(c *gin.Context) {
+	var clients []models.Client
+	database.DB.Order("name asc").Find(&clients)
+
+	render(c, http.StatusOK, "assets_new.html", gin.H{
+		"clients":    clients,
+		"assetTypes": models.AssetTypes,
+		"error":      "",
+	})
+}
+
+func CreateAsset(c *gin.Context) {
+	in := bindAssetForm(c)
+	asset, _, msg, err := prepareAsset(in, models.Asset{})
+	if msg != "" || err != nil {
+		renderAssetError(c, msg)
+		return
+	}
+
+	uid, _ := sessions.Default(c).Get("user_id").(uint)
+	if err := saveAsset(&asset, nil, "", uid); err != nil {
+		renderAssetError(c, "Ошибка сохранения объекта защиты в БД")
+		return
+	}
+
+	switch asset.AssetType {
+	case models.AssetISPD:
+		c.Redirect(http.StatusFound, fmt.Sprintf("/assets/%d/ispdn", asset.ID))
+	case models.AssetGIS:
+		c.Redirect(http.StatusFound, fmt.Sprintf("/assets/%d/gis", asset.ID))
+	default:
+		c.Redirect(http.StatusFound, "/assets")
+	}
+}
+
+func renderAssetError(c *gin.Context, msg string) {
+	var clients []models.Client
+	database.DB.Order("name asc").Find(&clients)
+
+	render(c, http.StatusBadRequest, "assets_new.html", gin.H{
+		"error":      msg,
+		"clients":    clients,
+		"assetTypes": models.AssetTypes,
+	})
+}
+
+// РЕДАКТИРОВАНИЕ ОБЪЕКТА
+
+func ShowEditAsset(c *gin.Context) {
+	sess := sessions.Default(c)
+	roleStr, _ := sess.Get("role").(string)
+	role := models.UserRole(roleStr)
+	if role != models.RoleAdmin {
+		c.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+
+	id := c.Param("id")
+
+	var asset models.Asset
+	if err := database.DB.Preload("Client").First(&asset, id).Error; err != nil {
+		c.String(http.StatusNotFound, "Объект защиты не найден")
+		return
+	}
+
+	var clients []models.Client
+	database.DB.Order("name asc").Find(&clients)
+
+	render(c, http.StatusOK, "assets_edit.html", gin.H{
+		"asset":      asset,
+		"clients":    clients,
+		"assetTypes": models.AssetTypes,
+		"error":      "",
+	})
+}
+
+func UpdateAsset(c *gin.Context) {
+	sess := sessions.Default(c)
+	roleStr, _ := sess.Get("role").(string)
+	role := models.UserRole(roleStr)
+	if role != models.RoleAdmin {
+		c.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+
+	id := c.Param("id")
+
+	var asset models.Asset
+	if err := database.DB.First(&asset, id).Error; err != nil {
+		c.String(http.StatusNotFound, "Объект защиты не найден")
+		return
+	}
+
+	updated, override, msg, err := prepareAsset(bindAssetForm(c), asset)
+	if err != nil {
+		renderAssetEditError(c, asset, "Ошибка загрузки классификации ГИС")
+		return
+	}
+	if msg != "" {
+		renderAssetEditError(c, asset, msg)
+		return
+	}
+
+	uid, _ := sess.Get("user_id").(uint)
+	if err := saveAsset(&updated, override, asset.Category, uid); err != nil {
+		renderAssetEditError(c, updated, "Ошибка сохранения объекта защиты в БД")
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/assets")
+}
+
+func renderAssetEditError(c *gin.Context, asset models.Asset, msg string) {
+	var clients []models.Client
+	database.DB.Order("name asc").Find(&clients)
+
+	render(c, http.StatusBadRequest, "assets_edit.html", gin.H{
+		"error":      msg,
+		"asset":      asset,
+		"clients":    clients,
+		"assetTypes": models.AssetTypes,
+	})
+}
+
+// assetInput — поля объекта защиты из формы или из запроса к API
+type assetInput struct {
+	ClientID    uint   `json:"client_id"`
+	Name        string `json:"name"`
+	AssetType   string `json:"asset_type"`
+	Category    string `json:"category"`
+	Description string `json:"description"`
+	// обоснование ручного изменения рассчитанного класса ГИС
+	CategoryJustification string `json:"category_justification"`
+}
+
+func bindAssetForm(c *gin.Context) assetInput {
+	clientID, _ := strconv.ParseUint(c.PostForm("client_id"), 10, 64)
+	in := assetInput{
+		ClientID:              uint(clientID),
+		Name:                  c.PostForm("name"),
+		AssetType:             c.PostForm("asset_type"),
+		Category:              c.PostForm("category"),
+		Description:           c.PostForm("description"),
+		CategoryJustification: c.PostForm("category_justification"),
+	}
+	in.trim()
+	return in
+}
+
+func (in *assetInput) trim() {
+	for _, f := range []*string{&in.Name, &in.AssetType, &in.Category, &in.Description, &in.CategoryJustification} {
+		*f = strings.TrimSpace(*f)
+	}
+}
+
+// prepareAsset проверяет поля и переносит их в объект. current — сохранённый объект
+// (для нового — пустой). Если класс ГИС изменён вручную с обоснованием, возвращается
+// акт классификации, который нужно сохранить вместе с объектом; err — ошибка загрузки акта.
+func prepareAsset(in assetInput, current models.Asset) (models.Asset, *models.GISClassification, string, error) {
+	asset := current
+	if len(in.Name) < 3 {
+		return asset, nil, "Название объекта защиты должно быть не короче 3 символов", nil
+	}
+	if in.AssetType == "" {
+		return asset, nil, "Укажите тип объекта защиты", nil
+	}
+
+	var client models.Client
+	if in.ClientID == 0 || database.DB.First(&client, in.ClientID).Error != nil {
+		return asset, nil, "Клиент не найден", nil
+	}
+
+	// Для ИСПДн/ГИС — требуем указать класс/уровень защищённости
+	category := in.Category
+	upperType := strings.ToUpper(in.AssetType)
+	if (strings.Contains(upperType, "ИСПД") || strings.Contains(upperType, "ГИС")) && category == "" {
+		return asset, nil, "Для ИСПДн/ГИС необходимо указать класс/уровень защищённости", nil
+	}
+
+	assetType := models.AssetType(in.AssetType)
+	var override *models.GISClassification
+	switch {
+	case current.ID == 0:
+		// УЗ ИСПДн (ПП №1119) и класс ГИС (приказ №17) рассчитываются по анкете и из формы не берутся
+		if assetType == models.AssetISPD || assetType == models.AssetGIS {
+			category = ""
+		}
+
+	// УЗ ИСПДн меняется только через анкету; при смене типа на ИСПДн его нужно рассчитать заново
+	case assetType == models.AssetISPD:
+		if current.AssetType == models.AssetISPD {
+			category = current.Category
+		} else {
+			category = ""
+		}
+
+	// класс ГИС определяется актом классификации; ручное изменение — только с обоснованием
+	case assetType == models.AssetGIS:
+		if current.AssetType != models.AssetGIS {
+			category = ""
+			break
+		}
+		g, err := findGISClassification(current.ID)
+		if err != nil {
+			return asset, nil, "", err
+		}
+		switch {
+		case g.Class == 0:
+			// класс ещё не рассчитан — значение в карточке меняется только через анкету
+			category = current.Category
+		case category != current.Category:
+			if in.CategoryJustification == "" {
+				return asset, nil, fmt.Sprintf(
+					"Класс ГИС %s определён по приказу №17. Для ручного изменения укажите обоснование",
+					models.GISClassLabel(g.Class)), nil
+			}
+			g.OverrideJustification = in.CategoryJustification
+			override = &g
+		}
+	}
+
+	asset.ClientID = client.ID
+	asset.Name = in.Name
+	asset.AssetType = assetType
+	asset.Category = category
+	asset.Description = in.Description
+	return asset, override, "", nil
+}
+
+// saveAsset создаёт или сохраняет объект защиты (с ручной переклассификацией ГИС,
+// если она есть) и пишет в журнал аудита. oldCategory — класс до изменения.
+func saveAsset(asset *models.Asset, override *models.GISClassification, oldCategory string, uid uint) error {
+	created := asset.ID == 0
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Client").Save(asset).Error; err != nil {
+			return err
+		}
+		if override != nil {
+			return tx.Save(override).Error
+		}
+		return nil
+	})
+	if err != nil || uid == 0 {
+		return err
+	}
+
+	if created {
+		database.CreateAuditLog(uid, "asset", asset.ID, "create", "Создан объект защиты: "+asset.Name)
+		return nil
+	}
+	database.CreateAuditLog(uid, "asset", asset.ID, "update", "Изменён объект защиты: "+asset.Name)
+	if override != nil {
+		database.CreateAuditLog(uid, "asset", asset.ID, "class_override",
+			fmt.Sprintf("Объект %s: класс ГИС изменён вручную %s → %s. Обоснование: %s",
+				asset.Name, oldCategory, asset.Category, override.OverrideJustification))
+	}
+	return nil
+}
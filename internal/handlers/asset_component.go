@@ -0,0 +1,579 @@
+package handlers
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"ib-integrator/internal/database"
+	"ib-integrator/internal/models"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ====== СОСТАВ ОБЪЕКТА ЗАЩИТЫ: СЕГМЕНТЫ, КОМПОНЕНТЫ, ПОТОКИ ДАННЫХ ======
+
+// inventorySegment — компоненты одного сегмента; Segment == nil — компоненты вне сегментов
+type inventorySegment struct {
+	Segment    *models.AssetSegment
+	Components []models.AssetComponent
+}
+
+// assetInventory — структурированный состав объекта для страниц и документов
+type assetInventory struct {
+	Segments   []models.AssetSegment
+	Components []models.AssetComponent
+	Groups     []inventorySegment
+	Flows      []models.DataFlow // потоки, описанные на объекте
+	Incoming   []models.DataFlow // потоки, в которых объект указан смежной системой
+}
+
+func (inv assetInventory) Empty() bool {
+	return len(inv.Segments) == 0 && len(inv.Components) == 0 && len(inv.Flows) == 0 && len(inv.Incoming) == 0
+}
+
+func assetComponents(assetID uint) []models.AssetComponent {
+	var components []models.AssetComponent
+	database.DB.Preload("Segment").Preload("Parent").Preload("Host").
+		Where("asset_id = ?", assetID).
+		Order("kind asc, name asc").
+		Find(&components)
+	return components
+}
+
+func loadAssetInventory(assetID uint) assetInventory {
+	inv := assetInventory{Components: assetComponents(assetID)}
+	database.DB.Where("asset_id = ?", assetID).Order("name asc").Find(&inv.Segments)
+
+	bySegment := make(map[uint][]models.AssetComponent)
+	var loose []models.AssetComponent
+	for _, comp := range inv.Components {
+		if comp.SegmentID != nil {
+			bySegment[*comp.SegmentID] = append(bySegment[*comp.SegmentID], comp)
+		} else {
+			loose = append(loose, comp)
+		}
+	}
+	for i := range inv.Segments {
+		inv.Groups = append(inv.Groups, inventorySegment{Segment: &inv.Segments[i], Components: bySegment[inv.Segments[i].ID]})
+	}
+	if len(loose) > 0 {
+		inv.Groups = append(inv.Groups, inventorySegment{Components: loose})
+	}
+
+	database.DB.Preload("Asset").Preload("Component").Preload("PeerAsset.Client").
+		Where("asset_id = ?", assetID).Order("id asc").Find(&inv.Flows)
+	database.DB.Preload("Asset.Client").Preload("PeerAsset").
+		Where("peer_asset_id = ?", assetID).Order("id asc").Find(&inv.Incoming)
+	return inv
+}
+
+// parseComponentIDs — компоненты объекта, отмеченные в форме; чужие ID — ошибка
+func parseComponentIDs(c *gin.Context, assetID uint) ([]models.AssetComponent, bool) {
+	ids := c.PostFormArray("component_ids")
+	if len(ids) == 0 {
+		return nil, true
+	}
+	var components []models.AssetComponent
+	database.DB.Where("asset_id = ? AND id IN ?", assetID, ids).Find(&components)
+	return components, len(components) == len(ids)
+}
+
+// componentSet — ID отмеченных компонентов для шаблона
+func componentSet(components []models.AssetComponent) map[uint]bool {
+	set := make(map[uint]bool, len(components))
+	for _, comp := range components {
+		set[comp.ID] = true
+	}
+	return set
+}
+
+// parseOptionalID — необязательная ссылка из формы: "" — nil
+func parseOptionalID(s string) (*uint, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, true
+	}
+	id, err := strconv.ParseUint(s, 10, 64)
+	if err != nil || id == 0 {
+		return nil, false
+	}
+	v := uint(id)
+	return &v, true
+}
+
+// validCIDRList — адреса и подсети через запятую
+func validCIDRList(s string) bool {
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(part); err != nil && net.ParseIP(part) == nil {
+			return false
+		}
+	}
+	return true
+}
+
+func renderAssetComponents(c *gin.Context, status int, role models.UserRole, asset models.Asset, msg string) {
+	var hosts []models.Host
+	database.DB.Where("client_id = ?", asset.ClientID).Order("address asc").Find(&hosts)
+
+	// смежные системы — объекты защиты всех клиентов, кроме самого объекта
+	var peers []models.Asset
+	database.DB.Preload("Client").Where("id <> ?", asset.ID).Order("client_id asc, name asc").Find(&peers)
+
+	render(c, status, "asset_components.html", gin.H{
+		"role":       string(role),
+		"asset":      asset,
+		"inventory":  loadAssetInventory(asset.ID),
+		"kinds":      models.ComponentKinds,
+		"directions": models.FlowDirections,
+		"hosts":      hosts,
+		"peers":      peers,
+		"error":      msg,
+	})
+}
+
+func ShowAssetComponents(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	asset, ok := loadAssetByParam(c)
+	if !ok {
+		return
+	}
+
+	renderAssetComponents(c, http.StatusOK, role, asset, "")
+}
+
+func auditAssetComposition(c *gin.Context, entity string, id uint, action, details string) {
+	sess := sessions.Default(c)
+	if uid, ok := sess.Get("user_id").(uint); ok {
+		database.CreateAuditLog(uid, entity, id, action, details)
+	}
+}
+
+// ---------- сегменты ----------
+
+func AddAssetSegment(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	asset, ok := loadAssetByParam(c)
+	if !ok {
+		return
+	}
+
+	segment := models.AssetSegment{
+		AssetID:     asset.ID,
+		Name:        strings.TrimSpace(c.PostForm("name")),
+		CIDR:        strings.TrimSpace(c.PostForm("cidr")),
+		Description: strings.TrimSpace(c.PostForm("description")),
+	}
+	if segment.Name == "" {
+		renderAssetComponents(c, http.StatusBadRequest, role, asset, "Укажите наименование сегмента")
+		return
+	}
+	if !validCIDRList(segment.CIDR) {
+		renderAssetComponents(c, http.StatusBadRequest, role, asset, "Адресное пространство — IP-адреса или подсети CIDR через запятую")
+		return
+	}
+
+	if err := database.DB.Create(&segment).Error; err != nil {
+		renderAssetComponents(c, http.StatusInternalServerError, role, asset, "Ошибка сохранения сегмента")
+		return
+	}
+	auditAssetComposition(c, "asset_segment", segment.ID, "create",
+		fmt.Sprintf("Объект %s: добавлен сегмент %s", asset.Name, segment.Name))
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/assets/%d/components", asset.ID))
+}
+
+func DeleteAssetSegment(c *gin.Context) {
+	_, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	asset, ok := loadAssetByParam(c)
+	if !ok {
+		return
+	}
+
+	var segment models.AssetSegment
+	if err := database.DB.Where("id = ? AND asset_id = ?", c.Param("segment_id"), asset.ID).First(&segment).Error; err != nil {
+		c.String(http.StatusNotFound, "Сегмент не найден")
+		return
+	}
+
+	// компоненты сегмента остаются в составе объекта вне сегментов
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.AssetComponent{}).Where("segment_id = ?", segment.ID).Update("segment_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&segment).Error
+	})
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Ошибка удаления сегмента")
+		return
+	}
+	auditAssetComposition(c, "asset_segment", segment.ID, "delete",
+		fmt.Sprintf("Объект %s: удалён сегмент %s", asset.Name, segment.Name))
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/assets/%d/components", asset.ID))
+}
+
+// ---------- компоненты ----------
+
+func loadAssetComponent(c *gin.Context) (models.Asset, models.AssetComponent, bool) {
+	asset, ok := loadAssetByParam(c)
+	if !ok {
+		return asset, models.AssetComponent{}, false
+	}
+
+	var comp models.AssetComponent
+	if err := database.DB.Where("id = ? AND asset_id = ?", c.Param("component_id"), asset.ID).First(&comp).Error; err != nil {
+		c.String(http.StatusNotFound, "Компонент не найден")
+		return asset, comp, false
+	}
+	return asset, comp, true
+}
+
+// bindAssetComponentForm заполняет компонент из формы; ссылки проверяются на принадлежность объекту
+func bindAssetComponentForm(c *gin.Context, asset models.Asset, comp *models.AssetComponent) string {
+	kind := models.ComponentKind(c.PostForm("kind"))
+	if !kind.Valid() {
+		return "Некорректный тип компонента"
+	}
+	name := strings.TrimSpace(c.PostForm("name"))
+	if name == "" {
+		return "Укажите наименование компонента"
+	}
+	quantity := 1
+	if s := strings.TrimSpace(c.PostForm("quantity")); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			return "Количество — целое число не меньше 1"
+		}
+		quantity = n
+	}
+
+	segmentID, ok1 := parseOptionalID(c.PostForm("segment_id"))
+	parentID, ok2 := parseOptionalID(c.PostForm("parent_id"))
+	hostID, ok3 := parseOptionalID(c.PostForm("host_id"))
+	if !ok1 || !ok2 || !ok3 {
+		return "Некорректные параметры"
+	}
+
+	var count int64
+	if segmentID != nil {
+		database.DB.Model(&models.AssetSegment{}).Where("id = ? AND asset_id = ?", *segmentID, asset.ID).Count(&count)
+		if count == 0 {
+			return "Сегмент не относится к объекту"
+		}
+	}
+	if parentID != nil {
+		if comp.ID != 0 && *parentID == comp.ID {
+			return "Компонент не может быть установлен сам на себя"
+		}
+		var parent models.AssetComponent
+		if err := database.DB.Where("id = ? AND asset_id = ?", *parentID, asset.ID).First(&parent).Error; err != nil {
+			return "Компонент размещения не относится к объекту"
+		}
+		if parent.Kind == models.ComponentSoftware {
+			return "ПО размещается на сервере, АРМ или устройстве, а не на другом ПО"
+		}
+	}
+	if hostID != nil {
+		database.DB.Model(&models.Host{}).Where("id = ? AND client_id = ?", *hostID, asset.ClientID).Count(&count)
+		if count == 0 {
+			return "Узел не найден в инвентаризации клиента"
+		}
+	}
+
+	comp.AssetID = asset.ID
+	comp.Kind = kind
+	comp.Name = name
+	comp.Vendor = strings.TrimSpace(c.PostForm("vendor"))
+	comp.Version = strings.TrimSpace(c.PostForm("version"))
+	comp.Quantity = quantity
+	comp.Description = strings.TrimSpace(c.PostForm("description"))
+	comp.SegmentID = segmentID
+	comp.ParentID = parentID
+	comp.HostID = hostID
+	return ""
+}
+
+func AddAssetComponent(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	asset, ok := loadAssetByParam(c)
+	if !ok {
+		return
+	}
+
+	var comp models.AssetComponent
+	if msg := bindAssetComponentForm(c, asset, &comp); msg != "" {
+		renderAssetComponents(c, http.StatusBadRequest, role, asset, msg)
+		return
+	}
+
+	if err := database.DB.Omit("Segment", "Parent", "Host").Create(&comp).Error; err != nil {
+		renderAssetComponents(c, http.StatusInternalServerError, role, asset, "Ошибка сохранения компонента")
+		return
+	}
+	auditAssetComposition(c, "asset_component", comp.ID, "create",
+		fmt.Sprintf("Объект %s: добавлен компонент %s (%s)", asset.Name, comp.Title(), comp.Kind.Label()))
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/assets/%d/components", asset.ID))
+}
+
+func renderAssetComponentEdit(c *gin.Context, status int, role models.UserRole, asset models.Asset, comp models.AssetComponent, msg string) {
+	var segments []models.AssetSegment
+	database.DB.Where("asset_id = ?", asset.ID).Order("name asc").Find(&segments)
+	var hosts []models.Host
+	database.DB.Where("client_id = ?", asset.ClientID).Order("address asc").Find(&hosts)
+
+	// разместить можно на любом компоненте объекта, кроме ПО и самого компонента
+	var parents []models.AssetComponent
+	database.DB.Where("asset_id = ? AND id <> ? AND kind <> ?", asset.ID, comp.ID, models.ComponentSoftware).
+		Order("name asc").Find(&parents)
+
+	var segmentID, parentID, hostID uint
+	if comp.SegmentID != nil {
+		segmentID = *comp.SegmentID
+	}
+	if comp.ParentID != nil {
+		parentID = *comp.ParentID
+	}
+	if comp.HostID != nil {
+		hostID = *comp.HostID
+	}
+
+	render(c, status, "asset_component_edit.html", gin.H{
+		"role":      string(role),
+		"asset":     asset,
+		"component": comp,
+		"kinds":     models.ComponentKinds,
+		"segments":  segments,
+		"parents":   parents,
+		"hosts":     hosts,
+		"segmentID": segmentID,
+		"parentID":  parentID,
+		"hostID":    hostID,
+		"error":     msg,
+	})
+}
+
+func ShowEditAssetComponent(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	asset, comp, ok := loadAssetComponent(c)
+	if !ok {
+		return
+	}
+
+	renderAssetComponentEdit(c, http.StatusOK, role, asset, comp, "")
+}
+
+func UpdateAssetComponent(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	asset, comp, ok := loadAssetComponent(c)
+	if !ok {
+		return
+	}
+
+	if msg := bindAssetComponentForm(c, asset, &comp); msg != "" {
+		renderAssetComponentEdit(c, http.StatusBadRequest, role, asset, comp, msg)
+		return
+	}
+	// ПО не может нести на себе другие компоненты
+	if comp.Kind == models.ComponentSoftware {
+		var children int64
+		database.DB.Model(&models.AssetComponent{}).Where("parent_id = ?", comp.ID).Count(&children)
+		if children > 0 {
+			renderAssetComponentEdit(c, http.StatusBadRequest, role, asset, comp, "На компоненте размещено ПО — тип «Программное обеспечение» для него недоступен")
+			return
+		}
+	}
+
+	if err := database.DB.Omit("Segment", "Parent", "Host").Save(&comp).Error; err != nil {
+		renderAssetComponentEdit(c, http.StatusInternalServerError, role, asset, comp, "Ошибка сохранения компонента")
+		return
+	}
+	auditAssetComposition(c, "asset_component", comp.ID, "update",
+		fmt.Sprintf("Объект %s: изменён компонент %s", asset.Name, comp.Title()))
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/assets/%d/components", asset.ID))
+}
+
+func DeleteAssetComponent(c *gin.Context) {
+	_, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	asset, comp, ok := loadAssetComponent(c)
+	if !ok {
+		return
+	}
+
+	// оценки угроз и меры, привязанные к компоненту, остаются на уровне объекта
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM asset_threat_components WHERE asset_component_id = ?", comp.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM asset_measure_components WHERE asset_component_id = ?", comp.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.AssetComponent{}).Where("parent_id = ?", comp.ID).Update("parent_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.DataFlow{}).Where("component_id = ?", comp.ID).Update("component_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&comp).Error
+	})
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Ошибка удаления компонента")
+		return
+	}
+	auditAssetComposition(c, "asset_component", comp.ID, "delete",
+		fmt.Sprintf("Объект %s: удалён компонент %s", asset.Name, comp.Title()))
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/assets/%d/components", asset.ID))
+}
+
+// ---------- потоки данных ----------
+
+func AddDataFlow(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	asset, ok := loadAssetByParam(c)
+	if !ok {
+		return
+	}
+	fail := func(msg string) {
+		renderAssetComponents(c, http.StatusBadRequest, role, asset, msg)
+	}
+
+	flow := models.DataFlow{
+		AssetID:        asset.ID,
+		ExternalSystem: strings.TrimSpace(c.PostForm("external_system")),
+		ExternalOwner:  strings.TrimSpace(c.PostForm("external_owner")),
+		Direction:      models.FlowDirection(c.PostForm("direction")),
+		Data:           strings.TrimSpace(c.PostForm("data")),
+		Protocol:       strings.TrimSpace(c.PostForm("protocol")),
+		Channel:        strings.TrimSpace(c.PostForm("channel")),
+		Protection:     strings.TrimSpace(c.PostForm("protection")),
+	}
+	if !flow.Direction.Valid() {
+		fail("Некорректное направление потока")
+		return
+	}
+	if flow.Data == "" {
+		fail("Укажите состав передаваемой информации")
+		return
+	}
+
+	componentID, ok1 := parseOptionalID(c.PostForm("component_id"))
+	peerID, ok2 := parseOptionalID(c.PostForm("peer_asset_id"))
+	if !ok1 || !ok2 {
+		fail("Некорректные параметры")
+		return
+	}
+	if componentID != nil {
+		var count int64
+		database.DB.Model(&models.AssetComponent{}).Where("id = ? AND asset_id = ?", *componentID, asset.ID).Count(&count)
+		if count == 0 {
+			fail("Компонент не относится к объекту")
+			return
+		}
+	}
+
+	// смежная система — либо объект из реестра (любого клиента), либо внешняя система
+	var peerName string
+	switch {
+	case peerID != nil && flow.ExternalSystem != "":
+		fail("Укажите либо объект из реестра, либо внешнюю систему")
+		return
+	case peerID != nil:
+		if *peerID == asset.ID {
+			fail("Поток должен связывать объект с другой системой")
+			return
+		}
+		var peer models.Asset
+		if err := database.DB.First(&peer, *peerID).Error; err != nil {
+			fail("Смежный объект не найден")
+			return
+		}
+		peerName = peer.Name
+		flow.ExternalOwner = ""
+	case flow.ExternalSystem != "":
+		peerName = flow.ExternalSystem
+	default:
+		fail("Укажите смежную систему")
+		return
+	}
+	flow.ComponentID = componentID
+	flow.PeerAssetID = peerID
+
+	if err := database.DB.Omit("Asset", "Component", "PeerAsset").Create(&flow).Error; err != nil {
+		renderAssetComponents(c, http.StatusInternalServerError, role, asset, "Ошибка сохранения потока данных")
+		return
+	}
+	auditAssetComposition(c, "data_flow", flow.ID, "create",
+		fmt.Sprintf("Объект %s: поток данных %s %s (%s)", asset.Name, flow.Direction.Arrow(), peerName, flow.Data))
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/assets/%d/components", asset.ID))
+}
+
+func DeleteDataFlow(c *gin.Context) {
+	_, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	asset, ok := loadAssetByParam(c)
+	if !ok {
+		return
+	}
+
+	var flow models.DataFlow
+	if err := database.DB.Preload("PeerAsset").Where("id = ? AND asset_id = ?", c.Param("flow_id"), asset.ID).First(&flow).Error; err != nil {
+		c.String(http.StatusNotFound, "Поток данных не найден")
+		return
+	}
+
+	if err := database.DB.Delete(&flow).Error; err != nil {
+		c.String(http.StatusInternalServerError, "Ошибка удаления потока данных")
+		return
+	}
+	auditAssetComposition(c, "data_flow", flow.ID, "delete",
+		fmt.Sprintf("Объект %s: удалён поток данных с %s", asset.Name, flow.PeerName()))
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/assets/%d/components", asset.ID))
+}
@@ -0,0 +1,503 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"ib-integrator/internal/database"
+	"ib-integrator/internal/models"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ====== РЕЕСТР ВНЕДРЕНИЯ МЕР НА ОБЪЕКТЕ ======
+
+// measureSuggestion — мера, рекомендованная для угроз объекта или входящая в положенный
+// ему базовый набор, но ещё не внесённая в реестр
+type measureSuggestion struct {
+	Measure  models.ControlMeasure
+	Threats  []string // коды угроз, для которых мера рекомендована
+	Baseline string   // базовый набор, из которого предложена мера
+}
+
+// coverageItem — мера, закрывающая угрозу, и её статус в реестре ("" — мера не в реестре)
+type coverageItem struct {
+	Measure models.ControlMeasure
+	Status  models.MeasureStatus
+}
+
+// threatCoverage — покрытие угрозы объекта мерами защиты
+type threatCoverage struct {
+	Items       []coverageItem
+	Implemented int
+	Applicable  int // без мер, признанных неприменимыми
+}
+
+// Mitigated — хотя бы одна мера по угрозе внедрена
+func (tc threatCoverage) Mitigated() bool {
+	return tc.Implemented > 0
+}
+
+// recommendedMeasures — рекомендованные меры по угрозам: threat_id -> меры
+func recommendedMeasures(threatIDs []uint) map[uint][]models.ControlMeasure {
+	result := make(map[uint][]models.ControlMeasure)
+	if len(threatIDs) == 0 {
+		return result
+	}
+
+	var recs []models.ThreatMeasure
+	database.DB.Preload("Measure").
+		Where("threat_id IN ?", threatIDs).
+		Order("measure_id asc").
+		Find(&recs)
+	for _, r := range recs {
+		if r.Measure.ID != 0 {
+			result[r.ThreatID] = append(result[r.ThreatID], r.Measure)
+		}
+	}
+	return result
+}
+
+// assetMeasureStatuses — статусы мер из реестра объекта: measure_id -> статус
+func assetMeasureStatuses(assetID uint) map[uint]models.MeasureStatus {
+	var entries []models.AssetMeasure
+	database.DB.Where("asset_id = ?", assetID).Find(&entries)
+
+	statuses := make(map[uint]models.MeasureStatus, len(entries))
+	for _, e := range entries {
+		statuses[e.MeasureID] = e.Status
+	}
+	return statuses
+}
+
+// assetThreatCoverage считает покрытие каждой угрозы объекта: рекомендованные меры
+// плюс меры, отмеченные применёнными при оценке риска. Ключ — ID связи AssetThreat.
+func assetThreatCoverage(assetID uint, links []models.AssetThreat) map[uint]threatCoverage {
+	threatIDs := make([]uint, 0, len(links))
+	for _, l := range links {
+		threatIDs = append(threatIDs, l.ThreatID)
+	}
+	recommended := recommendedMeasures(threatIDs)
+	statuses := assetMeasureStatuses(assetID)
+
+	coverage := make(map[uint]threatCoverage, len(links))
+	for _, l := range links {
+		var tc threatCoverage
+		seen := make(map[uint]bool)
+		measures := append(append([]models.ControlMeasure{}, recommended[l.ThreatID]...), l.Measures...)
+		for _, m := range measures {
+			if seen[m.ID] {
+				continue
+			}
+			seen[m.ID] = true
+
+			status := statuses[m.ID]
+			tc.Items = append(tc.Items, coverageItem{Measure: m, Status: status})
+			if status != models.MeasureNotApplicable {
+				tc.Applicable++
+			}
+			if status == models.MeasureImplemented {
+				tc.Implemented++
+			}
+		}
+		coverage[l.ID] = tc
+	}
+	return coverage
+}
+
+// assetMeasureSuggestions — меры, рекомендованные для привязанных к объекту угроз
+// (через ThreatMeasure) и входящие в положенный ему базовый набор, которых ещё нет в реестре объекта
+func assetMeasureSuggestions(asset models.Asset) []measureSuggestion {
+	var links []models.AssetThreat
+	database.DB.Preload("Threat").Where("asset_id = ? AND excluded = ?", asset.ID, false).Order("id asc").Find(&links)
+
+	threatIDs := make([]uint, 0, len(links))
+	for _, l := range links {
+		threatIDs = append(threatIDs, l.ThreatID)
+	}
+	recommended := recommendedMeasures(threatIDs)
+	statuses := assetMeasureStatuses(asset.ID)
+
+	var suggestions []measureSuggestion
+	index := make(map[uint]int)
+	for _, l := range links {
+		for _, m := range recommended[l.ThreatID] {
+			if _, inRegister := statuses[m.ID]; inRegister {
+				continue
+			}
+			i, ok := index[m.ID]
+			if !ok {
+				i = len(suggestions)
+				index[m.ID] = i
+				suggestions = append(suggestions, measureSuggestion{Measure: m})
+			}
+			suggestions[i].Threats = append(suggestions[i].Threats, l.Threat.Code)
+		}
+	}
+
+	if b, ok := assetBaseline(asset); ok {
+		measures, _ := database.BaselineMeasures(b.Regulation.Code, b.Level)
+		for _, m := range measures {
+			if _, inRegister := statuses[m.ID]; inRegister {
+				continue
+			}
+			i, ok := index[m.ID]
+			if !ok {
+				i = len(suggestions)
+				index[m.ID] = i
+				suggestions = append(suggestions, measureSuggestion{Measure: m})
+			}
+			suggestions[i].Baseline = b.Label()
+		}
+	}
+	return suggestions
+}
+
+func loadAssetByParam(c *gin.Context) (models.Asset, bool) {
+	assetID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || assetID <= 0 {
+		c.String(http.StatusBadRequest, "Некорректный ID объекта защиты")
+		return models.Asset{}, false
+	}
+
+	var asset models.Asset
+	if err := database.DB.Preload("Client").First(&asset, assetID).Error; err != nil {
+		c.String(http.StatusNotFound, "Объект защиты не найден")
+		return models.Asset{}, false
+	}
+	return asset, true
+}
+
+func loadAssetMeasure(c *gin.Context) (models.AssetMeasure, bool) {
+	assetID, err1 := strconv.Atoi(c.Param("id"))
+	entryID, err2 := strconv.Atoi(c.Param("entry_id"))
+	if err1 != nil || err2 != nil || assetID <= 0 || entryID <= 0 {
+		c.String(http.StatusBadRequest, "Некорректные параметры")
+		return models.AssetMeasure{}, false
+	}
+
+	var entry models.AssetMeasure
+	if err := database.DB.
+		Preload("Asset.Client").
+		Preload("Measure").
+		Preload("Owner").
+		Preload("Components").
+		Where("id = ? AND asset_id = ?", entryID, assetID).
+		First(&entry).Error; err != nil {
+		c.String(http.StatusNotFound, "Запись реестра мер не найдена")
+		return models.AssetMeasure{}, false
+	}
+	return entry, true
+}
+
+// measureOwners — кому можно поручить внедрение меры
+func measureOwners() []models.User {
+	var owners []models.User
+	database.DB.Where("role IN ?", []models.UserRole{models.RoleEngineer, models.RoleAdmin}).
+		Order("username asc").Find(&owners)
+	return owners
+}
+
+// bindAssetMeasureForm заполняет статус, ответственного, срок и подтверждение из формы
+func bindAssetMeasureForm(c *gin.Context, entry *models.AssetMeasure) string {
+	status := models.MeasureStatus(c.PostForm("status"))
+	if !status.Valid() {
+		return "Некорректный статус меры"
+	}
+
+	justification := strings.TrimSpace(c.PostForm("justification"))
+	if status == models.MeasureNotApplicable && justification == "" {
+		return "Для неприменимой меры укажите обоснование"
+	}
+
+	ownerID, ok := parseUserRef(c.PostForm("owner_id"), models.RoleEngineer, models.RoleAdmin)
+	if !ok {
+		return "Некорректный ответственный"
+	}
+
+	dueDate, err := parseFormDate(c.PostForm("due_date"))
+	if err != nil {
+		return "Некорректный срок внедрения"
+	}
+
+	entry.Status = status
+	entry.Justification = justification
+	entry.OwnerID = ownerID
+	entry.DueDate = dueDate
+	entry.Evidence = strings.TrimSpace(c.PostForm("evidence"))
+	return ""
+}
+
+func renderAssetMeasures(c *gin.Context, status int, role models.UserRole, asset models.Asset, msg string) {
+	var entries []models.AssetMeasure
+	database.DB.
+		Preload("Measure").
+		Preload("Owner").
+		Preload("Components").
+		Joins("JOIN control_measures ON control_measures.id = asset_measures.measure_id").
+		Where("asset_measures.asset_id = ?", asset.ID).
+		Order("control_measures.regulation asc, control_measures.id asc").
+		Find(&entries)
+
+	// в форму добавления — меры, которых ещё нет в реестре
+	usedIDs := make([]uint, 0, len(entries))
+	counts := make(map[models.MeasureStatus]int)
+	for _, e := range entries {
+		usedIDs = append(usedIDs, e.MeasureID)
+		counts[e.Status]++
+	}
+	// из каталогов приказов — только мера положенного объекту приказа; черновики и выведенные не предлагаются
+	baseline, hasBaseline := assetBaseline(asset)
+	mQuery := database.DB.Where("status = ?", models.CatalogPublished).Order("regulation asc, id asc")
+	if hasBaseline {
+		mQuery = mQuery.Where("regulation IN ?", []string{"", baseline.Regulation.Code})
+	} else {
+		mQuery = mQuery.Where("regulation = ?", "")
+	}
+	if len(usedIDs) > 0 {
+		mQuery = mQuery.Where("id NOT IN ?", usedIDs)
+	}
+	var measures []models.ControlMeasure
+	mQuery.Find(&measures)
+
+	render(c, status, "asset_measures.html", gin.H{
+		"role":        string(role),
+		"asset":       asset,
+		"baseline":    baseline,
+		"hasBaseline": hasBaseline,
+		"entries":     entries,
+		"counts":      counts,
+		"suggestions": assetMeasureSuggestions(asset),
+		"measures":    measures,
+		"owners":      measureOwners(),
+		"statuses":    models.MeasureStatuses,
+		"error":       msg,
+	})
+}
+
+func ShowAssetMeasures(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	asset, ok := loadAssetByParam(c)
+	if !ok {
+		return
+	}
+
+	renderAssetMeasures(c, http.StatusOK, role, asset, "")
+}
+
+// AddAssetMeasure — ручное добавление меры в реестр объекта
+func AddAssetMeasure(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	asset, ok := loadAssetByParam(c)
+	if !ok {
+		return
+	}
+
+	mid, err := strconv.Atoi(c.PostForm("measure_id"))
+	if err != nil || mid <= 0 {
+		renderAssetMeasures(c, http.StatusBadRequest, role, asset, "Выберите меру защиты")
+		return
+	}
+
+	var measure models.ControlMeasure
+	if err := database.DB.Where("status = ?", models.CatalogPublished).First(&measure, mid).Error; err != nil {
+		renderAssetMeasures(c, http.StatusBadRequest, role, asset, "Мера защиты не найдена в действующем каталоге")
+		return
+	}
+
+	var count int64
+	database.DB.Model(&models.AssetMeasure{}).
+		Where("asset_id = ? AND measure_id = ?", asset.ID, measure.ID).
+		Count(&count)
+	if count > 0 {
+		renderAssetMeasures(c, http.StatusBadRequest, role, asset, "Эта мера уже есть в реестре объекта")
+		return
+	}
+
+	entry := models.AssetMeasure{AssetID: asset.ID, MeasureID: measure.ID}
+	if msg := bindAssetMeasureForm(c, &entry); msg != "" {
+		renderAssetMeasures(c, http.StatusBadRequest, role, asset, msg)
+		return
+	}
+
+	if err := database.DB.Omit("Asset", "Measure", "Owner").Create(&entry).Error; err != nil {
+		renderAssetMeasures(c, http.StatusInternalServerError, role, asset, "Ошибка сохранения меры в реестре")
+		return
+	}
+
+	sess := sessions.Default(c)
+	if uid, ok := sess.Get("user_id").(uint); ok {
+		database.CreateAuditLog(uid, "asset_measure", entry.ID, "create",
+			fmt.Sprintf("Объект %s: мера %s добавлена в реестр (%s)", asset.Name, measure.Code, entry.Status.Label()))
+	}
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/assets/%d/measures", asset.ID))
+}
+
+// AcceptMeasureSuggestions — внести предложенные меры в реестр со статусом "запланирована"
+func AcceptMeasureSuggestions(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	asset, ok := loadAssetByParam(c)
+	if !ok {
+		return
+	}
+
+	// принимаются только актуальные предложения, произвольные ID из формы игнорируются
+	selected := make(map[string]bool)
+	for _, id := range c.PostFormArray("measure_ids") {
+		selected[id] = true
+	}
+
+	var entries []models.AssetMeasure
+	var codes []string
+	for _, s := range assetMeasureSuggestions(asset) {
+		if !selected[strconv.FormatUint(uint64(s.Measure.ID), 10)] {
+			continue
+		}
+		entries = append(entries, models.AssetMeasure{
+			AssetID:   asset.ID,
+			MeasureID: s.Measure.ID,
+			Status:    models.MeasurePlanned,
+		})
+		codes = append(codes, s.Measure.Code)
+	}
+	if len(entries) == 0 {
+		renderAssetMeasures(c, http.StatusBadRequest, role, asset, "Не выбрано ни одной предложенной меры")
+		return
+	}
+
+	if err := database.DB.Omit("Asset", "Measure", "Owner").Create(&entries).Error; err != nil {
+		renderAssetMeasures(c, http.StatusInternalServerError, role, asset, "Ошибка сохранения мер в реестре")
+		return
+	}
+
+	sess := sessions.Default(c)
+	if uid, ok := sess.Get("user_id").(uint); ok {
+		database.CreateAuditLog(uid, "asset_measure", asset.ID, "create",
+			fmt.Sprintf("Объект %s: в реестр внесены рекомендованные меры %s", asset.Name, strings.Join(codes, ", ")))
+	}
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/assets/%d/measures", asset.ID))
+}
+
+func renderAssetMeasureEdit(c *gin.Context, status int, role models.UserRole, entry models.AssetMeasure, msg string) {
+	var ownerID uint
+	if entry.OwnerID != nil {
+		ownerID = *entry.OwnerID
+	}
+
+	render(c, status, "asset_measure_edit.html", gin.H{
+		"role":       string(role),
+		"entry":      entry,
+		"ownerID":    ownerID,
+		"owners":     measureOwners(),
+		"statuses":   models.MeasureStatuses,
+		"components": assetComponents(entry.AssetID),
+		"inScope":    componentSet(entry.Components),
+		"error":      msg,
+	})
+}
+
+func ShowEditAssetMeasure(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	entry, ok := loadAssetMeasure(c)
+	if !ok {
+		return
+	}
+
+	renderAssetMeasureEdit(c, http.StatusOK, role, entry, "")
+}
+
+func UpdateAssetMeasure(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	entry, ok := loadAssetMeasure(c)
+	if !ok {
+		return
+	}
+
+	oldStatus := entry.Status
+	if msg := bindAssetMeasureForm(c, &entry); msg != "" {
+		renderAssetMeasureEdit(c, http.StatusBadRequest, role, entry, msg)
+		return
+	}
+	components, ok := parseComponentIDs(c, entry.AssetID)
+	if !ok {
+		renderAssetMeasureEdit(c, http.StatusBadRequest, role, entry, "Компонент не относится к объекту")
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Asset", "Measure", "Owner", "Components").Save(&entry).Error; err != nil {
+			return err
+		}
+		return tx.Model(&entry).Association("Components").Replace(components)
+	})
+	if err != nil {
+		renderAssetMeasureEdit(c, http.StatusInternalServerError, role, entry, "Ошибка сохранения записи реестра")
+		return
+	}
+
+	sess := sessions.Default(c)
+	if uid, ok := sess.Get("user_id").(uint); ok {
+		action := "update"
+		details := fmt.Sprintf("Объект %s: обновлена мера %s", entry.Asset.Name, entry.Measure.Code)
+		if oldStatus != entry.Status {
+			action = "status_change"
+			details = fmt.Sprintf("Объект %s: мера %s: %s → %s",
+				entry.Asset.Name, entry.Measure.Code, oldStatus.Label(), entry.Status.Label())
+		}
+		database.CreateAuditLog(uid, "asset_measure", entry.ID, action, details)
+	}
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/assets/%d/measures", entry.AssetID))
+}
+
+func DeleteAssetMeasure(c *gin.Context) {
+	_, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	entry, ok := loadAssetMeasure(c)
+	if !ok {
+		return
+	}
+
+	// удаляем физически: иначе уникальный индекс не даст снова внести меру в реестр
+	if err := database.DB.Unscoped().Select("Components").Delete(&entry).Error; err != nil {
+		c.String(http.StatusInternalServerError, "Ошибка удаления записи реестра")
+		return
+	}
+
+	sess := sessions.Default(c)
+	if uid, ok := sess.Get("user_id").(uint); ok {
+		database.CreateAuditLog(uid, "asset_measure", entry.ID, "delete",
+			fmt.Sprintf("Объект %s: мера %s удалена из реестра", entry.Asset.Name, entry.Measure.Code))
+	}
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/assets/%d/measures", entry.AssetID))
+}
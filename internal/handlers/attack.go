@@ -0,0 +1,437 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"ib-integrator/internal/attack"
+	"ib-integrator/internal/database"
+	"ib-integrator/internal/models"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+// ====== MITRE ATT&CK: ВЕРСИИ, СОПОСТАВЛЕНИЕ С УГРОЗАМИ, ПОКРЫТИЕ ======
+
+type attackReleaseRow struct {
+	Release    models.AttackRelease
+	Techniques int64
+	Mappings   int64
+}
+
+func renderAttackReleases(c *gin.Context, status int, role models.UserRole, msg string) {
+	var releases []models.AttackRelease
+	database.DB.Order("imported_at desc, id desc").Find(&releases)
+
+	rows := make([]attackReleaseRow, 0, len(releases))
+	for _, r := range releases {
+		row := attackReleaseRow{Release: r}
+		database.DB.Model(&models.AttackTechnique{}).Where("release_id = ?", r.ID).Count(&row.Techniques)
+		database.DB.Model(&models.ThreatAttackTechnique{}).
+			Joins("JOIN attack_techniques ON attack_techniques.id = threat_attack_techniques.technique_id").
+			Where("attack_techniques.release_id = ?", r.ID).
+			Count(&row.Mappings)
+		rows = append(rows, row)
+	}
+
+	render(c, status, "attack_releases.html", gin.H{
+		"role":     string(role),
+		"releases": rows,
+		"error":    msg,
+	})
+}
+
+func ListAttackReleases(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	renderAttackReleases(c, http.StatusOK, role, "")
+}
+
+// ImportAttack загружает enterprise-attack.json как новую версию ATT&CK (только admin)
+func ImportAttack(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	fh, err := c.FormFile("file")
+	if err != nil {
+		renderAttackReleases(c, http.StatusBadRequest, models.RoleAdmin, "Выберите файл enterprise-attack.json")
+		return
+	}
+	if strings.ToLower(filepath.Ext(fh.Filename)) != ".json" {
+		renderAttackReleases(c, http.StatusBadRequest, models.RoleAdmin, "Поддерживаются только файлы .json (STIX 2.1)")
+		return
+	}
+
+	tmp, err := os.CreateTemp("", "attack-import-*.json")
+	if err != nil {
+		renderAttackReleases(c, http.StatusInternalServerError, models.RoleAdmin, "Не удалось сохранить файл")
+		return
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	if err := c.SaveUploadedFile(fh, tmp.Name()); err != nil {
+		renderAttackReleases(c, http.StatusInternalServerError, models.RoleAdmin, "Не удалось сохранить файл")
+		return
+	}
+
+	bundle, err := attack.ParseFile(tmp.Name())
+	if err != nil {
+		renderAttackReleases(c, http.StatusBadRequest, models.RoleAdmin, "Ошибка разбора бандла: "+err.Error())
+		return
+	}
+
+	release, err := attack.Import(database.DB, bundle, c.PostForm("version"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, attack.ErrReleaseExists) || bundle.Version == "" {
+			status = http.StatusBadRequest
+		}
+		renderAttackReleases(c, status, models.RoleAdmin, "Ошибка загрузки: "+err.Error())
+		return
+	}
+
+	sess := sessions.Default(c)
+	if uid, ok := sess.Get("user_id").(uint); ok {
+		database.CreateAuditLog(uid, "attack", release.ID, "import",
+			fmt.Sprintf("Загружена версия ATT&CK %s: техник %d", release.Version, len(bundle.Techniques)))
+	}
+
+	c.Redirect(http.StatusFound, "/attack")
+}
+
+// attackReleaseParam — версия из ?release=, по умолчанию последняя загруженная
+func attackReleaseParam(c *gin.Context) (models.AttackRelease, bool) {
+	if id, err := strconv.ParseUint(c.Query("release"), 10, 64); err == nil && id > 0 {
+		var r models.AttackRelease
+		if database.DB.First(&r, id).Error == nil {
+			return r, true
+		}
+	}
+	return attack.LatestRelease(database.DB)
+}
+
+// --- сопоставление угрозы с техниками ---
+
+func loadThreatByParam(c *gin.Context) (models.Threat, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.String(http.StatusBadRequest, "Некорректный ID угрозы")
+		return models.Threat{}, false
+	}
+
+	var th models.Threat
+	if err := database.DB.First(&th, id).Error; err != nil {
+		c.String(http.StatusNotFound, "Угроза не найдена")
+		return models.Threat{}, false
+	}
+	return th, true
+}
+
+func renderThreatAttack(c *gin.Context, status int, role models.UserRole, th models.Threat, msg string) {
+	var mappings []models.ThreatAttackTechnique
+	database.DB.Preload("Technique.Release").
+		Where("threat_id = ?", th.ID).
+		Order("id asc").
+		Find(&mappings)
+
+	var releases []models.AttackRelease
+	database.DB.Order("imported_at desc, id desc").Find(&releases)
+	release, hasRelease := attackReleaseParam(c)
+
+	// поиск техник выбранной версии по коду или названию
+	q := strings.TrimSpace(c.Query("q"))
+	var found []models.AttackTechnique
+	if hasRelease && q != "" {
+		like := "%" + strings.ToLower(q) + "%"
+		database.DB.
+			Where("release_id = ? AND (LOWER(external_id) LIKE ? OR LOWER(name) LIKE ?)", release.ID, like, like).
+			Order("external_id asc").
+			Limit(50).
+			Find(&found)
+	}
+
+	render(c, status, "threat_attack.html", gin.H{
+		"role":       string(role),
+		"threat":     th,
+		"mappings":   mappings,
+		"releases":   releases,
+		"release":    release,
+		"hasRelease": hasRelease,
+		"q":          q,
+		"found":      found,
+		"error":      msg,
+	})
+}
+
+func ShowThreatAttack(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	th, ok := loadThreatByParam(c)
+	if !ok {
+		return
+	}
+
+	renderThreatAttack(c, http.StatusOK, role, th, "")
+}
+
+func AddThreatAttack(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	th, ok := loadThreatByParam(c)
+	if !ok {
+		return
+	}
+
+	techID, err := strconv.ParseUint(strings.TrimSpace(c.PostForm("technique_id")), 10, 64)
+	if err != nil || techID == 0 {
+		renderThreatAttack(c, http.StatusBadRequest, role, th, "Выберите технику ATT&CK")
+		return
+	}
+	var tech models.AttackTechnique
+	if err := database.DB.Preload("Release").First(&tech, techID).Error; err != nil {
+		renderThreatAttack(c, http.StatusBadRequest, role, th, "Техника ATT&CK не найдена")
+		return
+	}
+
+	// одна и та же техника другой версии — тоже дубль
+	var count int64
+	database.DB.Model(&models.ThreatAttackTechnique{}).
+		Joins("JOIN attack_techniques ON attack_techniques.id = threat_attack_techniques.technique_id").
+		Where("threat_attack_techniques.threat_id = ? AND attack_techniques.external_id = ?", th.ID, tech.ExternalID).
+		Count(&count)
+	if count > 0 {
+		renderThreatAttack(c, http.StatusBadRequest, role, th, "Техника "+tech.ExternalID+" уже сопоставлена с угрозой")
+		return
+	}
+
+	m := models.ThreatAttackTechnique{ThreatID: th.ID, TechniqueID: tech.ID}
+	if err := database.DB.Create(&m).Error; err != nil {
+		renderThreatAttack(c, http.StatusInternalServerError, role, th, "Ошибка сохранения сопоставления")
+		return
+	}
+
+	sess := sessions.Default(c)
+	if uid, ok := sess.Get("user_id").(uint); ok {
+		database.CreateAuditLog(uid, "threat", th.ID, "attack_map",
+			fmt.Sprintf("Угроза %s сопоставлена с ATT&CK %s %s (версия %s)", th.Code, tech.ExternalID, tech.Name, tech.Release.Version))
+	}
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/threats/%d/attack?release=%d", th.ID, tech.ReleaseID))
+}
+
+func DeleteThreatAttack(c *gin.Context) {
+	if _, ok := requireRiskEditor(c); !ok {
+		return
+	}
+
+	th, ok := loadThreatByParam(c)
+	if !ok {
+		return
+	}
+
+	var m models.ThreatAttackTechnique
+	if err := database.DB.Preload("Technique").
+		Where("id = ? AND threat_id = ?", c.Param("mapping_id"), th.ID).
+		First(&m).Error; err != nil {
+		c.String(http.StatusNotFound, "Сопоставление не найдено")
+		return
+	}
+
+	if err := database.DB.Delete(&m).Error; err != nil {
+		c.String(http.StatusInternalServerError, "Ошибка удаления сопоставления")
+		return
+	}
+
+	sess := sessions.Default(c)
+	if uid, ok := sess.Get("user_id").(uint); ok {
+		database.CreateAuditLog(uid, "threat", th.ID, "attack_unmap",
+			fmt.Sprintf("Угроза %s: снято сопоставление с ATT&CK %s", th.Code, m.Technique.ExternalID))
+	}
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/threats/%d/attack", th.ID))
+}
+
+// attackCodesByThreat — коды техник ATT&CK по угрозам для каталога
+func attackCodesByThreat() map[uint][]string {
+	var mappings []models.ThreatAttackTechnique
+	database.DB.Preload("Technique").Order("id asc").Find(&mappings)
+
+	result := make(map[uint][]string)
+	for _, m := range mappings {
+		result[m.ThreatID] = append(result[m.ThreatID], m.Technique.ExternalID)
+	}
+	return result
+}
+
+// --- покрытие техник мерами клиента ---
+
+type attackCoverageRow struct {
+	Technique models.AttackTechnique
+	Threats   []models.Threat
+	Measures  []models.ControlMeasure // внедрённые у клиента меры по сопоставленным угрозам
+}
+
+func (r attackCoverageRow) Mapped() bool  { return len(r.Threats) > 0 }
+func (r attackCoverageRow) Covered() bool { return len(r.Measures) > 0 }
+
+type attackTacticSummary struct {
+	Shortname string
+	Name      string
+	Total     int
+	Mapped    int
+	Covered   int
+}
+
+func (s attackTacticSummary) Percent() int {
+	if s.Total == 0 {
+		return 0
+	}
+	return s.Covered * 100 / s.Total
+}
+
+// clientAttackCoverage: техника версии считается закрытой, если с ней сопоставлена угроза
+// (в любой версии ATT&CK — по ExternalID), и рекомендованная для угрозы или применённая к ней
+// на объекте клиента мера внедрена хотя бы на одном объекте клиента.
+func clientAttackCoverage(client models.Client, release models.AttackRelease) ([]attackCoverageRow, []attackTacticSummary) {
+	var techniques []models.AttackTechnique
+	database.DB.Where("release_id = ?", release.ID).Order("external_id asc").Find(&techniques)
+
+	var mappings []models.ThreatAttackTechnique
+	database.DB.Preload("Threat").Preload("Technique").Find(&mappings)
+	threatsByCode := make(map[string][]models.Threat)
+	var threatIDs []uint
+	for _, m := range mappings {
+		threatsByCode[m.Technique.ExternalID] = append(threatsByCode[m.Technique.ExternalID], m.Threat)
+		threatIDs = append(threatIDs, m.ThreatID)
+	}
+
+	measuresByThreat := recommendedMeasures(threatIDs)
+	var links []models.AssetThreat
+	database.DB.Preload("Measures").
+		Joins("JOIN assets ON assets.id = asset_threats.asset_id AND assets.deleted_at IS NULL").
+		Where("assets.client_id = ? AND asset_threats.excluded = ?", client.ID, false).
+		Find(&links)
+	for _, l := range links {
+		measuresByThreat[l.ThreatID] = append(measuresByThreat[l.ThreatID], l.Measures...)
+	}
+
+	var implemented []uint
+	database.DB.Model(&models.AssetMeasure{}).
+		Joins("JOIN assets ON assets.id = asset_measures.asset_id AND assets.deleted_at IS NULL").
+		Where("assets.client_id = ? AND asset_measures.status = ?", client.ID, models.MeasureImplemented).
+		Pluck("asset_measures.measure_id", &implemented)
+	isImplemented := make(map[uint]bool, len(implemented))
+	for _, id := range implemented {
+		isImplemented[id] = true
+	}
+
+	summaries := make([]attackTacticSummary, len(models.AttackTactics))
+	tacticIndex := make(map[string]int, len(models.AttackTactics))
+	for i, t := range models.AttackTactics {
+		summaries[i] = attackTacticSummary{Shortname: t.Shortname, Name: t.Name}
+		tacticIndex[t.Shortname] = i
+	}
+
+	rows := make([]attackCoverageRow, 0, len(techniques))
+	for _, tech := range techniques {
+		row := attackCoverageRow{Technique: tech, Threats: threatsByCode[tech.ExternalID]}
+		if tech.Deprecated && !row.Mapped() {
+			continue
+		}
+
+		seen := make(map[uint]bool)
+		for _, th := range row.Threats {
+			for _, m := range measuresByThreat[th.ID] {
+				if isImplemented[m.ID] && !seen[m.ID] {
+					seen[m.ID] = true
+					row.Measures = append(row.Measures, m)
+				}
+			}
+		}
+		sort.Slice(row.Measures, func(i, j int) bool { return row.Measures[i].Code < row.Measures[j].Code })
+
+		for _, tactic := range tech.TacticList() {
+			i, ok := tacticIndex[tactic]
+			if !ok {
+				continue
+			}
+			summaries[i].Total++
+			if row.Mapped() {
+				summaries[i].Mapped++
+			}
+			if row.Covered() {
+				summaries[i].Covered++
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, summaries
+}
+
+func ShowClientAttackCoverage(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	client, ok := loadClientByParam(c)
+	if !ok {
+		return
+	}
+
+	var releases []models.AttackRelease
+	database.DB.Order("imported_at desc, id desc").Find(&releases)
+	release, hasRelease := attackReleaseParam(c)
+
+	data := gin.H{
+		"role":       string(role),
+		"client":     client,
+		"releases":   releases,
+		"release":    release,
+		"hasRelease": hasRelease,
+		"all":        c.Query("all") == "1",
+	}
+
+	if hasRelease {
+		rows, summaries := clientAttackCoverage(client, release)
+		total, mapped, covered := 0, 0, 0
+		var shown []attackCoverageRow
+		for _, r := range rows {
+			total++
+			if r.Mapped() {
+				mapped++
+			}
+			if r.Covered() {
+				covered++
+			}
+			if r.Mapped() || c.Query("all") == "1" {
+				shown = append(shown, r)
+			}
+		}
+		data["rows"] = shown
+		data["summaries"] = summaries
+		data["total"] = total
+		data["mapped"] = mapped
+		data["covered"] = covered
+	}
+
+	render(c, http.StatusOK, "attack_coverage.html", data)
+}
@@ -1,37 +1,51 @@
-package handlers
-
-import (
-	"net/http"
-
-	"ib-integrator/internal/database"
-	"ib-integrator/internal/models"
-
-	"github.com/gin-contrib/sessions"
-	"github.com/gin-gonic/gin"
-)
-
-func ListAuditLogs(c *gin.Context) {
-	// достаём роль из сессии
-	sess := sessions.Default(c)
-	roleStr, _ := sess.Get("role").(string)
-	role := models.UserRole(roleStr)
-
-	// можно сразу ограничить доступ
-	if role != models.RoleAdmin && role != models.RoleViewer {
-		c.AbortWithStatus(http.StatusForbidden)
-		return
-	}
-
-	var logs []models.AuditLog
-	database.DB.
-		Preload("User").
-		Order("created_at desc").
-		Limit(200).
-		Find(&logs)
-
-	render(c, http.StatusOK, "audit_list.html", gin.H{
-		"logs": logs,
-		"role": roleStr,                    // <- нужно для {{ .role }} в шаблоне
-		"IsAdmin": role == models.RoleAdmin, // если потом захочешь использовать
-	})
-}
+package handlers
+
+import (
+	"net/http"
+
+	"ib-integrator/internal/database"
+	"ib-integrator/internal/models"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+func ListAuditLogs(c *gin.Context) {
+	// достаём роль из сессии
+	sess := sessions.Default(c)
+	roleStr, _ := sess.Get("role").(string)
+	role := models.UserRole(roleStr)
+
+	// можно сразу ограничить доступ
+	if role != models.RoleAdmin && role != models.RoleViewer {
+		c.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+
+	// ?view=auth — только события входа: успешные и неудачные попытки, блокировки
+	authOnly := c.Query("view") == "auth"
+	action := c.Query("action")
+
+	query := database.DB.Preload("User")
+	if authOnly {
+		query = query.Where("entity = ?", models.AuthEntity)
+		if action != "" {
+			query = query.Where("action = ?", action)
+		}
+	}
+
+	var logs []models.AuditLog
+	query.
+		Order("created_at desc").
+		Limit(200).
+		Find(&logs)
+
+	render(c, http.StatusOK, "audit_list.html", gin.H{
+		"logs": logs,
+		"authOnly":    authOnly,
+		"authActions": models.AuthActions,
+		"action":      action,
+		"role": roleStr,                    // <- нужно для {{ .role }} в шаблоне
+		"IsAdmin": role == models.RoleAdmin, // если потом захочешь использовать
+	})
+}
@@ -1,112 +1,288 @@
-package handlers
-
-import (
-	"net/http"
-	"strings"
-
-	"ib-integrator/internal/database"
-	"ib-integrator/internal/models"
-
-	"github.com/gin-contrib/sessions"
-	"github.com/gin-gonic/gin"
-	"golang.org/x/crypto/bcrypt"
-)
-
-func ShowRegister(c *gin.Context) {
-	render(c, http.StatusOK, "register.html", gin.H{"error": ""})
-}
-
-type registerForm struct {
-	Username string `form:"username"`
-	Password string `form:"password"`
-	Role     string `form:"role"`
-}
-
-func Register(c *gin.Context) {
-	var form registerForm
-	if err := c.ShouldBind(&form); err != nil {
-		render(c, http.StatusBadRequest, "register.html", gin.H{"error": "Некорректные данные"})
-		return
-	}
-
-	form.Username = strings.TrimSpace(form.Username)
-	if len(form.Username) < 3 || len(form.Password) < 6 {
-		render(c, http.StatusBadRequest, "register.html", gin.H{"error": "Слишком короткий логин или пароль"})
-		return
-	}
-
-	role := models.UserRole(form.Role)
-
-	// через форму можно регистрировать только sales / engineer / viewer
-	switch role {
-	case models.RoleSales, models.RoleEngineer, models.RoleViewer:
-		// ок
-	default:
-		render(c, http.StatusBadRequest, "register.html", gin.H{"error": "Неверная роль"})
-		return
-	}
-
-	var existing models.User
-	if err := database.DB.Where("username = ?", form.Username).First(&existing).Error; err == nil {
-		render(c, http.StatusBadRequest, "register.html", gin.H{"error": "Пользователь уже существует"})
-		return
-	}
-
-	hash, _ := bcrypt.GenerateFromPassword([]byte(form.Password), bcrypt.DefaultCost)
-	user := models.User{
-		Username:     form.Username,
-		PasswordHash: string(hash),
-		Role:         role,
-	}
-	if err := database.DB.Create(&user).Error; err != nil {
-		render(c, http.StatusInternalServerError, "register.html", gin.H{"error": "Ошибка сохранения пользователя"})
-		return
-	}
-
-	// Можно при желании писать в аудит создание пользователя
-	// database.CreateAuditLog(user.ID, "user", user.ID, "create", "Создан пользователь "+user.Username)
-
-	c.Redirect(http.StatusFound, "/login")
-}
-
-func ShowLogin(c *gin.Context) {
-	render(c, http.StatusOK, "login.html", gin.H{"error": ""})
-}
-
-type loginForm struct {
-	Username string `form:"username"`
-	Password string `form:"password"`
-}
-
-func Login(c *gin.Context) {
-	var form loginForm
-	if err := c.ShouldBind(&form); err != nil {
-		render(c, http.StatusBadRequest, "login.html", gin.H{"error": "Некорректные данные"})
-		return
-	}
-
-	var user models.User
-	if err := database.DB.Where("username = ?", form.Username).First(&user).Error; err != nil {
-		render(c, http.StatusBadRequest, "login.html", gin.H{"error": "Неверный логин или пароль"})
-		return
-	}
-
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(form.Password)); err != nil {
-		render(c, http.StatusBadRequest, "login.html", gin.H{"error": "Неверный логин или пароль"})
-		return
-	}
-
-	sess := sessions.Default(c)
-	sess.Set("user_id", user.ID)
-	sess.Set("role", string(user.Role))
-	_ = sess.Save()
-
-	c.Redirect(http.StatusFound, "/clients")
-}
-
-func Logout(c *gin.Context) {
-	sess := sessions.Default(c)
-	sess.Clear()
-	_ = sess.Save()
-	c.Redirect(http.StatusFound, "/login")
-}
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"ib-integrator/internal/database"
+	"ib-integrator/internal/lockout"
+	"ib-integrator/internal/middleware"
+	"ib-integrator/internal/models"
+	"ib-integrator/internal/passpolicy"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func ShowRegister(c *gin.Context) {
+	render(c, http.StatusOK, "register.html", gin.H{
+		"error":        "",
+		"mode":         database.RegistrationMode(),
+		"passwordHint": passpolicy.Requirements(),
+	})
+}
+
+type registerForm struct {
+	Username string `form:"username"`
+	Password string `form:"password"`
+	Role     string `form:"role"`
+	Reason   string `form:"reason"` // зачем нужен доступ — для заявки
+}
+
+// Register — самостоятельная регистрация. В режиме «по заявке» учётная запись
+// создаётся неактивной и ждёт одобрения администратора.
+func Register(c *gin.Context) {
+	mode := database.RegistrationMode()
+	fail := func(status int, msg string) {
+		render(c, status, "register.html", gin.H{"error": msg, "mode": mode, "passwordHint": passpolicy.Requirements()})
+	}
+
+	var form registerForm
+	if err := c.ShouldBind(&form); err != nil {
+		fail(http.StatusBadRequest, "Некорректные данные")
+		return
+	}
+
+	form.Username = strings.TrimSpace(form.Username)
+	form.Reason = strings.TrimSpace(form.Reason)
+	if len(form.Username) < 3 {
+		fail(http.StatusBadRequest, "Слишком короткий логин")
+		return
+	}
+	if msg := passpolicy.Validate(form.Password, models.User{Username: form.Username}); msg != "" {
+		fail(http.StatusBadRequest, msg)
+		return
+	}
+
+	role := models.UserRole(form.Role)
+
+	// через форму можно регистрировать только sales / engineer / viewer
+	switch role {
+	case models.RoleSales, models.RoleEngineer, models.RoleViewer:
+		// ок
+	default:
+		fail(http.StatusBadRequest, "Неверная роль")
+		return
+	}
+
+	// после отклонённой заявки можно подать новую с тем же логином — прежняя запись
+	// не удаляется, на неё ссылается журнал аудита
+	var user models.User
+	err := database.DB.Unscoped().Where("username = ?", form.Username).First(&user).Error
+	if err == nil && user.Status != models.UserRejected {
+		fail(http.StatusBadRequest, "Пользователь уже существует")
+		return
+	}
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte(form.Password), bcrypt.DefaultCost)
+	user.Username = form.Username
+	user.PasswordHash = string(hash)
+	user.Role = role
+	user.Status = models.UserActive
+	if mode == models.RegistrationRequest {
+		user.Status = models.UserPending
+		user.Description = form.Reason
+	}
+	if err := database.DB.Save(&user).Error; err != nil {
+		fail(http.StatusInternalServerError, "Ошибка сохранения пользователя")
+		return
+	}
+
+	if user.Status == models.UserPending {
+		database.CreateAuditLog(user.ID, "user", user.ID, "access_request",
+			"Заявка на доступ: "+user.Username+", роль "+string(user.Role))
+		render(c, http.StatusOK, "login.html", gin.H{
+			"notice": "Заявка на доступ отправлена. Войти можно будет после одобрения администратором.",
+		})
+		return
+	}
+
+	database.CreateAuditLog(user.ID, "user", user.ID, "register",
+		"Зарегистрирован пользователь "+user.Username+", роль "+string(user.Role))
+	c.Redirect(http.StatusFound, "/login")
+}
+
+func ShowLogin(c *gin.Context) {
+	render(c, http.StatusOK, "login.html", gin.H{"error": ""})
+}
+
+type loginForm struct {
+	Username string `form:"username"`
+	Password string `form:"password"`
+}
+
+// authEvent — событие входа в журнал аудита с адресом и браузером клиента;
+// userID == 0, если логин не найден
+func authEvent(c *gin.Context, userID uint, action, details string) {
+	database.CreateRequestAuditLog(userID, models.AuthEntity, userID, action, details, c.ClientIP(), c.Request.UserAgent())
+}
+
+// lockTarget — что заблокировано, для журнала
+func lockTarget(l models.LoginLock) string {
+	if ip := strings.TrimPrefix(l.Key, "ip:"); ip != l.Key {
+		return "адрес " + ip
+	}
+	return "логин " + strings.TrimPrefix(l.Key, "account:")
+}
+
+func lockedMessage(l models.LoginLock) string {
+	return "Слишком много неудачных попыток входа. Повторите после " + l.LockedUntil.Format("15:04:05")
+}
+
+// loginFailed — неудачная попытка: счётчики блокировки и журнал аудита
+func loginFailed(c *gin.Context, user models.User, username string) {
+	authEvent(c, user.ID, models.AuthLoginFailed, "Неверный логин или пароль: "+username)
+
+	status, msg := http.StatusBadRequest, "Неверный логин или пароль"
+	for _, l := range lockout.Fail(username, c.ClientIP()) {
+		authEvent(c, user.ID, models.AuthLockout, fmt.Sprintf("%s заблокирован до %s (блокировка №%d)",
+			lockTarget(l), l.LockedUntil.Format("02.01.2006 15:04:05"), l.Lockouts))
+		status, msg = http.StatusTooManyRequests, lockedMessage(l)
+	}
+	render(c, status, "login.html", gin.H{"error": msg})
+}
+
+func Login(c *gin.Context) {
+	var form loginForm
+	if err := c.ShouldBind(&form); err != nil {
+		render(c, http.StatusBadRequest, "login.html", gin.H{"error": "Некорректные данные"})
+		return
+	}
+	form.Username = strings.TrimSpace(form.Username)
+	if r := []rune(form.Username); len(r) > 100 {
+		form.Username = string(r[:100])
+	}
+
+	// user.ID == 0 — такого логина нет
+	var user models.User
+	database.DB.Where("username = ?", form.Username).First(&user)
+
+	// во время блокировки пароль не проверяем вовсе
+	if lock, locked := lockout.Check(form.Username, c.ClientIP()); locked {
+		authEvent(c, user.ID, models.AuthLoginBlocked,
+			fmt.Sprintf("Попытка входа под %s во время блокировки (%s)", form.Username, lockTarget(lock)))
+		render(c, http.StatusTooManyRequests, "login.html", gin.H{"error": lockedMessage(lock)})
+		return
+	}
+
+	// сервисные учётные записи работают только через токены API
+	if user.ID == 0 || user.IsService {
+		loginFailed(c, user, form.Username)
+		return
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(form.Password)); err != nil {
+		loginFailed(c, user, form.Username)
+		return
+	}
+	lockout.Success(form.Username)
+
+	// состояние учётной записи сообщаем только после проверки пароля
+	var denied string
+	switch user.Status {
+	case models.UserPending:
+		denied = "Заявка на доступ ещё не одобрена администратором"
+	case models.UserDisabled:
+		denied = "Учётная запись отключена администратором"
+	case models.UserRejected:
+		denied = "Заявка на доступ отклонена администратором"
+	}
+	if denied != "" {
+		authEvent(c, user.ID, models.AuthLoginDenied, "Вход не разрешён: "+user.Username+", "+user.Status.Label())
+		render(c, http.StatusForbidden, "login.html", gin.H{"error": denied})
+		return
+	}
+
+	sess := sessions.Default(c)
+	sess.Set("user_id", user.ID)
+	sess.Set("role", string(user.Role))
+	_ = sess.Save()
+
+	if user.MustChangePassword {
+		authEvent(c, user.ID, models.AuthLoginSuccess, "Вход в систему: "+user.Username+" (требуется смена пароля)")
+		c.Redirect(http.StatusFound, middleware.PasswordChangePath)
+		return
+	}
+	authEvent(c, user.ID, models.AuthLoginSuccess, "Вход в систему: "+user.Username)
+	c.Redirect(http.StatusFound, "/clients")
+}
+
+func Logout(c *gin.Context) {
+	sess := sessions.Default(c)
+	sess.Clear()
+	_ = sess.Save()
+	c.Redirect(http.StatusFound, "/login")
+}
+
+// ---------- смена собственного пароля ----------
+
+func ShowChangePassword(c *gin.Context) {
+	renderChangePassword(c, http.StatusOK, "")
+}
+
+func renderChangePassword(c *gin.Context, status int, msg string) {
+	user, _ := c.Get("CurrentUser")
+	current, _ := user.(models.User)
+	render(c, status, "account_password.html", gin.H{
+		"error":        msg,
+		"forced":       current.MustChangePassword,
+		"role":         string(current.Role),
+		"passwordHint": passpolicy.Requirements(),
+	})
+}
+
+type changePasswordForm struct {
+	Current  string `form:"current_password"`
+	Password string `form:"password"`
+	Confirm  string `form:"password_confirm"`
+}
+
+func ChangePassword(c *gin.Context) {
+	user, _ := c.Get("CurrentUser")
+	current, ok := user.(models.User)
+	if !ok {
+		c.Redirect(http.StatusFound, "/login")
+		return
+	}
+
+	var form changePasswordForm
+	_ = c.ShouldBind(&form)
+
+	if err := bcrypt.CompareHashAndPassword([]byte(current.PasswordHash), []byte(form.Current)); err != nil {
+		renderChangePassword(c, http.StatusBadRequest, "Текущий пароль указан неверно")
+		return
+	}
+	if form.Password != form.Confirm {
+		renderChangePassword(c, http.StatusBadRequest, "Новый пароль и подтверждение не совпадают")
+		return
+	}
+	if form.Password == form.Current {
+		renderChangePassword(c, http.StatusBadRequest, "Новый пароль должен отличаться от текущего")
+		return
+	}
+	if msg := passpolicy.Validate(form.Password, current); msg != "" {
+		renderChangePassword(c, http.StatusBadRequest, msg)
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(form.Password), bcrypt.DefaultCost)
+	if err != nil {
+		renderChangePassword(c, http.StatusInternalServerError, "Ошибка сохранения пароля")
+		return
+	}
+	if err := passpolicy.Remember(current); err != nil {
+		renderChangePassword(c, http.StatusInternalServerError, "Ошибка сохранения пароля")
+		return
+	}
+	if err := database.DB.Model(&current).Updates(map[string]interface{}{
+		"password_hash":        string(hash),
+		"must_change_password": false,
+	}).Error; err != nil {
+		renderChangePassword(c, http.StatusInternalServerError, "Ошибка сохранения пароля")
+		return
+	}
+	database.CreateAuditLog(current.ID, "user", current.ID, "password_change", "Пользователь сменил пароль")
+
+	c.Redirect(http.StatusFound, "/clients")
+}
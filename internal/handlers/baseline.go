@@ -0,0 +1,332 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"ib-integrator/internal/database"
+	"ib-integrator/internal/models"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+// ====== БАЗОВЫЕ НАБОРЫ МЕР И АНАЛИЗ СООТВЕТСТВИЯ ======
+
+// assetBaseline — базовый набор по результату классификации объекта:
+// ИСПДн — УЗ (приказ №21), ГИС — класс (№17), значимый объект КИИ — категория (№239),
+// АСУ ТП, не являющаяся значимым объектом КИИ, — класс из карточки объекта (№31)
+func assetBaseline(asset models.Asset) (models.AssetBaseline, bool) {
+	var reg string
+	var level, threatType int
+
+	switch {
+	case asset.AssetType == models.AssetISPD:
+		var a models.ISPDnAssessment
+		if err := database.DB.Where("asset_id = ?", asset.ID).First(&a).Error; err == nil {
+			reg, level, threatType = models.Regulation21, a.Level, a.ThreatType
+		}
+	case asset.AssetType == models.AssetGIS:
+		if g, err := findGISClassification(asset.ID); err == nil {
+			reg, level = models.Regulation17, g.Class
+			// класс, изменённый в карточке объекта с обоснованием, приоритетнее расчётного
+			var override int
+			if g.OverrideJustification != "" {
+				if _, err := fmt.Sscanf(asset.Category, "К%d", &override); err == nil {
+					level = override
+				}
+			}
+		}
+	case asset.AssetType.IsKIICandidate():
+		if o, err := findKIIObject(asset.ID); err == nil && o.Category > 0 {
+			reg, level = models.Regulation239, o.Category
+		} else if asset.AssetType == models.AssetASUTP {
+			if _, err := fmt.Sscanf(asset.Category, "К%d", &level); err == nil {
+				reg = models.Regulation31
+			}
+		}
+	}
+
+	r, ok := models.FindRegulation(reg)
+	if !ok || !r.ValidLevel(level) {
+		return models.AssetBaseline{}, false
+	}
+	return models.AssetBaseline{Regulation: r, Level: level, ThreatType: threatType}, true
+}
+
+func parseBaseline(reg, levelStr string) (models.AssetBaseline, bool) {
+	r, ok := models.FindRegulation(reg)
+	if !ok {
+		return models.AssetBaseline{}, false
+	}
+	level, err := strconv.Atoi(levelStr)
+	if err != nil || !r.ValidLevel(level) {
+		return models.AssetBaseline{}, false
+	}
+	return models.AssetBaseline{Regulation: r, Level: level}, true
+}
+
+// gapRow — мера базового набора и запись о ней в реестре объекта (nil — меры нет в реестре)
+type gapRow struct {
+	Measure models.ControlMeasure
+	Entry   *models.AssetMeasure
+}
+
+type gapSummary struct {
+	Required      int
+	Implemented   int
+	InProgress    int
+	Planned       int
+	NotApplicable int
+	Missing       int
+}
+
+// Coverage — доля внедрённых мер в процентах; неприменимые меры из базы исключаются
+func (s gapSummary) Coverage() int {
+	base := s.Required - s.NotApplicable
+	if base <= 0 {
+		return 100
+	}
+	return s.Implemented * 100 / base
+}
+
+// assetGap сопоставляет базовый набор с реестром мер объекта
+func assetGap(assetID uint, b models.AssetBaseline) ([]gapRow, gapSummary, error) {
+	measures, err := database.BaselineMeasures(b.Regulation.Code, b.Level)
+	if err != nil {
+		return nil, gapSummary{}, err
+	}
+
+	var entries []models.AssetMeasure
+	if err := database.DB.Preload("Owner").Where("asset_id = ?", assetID).Find(&entries).Error; err != nil {
+		return nil, gapSummary{}, err
+	}
+	byMeasure := make(map[uint]*models.AssetMeasure, len(entries))
+	for i := range entries {
+		byMeasure[entries[i].MeasureID] = &entries[i]
+	}
+
+	rows := make([]gapRow, 0, len(measures))
+	summary := gapSummary{Required: len(measures)}
+	for _, m := range measures {
+		e := byMeasure[m.ID]
+		rows = append(rows, gapRow{Measure: m, Entry: e})
+		if e == nil {
+			summary.Missing++
+			continue
+		}
+		switch e.Status {
+		case models.MeasureImplemented:
+			summary.Implemented++
+		case models.MeasureInProgress:
+			summary.InProgress++
+		case models.MeasurePlanned:
+			summary.Planned++
+		case models.MeasureNotApplicable:
+			summary.NotApplicable++
+		}
+	}
+	return rows, summary, nil
+}
+
+// ShowAssetGap — требуемые меры базового набора против реестра мер объекта.
+// По умолчанию набор берётся из классификации объекта, ?regulation=&level= задают его явно;
+// ?format=csv выгружает таблицу.
+func ShowAssetGap(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	asset, ok := loadAssetByParam(c)
+	if !ok {
+		return
+	}
+
+	computed, hasComputed := assetBaseline(asset)
+	b, selected := computed, hasComputed
+	if reg := c.Query("regulation"); reg != "" {
+		if b, selected = parseBaseline(reg, c.Query("level")); !selected {
+			c.String(http.StatusBadRequest, "Некорректный приказ или уровень защищённости")
+			return
+		}
+	}
+
+	var rows []gapRow
+	var summary gapSummary
+	if selected {
+		var err error
+		if rows, summary, err = assetGap(asset.ID, b); err != nil {
+			c.String(http.StatusInternalServerError, "Ошибка загрузки базового набора мер")
+			return
+		}
+	}
+
+	if c.Query("format") == "csv" {
+		if !selected {
+			c.String(http.StatusBadRequest, "Базовый набор мер для объекта не определён")
+			return
+		}
+		writeGapCSV(c, asset, b, rows)
+		return
+	}
+
+	// уровни для формы выбора: до наибольшего числа уровней среди приказов
+	var levels []int
+	for _, r := range models.Regulations {
+		if l := r.LevelNumbers(); len(l) > len(levels) {
+			levels = l
+		}
+	}
+
+	render(c, http.StatusOK, "asset_gap.html", gin.H{
+		"role":        string(role),
+		"asset":       asset,
+		"computed":    computed,
+		"hasComputed": hasComputed,
+		"baseline":    b,
+		"selected":    selected,
+		"rows":        rows,
+		"summary":     summary,
+		"regulations": models.Regulations,
+		"levels":      levels,
+	})
+}
+
+func writeGapCSV(c *gin.Context, asset models.Asset, b models.AssetBaseline, rows []gapRow) {
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="gap-%d-%s-%d.csv"`,
+		asset.ID, b.Regulation.Code, b.Level))
+	c.Status(http.StatusOK)
+
+	// BOM и ";" — чтобы Excel открыл файл без мастера импорта
+	c.Writer.WriteString("\xEF\xBB\xBF")
+	w := csv.NewWriter(c.Writer)
+	w.Comma = ';'
+	w.Write([]string{"Объект", asset.Name})
+	w.Write([]string{"Базовый набор", b.Label()})
+	w.Write([]string{"Группа", "Код", "Мера", "Статус", "Ответственный", "Срок", "Обоснование", "Подтверждение"})
+	for _, r := range rows {
+		record := []string{r.Measure.GroupCode, r.Measure.Code, r.Measure.Name, "Нет в реестре", "", "", "", ""}
+		if e := r.Entry; e != nil {
+			record[3] = e.Status.Label()
+			if e.Owner != nil {
+				record[4] = e.Owner.Username
+			}
+			if e.DueDate != nil {
+				record[5] = e.DueDate.Format("02.01.2006")
+			}
+			record[6] = e.Justification
+			record[7] = e.Evidence
+		}
+		w.Write(record)
+	}
+	w.Flush()
+}
+
+// AddGapMeasures — внести недостающие меры базового набора в реестр объекта как запланированные
+func AddGapMeasures(c *gin.Context) {
+	if _, ok := requireRiskEditor(c); !ok {
+		return
+	}
+
+	asset, ok := loadAssetByParam(c)
+	if !ok {
+		return
+	}
+
+	b, ok := parseBaseline(c.PostForm("regulation"), c.PostForm("level"))
+	if !ok {
+		c.String(http.StatusBadRequest, "Некорректный приказ или уровень защищённости")
+		return
+	}
+
+	rows, _, err := assetGap(asset.ID, b)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Ошибка загрузки базового набора мер")
+		return
+	}
+
+	var entries []models.AssetMeasure
+	for _, r := range rows {
+		if r.Entry == nil {
+			entries = append(entries, models.AssetMeasure{
+				AssetID:   asset.ID,
+				MeasureID: r.Measure.ID,
+				Status:    models.MeasurePlanned,
+			})
+		}
+	}
+
+	redirect := fmt.Sprintf("/assets/%d/gap?regulation=%s&level=%d", asset.ID, b.Regulation.Code, b.Level)
+	if len(entries) == 0 {
+		c.Redirect(http.StatusFound, redirect)
+		return
+	}
+
+	if err := database.DB.Omit("Asset", "Measure", "Owner").Create(&entries).Error; err != nil {
+		c.String(http.StatusInternalServerError, "Ошибка сохранения мер в реестре")
+		return
+	}
+
+	sess := sessions.Default(c)
+	if uid, ok := sess.Get("user_id").(uint); ok {
+		database.CreateAuditLog(uid, "asset_measure", asset.ID, "create",
+			fmt.Sprintf("Объект %s: в реестр внесено мер базового набора — %d (%s)", asset.Name, len(entries), b.Label()))
+	}
+
+	c.Redirect(http.StatusFound, redirect)
+}
+
+// baselineRow — мера приказа и уровни, в базовый набор которых она входит
+type baselineRow struct {
+	Measure models.ControlMeasure
+	Levels  map[int]bool
+}
+
+// ListBaselines — каталог мер приказа с отметками базовых наборов
+func ListBaselines(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	reg, ok := models.FindRegulation(c.DefaultQuery("regulation", models.Regulation21))
+	if !ok {
+		c.String(http.StatusBadRequest, "Неизвестный приказ")
+		return
+	}
+
+	var measures []models.ControlMeasure
+	database.DB.Where("regulation = ?", reg.Code).Order("id asc").Find(&measures)
+
+	var marks []models.BaselineMeasure
+	database.DB.
+		Joins("JOIN control_measures ON control_measures.id = baseline_measures.measure_id").
+		Where("control_measures.regulation = ?", reg.Code).
+		Find(&marks)
+	levels := make(map[uint]map[int]bool)
+	counts := make(map[int]int)
+	for _, m := range marks {
+		if levels[m.MeasureID] == nil {
+			levels[m.MeasureID] = make(map[int]bool)
+		}
+		levels[m.MeasureID][m.Level] = true
+		counts[m.Level]++
+	}
+
+	rows := make([]baselineRow, 0, len(measures))
+	for _, m := range measures {
+		rows = append(rows, baselineRow{Measure: m, Levels: levels[m.ID]})
+	}
+
+	render(c, http.StatusOK, "baselines.html", gin.H{
+		"role":        string(role),
+		"regulation":  reg,
+		"regulations": models.Regulations,
+		"rows":        rows,
+		"counts":      counts,
+	})
+}
@@ -0,0 +1,697 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ib-integrator/internal/catalog"
+	"ib-integrator/internal/database"
+	"ib-integrator/internal/models"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ====== ВЕРСИИ КАТАЛОГА УГРОЗ И МЕР ======
+
+// catalogVersionRow — версия записи каталога для страницы истории (угрозы и меры выводятся одинаково)
+type catalogVersionRow struct {
+	Version       int
+	Status        models.CatalogStatus
+	Comment       string
+	EffectiveFrom *time.Time
+	EffectiveTo   *time.Time
+	CreatedAt     time.Time
+	Author        *models.User
+	Changes       []models.CatalogFieldChange // относительно предыдущей версии
+}
+
+func (r catalogVersionRow) Draft() bool { return r.Status == models.CatalogDraft }
+
+// catalogAffectedRow — оценка объекта, проведённая по более ранней версии
+type catalogAffectedRow struct {
+	Asset   models.Asset
+	Version int    // оценённая версия; 0 — неизвестна
+	Details string // что на объекте связано с записью
+	Link    string
+}
+
+func currentUserID(c *gin.Context) *uint {
+	if uid, ok := sessions.Default(c).Get("user_id").(uint); ok {
+		return &uid
+	}
+	return nil
+}
+
+func catalogAudit(c *gin.Context, entity string, id uint, action, details string) {
+	if uid := currentUserID(c); uid != nil {
+		database.CreateAuditLog(*uid, entity, id, action, details)
+	}
+}
+
+// compareVersions разбирает ?from=&to= (номера версий); по умолчанию — предыдущая и действующая
+func compareVersions(c *gin.Context, current, latest int) (int, int) {
+	to, err := strconv.Atoi(c.Query("to"))
+	if err != nil || to < 1 || to > latest {
+		to = current
+	}
+	from, err := strconv.Atoi(c.Query("from"))
+	if err != nil || from < 1 || from > latest {
+		from = to - 1
+	}
+	return from, to
+}
+
+// --- угрозы ---
+
+// threatVersionInput — поля новой версии угрозы из формы или из запроса к API
+type threatVersionInput struct {
+	Name            string `json:"name"`
+	Category        string `json:"category"`
+	Description     string `json:"description"`
+	Source          string `json:"source"`
+	ViolatorType    string `json:"violator_type"`
+	Object          string `json:"object"`
+	Confidentiality bool   `json:"confidentiality"`
+	Integrity       bool   `json:"integrity"`
+	Availability    bool   `json:"availability"`
+	Comment         string `json:"comment"` // что изменилось в версии
+	Publish         bool   `json:"publish"` // сразу опубликовать черновик
+}
+
+func (in threatVersionInput) version() (models.ThreatVersion, string) {
+	v := models.ThreatVersion{
+		Name:            strings.TrimSpace(in.Name),
+		Category:        strings.TrimSpace(in.Category),
+		Description:     strings.TrimSpace(in.Description),
+		Source:          strings.TrimSpace(in.Source),
+		ViolatorType:    strings.TrimSpace(in.ViolatorType),
+		Object:          strings.TrimSpace(in.Object),
+		Confidentiality: in.Confidentiality,
+		Integrity:       in.Integrity,
+		Availability:    in.Availability,
+		Comment:         strings.TrimSpace(in.Comment),
+	}
+	if len(v.Name) < 3 {
+		return v, "Название угрозы должно быть не короче 3 символов"
+	}
+	return v, ""
+}
+
+func bindThreatVersion(c *gin.Context) (models.ThreatVersion, string) {
+	return threatVersionInput{
+		Name:            c.PostForm("name"),
+		Category:        c.PostForm("category"),
+		Description:     c.PostForm("description"),
+		Source:          c.PostForm("source"),
+		ViolatorType:    c.PostForm("violator_type"),
+		Object:          c.PostForm("object"),
+		Confidentiality: c.PostForm("confidentiality") == "1",
+		Integrity:       c.PostForm("integrity") == "1",
+		Availability:    c.PostForm("availability") == "1",
+		Comment:         c.PostForm("comment"),
+	}.version()
+}
+
+// saveThreatVersion сохраняет черновик следующей версии угрозы, при publish — сразу публикует
+func saveThreatVersion(th *models.Threat, form models.ThreatVersion, publish bool, uid *uint) (models.ThreatVersion, error) {
+	var draft models.ThreatVersion
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if draft, err = catalog.SaveThreatDraft(tx, *th, form, uid); err != nil {
+			return err
+		}
+		if publish {
+			return catalog.PublishThreat(tx, th, draft)
+		}
+		return nil
+	})
+	if err != nil || uid == nil {
+		return draft, err
+	}
+
+	if publish {
+		database.CreateAuditLog(*uid, "threat", th.ID, "catalog_publish", fmt.Sprintf("Опубликована версия %d угрозы %s", draft.Version, th.Code))
+	} else {
+		database.CreateAuditLog(*uid, "threat", th.ID, "catalog_draft", fmt.Sprintf("Сохранён черновик версии %d угрозы %s", draft.Version, th.Code))
+	}
+	return draft, nil
+}
+
+// deprecateThreat выводит действующую угрозу из каталога; msg — почему это невозможно
+func deprecateThreat(th *models.Threat, uid *uint) (string, error) {
+	if th.Status != models.CatalogPublished {
+		return "Вывести из каталога можно только действующую угрозу", nil
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		return catalog.DeprecateThreat(tx, th)
+	})
+	if err != nil {
+		return "", err
+	}
+	if uid != nil {
+		database.CreateAuditLog(*uid, "threat", th.ID, "catalog_deprecate", fmt.Sprintf("Угроза %s выведена из каталога", th.Code))
+	}
+	return "", nil
+}
+
+func renderThreatEdit(c *gin.Context, status int, role models.UserRole, th models.Threat, form models.ThreatVersion, msg string) {
+	_, hasDraft := catalog.ThreatDraft(database.DB, th.ID)
+	render(c, status, "threat_edit.html", gin.H{
+		"role":     string(role),
+		"threat":   th,
+		"form":     form,
+		"hasDraft": hasDraft,
+		"error":    msg,
+	})
+}
+
+// ShowEditThreat — форма черновика: открывается существующий черновик или действующая версия
+func ShowEditThreat(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	th, ok := loadThreatByParam(c)
+	if !ok {
+		return
+	}
+
+	form, hasDraft := catalog.ThreatDraft(database.DB, th.ID)
+	if !hasDraft {
+		form = models.NewThreatVersion(th)
+	}
+	renderThreatEdit(c, http.StatusOK, role, th, form, "")
+}
+
+// UpdateThreat сохраняет черновик; с action=publish он сразу публикуется
+func UpdateThreat(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	th, ok := loadThreatByParam(c)
+	if !ok {
+		return
+	}
+
+	form, msg := bindThreatVersion(c)
+	if msg != "" {
+		renderThreatEdit(c, http.StatusBadRequest, role, th, form, msg)
+		return
+	}
+
+	if _, err := saveThreatVersion(&th, form, c.PostForm("action") == "publish", currentUserID(c)); err != nil {
+		renderThreatEdit(c, http.StatusInternalServerError, role, th, form, "Ошибка сохранения версии угрозы")
+		return
+	}
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/threats/%d/history", th.ID))
+}
+
+// PublishThreat публикует черновик. Выведенная угроза без черновика возвращается в каталог
+// новой версией с прежними полями.
+func PublishThreat(c *gin.Context) {
+	if _, ok := requireRiskEditor(c); !ok {
+		return
+	}
+
+	th, ok := loadThreatByParam(c)
+	if !ok {
+		return
+	}
+
+	draft, hasDraft := catalog.ThreatDraft(database.DB, th.ID)
+	if !hasDraft {
+		if th.Status != models.CatalogDeprecated {
+			c.String(http.StatusBadRequest, "Нет черновика для публикации")
+			return
+		}
+		draft = models.NewThreatVersion(th)
+		draft.Comment = "Возврат в каталог"
+		draft.AuthorID = currentUserID(c)
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		return catalog.PublishThreat(tx, &th, draft)
+	})
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Ошибка публикации версии")
+		return
+	}
+
+	catalogAudit(c, "threat", th.ID, "catalog_publish", fmt.Sprintf("Опубликована версия %d угрозы %s", th.Version, th.Code))
+	c.Redirect(http.StatusFound, fmt.Sprintf("/threats/%d/history", th.ID))
+}
+
+func DeprecateThreat(c *gin.Context) {
+	if _, ok := requireRiskEditor(c); !ok {
+		return
+	}
+
+	th, ok := loadThreatByParam(c)
+	if !ok {
+		return
+	}
+	msg, err := deprecateThreat(&th, currentUserID(c))
+	if msg != "" {
+		c.String(http.StatusBadRequest, msg)
+		return
+	}
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Ошибка вывода угрозы из каталога")
+		return
+	}
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/threats/%d/history", th.ID))
+}
+
+// DeleteThreatDraft отбрасывает черновик; черновик ещё не опубликованной угрозы удалить нельзя
+func DeleteThreatDraft(c *gin.Context) {
+	if _, ok := requireRiskEditor(c); !ok {
+		return
+	}
+
+	th, ok := loadThreatByParam(c)
+	if !ok {
+		return
+	}
+
+	draft, hasDraft := catalog.ThreatDraft(database.DB, th.ID)
+	if !hasDraft || th.Status == models.CatalogDraft {
+		c.String(http.StatusBadRequest, "Нет черновика, который можно отбросить")
+		return
+	}
+	if err := database.DB.Delete(&draft).Error; err != nil {
+		c.String(http.StatusInternalServerError, "Ошибка удаления черновика")
+		return
+	}
+
+	catalogAudit(c, "threat", th.ID, "catalog_discard", fmt.Sprintf("Отброшен черновик версии %d угрозы %s", draft.Version, th.Code))
+	c.Redirect(http.StatusFound, fmt.Sprintf("/threats/%d/history", th.ID))
+}
+
+// ShowThreatHistory — версии угрозы, сравнение двух версий и оценки объектов,
+// проведённые по версии старее сравниваемой
+func ShowThreatHistory(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	th, ok := loadThreatByParam(c)
+	if !ok {
+		return
+	}
+	if _, err := catalog.CurrentThreatVersion(database.DB, th); err != nil {
+		c.String(http.StatusInternalServerError, "Ошибка загрузки версий угрозы")
+		return
+	}
+
+	var versions []models.ThreatVersion
+	database.DB.Preload("Author").Where("threat_id = ?", th.ID).Order("version asc").Find(&versions)
+
+	byNumber := make(map[int]models.ThreatVersion, len(versions))
+	rows := make([]catalogVersionRow, 0, len(versions))
+	for i, v := range versions {
+		byNumber[v.Version] = v
+		row := catalogVersionRow{
+			Version: v.Version, Status: v.Status, Comment: v.Comment,
+			EffectiveFrom: v.EffectiveFrom, EffectiveTo: v.EffectiveTo,
+			CreatedAt: v.CreatedAt, Author: v.Author,
+		}
+		if i > 0 {
+			row.Changes = v.Changes(versions[i-1])
+		}
+		rows = append(rows, row)
+	}
+
+	latest := versions[len(versions)-1].Version
+	from, to := compareVersions(c, th.Version, latest)
+	var changes []models.CatalogFieldChange
+	if a, ok := byNumber[from]; ok {
+		if b, ok := byNumber[to]; ok {
+			changes = b.Changes(a)
+		}
+	}
+
+	var links []models.AssetThreat
+	database.DB.Preload("Asset.Client").Preload("ThreatVersion").
+		Joins("JOIN assets ON assets.id = asset_threats.asset_id AND assets.deleted_at IS NULL").
+		Where("asset_threats.threat_id = ?", th.ID).
+		Order("asset_threats.id asc").
+		Find(&links)
+	var affected []catalogAffectedRow
+	for _, l := range links {
+		version := 0
+		if l.ThreatVersion != nil {
+			version = l.ThreatVersion.Version
+		}
+		if version >= to {
+			continue
+		}
+		details := "Риск: " + models.RiskLevelLabel(l.RiskLevel)
+		if l.Excluded {
+			details += ", признана неактуальной"
+		}
+		affected = append(affected, catalogAffectedRow{
+			Asset:   l.Asset,
+			Version: version,
+			Details: details,
+			Link:    fmt.Sprintf("/assets/%d/threats/%d/edit", l.AssetID, l.ID),
+		})
+	}
+
+	render(c, http.StatusOK, "catalog_history.html", gin.H{
+		"role":     string(role),
+		"kind":     "threat",
+		"title":    th.Code + " — " + th.Name,
+		"base":     fmt.Sprintf("/threats/%d", th.ID),
+		"status":   string(th.Status),
+		"label":    th.Status.Label(),
+		"current":  th.Version,
+		"versions": rows,
+		"from":     from,
+		"to":       to,
+		"changes":  changes,
+		"affected": affected,
+	})
+}
+
+// --- меры ---
+
+func loadMeasureByParam(c *gin.Context) (models.ControlMeasure, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.String(http.StatusBadRequest, "Некорректный ID меры защиты")
+		return models.ControlMeasure{}, false
+	}
+
+	var m models.ControlMeasure
+	if err := database.DB.First(&m, id).Error; err != nil {
+		c.String(http.StatusNotFound, "Мера защиты не найдена")
+		return models.ControlMeasure{}, false
+	}
+	return m, true
+}
+
+// measureVersionInput — поля новой версии меры из формы или из запроса к API
+type measureVersionInput struct {
+	Name        string `json:"name"`
+	Standard    string `json:"standard"`
+	Description string `json:"description"`
+	Comment     string `json:"comment"`
+	Publish     bool   `json:"publish"`
+}
+
+func (in measureVersionInput) version(m models.ControlMeasure) (models.MeasureVersion, string) {
+	v := models.MeasureVersion{
+		Name:        strings.TrimSpace(in.Name),
+		GroupCode:   m.GroupCode, // группа мер задаётся приказом
+		Standard:    strings.TrimSpace(in.Standard),
+		Description: strings.TrimSpace(in.Description),
+		Comment:     strings.TrimSpace(in.Comment),
+	}
+	if len(v.Name) < 3 {
+		return v, "Название меры защиты должно быть не короче 3 символов"
+	}
+	return v, ""
+}
+
+func bindMeasureVersion(c *gin.Context, m models.ControlMeasure) (models.MeasureVersion, string) {
+	return measureVersionInput{
+		Name:        c.PostForm("name"),
+		Standard:    c.PostForm("standard"),
+		Description: c.PostForm("description"),
+		Comment:     c.PostForm("comment"),
+	}.version(m)
+}
+
+func saveMeasureVersion(m *models.ControlMeasure, form models.MeasureVersion, publish bool, uid *uint) (models.MeasureVersion, error) {
+	var draft models.MeasureVersion
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if draft, err = catalog.SaveMeasureDraft(tx, *m, form, uid); err != nil {
+			return err
+		}
+		if publish {
+			return catalog.PublishMeasure(tx, m, draft)
+		}
+		return nil
+	})
+	if err != nil || uid == nil {
+		return draft, err
+	}
+
+	if publish {
+		database.CreateAuditLog(*uid, "measure", m.ID, "catalog_publish", fmt.Sprintf("Опубликована версия %d меры %s", draft.Version, m.Code))
+	} else {
+		database.CreateAuditLog(*uid, "measure", m.ID, "catalog_draft", fmt.Sprintf("Сохранён черновик версии %d меры %s", draft.Version, m.Code))
+	}
+	return draft, nil
+}
+
+func deprecateMeasure(m *models.ControlMeasure, uid *uint) (string, error) {
+	if m.Status != models.CatalogPublished {
+		return "Вывести из каталога можно только действующую меру", nil
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		return catalog.DeprecateMeasure(tx, m)
+	})
+	if err != nil {
+		return "", err
+	}
+	if uid != nil {
+		database.CreateAuditLog(*uid, "measure", m.ID, "catalog_deprecate", fmt.Sprintf("Мера %s выведена из каталога", m.Code))
+	}
+	return "", nil
+}
+
+func renderMeasureEdit(c *gin.Context, status int, role models.UserRole, m models.ControlMeasure, form models.MeasureVersion, msg string) {
+	_, hasDraft := catalog.MeasureDraft(database.DB, m.ID)
+	render(c, status, "measure_edit.html", gin.H{
+		"role":     string(role),
+		"measure":  m,
+		"form":     form,
+		"hasDraft": hasDraft,
+		"error":    msg,
+	})
+}
+
+func ShowEditMeasure(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	m, ok := loadMeasureByParam(c)
+	if !ok {
+		return
+	}
+
+	form, hasDraft := catalog.MeasureDraft(database.DB, m.ID)
+	if !hasDraft {
+		form = models.NewMeasureVersion(m)
+	}
+	renderMeasureEdit(c, http.StatusOK, role, m, form, "")
+}
+
+func UpdateMeasure(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	m, ok := loadMeasureByParam(c)
+	if !ok {
+		return
+	}
+
+	form, msg := bindMeasureVersion(c, m)
+	if msg != "" {
+		renderMeasureEdit(c, http.StatusBadRequest, role, m, form, msg)
+		return
+	}
+
+	if _, err := saveMeasureVersion(&m, form, c.PostForm("action") == "publish", currentUserID(c)); err != nil {
+		renderMeasureEdit(c, http.StatusInternalServerError, role, m, form, "Ошибка сохранения версии меры")
+		return
+	}
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/measures/%d/history", m.ID))
+}
+
+func PublishMeasure(c *gin.Context) {
+	if _, ok := requireRiskEditor(c); !ok {
+		return
+	}
+
+	m, ok := loadMeasureByParam(c)
+	if !ok {
+		return
+	}
+
+	draft, hasDraft := catalog.MeasureDraft(database.DB, m.ID)
+	if !hasDraft {
+		if m.Status != models.CatalogDeprecated {
+			c.String(http.StatusBadRequest, "Нет черновика для публикации")
+			return
+		}
+		draft = models.NewMeasureVersion(m)
+		draft.Comment = "Возврат в каталог"
+		draft.AuthorID = currentUserID(c)
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		return catalog.PublishMeasure(tx, &m, draft)
+	})
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Ошибка публикации версии")
+		return
+	}
+
+	catalogAudit(c, "measure", m.ID, "catalog_publish", fmt.Sprintf("Опубликована версия %d меры %s", m.Version, m.Code))
+	c.Redirect(http.StatusFound, fmt.Sprintf("/measures/%d/history", m.ID))
+}
+
+func DeprecateMeasure(c *gin.Context) {
+	if _, ok := requireRiskEditor(c); !ok {
+		return
+	}
+
+	m, ok := loadMeasureByParam(c)
+	if !ok {
+		return
+	}
+	msg, err := deprecateMeasure(&m, currentUserID(c))
+	if msg != "" {
+		c.String(http.StatusBadRequest, msg)
+		return
+	}
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Ошибка вывода меры из каталога")
+		return
+	}
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/measures/%d/history", m.ID))
+}
+
+func DeleteMeasureDraft(c *gin.Context) {
+	if _, ok := requireRiskEditor(c); !ok {
+		return
+	}
+
+	m, ok := loadMeasureByParam(c)
+	if !ok {
+		return
+	}
+
+	draft, hasDraft := catalog.MeasureDraft(database.DB, m.ID)
+	if !hasDraft || m.Status == models.CatalogDraft {
+		c.String(http.StatusBadRequest, "Нет черновика, который можно отбросить")
+		return
+	}
+	if err := database.DB.Delete(&draft).Error; err != nil {
+		c.String(http.StatusInternalServerError, "Ошибка удаления черновика")
+		return
+	}
+
+	catalogAudit(c, "measure", m.ID, "catalog_discard", fmt.Sprintf("Отброшен черновик версии %d меры %s", draft.Version, m.Code))
+	c.Redirect(http.StatusFound, fmt.Sprintf("/measures/%d/history", m.ID))
+}
+
+// ShowMeasureHistory — версии меры и объекты, где мера внедряется или применена к угрозе.
+// Оценки мер не привязаны к версии, поэтому затронутыми считаются все такие объекты.
+func ShowMeasureHistory(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	m, ok := loadMeasureByParam(c)
+	if !ok {
+		return
+	}
+	if _, err := catalog.CurrentMeasureVersion(database.DB, m); err != nil {
+		c.String(http.StatusInternalServerError, "Ошибка загрузки версий меры")
+		return
+	}
+
+	var versions []models.MeasureVersion
+	database.DB.Preload("Author").Where("measure_id = ?", m.ID).Order("version asc").Find(&versions)
+
+	byNumber := make(map[int]models.MeasureVersion, len(versions))
+	rows := make([]catalogVersionRow, 0, len(versions))
+	for i, v := range versions {
+		byNumber[v.Version] = v
+		row := catalogVersionRow{
+			Version: v.Version, Status: v.Status, Comment: v.Comment,
+			EffectiveFrom: v.EffectiveFrom, EffectiveTo: v.EffectiveTo,
+			CreatedAt: v.CreatedAt, Author: v.Author,
+		}
+		if i > 0 {
+			row.Changes = v.Changes(versions[i-1])
+		}
+		rows = append(rows, row)
+	}
+
+	latest := versions[len(versions)-1].Version
+	from, to := compareVersions(c, m.Version, latest)
+	var changes []models.CatalogFieldChange
+	if a, ok := byNumber[from]; ok {
+		if b, ok := byNumber[to]; ok {
+			changes = b.Changes(a)
+		}
+	}
+
+	var entries []models.AssetMeasure
+	database.DB.Preload("Asset.Client").
+		Joins("JOIN assets ON assets.id = asset_measures.asset_id AND assets.deleted_at IS NULL").
+		Where("asset_measures.measure_id = ?", m.ID).
+		Order("asset_measures.id asc").
+		Find(&entries)
+	var affected []catalogAffectedRow
+	for _, e := range entries {
+		affected = append(affected, catalogAffectedRow{
+			Asset:   e.Asset,
+			Details: "Реестр мер: " + e.Status.Label(),
+			Link:    fmt.Sprintf("/assets/%d/measures/%d/edit", e.AssetID, e.ID),
+		})
+	}
+
+	var links []models.AssetThreat
+	database.DB.Preload("Asset.Client").Preload("Threat").
+		Joins("JOIN asset_threat_measures ON asset_threat_measures.asset_threat_id = asset_threats.id").
+		Joins("JOIN assets ON assets.id = asset_threats.asset_id AND assets.deleted_at IS NULL").
+		Where("asset_threat_measures.control_measure_id = ?", m.ID).
+		Order("asset_threats.id asc").
+		Find(&links)
+	for _, l := range links {
+		affected = append(affected, catalogAffectedRow{
+			Asset:   l.Asset,
+			Details: "Применена к угрозе " + l.Threat.Code,
+			Link:    fmt.Sprintf("/assets/%d/threats/%d/edit", l.AssetID, l.ID),
+		})
+	}
+
+	render(c, http.StatusOK, "catalog_history.html", gin.H{
+		"role":     string(role),
+		"kind":     "measure",
+		"title":    m.Code + " — " + m.Name,
+		"base":     fmt.Sprintf("/measures/%d", m.ID),
+		"status":   string(m.Status),
+		"label":    m.Status.Label(),
+		"current":  m.Version,
+		"versions": rows,
+		"from":     from,
+		"to":       to,
+		"changes":  changes,
+		"affected": affected,
+	})
+}
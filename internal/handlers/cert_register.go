@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"ib-integrator/internal/certreg"
+	"ib-integrator/internal/database"
+	"ib-integrator/internal/models"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ====== ГОСРЕЕСТР СЕРТИФИЦИРОВАННЫХ СЗИ ФСТЭК И УВЕДОМЛЕНИЯ ПО СЕРТИФИКАТАМ ======
+
+// размер выгрузки реестра — несколько тысяч строк, с запасом
+const maxRegisterFileSize = 64 << 20
+
+type certificateStatusCount struct {
+	Status models.CertificateStatus
+	Count  int64
+}
+
+func renderCertRegister(c *gin.Context, status int, role models.UserRole, result *certreg.Result, msg string) {
+	q := strings.TrimSpace(c.Query("q"))
+	filterStatus := models.CertificateStatus(c.Query("status"))
+
+	query := database.DB.Model(&models.RegisterCertificate{})
+	if q != "" {
+		like := "%" + strings.ToLower(q) + "%"
+		query = query.Where("LOWER(number) LIKE ? OR LOWER(product) LIKE ? OR LOWER(applicant) LIKE ?", like, like, like)
+	}
+	if filterStatus != "" {
+		query = query.Where("status = ?", filterStatus)
+	}
+
+	var total int64
+	query.Count(&total)
+	var certs []models.RegisterCertificate
+	query.Order("number asc").Limit(200).Find(&certs)
+
+	statuses := []models.CertificateStatus{models.CertificateValid, models.CertificateSuspended,
+		models.CertificateTerminated, models.CertificateExpired}
+	counts := make([]certificateStatusCount, 0, len(statuses))
+	for _, s := range statuses {
+		sc := certificateStatusCount{Status: s}
+		database.DB.Model(&models.RegisterCertificate{}).Where("status = ?", s).Count(&sc.Count)
+		counts = append(counts, sc)
+	}
+
+	var last models.RegisterCertificate
+	var importedAt *time.Time
+	if database.DB.Order("imported_at desc").Limit(1).Find(&last).RowsAffected > 0 {
+		importedAt = &last.ImportedAt
+	}
+
+	render(c, status, "cert_register.html", gin.H{
+		"role":         string(role),
+		"certs":        certs,
+		"total":        total,
+		"counts":       counts,
+		"importedAt":   importedAt,
+		"q":            q,
+		"filterStatus": filterStatus,
+		"result":       result,
+		"error":        msg,
+	})
+}
+
+func ShowCertRegister(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+	renderCertRegister(c, http.StatusOK, role, nil, "")
+}
+
+// ImportCertRegister — загрузка выгрузки реестра с сайта ФСТЭК (только администратор)
+func ImportCertRegister(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	fh, err := c.FormFile("file")
+	if err != nil {
+		renderCertRegister(c, http.StatusBadRequest, models.RoleAdmin, nil, "Выберите файл выгрузки реестра")
+		return
+	}
+	ext := strings.ToLower(filepath.Ext(fh.Filename))
+	if ext != ".xlsx" && ext != ".csv" {
+		renderCertRegister(c, http.StatusBadRequest, models.RoleAdmin, nil, "Поддерживаются выгрузки реестра в форматах .xlsx и .csv")
+		return
+	}
+	if fh.Size > maxRegisterFileSize {
+		renderCertRegister(c, http.StatusBadRequest, models.RoleAdmin, nil, "Файл слишком большой")
+		return
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		renderCertRegister(c, http.StatusInternalServerError, models.RoleAdmin, nil, "Не удалось прочитать файл")
+		return
+	}
+	data, err := io.ReadAll(f)
+	f.Close()
+	if err != nil {
+		renderCertRegister(c, http.StatusInternalServerError, models.RoleAdmin, nil, "Не удалось прочитать файл")
+		return
+	}
+
+	entries, err := certreg.Parse(fh.Filename, data)
+	if err != nil {
+		renderCertRegister(c, http.StatusBadRequest, models.RoleAdmin, nil, "Ошибка разбора выгрузки: "+err.Error())
+		return
+	}
+
+	res, err := certreg.Import(database.DB, entries, time.Now())
+	if err != nil {
+		renderCertRegister(c, http.StatusInternalServerError, models.RoleAdmin, nil, "Ошибка загрузки: "+err.Error())
+		return
+	}
+
+	sess := sessions.Default(c)
+	if uid, ok := sess.Get("user_id").(uint); ok {
+		database.CreateAuditLog(uid, "security_tool", 0, "register_import",
+			fmt.Sprintf("Загружен реестр ФСТЭК %s: добавлено %d, обновлено %d, без изменений %d; сертификат изменился у %d СЗИ, новых уведомлений %d",
+				fh.Filename, res.Added, res.Updated, res.Unchanged, len(res.Changes), res.Alerts))
+	}
+
+	renderCertRegister(c, http.StatusOK, models.RoleAdmin, &res, "")
+}
+
+// openCertificateAlerts — непринятые уведомления по сертификатам. Администратор видит все,
+// инженер — по клиентам своих проектов и по клиентам, у проектов которых нет инженера.
+// clientID != 0 — только по одному клиенту.
+func openCertificateAlerts(role models.UserRole, userID uint, clientID uint) []models.CertificateAlert {
+	query := database.DB.
+		Preload("Client").Preload("Asset").Preload("Tool").
+		Where("acknowledged_at IS NULL")
+	if clientID != 0 {
+		query = query.Where("client_id = ?", clientID)
+	}
+	if role == models.RoleEngineer {
+		query = query.Where(
+			"client_id IN (SELECT client_id FROM projects WHERE engineer_id = ? AND deleted_at IS NULL) OR "+
+				"client_id NOT IN (SELECT client_id FROM projects WHERE engineer_id IS NOT NULL AND deleted_at IS NULL)",
+			userID)
+	}
+
+	var alerts []models.CertificateAlert
+	query.Order("created_at desc, id desc").Find(&alerts)
+	return alerts
+}
+
+// AcknowledgeCertificateAlert — уведомление принято в работу и больше не показывается
+func AcknowledgeCertificateAlert(c *gin.Context) {
+	if _, ok := requireRiskEditor(c); !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.String(http.StatusBadRequest, "Некорректный ID уведомления")
+		return
+	}
+	var alert models.CertificateAlert
+	if err := database.DB.First(&alert, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.String(http.StatusNotFound, "Уведомление не найдено")
+			return
+		}
+		c.String(http.StatusInternalServerError, "Ошибка БД")
+		return
+	}
+
+	uid, _ := sessions.Default(c).Get("user_id").(uint)
+	if alert.AcknowledgedAt == nil {
+		now := time.Now()
+		if err := database.DB.Model(&alert).Updates(map[string]interface{}{
+			"acknowledged_by_id": uid,
+			"acknowledged_at":    now,
+		}).Error; err != nil {
+			c.String(http.StatusInternalServerError, "Ошибка БД")
+			return
+		}
+		database.CreateAuditLog(uid, "security_tool_installation", alert.InstallationID, "certificate_alert_ack",
+			"Принято уведомление: "+alert.Message)
+	}
+
+	// возврат на страницу, с которой приняли уведомление: главная или карточка клиента
+	back := c.PostForm("back")
+	if !strings.HasPrefix(back, "/") || strings.HasPrefix(back, "//") {
+		back = "/"
+	}
+	c.Redirect(http.StatusFound, back)
+}
@@ -1,315 +1,243 @@
-package handlers
-
-import (
-	"net/http"
-	"strconv"
-	"strings"
-
-	"ib-integrator/internal/database"
-	"ib-integrator/internal/models"
-
-	"github.com/gin-contrib/sessions"
-	"github.com/gin-gonic/gin"
-)
-
-// admin или sales: могут СОЗДАВАТЬ клиентов
-func canManageClients(c *gin.Context) bool {
-	sess := sessions.Default(c)
-	roleStr, _ := sess.Get("role").(string)
-	role := models.UserRole(roleStr)
-	return role == models.RoleAdmin || role == models.RoleSales
-}
-
-// только admin: может РЕДАКТИРОВАТЬ клиентов
-func canEditClients(c *gin.Context) bool {
-	sess := sessions.Default(c)
-	roleStr, _ := sess.Get("role").(string)
-	role := models.UserRole(roleStr)
-	return role == models.RoleAdmin
-}
-
-//
-// СПИСОК / СОЗДАНИЕ
-//
-
-func ListClients(c *gin.Context) {
-	sess := sessions.Default(c)
-	roleStr, _ := sess.Get("role").(string)
-	role := models.UserRole(roleStr)
-
-	var clients []models.Client
-	database.DB.Order("name asc").Find(&clients)
-
-	render(c, http.StatusOK, "clients_list.html", gin.H{
-		"clients": clients,
-		"IsAdmin": role == models.RoleAdmin,
-		"IsSales": role == models.RoleSales,
-	})
-}
-
-func ShowNewClient(c *gin.Context) {
-	if !canManageClients(c) {
-		c.String(http.StatusForbidden, "Недостаточно прав")
-		return
-	}
-
-	render(c, http.StatusOK, "clients_new.html", gin.H{
-		"error": "",
-	})
-}
-
-func CreateClient(c *gin.Context) {
-	if !canManageClients(c) {
-		c.String(http.StatusForbidden, "Недостаточно прав")
-		return
-	}
-
-	name := strings.TrimSpace(c.PostForm("name"))
-	orgType := strings.TrimSpace(c.PostForm("org_type"))
-	inn := strings.TrimSpace(c.PostForm("inn"))
-	industry := strings.TrimSpace(c.PostForm("industry"))
-	contactEmail := strings.TrimSpace(c.PostForm("contact_email"))
-	contactPhone := strings.TrimSpace(c.PostForm("contact_phone"))
-	notes := strings.TrimSpace(c.PostForm("notes"))
-
-	if len(name) < 3 {
-		renderClientError(c, "Название организации должно быть не короче 3 символов")
-		return
-	}
-
-	// --- ПРОВЕРКА УНИКАЛЬНОСТИ ИНН ---
-	if inn != "" {
-		var count int64
-		database.DB.Model(&models.Client{}).
-			Where("inn = ?", inn).
-			Count(&count)
-
-		if count > 0 {
-			renderClientError(c, "Клиент с таким ИНН уже существует")
-			return
-		}
-	}
-
-	// --- ПРОВЕРКА УНИКАЛЬНОСТИ ИМЕНИ ---
-	if name != "" {
-		var count int64
-		database.DB.Model(&models.Client{}).
-			Where("LOWER(name) = LOWER(?)", name).
-			Count(&count)
-
-		if count > 0 {
-			renderClientError(c, "Клиент с таким названием уже существует")
-			return
-		}
-	}
-
-	// --- ПРОВЕРКА УНИКАЛЬНОСТИ EMAIL ---
-	if contactEmail != "" {
-		var count int64
-		database.DB.Model(&models.Client{}).
-			Where("LOWER(contact_email) = LOWER(?)", contactEmail).
-			Count(&count)
-
-		if count > 0 {
-			renderClientError(c, "Клиент с таким e-mail уже существует")
-			return
-		}
-	}
-
-	// --- ПРОВЕРКА УНИКАЛЬНОСТИ ТЕЛЕФОНА ---
-	if contactPhone != "" {
-		var count int64
-		database.DB.Model(&models.Client{}).
-			Where("contact_phone = ?", contactPhone).
-			Count(&count)
-
-		if count > 0 {
-			renderClientError(c, "Клиент с таким номером телефона уже существует")
-			return
-		}
-	}
-
-	client := models.Client{
-		Name:         name,
-		OrgType:      orgType,
-		INN:          inn,
-		Industry:     industry,
-		ContactEmail: contactEmail,
-		ContactPhone: contactPhone,
-		Notes:        notes,
-	}
-
-	if err := database.DB.Create(&client).Error; err != nil {
-		renderClientError(c, "Ошибка сохранения клиента в БД")
-		return
-	}
-
-	// --- АУДИТ: создание клиента ---
-	sess := sessions.Default(c)
-	if v := sess.Get("user_id"); v != nil {
-		if uid, ok := v.(uint); ok {
-			database.CreateAuditLog(uid, "client", client.ID, "create", "Создан клиент: "+client.Name)
-		}
-	}
-
-	c.Redirect(http.StatusFound, "/clients")
-}
-
-// форма редактирования — ТОЛЬКО ADMIN
-func ShowEditClient(c *gin.Context) {
-	if !canEditClients(c) {
-		c.String(http.StatusForbidden, "Недостаточно прав")
-		return
-	}
-
-	idStr := c.Param("id")
-	id, err := strconv.Atoi(idStr)
-	if err != nil || id <= 0 {
-		c.String(http.StatusBadRequest, "Некорректный ID клиента")
-		return
-	}
-
-	var client models.Client
-	if err := database.DB.First(&client, id).Error; err != nil {
-		c.String(http.StatusNotFound, "Клиент не найден")
-		return
-	}
-
-	render(c, http.StatusOK, "clients_edit.html", gin.H{
-		"client": client,
-		"error":  "",
-	})
-}
-
-// сохранение изменений — ТОЛЬКО ADMIN
-func UpdateClient(c *gin.Context) {
-	if !canEditClients(c) {
-		c.String(http.StatusForbidden, "Недостаточно прав")
-		return
-	}
-
-	idStr := c.Param("id")
-	id, err := strconv.Atoi(idStr)
-	if err != nil || id <= 0 {
-		c.String(http.StatusBadRequest, "Некорректный ID клиента")
-		return
-	}
-
-	var client models.Client
-	if err := database.DB.First(&client, id).Error; err != nil {
-		c.String(http.StatusNotFound, "Клиент не найден")
-		return
-	}
-
-	name := strings.TrimSpace(c.PostForm("name"))
-	orgType := strings.TrimSpace(c.PostForm("org_type"))
-	inn := strings.TrimSpace(c.PostForm("inn"))
-	industry := strings.TrimSpace(c.PostForm("industry"))
-	contactEmail := strings.TrimSpace(c.PostForm("contact_email"))
-	contactPhone := strings.TrimSpace(c.PostForm("contact_phone"))
-	notes := strings.TrimSpace(c.PostForm("notes"))
-
-	if len(name) < 3 {
-		render(c, http.StatusBadRequest, "clients_edit.html", gin.H{
-			"client": client,
-			"error":  "Название организации должно быть не короче 3 символов",
-		})
-		return
-	}
-
-	// --- ПРОВЕРКА УНИКАЛЬНОСТИ ИНН (кроме текущего клиента) ---
-	if inn != "" && inn != client.INN {
-		var count int64
-		database.DB.Model(&models.Client{}).
-			Where("inn = ? AND id <> ?", inn, client.ID).
-			Count(&count)
-
-		if count > 0 {
-			render(c, http.StatusBadRequest, "clients_edit.html", gin.H{
-				"client": client,
-				"error":  "Клиент с таким ИНН уже существует",
-			})
-			return
-		}
-	}
-
-	// --- ПРОВЕРКА УНИКАЛЬНОСТИ ИМЕНИ ---
-	if name != "" && name != client.Name {
-		var count int64
-		database.DB.Model(&models.Client{}).
-			Where("LOWER(name) = LOWER(?) AND id <> ?", name, client.ID).
-			Count(&count)
-
-		if count > 0 {
-			render(c, http.StatusBadRequest, "clients_edit.html", gin.H{
-				"client": client,
-				"error":  "Клиент с таким названием уже существует",
-			})
-			return
-		}
-	}
-
-	// --- ПРОВЕРКА УНИКАЛЬНОСТИ EMAIL ---
-	if contactEmail != "" && !strings.EqualFold(contactEmail, client.ContactEmail) {
-		var count int64
-		database.DB.Model(&models.Client{}).
-			Where("LOWER(contact_email) = LOWER(?) AND id <> ?", contactEmail, client.ID).
-			Count(&count)
-
-		if count > 0 {
-			render(c, http.StatusBadRequest, "clients_edit.html", gin.H{
-				"client": client,
-				"error":  "Клиент с таким e-mail уже существует",
-			})
-			return
-		}
-	}
-
-	// --- ПРОВЕРКА УНИКАЛЬНОСТИ ТЕЛЕФОНА ---
-	if contactPhone != "" && contactPhone != client.ContactPhone {
-		var count int64
-		database.DB.Model(&models.Client{}).
-			Where("contact_phone = ? AND id <> ?", contactPhone, client.ID).
-			Count(&count)
-
-		if count > 0 {
-			render(c, http.StatusBadRequest, "clients_edit.html", gin.H{
-				"client": client,
-				"error":  "Клиент с таким номером телефона уже существует",
-			})
-			return
-		}
-	}
-
-	client.Name = name
-	client.OrgType = orgType
-	client.INN = inn
-	client.Industry = industry
-	client.ContactEmail = contactEmail
-	client.ContactPhone = contactPhone
-	client.Notes = notes
-
-	if err := database.DB.Save(&client).Error; err != nil {
-		render(c, http.StatusInternalServerError, "clients_edit.html", gin.H{
-			"client": client,
-			"error":  "Ошибка сохранения клиента",
-		})
-		return
-	}
-
-	// --- АУДИТ: изменение клиента ---
-	sess := sessions.Default(c)
-	if v := sess.Get("user_id"); v != nil {
-		if uid, ok := v.(uint); ok {
-			database.CreateAuditLog(uid, "client", client.ID, "update", "Изменён клиент: "+client.Name)
-		}
-	}
-
-	c.Redirect(http.StatusFound, "/clients/"+idStr)
-}
-
-func renderClientError(c *gin.Context, msg string) {
-	render(c, http.StatusBadRequest, "clients_new.html", gin.H{
-		"error": msg,
-	})
-}
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"ib-integrator/internal/database"
+	"ib-integrator/internal/models"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+// admin или sales: могут СОЗДАВАТЬ клиентов
+func canManageClients(c *gin.Context) bool {
+	sess := sessions.Default(c)
+	roleStr, _ := sess.Get("role").(string)
+	role := models.UserRole(roleStr)
+	return role == models.RoleAdmin || role == models.RoleSales
+}
+
+// только admin: может РЕДАКТИРОВАТЬ клиентов
+func canEditClients(c *gin.Context) bool {
+	sess := sessions.Default(c)
+	roleStr, _ := sess.Get("role").(string)
+	role := models.UserRole(roleStr)
+	return role == models.RoleAdmin
+}
+
+//
+// СПИСОК / СОЗДАНИЕ
+//
+
+func ListClients(c *gin.Context) {
+	sess := sessions.Default(c)
+	roleStr, _ := sess.Get("role").(string)
+	role := models.UserRole(roleStr)
+
+	var clients []models.Client
+	database.DB.Order("name asc").Find(&clients)
+
+	render(c, http.StatusOK, "clients_list.html", gin.H{
+		"clients": clients,
+		"IsAdmin": role == models.RoleAdmin,
+		"IsSales": role == models.RoleSales,
+	})
+}
+
+func ShowNewClient(c *gin.Context) {
+	if !canManageClients(c) {
+		c.String(http.StatusForbidden, "Недостаточно прав")
+		return
+	}
+
+	render(c, http.StatusOK, "clients_new.html", gin.H{
+		"error": "",
+	})
+}
+
+func CreateClient(c *gin.Context) {
+	if !canManageClients(c) {
+		c.String(http.StatusForbidden, "Недостаточно прав")
+		return
+	}
+
+	in := bindClientForm(c)
+	if msg := validateClient(in, 0); msg != "" {
+		renderClientError(c, msg)
+		return
+	}
+
+	var client models.Client
+	in.apply(&client)
+	uid, _ := sessions.Default(c).Get("user_id").(uint)
+	if err := saveClient(&client, uid); err != nil {
+		renderClientError(c, "Ошибка сохранения клиента в БД")
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/clients")
+}
+
+// форма редактирования — ТОЛЬКО ADMIN
+func ShowEditClient(c *gin.Context) {
+	if !canEditClients(c) {
+		c.String(http.StatusForbidden, "Недостаточно прав")
+		return
+	}
+
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id <= 0 {
+		c.String(http.StatusBadRequest, "Некорректный ID клиента")
+		return
+	}
+
+	var client models.Client
+	if err := database.DB.First(&client, id).Error; err != nil {
+		c.String(http.StatusNotFound, "Клиент не найден")
+		return
+	}
+
+	render(c, http.StatusOK, "clients_edit.html", gin.H{
+		"client": client,
+		"error":  "",
+	})
+}
+
+// сохранение изменений — ТОЛЬКО ADMIN
+func UpdateClient(c *gin.Context) {
+	if !canEditClients(c) {
+		c.String(http.StatusForbidden, "Недостаточно прав")
+		return
+	}
+
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id <= 0 {
+		c.String(http.StatusBadRequest, "Некорректный ID клиента")
+		return
+	}
+
+	var client models.Client
+	if err := database.DB.First(&client, id).Error; err != nil {
+		c.String(http.StatusNotFound, "Клиент не найден")
+		return
+	}
+
+	in := bindClientForm(c)
+	if msg := validateClient(in, client.ID); msg != "" {
+		render(c, http.StatusBadRequest, "clients_edit.html", gin.H{
+			"client": client,
+			"error":  msg,
+		})
+		return
+	}
+
+	in.apply(&client)
+	uid, _ := sessions.Default(c).Get("user_id").(uint)
+	if err := saveClient(&client, uid); err != nil {
+		render(c, http.StatusInternalServerError, "clients_edit.html", gin.H{
+			"client": client,
+			"error":  "Ошибка сохранения клиента",
+		})
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/clients/"+idStr)
+}
+
+func renderClientError(c *gin.Context, msg string) {
+	render(c, http.StatusBadRequest, "clients_new.html", gin.H{
+		"error": msg,
+	})
+}
+
+// clientInput — поля клиента из формы или из запроса к API
+type clientInput struct {
+	Name         string `json:"name"`
+	OrgType      string `json:"org_type"`
+	INN          string `json:"inn"`
+	Industry     string `json:"industry"`
+	ContactEmail string `json:"contact_email"`
+	ContactPhone string `json:"contact_phone"`
+	Notes        string `json:"notes"`
+}
+
+func bindClientForm(c *gin.Context) clientInput {
+	in := clientInput{
+		Name:         c.PostForm("name"),
+		OrgType:      c.PostForm("org_type"),
+		INN:          c.PostForm("inn"),
+		Industry:     c.PostForm("industry"),
+		ContactEmail: c.PostForm("contact_email"),
+		ContactPhone: c.PostForm("contact_phone"),
+		Notes:        c.PostForm("notes"),
+	}
+	in.trim()
+	return in
+}
+
+func (in *clientInput) trim() {
+	for _, f := range []*string{&in.Name, &in.OrgType, &in.INN, &in.Industry, &in.ContactEmail, &in.ContactPhone, &in.Notes} {
+		*f = strings.TrimSpace(*f)
+	}
+}
+
+func (in clientInput) apply(client *models.Client) {
+	client.Name = in.Name
+	client.OrgType = in.OrgType
+	client.INN = in.INN
+	client.Industry = in.Industry
+	client.ContactEmail = in.ContactEmail
+	client.ContactPhone = in.ContactPhone
+	client.Notes = in.Notes
+}
+
+// validateClient — проверки перед сохранением; id — редактируемый клиент (0 — новый),
+// он не считается дубликатом самого себя
+func validateClient(in clientInput, id uint) string {
+	if len(in.Name) < 3 {
+		return "Название организации должно быть не короче 3 символов"
+	}
+
+	duplicate := func(where string, value string) bool {
+		var count int64
+		database.DB.Model(&models.Client{}).Where(where+" AND id <> ?", value, id).Count(&count)
+		return count > 0
+	}
+	switch {
+	case in.INN != "" && duplicate("inn = ?", in.INN):
+		return "Клиент с таким ИНН уже существует"
+	case duplicate("LOWER(name) = LOWER(?)", in.Name):
+		return "Клиент с таким названием уже существует"
+	case in.ContactEmail != "" && duplicate("LOWER(contact_email) = LOWER(?)", in.ContactEmail):
+		return "Клиент с таким e-mail уже существует"
+	case in.ContactPhone != "" && duplicate("contact_phone = ?", in.ContactPhone):
+		return "Клиент с таким номером телефона уже существует"
+	}
+	return ""
+}
+
+// saveClient создаёт или сохраняет клиента и пишет в журнал аудита
+func saveClient(client *models.Client, uid uint) error {
+	if client.ID == 0 {
+		if err := database.DB.Create(client).Error; err != nil {
+			return err
+		}
+		if uid != 0 {
+			database.CreateAuditLog(uid, "client", client.ID, "create", "Создан клиент: "+client.Name)
+		}
+		return nil
+	}
+
+	if err := database.DB.Omit("Assets", "Projects").Save(client).Error; err != nil {
+		return err
+	}
+	if uid != 0 {
+		database.CreateAuditLog(uid, "client", client.ID, "update", "Изменён клиент: "+client.Name)
+	}
+	return nil
+}
@@ -1,33 +1,68 @@
-package handlers
-
-import (
-	"net/http"
-	"strconv"
-
-	"ib-integrator/internal/database"
-	"ib-integrator/internal/models"
-
-	"github.com/gin-gonic/gin"
-)
-
-func ShowClientDetail(c *gin.Context) {
-	idStr := c.Param("id")
-	id, err := strconv.ParseUint(idStr, 10, 64)
-	if err != nil || id == 0 {
-		c.String(http.StatusBadRequest, "Некорректный ID клиента")
-		return
-	}
-
-	var client models.Client
-	// Грузим клиента сразу с объектами защиты и проектами
-	if err := database.DB.
-		Preload("Assets").
-		First(&client, id).Error; err != nil {
-		c.String(http.StatusNotFound, "Клиент не найден")
-		return
-	}
-
-	render(c, http.StatusOK, "client_detail.html", gin.H{
-		"client": client,
-	})
-}
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"ib-integrator/internal/database"
+	"ib-integrator/internal/models"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func ShowClientDetail(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil || id == 0 {
+		c.String(http.StatusBadRequest, "Некорректный ID клиента")
+		return
+	}
+
+	var client models.Client
+	// Грузим клиента сразу с объектами защиты и проектами
+	if err := database.DB.
+		Preload("Assets").
+		Preload("Projects", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at desc")
+		}).
+		First(&client, id).Error; err != nil {
+		c.String(http.StatusNotFound, "Клиент не найден")
+		return
+	}
+
+	sess := sessions.Default(c)
+	roleStr, _ := sess.Get("role").(string)
+	role := models.UserRole(roleStr)
+	canCategorize := role == models.RoleAdmin || role == models.RoleEngineer
+
+	// на карточке клиента — все его непринятые уведомления по сертификатам СЗИ, независимо от инженера проекта
+	var alerts []models.CertificateAlert
+	if canCategorize {
+		uid, _ := sess.Get("user_id").(uint)
+		alerts = openCertificateAlerts(models.RoleAdmin, uid, client.ID)
+	}
+
+	render(c, http.StatusOK, "client_detail.html", gin.H{
+		"client":        client,
+		"CanCreate":     canManageClients(c),
+		"CanCategorize": canCategorize,
+		"certAlerts":    alerts,
+	})
+}
+
+func loadClientByParam(c *gin.Context) (models.Client, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.String(http.StatusBadRequest, "Некорректный ID клиента")
+		return models.Client{}, false
+	}
+
+	var client models.Client
+	if err := database.DB.First(&client, id).Error; err != nil {
+		c.String(http.StatusNotFound, "Клиент не найден")
+		return models.Client{}, false
+	}
+	return client, true
+}
@@ -0,0 +1,840 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"ib-integrator/internal/catalog"
+	"ib-integrator/internal/database"
+	"ib-integrator/internal/dfd"
+	"ib-integrator/internal/models"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ====== DFD ОБЪЕКТА И ПОДБОР УГРОЗ STRIDE ======
+
+// размеры схемы, px
+const (
+	dfdNodeW   = 170
+	dfdNodeH   = 46
+	dfdColGap  = 90
+	dfdRowGap  = 28
+	dfdPadding = 16
+	dfdHeaderH = 26
+)
+
+type dfdNode struct {
+	Element    models.DFDElement
+	X, Y, W, H int
+}
+
+func (n dfdNode) CX() int { return n.X + n.W/2 }
+func (n dfdNode) CY() int { return n.Y + n.H/2 }
+
+type dfdZone struct {
+	Name       string
+	Trusted    bool // граница доверия, а не область "вне границ"
+	X, Y, W, H int
+}
+
+type dfdEdge struct {
+	Flow           models.DFDFlow
+	X1, Y1, X2, Y2 int
+	LX, LY         int // подпись
+}
+
+// dfdLayout — схема для SVG: колонка на каждую границу доверия, элементы вне границ — первой колонкой
+type dfdLayout struct {
+	Width, Height int
+	Zones         []dfdZone
+	Nodes         []dfdNode
+	Edges         []dfdEdge
+}
+
+func layoutDFD(boundaries []models.DFDBoundary, elements []models.DFDElement, flows []models.DFDFlow) dfdLayout {
+	type column struct {
+		zone  dfdZone
+		items []models.DFDElement
+	}
+	var outside column
+	outside.zone.Name = "Вне границ доверия"
+	cols := make([]column, len(boundaries))
+	index := make(map[uint]int, len(boundaries))
+	for i, b := range boundaries {
+		cols[i].zone = dfdZone{Name: b.Name, Trusted: true}
+		index[b.ID] = i
+	}
+	for _, el := range elements {
+		if el.BoundaryID != nil {
+			if i, ok := index[*el.BoundaryID]; ok {
+				cols[i].items = append(cols[i].items, el)
+				continue
+			}
+		}
+		outside.items = append(outside.items, el)
+	}
+	if len(outside.items) > 0 {
+		cols = append([]column{outside}, cols...)
+	}
+
+	var l dfdLayout
+	pos := make(map[uint]dfdNode, len(elements))
+	x := dfdPadding
+	for _, col := range cols {
+		zone := col.zone
+		zone.X, zone.Y, zone.W = x, dfdPadding, dfdNodeW+2*dfdPadding
+		y := zone.Y + dfdHeaderH
+		for _, el := range col.items {
+			n := dfdNode{Element: el, X: x + dfdPadding, Y: y, W: dfdNodeW, H: dfdNodeH}
+			l.Nodes = append(l.Nodes, n)
+			pos[el.ID] = n
+			y += dfdNodeH + dfdRowGap
+		}
+		zone.H = y - zone.Y
+		if zone.H < dfdHeaderH+dfdNodeH {
+			zone.H = dfdHeaderH + dfdNodeH
+		}
+		l.Zones = append(l.Zones, zone)
+		if zone.Y+zone.H+dfdPadding > l.Height {
+			l.Height = zone.Y + zone.H + dfdPadding
+		}
+		x += zone.W + dfdColGap
+	}
+	l.Width = x - dfdColGap + dfdPadding
+
+	for _, f := range flows {
+		src, ok1 := pos[f.SourceID]
+		dst, ok2 := pos[f.TargetID]
+		if !ok1 || !ok2 {
+			continue
+		}
+		e := dfdEdge{Flow: f, X1: src.CX(), Y1: src.CY(), X2: dst.CX(), Y2: dst.CY()}
+		// линия — от края блока, чтобы стрелка не пряталась под ним
+		switch {
+		case dst.X > src.X:
+			e.X1, e.X2 = src.X+src.W, dst.X
+		case dst.X < src.X:
+			e.X1, e.X2 = src.X, dst.X+dst.W
+		case dst.Y > src.Y:
+			e.Y1, e.Y2 = src.Y+src.H, dst.Y
+		default:
+			e.Y1, e.Y2 = src.Y, dst.Y+dst.H
+		}
+		e.LX, e.LY = (e.X1+e.X2)/2, (e.Y1+e.Y2)/2-4
+		l.Edges = append(l.Edges, e)
+	}
+	return l
+}
+
+func loadDFD(assetID uint) ([]models.DFDBoundary, []models.DFDElement, []models.DFDFlow) {
+	var boundaries []models.DFDBoundary
+	database.DB.Where("asset_id = ?", assetID).Order("id asc").Find(&boundaries)
+	var elements []models.DFDElement
+	database.DB.Preload("Boundary").Preload("Component").
+		Where("asset_id = ?", assetID).Order("id asc").Find(&elements)
+	var flows []models.DFDFlow
+	database.DB.Preload("Source.Boundary").Preload("Target.Boundary").
+		Where("asset_id = ?", assetID).Order("id asc").Find(&flows)
+	return boundaries, elements, flows
+}
+
+func renderAssetDFD(c *gin.Context, status int, role models.UserRole, asset models.Asset, data gin.H) {
+	boundaries, elements, flows := loadDFD(asset.ID)
+
+	var suggestions []models.DFDSuggestion
+	database.DB.Preload("Threat").Preload("Component").Preload("DecidedBy").
+		Where("asset_id = ?", asset.ID).
+		Order("status desc, threat_id asc, id asc").
+		Find(&suggestions)
+	var pending, decided []models.DFDSuggestion
+	for _, s := range suggestions {
+		if s.Status == models.SuggestionPending {
+			pending = append(pending, s)
+		} else {
+			decided = append(decided, s)
+		}
+	}
+
+	matrix, err := database.LoadRiskMatrix()
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Матрица рисков не настроена")
+		return
+	}
+
+	h := gin.H{
+		"role":            string(role),
+		"asset":           asset,
+		"boundaries":      boundaries,
+		"elements":        elements,
+		"flows":           flows,
+		"layout":          layoutDFD(boundaries, elements, flows),
+		"kinds":           models.DFDElementKinds,
+		"components":      assetComponents(asset.ID),
+		"pending":         pending,
+		"decided":         decided,
+		"likelihoodScale": likelihoodScale(matrix),
+		"impactScale":     impactScale(matrix),
+		"error":           "",
+		"notice":          "",
+	}
+	for k, v := range data {
+		h[k] = v
+	}
+	render(c, status, "asset_dfd.html", h)
+}
+
+func ShowAssetDFD(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	asset, ok := loadAssetByParam(c)
+	if !ok {
+		return
+	}
+
+	renderAssetDFD(c, http.StatusOK, role, asset, nil)
+}
+
+func dfdFail(c *gin.Context, role models.UserRole, asset models.Asset, status int, msg string) {
+	renderAssetDFD(c, status, role, asset, gin.H{"error": msg})
+}
+
+func redirectDFD(c *gin.Context, asset models.Asset) {
+	c.Redirect(http.StatusFound, fmt.Sprintf("/assets/%d/dfd", asset.ID))
+}
+
+func auditDFD(c *gin.Context, asset models.Asset, action, details string) {
+	sess := sessions.Default(c)
+	if uid, ok := sess.Get("user_id").(uint); ok {
+		database.CreateAuditLog(uid, "asset", asset.ID, action, fmt.Sprintf("Объект %s: %s", asset.Name, details))
+	}
+}
+
+// ---------- редактор ----------
+
+func AddDFDBoundary(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	asset, ok := loadAssetByParam(c)
+	if !ok {
+		return
+	}
+
+	b := models.DFDBoundary{
+		AssetID:     asset.ID,
+		Name:        strings.TrimSpace(c.PostForm("name")),
+		Description: strings.TrimSpace(c.PostForm("description")),
+	}
+	if b.Name == "" {
+		dfdFail(c, role, asset, http.StatusBadRequest, "Укажите наименование границы доверия")
+		return
+	}
+	var count int64
+	database.DB.Model(&models.DFDBoundary{}).Where("asset_id = ? AND name = ?", asset.ID, b.Name).Count(&count)
+	if count > 0 {
+		dfdFail(c, role, asset, http.StatusBadRequest, "Граница доверия с таким наименованием уже есть")
+		return
+	}
+
+	if err := database.DB.Create(&b).Error; err != nil {
+		dfdFail(c, role, asset, http.StatusInternalServerError, "Ошибка сохранения границы доверия")
+		return
+	}
+	redirectDFD(c, asset)
+}
+
+func DeleteDFDBoundary(c *gin.Context) {
+	_, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	asset, ok := loadAssetByParam(c)
+	if !ok {
+		return
+	}
+
+	var b models.DFDBoundary
+	if err := database.DB.Where("id = ? AND asset_id = ?", c.Param("boundary_id"), asset.ID).First(&b).Error; err != nil {
+		c.String(http.StatusNotFound, "Граница доверия не найдена")
+		return
+	}
+
+	// элементы остаются на диаграмме вне границ; удаляем физически, чтобы имя можно было занять снова
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.DFDElement{}).Where("boundary_id = ?", b.ID).Update("boundary_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&b).Error
+	})
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Ошибка удаления границы доверия")
+		return
+	}
+	redirectDFD(c, asset)
+}
+
+func AddDFDElement(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	asset, ok := loadAssetByParam(c)
+	if !ok {
+		return
+	}
+
+	el := models.DFDElement{
+		AssetID:     asset.ID,
+		Name:        strings.TrimSpace(c.PostForm("name")),
+		Kind:        models.DFDElementKind(c.PostForm("kind")),
+		Description: strings.TrimSpace(c.PostForm("description")),
+	}
+	if el.Name == "" {
+		dfdFail(c, role, asset, http.StatusBadRequest, "Укажите наименование элемента")
+		return
+	}
+	if !el.Kind.Valid() {
+		dfdFail(c, role, asset, http.StatusBadRequest, "Некорректный тип элемента")
+		return
+	}
+
+	boundaryID, ok1 := parseOptionalID(c.PostForm("boundary_id"))
+	componentID, ok2 := parseOptionalID(c.PostForm("component_id"))
+	if !ok1 || !ok2 {
+		dfdFail(c, role, asset, http.StatusBadRequest, "Некорректные параметры")
+		return
+	}
+	var count int64
+	if boundaryID != nil {
+		database.DB.Model(&models.DFDBoundary{}).Where("id = ? AND asset_id = ?", *boundaryID, asset.ID).Count(&count)
+		if count == 0 {
+			dfdFail(c, role, asset, http.StatusBadRequest, "Граница доверия не относится к объекту")
+			return
+		}
+	}
+	if componentID != nil {
+		database.DB.Model(&models.AssetComponent{}).Where("id = ? AND asset_id = ?", *componentID, asset.ID).Count(&count)
+		if count == 0 {
+			dfdFail(c, role, asset, http.StatusBadRequest, "Компонент не относится к объекту")
+			return
+		}
+	}
+	database.DB.Model(&models.DFDElement{}).Where("asset_id = ? AND name = ?", asset.ID, el.Name).Count(&count)
+	if count > 0 {
+		dfdFail(c, role, asset, http.StatusBadRequest, "Элемент с таким наименованием уже есть на диаграмме")
+		return
+	}
+	el.BoundaryID = boundaryID
+	el.ComponentID = componentID
+
+	if err := database.DB.Omit("Boundary", "Component").Create(&el).Error; err != nil {
+		dfdFail(c, role, asset, http.StatusInternalServerError, "Ошибка сохранения элемента")
+		return
+	}
+	redirectDFD(c, asset)
+}
+
+func DeleteDFDElement(c *gin.Context) {
+	_, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	asset, ok := loadAssetByParam(c)
+	if !ok {
+		return
+	}
+
+	var el models.DFDElement
+	if err := database.DB.Where("id = ? AND asset_id = ?", c.Param("element_id"), asset.ID).First(&el).Error; err != nil {
+		c.String(http.StatusNotFound, "Элемент не найден")
+		return
+	}
+
+	// вместе с элементом удаляются его потоки
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("source_id = ? OR target_id = ?", el.ID, el.ID).Delete(&models.DFDFlow{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&el).Error
+	})
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Ошибка удаления элемента")
+		return
+	}
+	redirectDFD(c, asset)
+}
+
+func AddDFDFlow(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	asset, ok := loadAssetByParam(c)
+	if !ok {
+		return
+	}
+
+	var source, target models.DFDElement
+	err1 := database.DB.Where("id = ? AND asset_id = ?", c.PostForm("source_id"), asset.ID).First(&source).Error
+	err2 := database.DB.Where("id = ? AND asset_id = ?", c.PostForm("target_id"), asset.ID).First(&target).Error
+	if err1 != nil || err2 != nil {
+		dfdFail(c, role, asset, http.StatusBadRequest, "Выберите элементы диаграммы")
+		return
+	}
+	if source.ID == target.ID {
+		dfdFail(c, role, asset, http.StatusBadRequest, "Поток должен связывать разные элементы")
+		return
+	}
+	if err := dfd.CheckFlow(source.Kind, target.Kind); err != nil {
+		dfdFail(c, role, asset, http.StatusBadRequest, "Поток данных должен начинаться или заканчиваться процессом")
+		return
+	}
+
+	flow := models.DFDFlow{
+		AssetID:       asset.ID,
+		SourceID:      source.ID,
+		TargetID:      target.ID,
+		Name:          strings.TrimSpace(c.PostForm("name")),
+		Protocol:      strings.TrimSpace(c.PostForm("protocol")),
+		Encrypted:     c.PostForm("encrypted") == "on",
+		Authenticated: c.PostForm("authenticated") == "on",
+	}
+	if flow.Name == "" {
+		dfdFail(c, role, asset, http.StatusBadRequest, "Укажите передаваемые данные")
+		return
+	}
+
+	if err := database.DB.Omit("Source", "Target").Create(&flow).Error; err != nil {
+		dfdFail(c, role, asset, http.StatusInternalServerError, "Ошибка сохранения потока")
+		return
+	}
+	redirectDFD(c, asset)
+}
+
+func DeleteDFDFlow(c *gin.Context) {
+	_, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	asset, ok := loadAssetByParam(c)
+	if !ok {
+		return
+	}
+
+	var flow models.DFDFlow
+	if err := database.DB.Where("id = ? AND asset_id = ?", c.Param("flow_id"), asset.ID).First(&flow).Error; err != nil {
+		c.String(http.StatusNotFound, "Поток не найден")
+		return
+	}
+	if err := database.DB.Delete(&flow).Error; err != nil {
+		c.String(http.StatusInternalServerError, "Ошибка удаления потока")
+		return
+	}
+	redirectDFD(c, asset)
+}
+
+// ---------- обмен в JSON ----------
+
+func ExportAssetDFD(c *gin.Context) {
+	if _, ok := requireRiskEditor(c); !ok {
+		return
+	}
+
+	asset, ok := loadAssetByParam(c)
+	if !ok {
+		return
+	}
+
+	boundaries, elements, flows := loadDFD(asset.ID)
+	data, err := json.MarshalIndent(dfd.Export(asset.Name, boundaries, elements, flows), "", "  ")
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Ошибка формирования файла")
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="dfd-asset-%d.json"`, asset.ID))
+	c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+}
+
+// ImportAssetDFD заменяет диаграмму объекта содержимым файла. Решения по предложенным угрозам
+// сохраняются: они привязаны к наименованиям элементов, а не к их ID.
+func ImportAssetDFD(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	asset, ok := loadAssetByParam(c)
+	if !ok {
+		return
+	}
+
+	fh, err := c.FormFile("file")
+	if err != nil {
+		dfdFail(c, role, asset, http.StatusBadRequest, "Выберите файл диаграммы (.json)")
+		return
+	}
+	f, err := fh.Open()
+	if err != nil {
+		dfdFail(c, role, asset, http.StatusBadRequest, "Не удалось прочитать файл")
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(f, 5<<20))
+	f.Close()
+	if err != nil {
+		dfdFail(c, role, asset, http.StatusBadRequest, "Не удалось прочитать файл")
+		return
+	}
+
+	doc, err := dfd.Parse(data)
+	if err != nil {
+		dfdFail(c, role, asset, http.StatusBadRequest, "Ошибка в файле диаграммы: "+err.Error())
+		return
+	}
+
+	// компоненты сопоставляются по наименованию в составе этого объекта
+	componentIDs := make(map[string]uint)
+	for _, comp := range assetComponents(asset.ID) {
+		componentIDs[comp.Name] = comp.ID
+	}
+	var unknown []string
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		for _, m := range []interface{}{&models.DFDFlow{}, &models.DFDElement{}, &models.DFDBoundary{}} {
+			if err := tx.Unscoped().Where("asset_id = ?", asset.ID).Delete(m).Error; err != nil {
+				return err
+			}
+		}
+
+		boundaryIDs := make(map[string]uint, len(doc.Boundaries))
+		for _, b := range doc.Boundaries {
+			row := models.DFDBoundary{AssetID: asset.ID, Name: b.Name, Description: b.Description}
+			if err := tx.Create(&row).Error; err != nil {
+				return err
+			}
+			boundaryIDs[b.Name] = row.ID
+		}
+
+		elementIDs := make(map[string]uint, len(doc.Elements))
+		for _, el := range doc.Elements {
+			row := models.DFDElement{AssetID: asset.ID, Name: el.Name, Kind: models.DFDElementKind(el.Kind), Description: el.Description}
+			if id, ok := boundaryIDs[el.Boundary]; ok {
+				row.BoundaryID = &id
+			}
+			if el.Component != "" {
+				if id, ok := componentIDs[el.Component]; ok {
+					row.ComponentID = &id
+				} else {
+					unknown = append(unknown, el.Component)
+				}
+			}
+			if err := tx.Omit("Boundary", "Component").Create(&row).Error; err != nil {
+				return err
+			}
+			elementIDs[el.Name] = row.ID
+		}
+
+		for _, f := range doc.Flows {
+			row := models.DFDFlow{
+				AssetID: asset.ID, SourceID: elementIDs[f.From], TargetID: elementIDs[f.To],
+				Name: strings.TrimSpace(f.Name), Protocol: f.Protocol, Encrypted: f.Encrypted, Authenticated: f.Authenticated,
+			}
+			if err := tx.Omit("Source", "Target").Create(&row).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		dfdFail(c, role, asset, http.StatusInternalServerError, "Ошибка сохранения диаграммы")
+		return
+	}
+
+	summary := fmt.Sprintf("диаграмма загружена из %s: границ доверия %d, элементов %d, потоков %d",
+		fh.Filename, len(doc.Boundaries), len(doc.Elements), len(doc.Flows))
+	auditDFD(c, asset, "dfd_import", summary)
+
+	notice := strings.ToUpper(summary[:1]) + summary[1:]
+	if len(unknown) > 0 {
+		notice += ". Не найдены в составе объекта компоненты: " + strings.Join(unknown, ", ")
+	}
+	renderAssetDFD(c, http.StatusOK, role, asset, gin.H{"notice": notice})
+}
+
+// ---------- подбор угроз STRIDE ----------
+
+// AnalyzeAssetDFD применяет правила STRIDE к диаграмме. Новые предложения ждут решения инженера,
+// принятые и отклонённые повторно не предлагаются, неактуальные предложения снимаются.
+func AnalyzeAssetDFD(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	asset, ok := loadAssetByParam(c)
+	if !ok {
+		return
+	}
+
+	_, elements, flows := loadDFD(asset.ID)
+	if len(elements) == 0 {
+		dfdFail(c, role, asset, http.StatusBadRequest, "Диаграмма пуста — добавьте элементы и потоки")
+		return
+	}
+	components := make(map[uint]*uint, len(elements))
+	for _, el := range elements {
+		components[el.ID] = el.ComponentID
+	}
+
+	var threats []models.Threat
+	database.DB.Where("category = ? AND status = ? AND withdrawn = ?", "STRIDE", models.CatalogPublished, false).Find(&threats)
+	byCode := make(map[string]models.Threat, len(threats))
+	for _, th := range threats {
+		byCode[th.Code] = th
+	}
+
+	var existing []models.DFDSuggestion
+	database.DB.Where("asset_id = ?", asset.ID).Find(&existing)
+	byKey := make(map[string]models.DFDSuggestion, len(existing))
+	for _, s := range existing {
+		byKey[s.Key] = s
+	}
+
+	produced := make(map[string]bool)
+	missing := make(map[string]bool)
+	added := 0
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		for _, s := range dfd.Analyze(elements, flows) {
+			th, ok := byCode[dfd.ThreatCode(s.Category)]
+			if !ok {
+				missing[dfd.ThreatCode(s.Category)] = true
+				continue
+			}
+			produced[s.Key] = true
+
+			row, ok := byKey[s.Key]
+			if ok && row.Status != models.SuggestionPending {
+				continue
+			}
+			row.AssetID, row.Key, row.ThreatID = asset.ID, s.Key, th.ID
+			row.Target, row.Reason = s.Target, s.Reason
+			row.ComponentID = components[s.ElementID]
+			row.Status = models.SuggestionPending
+			if !ok {
+				added++
+			}
+			if err := tx.Omit("Threat", "Component", "DecidedBy").Save(&row).Error; err != nil {
+				return err
+			}
+		}
+
+		for _, s := range existing {
+			if s.Status == models.SuggestionPending && !produced[s.Key] {
+				if err := tx.Unscoped().Delete(&s).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		dfdFail(c, role, asset, http.StatusInternalServerError, "Ошибка сохранения предложенных угроз")
+		return
+	}
+
+	notice := fmt.Sprintf("Анализ STRIDE: новых предложений %d", added)
+	if len(missing) > 0 {
+		codes := make([]string, 0, len(missing))
+		for code := range missing {
+			codes = append(codes, code)
+		}
+		sort.Strings(codes)
+		notice += ". В действующем каталоге нет угроз " + strings.Join(codes, ", ") + " — по ним предложения не сформированы"
+	}
+	auditDFD(c, asset, "dfd_analyze", notice)
+	renderAssetDFD(c, http.StatusOK, role, asset, gin.H{"notice": notice})
+}
+
+// DecideDFDSuggestions принимает или отклоняет отмеченные предложения. Принятые становятся
+// угрозами объекта с указанной оценкой риска; уже привязанные угрозы дополняются компонентами.
+func DecideDFDSuggestions(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	asset, ok := loadAssetByParam(c)
+	if !ok {
+		return
+	}
+
+	ids := c.PostFormArray("suggestion_ids")
+	if len(ids) == 0 {
+		dfdFail(c, role, asset, http.StatusBadRequest, "Не выбрано ни одного предложения")
+		return
+	}
+	var suggestions []models.DFDSuggestion
+	database.DB.Preload("Threat").
+		Where("asset_id = ? AND status = ? AND id IN ?", asset.ID, models.SuggestionPending, ids).
+		Order("id asc").Find(&suggestions)
+	if len(suggestions) != len(ids) {
+		dfdFail(c, role, asset, http.StatusBadRequest, "Предложение не найдено или уже рассмотрено")
+		return
+	}
+
+	sess := sessions.Default(c)
+	uid, _ := sess.Get("user_id").(uint)
+	now := time.Now()
+	decide := func(tx *gorm.DB, status models.SuggestionStatus) error {
+		for i := range suggestions {
+			s := &suggestions[i]
+			s.Status = status
+			s.DecidedAt = &now
+			if uid != 0 {
+				s.DecidedByID = &uid
+			}
+			if err := tx.Omit("Threat", "Component", "DecidedBy").Save(s).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	codes := make([]string, 0, len(suggestions))
+	seen := make(map[string]bool)
+	for _, s := range suggestions {
+		if !seen[s.Threat.Code] {
+			seen[s.Threat.Code] = true
+			codes = append(codes, s.Threat.Code)
+		}
+	}
+
+	if c.PostForm("decision") == "reject" {
+		if err := database.DB.Transaction(func(tx *gorm.DB) error { return decide(tx, models.SuggestionRejected) }); err != nil {
+			dfdFail(c, role, asset, http.StatusInternalServerError, "Ошибка сохранения решения")
+			return
+		}
+		auditDFD(c, asset, "dfd_reject", fmt.Sprintf("отклонены предложенные по DFD угрозы %s (%d)", strings.Join(codes, ", "), len(suggestions)))
+		redirectDFD(c, asset)
+		return
+	}
+
+	matrix, err := database.LoadRiskMatrix()
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Матрица рисков не настроена")
+		return
+	}
+	likelihood, ok1 := parseRiskScore(c.PostForm("likelihood"), matrix.LikelihoodScale, false)
+	impact, ok2 := parseRiskScore(c.PostForm("impact"), matrix.ImpactScale, false)
+	if !ok1 || !ok2 {
+		dfdFail(c, role, asset, http.StatusBadRequest, "Укажите вероятность и ущерб для новых угроз объекта")
+		return
+	}
+
+	// по угрозе: компоненты из предложений; предложение без компонента — угроза объекта целиком
+	type scope struct {
+		threat     models.Threat
+		components []uint
+		whole      bool
+		targets    []string
+	}
+	var order []uint
+	scopes := make(map[uint]*scope)
+	for _, s := range suggestions {
+		sc, ok := scopes[s.ThreatID]
+		if !ok {
+			sc = &scope{threat: s.Threat}
+			scopes[s.ThreatID] = sc
+			order = append(order, s.ThreatID)
+		}
+		if s.ComponentID == nil {
+			sc.whole = true
+		} else {
+			sc.components = append(sc.components, *s.ComponentID)
+		}
+		sc.targets = append(sc.targets, s.Target)
+	}
+
+	created := 0
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		for _, threatID := range order {
+			sc := scopes[threatID]
+			var comps []models.AssetComponent
+			if len(sc.components) > 0 {
+				tx.Where("asset_id = ? AND id IN ?", asset.ID, sc.components).Find(&comps)
+			}
+
+			var link models.AssetThreat
+			err := tx.Preload("Components").Where("asset_id = ? AND threat_id = ?", asset.ID, threatID).First(&link).Error
+			switch {
+			case err == nil:
+				// угроза уже в модели: сужаем или расширяем применимость, оценку не трогаем
+				if len(link.Components) == 0 {
+					continue
+				}
+				if sc.whole {
+					if err := tx.Model(&link).Association("Components").Clear(); err != nil {
+						return err
+					}
+				} else if err := tx.Model(&link).Association("Components").Append(comps); err != nil {
+					return err
+				}
+				continue
+			case err != gorm.ErrRecordNotFound:
+				return err
+			}
+
+			version, err := catalog.CurrentThreatVersion(tx, sc.threat)
+			if err != nil {
+				return err
+			}
+			level := matrix.Level(likelihood, impact)
+			link = models.AssetThreat{
+				AssetID:            asset.ID,
+				ThreatID:           threatID,
+				ThreatVersionID:    &version.ID,
+				Likelihood:         likelihood,
+				Impact:             impact,
+				RiskLevel:          level,
+				ResidualLikelihood: likelihood,
+				ResidualImpact:     impact,
+				ResidualLevel:      level,
+				Notes:              "Предложена по DFD: " + strings.Join(sc.targets, "; "),
+			}
+			if !sc.whole {
+				link.Components = comps
+			}
+			if err := tx.Omit("Asset", "Threat", "ThreatVersion", "Measures", "Components.*").Create(&link).Error; err != nil {
+				return err
+			}
+			created++
+		}
+		return decide(tx, models.SuggestionAccepted)
+	})
+	if err != nil {
+		dfdFail(c, role, asset, http.StatusInternalServerError, "Ошибка добавления угроз объекта")
+		return
+	}
+
+	auditDFD(c, asset, "dfd_accept", fmt.Sprintf("приняты предложенные по DFD угрозы %s (предложений %d, новых угроз объекта %d)",
+		strings.Join(codes, ", "), len(suggestions), created))
+	redirectDFD(c, asset)
+}
@@ -0,0 +1,369 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"ib-integrator/internal/database"
+	"ib-integrator/internal/models"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+// ====== ТРЕБОВАНИЯ СТАНДАРТОВ И СОПОСТАВЛЕНИЕ С МЕРАМИ ======
+
+type frameworkRow struct {
+	Framework    models.Framework
+	Requirements int64
+	Mapped       int64 // требований хотя бы с одной мерой
+}
+
+func ListFrameworks(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	var frameworks []models.Framework
+	database.DB.Order("id asc").Find(&frameworks)
+
+	rows := make([]frameworkRow, 0, len(frameworks))
+	for _, f := range frameworks {
+		row := frameworkRow{Framework: f}
+		database.DB.Model(&models.FrameworkRequirement{}).Where("framework_id = ?", f.ID).Count(&row.Requirements)
+		database.DB.Model(&models.FrameworkRequirement{}).
+			Where("framework_id = ? AND id IN (SELECT requirement_id FROM requirement_measures)", f.ID).
+			Count(&row.Mapped)
+		rows = append(rows, row)
+	}
+
+	render(c, http.StatusOK, "frameworks.html", gin.H{
+		"role":       string(role),
+		"frameworks": rows,
+	})
+}
+
+func loadFrameworkByParam(c *gin.Context) (models.Framework, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.String(http.StatusBadRequest, "Некорректный ID стандарта")
+		return models.Framework{}, false
+	}
+
+	var f models.Framework
+	if err := database.DB.First(&f, id).Error; err != nil {
+		c.String(http.StatusNotFound, "Стандарт не найден")
+		return models.Framework{}, false
+	}
+	return f, true
+}
+
+// requirementMeasures — сопоставленные меры по требованиям стандарта
+func requirementMeasures(frameworkID uint) map[uint][]models.RequirementMeasure {
+	var links []models.RequirementMeasure
+	database.DB.Preload("Measure").
+		Joins("JOIN framework_requirements ON framework_requirements.id = requirement_measures.requirement_id").
+		Where("framework_requirements.framework_id = ?", frameworkID).
+		Order("requirement_measures.id asc").
+		Find(&links)
+
+	byReq := make(map[uint][]models.RequirementMeasure)
+	for _, l := range links {
+		byReq[l.RequirementID] = append(byReq[l.RequirementID], l)
+	}
+	return byReq
+}
+
+type requirementRow struct {
+	Requirement models.FrameworkRequirement
+	Links       []models.RequirementMeasure
+}
+
+func renderFramework(c *gin.Context, status int, role models.UserRole, f models.Framework, msg string) {
+	var reqs []models.FrameworkRequirement
+	database.DB.Where("framework_id = ?", f.ID).Order("id asc").Find(&reqs)
+	byReq := requirementMeasures(f.ID)
+
+	rows := make([]requirementRow, 0, len(reqs))
+	for _, r := range reqs {
+		rows = append(rows, requirementRow{Requirement: r, Links: byReq[r.ID]})
+	}
+
+	// поиск меры каталога для сопоставления с выбранным требованием
+	var selected models.FrameworkRequirement
+	if id, err := strconv.ParseUint(c.Query("req"), 10, 64); err == nil {
+		database.DB.Where("id = ? AND framework_id = ?", id, f.ID).First(&selected)
+	}
+	q := strings.TrimSpace(c.Query("q"))
+	var found []models.ControlMeasure
+	if selected.ID != 0 && q != "" {
+		like := "%" + strings.ToLower(q) + "%"
+		database.DB.
+			Where("LOWER(code) LIKE ? OR LOWER(name) LIKE ?", like, like).
+			Order("regulation asc, id asc").
+			Limit(50).
+			Find(&found)
+	}
+
+	render(c, status, "framework_detail.html", gin.H{
+		"role":      string(role),
+		"framework": f,
+		"rows":      rows,
+		"selected":  selected,
+		"q":         q,
+		"found":     found,
+		"error":     msg,
+	})
+}
+
+func ShowFramework(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	f, ok := loadFrameworkByParam(c)
+	if !ok {
+		return
+	}
+
+	renderFramework(c, http.StatusOK, role, f, "")
+}
+
+func loadFrameworkRequirement(c *gin.Context, f models.Framework) (models.FrameworkRequirement, bool) {
+	var r models.FrameworkRequirement
+	if err := database.DB.Where("id = ? AND framework_id = ?", c.Param("req_id"), f.ID).First(&r).Error; err != nil {
+		c.String(http.StatusNotFound, "Требование не найдено")
+		return models.FrameworkRequirement{}, false
+	}
+	return r, true
+}
+
+func AddRequirementMeasure(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	f, ok := loadFrameworkByParam(c)
+	if !ok {
+		return
+	}
+	req, ok := loadFrameworkRequirement(c, f)
+	if !ok {
+		return
+	}
+
+	measureID, err := strconv.ParseUint(strings.TrimSpace(c.PostForm("measure_id")), 10, 64)
+	if err != nil || measureID == 0 {
+		renderFramework(c, http.StatusBadRequest, role, f, "Выберите меру защиты")
+		return
+	}
+	var measure models.ControlMeasure
+	if err := database.DB.First(&measure, measureID).Error; err != nil {
+		renderFramework(c, http.StatusBadRequest, role, f, "Мера защиты не найдена")
+		return
+	}
+
+	var count int64
+	database.DB.Model(&models.RequirementMeasure{}).
+		Where("requirement_id = ? AND measure_id = ?", req.ID, measure.ID).
+		Count(&count)
+	if count > 0 {
+		renderFramework(c, http.StatusBadRequest, role, f, "Мера "+measure.Code+" уже сопоставлена с требованием "+req.Code)
+		return
+	}
+
+	if err := database.DB.Create(&models.RequirementMeasure{RequirementID: req.ID, MeasureID: measure.ID}).Error; err != nil {
+		renderFramework(c, http.StatusInternalServerError, role, f, "Ошибка сохранения сопоставления")
+		return
+	}
+
+	sess := sessions.Default(c)
+	if uid, ok := sess.Get("user_id").(uint); ok {
+		database.CreateAuditLog(uid, "framework", f.ID, "requirement_map",
+			fmt.Sprintf("%s %s сопоставлено с мерой %s", f.Name, req.Code, measure.Code))
+	}
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/frameworks/%d#req-%d", f.ID, req.ID))
+}
+
+func DeleteRequirementMeasure(c *gin.Context) {
+	if _, ok := requireRiskEditor(c); !ok {
+		return
+	}
+
+	f, ok := loadFrameworkByParam(c)
+	if !ok {
+		return
+	}
+	req, ok := loadFrameworkRequirement(c, f)
+	if !ok {
+		return
+	}
+
+	var link models.RequirementMeasure
+	if err := database.DB.Preload("Measure").
+		Where("requirement_id = ? AND measure_id = ?", req.ID, c.Param("measure_id")).
+		First(&link).Error; err != nil {
+		c.String(http.StatusNotFound, "Сопоставление не найдено")
+		return
+	}
+
+	if err := database.DB.Delete(&link).Error; err != nil {
+		c.String(http.StatusInternalServerError, "Ошибка удаления сопоставления")
+		return
+	}
+
+	sess := sessions.Default(c)
+	if uid, ok := sess.Get("user_id").(uint); ok {
+		database.CreateAuditLog(uid, "framework", f.ID, "requirement_unmap",
+			fmt.Sprintf("%s %s: снято сопоставление с мерой %s", f.Name, req.Code, link.Measure.Code))
+	}
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/frameworks/%d#req-%d", f.ID, req.ID))
+}
+
+// --- соответствие клиента ---
+
+type complianceRow struct {
+	Requirement models.FrameworkRequirement
+	Measures    []models.ControlMeasure // сопоставленные меры
+	Implemented []models.ControlMeasure // из них внедрены у клиента
+}
+
+func (r complianceRow) Mapped() bool    { return len(r.Measures) > 0 }
+func (r complianceRow) Satisfied() bool { return len(r.Implemented) > 0 }
+
+type complianceSummary struct {
+	Framework models.Framework
+	Level     int // 0 — стандарт без уровней
+	Total     int
+	Satisfied int
+}
+
+func (s complianceSummary) LevelLabel() string {
+	return s.Framework.LevelLabel(s.Level)
+}
+
+func (s complianceSummary) Percent() int {
+	if s.Total == 0 {
+		return 0
+	}
+	return s.Satisfied * 100 / s.Total
+}
+
+// clientImplementedMeasures — меры, внедрённые хотя бы на одном объекте клиента
+func clientImplementedMeasures(clientID uint) map[uint]bool {
+	var implemented []uint
+	database.DB.Model(&models.AssetMeasure{}).
+		Joins("JOIN assets ON assets.id = asset_measures.asset_id AND assets.deleted_at IS NULL").
+		Where("assets.client_id = ? AND asset_measures.status = ?", clientID, models.MeasureImplemented).
+		Pluck("asset_measures.measure_id", &implemented)
+	is := make(map[uint]bool, len(implemented))
+	for _, id := range implemented {
+		is[id] = true
+	}
+	return is
+}
+
+// clientCompliance: требование выполнено, если хотя бы одна сопоставленная с ним мера
+// внедрена на каком-либо объекте клиента. Требования без сопоставленных мер — не выполнены.
+func clientCompliance(f models.Framework, implemented map[uint]bool) []complianceRow {
+	var reqs []models.FrameworkRequirement
+	database.DB.Where("framework_id = ?", f.ID).Order("id asc").Find(&reqs)
+	byReq := requirementMeasures(f.ID)
+
+	rows := make([]complianceRow, 0, len(reqs))
+	for _, r := range reqs {
+		row := complianceRow{Requirement: r}
+		for _, l := range byReq[r.ID] {
+			row.Measures = append(row.Measures, l.Measure)
+			if implemented[l.MeasureID] {
+				row.Implemented = append(row.Implemented, l.Measure)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func summarizeCompliance(f models.Framework, level int, rows []complianceRow) complianceSummary {
+	s := complianceSummary{Framework: f, Level: level}
+	for _, r := range rows {
+		if !r.Requirement.AppliesAt(level) {
+			continue
+		}
+		s.Total++
+		if r.Satisfied() {
+			s.Satisfied++
+		}
+	}
+	return s
+}
+
+func ShowClientCompliance(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	client, ok := loadClientByParam(c)
+	if !ok {
+		return
+	}
+
+	var frameworks []models.Framework
+	database.DB.Order("id asc").Find(&frameworks)
+	implemented := clientImplementedMeasures(client.ID)
+
+	// сводка: по стандартам с уровнями — отдельная строка на каждый уровень
+	var summaries []complianceSummary
+	rowsByFramework := make(map[uint][]complianceRow, len(frameworks))
+	for _, f := range frameworks {
+		rows := clientCompliance(f, implemented)
+		rowsByFramework[f.ID] = rows
+		levels := f.LevelList()
+		if len(levels) == 0 {
+			summaries = append(summaries, summarizeCompliance(f, 0, rows))
+			continue
+		}
+		for _, l := range levels {
+			summaries = append(summaries, summarizeCompliance(f, l.Number, rows))
+		}
+	}
+
+	data := gin.H{
+		"role":       string(role),
+		"client":     client,
+		"frameworks": frameworks,
+		"summaries":  summaries,
+	}
+
+	if id, err := strconv.ParseUint(c.Query("framework"), 10, 64); err == nil {
+		for _, f := range frameworks {
+			if uint64(f.ID) != id {
+				continue
+			}
+			level, _ := strconv.Atoi(c.Query("level"))
+			if !f.ValidLevel(level) {
+				level = 0
+			}
+			var shown []complianceRow
+			for _, r := range rowsByFramework[f.ID] {
+				if r.Requirement.AppliesAt(level) {
+					shown = append(shown, r)
+				}
+			}
+			data["selected"] = f
+			data["level"] = level
+			data["rows"] = shown
+			data["summary"] = summarizeCompliance(f, level, rowsByFramework[f.ID])
+		}
+	}
+
+	render(c, http.StatusOK, "client_compliance.html", data)
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"ib-integrator/internal/database"
+	"ib-integrator/internal/models"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ====== КЛАСС ЗАЩИЩЁННОСТИ ГИС (приказ ФСТЭК №17) ======
+
+// loadGISAsset — объект защиты типа ГИС и его классификация (пустая, если ещё не проводилась)
+func loadGISAsset(c *gin.Context) (models.Asset, models.GISClassification, bool) {
+	asset, ok := loadAssetByParam(c)
+	if !ok {
+		return asset, models.GISClassification{}, false
+	}
+	if asset.AssetType != models.AssetGIS {
+		c.String(http.StatusBadRequest, "Классификация по приказу №17 проводится только для объектов типа ГИС")
+		return asset, models.GISClassification{}, false
+	}
+
+	g, err := findGISClassification(asset.ID)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Ошибка загрузки классификации ГИС")
+		return asset, g, false
+	}
+	return asset, g, true
+}
+
+func findGISClassification(assetID uint) (models.GISClassification, error) {
+	var g models.GISClassification
+	err := database.DB.Where("asset_id = ?", assetID).First(&g).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+	g.AssetID = assetID
+	return g, err
+}
+
+func renderGIS(c *gin.Context, status int, role models.UserRole, asset models.Asset, g models.GISClassification, msg string) {
+	render(c, status, "asset_gis.html", gin.H{
+		"role":           string(role),
+		"asset":          asset,
+		"classification": g,
+		"damageLevels":   models.DamageLevels,
+		"scales":         models.GISScales,
+		"error":          msg,
+	})
+}
+
+func ShowGISClassification(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	asset, g, ok := loadGISAsset(c)
+	if !ok {
+		return
+	}
+
+	renderGIS(c, http.StatusOK, role, asset, g, "")
+}
+
+// SaveGISClassification сохраняет исходные данные акта и пересчитывает класс.
+// Пересчёт сбрасывает ручное изменение класса в карточке объекта.
+func SaveGISClassification(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	asset, g, ok := loadGISAsset(c)
+	if !ok {
+		return
+	}
+	prev := g
+
+	g.ConfidentialityDamage = c.PostForm("confidentiality_damage")
+	g.IntegrityDamage = c.PostForm("integrity_damage")
+	g.AvailabilityDamage = c.PostForm("availability_damage")
+	g.Scale = c.PostForm("scale")
+	g.ActNumber = strings.TrimSpace(c.PostForm("act_number"))
+	g.InformationTypes = strings.TrimSpace(c.PostForm("information_types"))
+	g.Commission = strings.TrimSpace(c.PostForm("commission"))
+
+	actDate, err := parseFormDate(c.PostForm("act_date"))
+	if err != nil {
+		renderGIS(c, http.StatusBadRequest, role, asset, g, "Некорректная дата акта")
+		return
+	}
+	g.ActDate = actDate
+
+	if err := g.Validate(); err != nil {
+		renderGIS(c, http.StatusBadRequest, role, asset, g, "Анкета заполнена не полностью: "+err.Error())
+		return
+	}
+
+	significance, class, trail := g.Calculate()
+	g.Significance = significance
+	g.Class = class
+	g.Trail = strings.Join(trail, "\n")
+
+	category := models.GISClassLabel(class)
+	recalculated := g.ID == 0 || !sameGISInputs(prev, g) || asset.Category != category
+	if recalculated {
+		g.OverrideJustification = ""
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&g).Error; err != nil {
+			return err
+		}
+		return tx.Model(&asset).Update("category", category).Error
+	})
+	if err != nil {
+		renderGIS(c, http.StatusInternalServerError, role, asset, g, "Ошибка сохранения классификации ГИС")
+		return
+	}
+
+	sess := sessions.Default(c)
+	if uid, ok := sess.Get("user_id").(uint); ok {
+		if recalculated {
+			oldCategory := asset.Category
+			if oldCategory == "" {
+				oldCategory = "не определён"
+			}
+			database.CreateAuditLog(uid, "asset", asset.ID, "gis_class",
+				fmt.Sprintf("Объект %s: класс защищённости ГИС %s → %s. %s",
+					asset.Name, oldCategory, category, strings.Join(trail, "; ")))
+		} else {
+			database.CreateAuditLog(uid, "asset", asset.ID, "update",
+				"Обновлены реквизиты акта классификации ГИС: "+asset.Name)
+		}
+	}
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/assets/%d/gis", asset.ID))
+}
+
+func sameGISInputs(a, b models.GISClassification) bool {
+	return a.ConfidentialityDamage == b.ConfidentialityDamage &&
+		a.IntegrityDamage == b.IntegrityDamage &&
+		a.AvailabilityDamage == b.AvailabilityDamage &&
+		a.Scale == b.Scale
+}
+
+// ExportGISAct — акт классификации для печати; с ?download=1 отдаётся файлом
+func ExportGISAct(c *gin.Context) {
+	if _, ok := requireRiskEditor(c); !ok {
+		return
+	}
+
+	asset, g, ok := loadGISAsset(c)
+	if !ok {
+		return
+	}
+	if g.Class == 0 {
+		c.String(http.StatusBadRequest, "Класс ГИС ещё не определён — заполните анкету")
+		return
+	}
+
+	if c.Query("download") != "" {
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="gis-act-%d.html"`, asset.ID))
+	}
+	c.HTML(http.StatusOK, "gis_act.html", gin.H{
+		"asset":          asset,
+		"classification": g,
+	})
+}
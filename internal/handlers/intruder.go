@@ -0,0 +1,383 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"ib-integrator/internal/database"
+	"ib-integrator/internal/models"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+// ====== НАРУШИТЕЛИ (МЕТОДИКА ФСТЭК 2021) ======
+
+// threatRelevance — может ли угрозу реализовать нарушитель из профиля объекта
+type threatRelevance struct {
+	Known        bool // профиль нарушителей объекта заполнен
+	Possible     bool
+	Requirements []models.IntruderRequirement
+	Intruders    []string // нарушители профиля, способные реализовать угрозу
+}
+
+// assetIntruders — профиль нарушителей объекта
+func assetIntruders(assetID uint) []models.AssetIntruder {
+	var profile []models.AssetIntruder
+	database.DB.Preload("Intruder").
+		Where("asset_id = ?", assetID).
+		Order("level desc, id asc").
+		Find(&profile)
+	return profile
+}
+
+// assetThreatRelevance сопоставляет источник каждой угрозы с профилем нарушителей.
+// Ключ — ID связи AssetThreat. Пока профиль пуст, угрозы не отсекаются.
+func assetThreatRelevance(profile []models.AssetIntruder, links []models.AssetThreat) map[uint]threatRelevance {
+	result := make(map[uint]threatRelevance, len(links))
+	for _, l := range links {
+		r := threatRelevance{
+			Known:        len(profile) > 0,
+			Possible:     len(profile) == 0,
+			Requirements: l.Threat.IntruderRequirements(),
+		}
+		for _, ai := range profile {
+			if ai.CanRealise(r.Requirements) {
+				r.Possible = true
+				r.Intruders = append(r.Intruders, ai.Intruder.Name)
+			}
+		}
+		result[l.ID] = r
+	}
+	return result
+}
+
+// --- каталог нарушителей ---
+
+func renderIntruders(c *gin.Context, status int, role models.UserRole, form models.Intruder, msg string) {
+	var intruders []models.Intruder
+	database.DB.Order("id asc").Find(&intruders)
+
+	render(c, status, "intruders_list.html", gin.H{
+		"role":      string(role),
+		"intruders": intruders,
+		"form":      form,
+		"kinds":     models.IntruderKinds,
+		"levels":    models.IntruderLevels,
+		"error":     msg,
+	})
+}
+
+func ListIntruders(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	renderIntruders(c, http.StatusOK, role, models.Intruder{Kind: models.IntruderExternal, Level: 1}, "")
+}
+
+func bindIntruderForm(c *gin.Context, in *models.Intruder) string {
+	in.Code = strings.TrimSpace(c.PostForm("code"))
+	in.Name = strings.TrimSpace(c.PostForm("name"))
+	in.Kind = c.PostForm("kind")
+	in.Level, _ = strconv.Atoi(c.PostForm("level"))
+	in.Motivation = strings.TrimSpace(c.PostForm("motivation"))
+
+	if in.Code == "" {
+		return "Укажите код нарушителя"
+	}
+	if err := in.Validate(); err != nil {
+		return "Некорректные данные: " + err.Error()
+	}
+
+	var count int64
+	database.DB.Model(&models.Intruder{}).Where("code = ? AND id <> ?", in.Code, in.ID).Count(&count)
+	if count > 0 {
+		return "Нарушитель с таким кодом уже есть в каталоге"
+	}
+	return ""
+}
+
+func CreateIntruder(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	var in models.Intruder
+	if msg := bindIntruderForm(c, &in); msg != "" {
+		renderIntruders(c, http.StatusBadRequest, role, in, msg)
+		return
+	}
+
+	if err := database.DB.Create(&in).Error; err != nil {
+		renderIntruders(c, http.StatusInternalServerError, role, in, "Ошибка сохранения нарушителя")
+		return
+	}
+
+	sess := sessions.Default(c)
+	if uid, ok := sess.Get("user_id").(uint); ok {
+		database.CreateAuditLog(uid, "intruder", in.ID, "create", "Добавлен нарушитель: "+in.Code+" "+in.Name)
+	}
+
+	c.Redirect(http.StatusFound, "/intruders")
+}
+
+func loadIntruder(c *gin.Context) (models.Intruder, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.String(http.StatusBadRequest, "Некорректный ID нарушителя")
+		return models.Intruder{}, false
+	}
+
+	var in models.Intruder
+	if err := database.DB.First(&in, id).Error; err != nil {
+		c.String(http.StatusNotFound, "Нарушитель не найден")
+		return models.Intruder{}, false
+	}
+	return in, true
+}
+
+func renderIntruderEdit(c *gin.Context, status int, role models.UserRole, in models.Intruder, msg string) {
+	render(c, status, "intruder_edit.html", gin.H{
+		"role":     string(role),
+		"intruder": in,
+		"kinds":    models.IntruderKinds,
+		"levels":   models.IntruderLevels,
+		"error":    msg,
+	})
+}
+
+func ShowEditIntruder(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	in, ok := loadIntruder(c)
+	if !ok {
+		return
+	}
+
+	renderIntruderEdit(c, http.StatusOK, role, in, "")
+}
+
+func UpdateIntruder(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	in, ok := loadIntruder(c)
+	if !ok {
+		return
+	}
+
+	if msg := bindIntruderForm(c, &in); msg != "" {
+		renderIntruderEdit(c, http.StatusBadRequest, role, in, msg)
+		return
+	}
+
+	if err := database.DB.Save(&in).Error; err != nil {
+		renderIntruderEdit(c, http.StatusInternalServerError, role, in, "Ошибка сохранения нарушителя")
+		return
+	}
+
+	sess := sessions.Default(c)
+	if uid, ok := sess.Get("user_id").(uint); ok {
+		database.CreateAuditLog(uid, "intruder", in.ID, "update", "Изменён нарушитель: "+in.Code+" "+in.Name)
+	}
+
+	c.Redirect(http.StatusFound, "/intruders")
+}
+
+// --- профиль нарушителей объекта ---
+
+func renderAssetIntruders(c *gin.Context, status int, role models.UserRole, asset models.Asset, msg string) {
+	profile := assetIntruders(asset.ID)
+
+	var intruders []models.Intruder
+	database.DB.Order("id asc").Find(&intruders)
+
+	render(c, status, "asset_intruders.html", gin.H{
+		"role":      string(role),
+		"asset":     asset,
+		"profile":   profile,
+		"intruders": intruders,
+		"levels":    models.IntruderLevels,
+		"error":     msg,
+	})
+}
+
+func ShowAssetIntruders(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	asset, ok := loadAssetByParam(c)
+	if !ok {
+		return
+	}
+
+	renderAssetIntruders(c, http.StatusOK, role, asset, "")
+}
+
+// SaveAssetIntruder добавляет нарушителя в профиль объекта или обновляет его уровень и обоснование
+func SaveAssetIntruder(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	asset, ok := loadAssetByParam(c)
+	if !ok {
+		return
+	}
+
+	iid, err := strconv.Atoi(c.PostForm("intruder_id"))
+	if err != nil || iid <= 0 {
+		renderAssetIntruders(c, http.StatusBadRequest, role, asset, "Выберите нарушителя")
+		return
+	}
+	var intruder models.Intruder
+	if err := database.DB.First(&intruder, iid).Error; err != nil {
+		renderAssetIntruders(c, http.StatusBadRequest, role, asset, "Нарушитель не найден")
+		return
+	}
+
+	level := intruder.Level
+	if v := c.PostForm("level"); v != "" {
+		level, err = strconv.Atoi(v)
+		if err != nil || level < 1 || level > 4 {
+			renderAssetIntruders(c, http.StatusBadRequest, role, asset, "Некорректный уровень возможностей")
+			return
+		}
+	}
+
+	justification := strings.TrimSpace(c.PostForm("justification"))
+	if justification == "" {
+		renderAssetIntruders(c, http.StatusBadRequest, role, asset, "Укажите обоснование актуальности нарушителя")
+		return
+	}
+
+	var entry models.AssetIntruder
+	database.DB.Where("asset_id = ? AND intruder_id = ?", asset.ID, intruder.ID).First(&entry)
+	action := "create"
+	if entry.ID != 0 {
+		action = "update"
+	}
+	entry.AssetID = asset.ID
+	entry.IntruderID = intruder.ID
+	entry.Level = level
+	entry.Motivation = strings.TrimSpace(c.PostForm("motivation"))
+	entry.Justification = justification
+
+	if err := database.DB.Omit("Intruder").Save(&entry).Error; err != nil {
+		renderAssetIntruders(c, http.StatusInternalServerError, role, asset, "Ошибка сохранения профиля нарушителей")
+		return
+	}
+
+	sess := sessions.Default(c)
+	if uid, ok := sess.Get("user_id").(uint); ok {
+		database.CreateAuditLog(uid, "asset", asset.ID, "intruder_"+action,
+			fmt.Sprintf("Объект %s: нарушитель %s (Н%d) — %s", asset.Name, intruder.Name, level, justification))
+	}
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/assets/%d/intruders", asset.ID))
+}
+
+func DeleteAssetIntruder(c *gin.Context) {
+	if _, ok := requireRiskEditor(c); !ok {
+		return
+	}
+
+	asset, ok := loadAssetByParam(c)
+	if !ok {
+		return
+	}
+
+	var entry models.AssetIntruder
+	if err := database.DB.Preload("Intruder").
+		Where("id = ? AND asset_id = ?", c.Param("entry_id"), asset.ID).
+		First(&entry).Error; err != nil {
+		c.String(http.StatusNotFound, "Нарушитель в профиле объекта не найден")
+		return
+	}
+
+	if err := database.DB.Delete(&entry).Error; err != nil {
+		c.String(http.StatusInternalServerError, "Ошибка удаления нарушителя из профиля")
+		return
+	}
+
+	sess := sessions.Default(c)
+	if uid, ok := sess.Get("user_id").(uint); ok {
+		database.CreateAuditLog(uid, "asset", asset.ID, "intruder_delete",
+			fmt.Sprintf("Объект %s: нарушитель %s исключён из профиля", asset.Name, entry.Intruder.Name))
+	}
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/assets/%d/intruders", asset.ID))
+}
+
+// --- исключение неактуальных угроз ---
+
+// ExcludeAssetThreat признаёт угрозу неактуальной для объекта с обязательным обоснованием
+func ExcludeAssetThreat(c *gin.Context) {
+	if _, ok := requireRiskEditor(c); !ok {
+		return
+	}
+
+	link, ok := loadAssetThreatLink(c)
+	if !ok {
+		return
+	}
+
+	reason := strings.TrimSpace(c.PostForm("reason"))
+	if reason == "" {
+		c.String(http.StatusBadRequest, "Укажите причину исключения угрозы")
+		return
+	}
+
+	if err := database.DB.Model(&models.AssetThreat{}).Where("id = ?", link.ID).
+		Updates(map[string]interface{}{"excluded": true, "exclusion_reason": reason}).Error; err != nil {
+		c.String(http.StatusInternalServerError, "Ошибка сохранения")
+		return
+	}
+
+	sess := sessions.Default(c)
+	if uid, ok := sess.Get("user_id").(uint); ok {
+		database.CreateAuditLog(uid, "asset", link.AssetID, "threat_excluded",
+			fmt.Sprintf("Объект %s: угроза %s признана неактуальной — %s", link.Asset.Name, link.Threat.Code, reason))
+	}
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/assets/%d/threats", link.AssetID))
+}
+
+// IncludeAssetThreat возвращает исключённую угрозу в перечень актуальных
+func IncludeAssetThreat(c *gin.Context) {
+	if _, ok := requireRiskEditor(c); !ok {
+		return
+	}
+
+	link, ok := loadAssetThreatLink(c)
+	if !ok {
+		return
+	}
+
+	if err := database.DB.Model(&models.AssetThreat{}).Where("id = ?", link.ID).
+		Updates(map[string]interface{}{"excluded": false, "exclusion_reason": ""}).Error; err != nil {
+		c.String(http.StatusInternalServerError, "Ошибка сохранения")
+		return
+	}
+
+	sess := sessions.Default(c)
+	if uid, ok := sess.Get("user_id").(uint); ok {
+		database.CreateAuditLog(uid, "asset", link.AssetID, "threat_included",
+			fmt.Sprintf("Объект %s: угроза %s возвращена в перечень актуальных", link.Asset.Name, link.Threat.Code))
+	}
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/assets/%d/threats", link.AssetID))
+}
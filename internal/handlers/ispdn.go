@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"ib-integrator/internal/database"
+	"ib-integrator/internal/models"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ====== УРОВЕНЬ ЗАЩИЩЁННОСТИ ИСПДн (ПП РФ №1119) ======
+
+// loadISPDnAsset — объект защиты типа ИСПДн и его анкета (пустая, если ещё не заполнялась)
+func loadISPDnAsset(c *gin.Context) (models.Asset, models.ISPDnAssessment, bool) {
+	asset, ok := loadAssetByParam(c)
+	if !ok {
+		return asset, models.ISPDnAssessment{}, false
+	}
+	if asset.AssetType != models.AssetISPD {
+		c.String(http.StatusBadRequest, "Анкета ПП №1119 заполняется только для объектов типа ИСПДн")
+		return asset, models.ISPDnAssessment{}, false
+	}
+
+	var a models.ISPDnAssessment
+	err := database.DB.Where("asset_id = ?", asset.ID).First(&a).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.String(http.StatusInternalServerError, "Ошибка загрузки анкеты ИСПДн")
+		return asset, a, false
+	}
+	a.AssetID = asset.ID
+	return asset, a, true
+}
+
+func renderISPDn(c *gin.Context, status int, role models.UserRole, asset models.Asset, a models.ISPDnAssessment, msg string) {
+	selected := make(map[string]bool)
+	for _, cat := range a.Categories() {
+		selected[cat] = true
+	}
+
+	subjects := "under100k"
+	if a.EmployeesOnly {
+		subjects = "employees"
+	} else if a.Over100k {
+		subjects = "over100k"
+	}
+
+	render(c, status, "asset_ispdn.html", gin.H{
+		"role":       string(role),
+		"asset":      asset,
+		"assessment": a,
+		"categories": models.PDCategories,
+		"selected":   selected,
+		"subjects":   subjects,
+		"error":      msg,
+	})
+}
+
+func ShowISPDnAssessment(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	asset, a, ok := loadISPDnAsset(c)
+	if !ok {
+		return
+	}
+
+	renderISPDn(c, http.StatusOK, role, asset, a, "")
+}
+
+// SaveISPDnAssessment сохраняет анкету и пересчитывает УЗ.
+// Если исходные данные не изменились, ничего не пишется.
+func SaveISPDnAssessment(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	asset, a, ok := loadISPDnAsset(c)
+	if !ok {
+		return
+	}
+	prev := a
+
+	cats := make(map[string]bool)
+	for _, cat := range c.PostFormArray("categories") {
+		cats[cat] = true
+	}
+	a.Special = cats[models.PDSpecial]
+	a.Biometric = cats[models.PDBiometric]
+	a.Public = cats[models.PDPublic]
+	a.Other = cats[models.PDOther]
+
+	switch c.PostForm("subjects") {
+	case "employees":
+		a.EmployeesOnly, a.Over100k = true, false
+	case "under100k":
+		a.EmployeesOnly, a.Over100k = false, false
+	case "over100k":
+		a.EmployeesOnly, a.Over100k = false, true
+	default:
+		renderISPDn(c, http.StatusBadRequest, role, asset, a, "Укажите состав и количество субъектов ПДн")
+		return
+	}
+
+	a.ThreatType, _ = strconv.Atoi(c.PostForm("threat_type"))
+	if err := a.Validate(); err != nil {
+		renderISPDn(c, http.StatusBadRequest, role, asset, a, "Анкета заполнена не полностью: "+err.Error())
+		return
+	}
+
+	level, trail := a.Calculate()
+	a.Level = level
+	a.Trail = strings.Join(trail, "\n")
+
+	category := models.ISPDnLevelLabel(level)
+	if a.ID != 0 && sameISPDnInputs(prev, a) && asset.Category == category {
+		c.Redirect(http.StatusFound, fmt.Sprintf("/assets/%d/ispdn", asset.ID))
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&a).Error; err != nil {
+			return err
+		}
+		return tx.Model(&asset).Update("category", category).Error
+	})
+	if err != nil {
+		renderISPDn(c, http.StatusInternalServerError, role, asset, a, "Ошибка сохранения анкеты ИСПДн")
+		return
+	}
+
+	sess := sessions.Default(c)
+	if uid, ok := sess.Get("user_id").(uint); ok {
+		oldCategory := "не определён"
+		if prev.Level > 0 {
+			oldCategory = models.ISPDnLevelLabel(prev.Level)
+		}
+		database.CreateAuditLog(uid, "asset", asset.ID, "ispdn_level",
+			fmt.Sprintf("Объект %s: уровень защищённости ПДн %s → %s. %s",
+				asset.Name, oldCategory, category, strings.Join(trail, "; ")))
+	}
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/assets/%d/ispdn", asset.ID))
+}
+
+func sameISPDnInputs(a, b models.ISPDnAssessment) bool {
+	return a.Special == b.Special && a.Biometric == b.Biometric &&
+		a.Public == b.Public && a.Other == b.Other &&
+		a.EmployeesOnly == b.EmployeesOnly && a.Over100k == b.Over100k &&
+		a.ThreatType == b.ThreatType
+}
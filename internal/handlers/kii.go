@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"ib-integrator/internal/database"
+	"ib-integrator/internal/models"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ====== КАТЕГОРИРОВАНИЕ ОБЪЕКТОВ КИИ (187-ФЗ, ПП РФ №127) ======
+
+type kiiIndicatorRow struct {
+	Indicator     models.KIIIndicator
+	Value         string
+	Justification string
+	Category      int
+	Filled        bool
+}
+
+type kiiIndicatorGroup struct {
+	Label string
+	Rows  []kiiIndicatorRow
+}
+
+// findKIIObject — запись реестра КИИ по объекту защиты (пустая, если категорирование не проводилось)
+func findKIIObject(assetID uint) (models.KIIObject, error) {
+	var o models.KIIObject
+	err := database.DB.Preload("Indicators").Where("asset_id = ?", assetID).First(&o).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+	o.AssetID = assetID
+	return o, err
+}
+
+func loadKIIAsset(c *gin.Context) (models.Asset, models.KIIObject, bool) {
+	asset, ok := loadAssetByParam(c)
+	if !ok {
+		return asset, models.KIIObject{}, false
+	}
+	if !asset.AssetType.IsKIICandidate() {
+		c.String(http.StatusBadRequest, "Категорирование КИИ проводится для объектов типа АСУ ТП и корпоративная сеть")
+		return asset, models.KIIObject{}, false
+	}
+
+	o, err := findKIIObject(asset.ID)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Ошибка загрузки реестра КИИ")
+		return asset, o, false
+	}
+	o.ClientID = asset.ClientID
+	return asset, o, true
+}
+
+// kiiIndicatorGroups раскладывает показатели ПП №127 по группам со значениями объекта
+func kiiIndicatorGroups(o models.KIIObject) []kiiIndicatorGroup {
+	values := make(map[string]models.KIIIndicatorValue, len(o.Indicators))
+	for _, v := range o.Indicators {
+		values[v.Code] = v
+	}
+
+	var groups []kiiIndicatorGroup
+	for _, g := range models.KIIGroups {
+		group := kiiIndicatorGroup{Label: models.KIIGroupLabel(g)}
+		for _, ind := range models.KIIIndicators {
+			if ind.Group != g {
+				continue
+			}
+			row := kiiIndicatorRow{Indicator: ind}
+			if v, ok := values[ind.Code]; ok {
+				row.Filled = true
+				row.Value = strconv.FormatFloat(v.Value, 'f', -1, 64)
+				row.Justification = v.Justification
+				row.Category = ind.Category(v.Value)
+			}
+			group.Rows = append(group.Rows, row)
+		}
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+func renderKII(c *gin.Context, status int, role models.UserRole, asset models.Asset, o models.KIIObject, msg string) {
+	render(c, status, "asset_kii.html", gin.H{
+		"role":   string(role),
+		"asset":  asset,
+		"object": o,
+		"groups": kiiIndicatorGroups(o),
+		"error":  msg,
+	})
+}
+
+func ShowKIIObject(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	asset, o, ok := loadKIIAsset(c)
+	if !ok {
+		return
+	}
+
+	renderKII(c, http.StatusOK, role, asset, o, "")
+}
+
+// SaveKIIObject сохраняет критические процессы, значения показателей и решение комиссии,
+// пересчитывает категорию значимости
+func SaveKIIObject(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	asset, o, ok := loadKIIAsset(c)
+	if !ok {
+		return
+	}
+	prevCategory, prevTrail, known := o.Category, o.Trail, o.ID != 0
+
+	o.CriticalProcesses = strings.TrimSpace(c.PostForm("critical_processes"))
+	o.DecisionNumber = strings.TrimSpace(c.PostForm("decision_number"))
+
+	decisionDate, err := parseFormDate(c.PostForm("decision_date"))
+	if err != nil {
+		renderKII(c, http.StatusBadRequest, role, asset, o, "Некорректная дата решения комиссии")
+		return
+	}
+	o.DecisionDate = decisionDate
+
+	// пустое значение — показатель к объекту не применим
+	var values []models.KIIIndicatorValue
+	for _, ind := range models.KIIIndicators {
+		raw := strings.TrimSpace(strings.ReplaceAll(c.PostForm("value_"+ind.Code), ",", "."))
+		if raw == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil || v < 0 {
+			renderKII(c, http.StatusBadRequest, role, asset, o,
+				fmt.Sprintf("Некорректное значение показателя %s", ind.Code))
+			return
+		}
+		values = append(values, models.KIIIndicatorValue{
+			Code:          ind.Code,
+			Value:         v,
+			Justification: strings.TrimSpace(c.PostForm("justification_" + ind.Code)),
+		})
+	}
+	o.Indicators = values
+
+	if len(o.ProcessLines()) == 0 {
+		renderKII(c, http.StatusBadRequest, role, asset, o, "Укажите хотя бы один критический процесс")
+		return
+	}
+
+	category, trail := o.Calculate()
+	o.Category = category
+	o.Trail = strings.Join(trail, "\n")
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Asset", "Indicators").Save(&o).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("object_id = ?", o.ID).Delete(&models.KIIIndicatorValue{}).Error; err != nil {
+			return err
+		}
+		if len(values) > 0 {
+			for i := range values {
+				values[i].ObjectID = o.ID
+			}
+			if err := tx.Create(&values).Error; err != nil {
+				return err
+			}
+		}
+		// у незначимой АСУ ТП в карточке остаётся класс защищённости по приказу №31
+		var class int
+		if o.Category == 0 && asset.AssetType == models.AssetASUTP {
+			if _, err := fmt.Sscanf(asset.Category, "К%d", &class); err == nil {
+				return nil
+			}
+		}
+		return tx.Model(&asset).Update("category", o.AssetCategory()).Error
+	})
+	if err != nil {
+		renderKII(c, http.StatusInternalServerError, role, asset, o, "Ошибка сохранения категорирования")
+		return
+	}
+
+	sess := sessions.Default(c)
+	if uid, ok := sess.Get("user_id").(uint); ok {
+		if !known || prevCategory != o.Category || prevTrail != o.Trail {
+			old := "не категорирован"
+			if known {
+				old = models.KIICategoryLabel(prevCategory)
+			}
+			database.CreateAuditLog(uid, "asset", asset.ID, "kii_category",
+				fmt.Sprintf("Объект %s: категория КИИ %s → %s. %s",
+					asset.Name, old, models.KIICategoryLabel(o.Category), strings.Join(trail, "; ")))
+		} else {
+			database.CreateAuditLog(uid, "asset", asset.ID, "update",
+				"Обновлены сведения о категорировании КИИ: "+asset.Name)
+		}
+	}
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/assets/%d/kii", asset.ID))
+}
+
+// kiiRegisterRow — строка реестра КИИ клиента; Object.ID == 0 — категорирование не проводилось
+type kiiRegisterRow struct {
+	Asset  models.Asset
+	Object models.KIIObject
+}
+
+// ListClientKII — реестр объектов КИИ клиента
+func ListClientKII(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.String(http.StatusBadRequest, "Некорректный ID клиента")
+		return
+	}
+
+	var client models.Client
+	if err := database.DB.First(&client, id).Error; err != nil {
+		c.String(http.StatusNotFound, "Клиент не найден")
+		return
+	}
+
+	var assets []models.Asset
+	database.DB.
+		Where("client_id = ? AND asset_type IN ?", client.ID, []models.AssetType{models.AssetASUTP, models.AssetCorpIT}).
+		Order("name asc").
+		Find(&assets)
+
+	assetIDs := make([]uint, 0, len(assets))
+	for _, a := range assets {
+		assetIDs = append(assetIDs, a.ID)
+	}
+	objects := make(map[uint]models.KIIObject)
+	if len(assetIDs) > 0 {
+		var list []models.KIIObject
+		database.DB.Where("asset_id IN ?", assetIDs).Find(&list)
+		for _, o := range list {
+			objects[o.AssetID] = o
+		}
+	}
+
+	rows := make([]kiiRegisterRow, 0, len(assets))
+	counts := make(map[int]int)
+	for _, a := range assets {
+		o, ok := objects[a.ID]
+		if ok {
+			counts[o.Category]++
+		}
+		rows = append(rows, kiiRegisterRow{Asset: a, Object: o})
+	}
+
+	render(c, http.StatusOK, "kii_register.html", gin.H{
+		"role":   string(role),
+		"client": client,
+		"rows":   rows,
+		"counts": counts,
+	})
+}
@@ -1,17 +1,28 @@
-package handlers
-
-import (
-	"net/http"
-
-	"github.com/gin-contrib/sessions"
-	"github.com/gin-gonic/gin"
-)
-
-func IndexPage(c *gin.Context) {
-	sess := sessions.Default(c)
-	_, ok := sess.Get("user_id").(uint)
-
-	render(c, http.StatusOK, "index.html", gin.H{
-		"isAuthed": ok,
-	})
-}
+package handlers
+
+import (
+	"net/http"
+
+	"ib-integrator/internal/models"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+func IndexPage(c *gin.Context) {
+	sess := sessions.Default(c)
+	uid, ok := sess.Get("user_id").(uint)
+	roleStr, _ := sess.Get("role").(string)
+	role := models.UserRole(roleStr)
+
+	// уведомления по сертификатам СЗИ — для тех, кто ведёт объекты клиентов
+	var alerts []models.CertificateAlert
+	if ok && (role == models.RoleAdmin || role == models.RoleEngineer) {
+		alerts = openCertificateAlerts(role, uid, 0)
+	}
+
+	render(c, http.StatusOK, "index.html", gin.H{
+		"isAuthed":   ok,
+		"certAlerts": alerts,
+	})
+}
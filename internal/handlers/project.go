@@ -0,0 +1,418 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ib-integrator/internal/database"
+	"ib-integrator/internal/models"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Права на проекты совпадают с правами на клиентов:
+// создавать — admin/sales (canManageClients), редактировать и менять статус — admin (canEditClients).
+
+const dateLayout = "2006-01-02"
+
+// parseFormDate — пустая строка означает "дата не задана"
+func parseFormDate(s string) (*time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(dateLayout, s)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// parseUserRef — ответственный из формы: пусто = не назначен, иначе пользователь с одной из ролей
+func parseUserRef(idStr string, roles ...models.UserRole) (*uint, bool) {
+	idStr = strings.TrimSpace(idStr)
+	if idStr == "" {
+		return nil, true
+	}
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil || id == 0 {
+		return nil, false
+	}
+
+	var user models.User
+	if err := database.DB.Where("role IN ?", roles).First(&user, id).Error; err != nil {
+		return nil, false
+	}
+	return &user.ID, true
+}
+
+// projectFormData — справочники для форм создания/редактирования проекта
+func projectFormData() gin.H {
+	var clients []models.Client
+	database.DB.Order("name asc").Find(&clients)
+
+	var assets []models.Asset
+	database.DB.Preload("Client").Order("client_id asc, name asc").Find(&assets)
+
+	var engineers []models.User
+	database.DB.Where("role IN ?", []models.UserRole{models.RoleEngineer, models.RoleAdmin}).
+		Order("username asc").Find(&engineers)
+
+	var sales []models.User
+	database.DB.Where("role IN ?", []models.UserRole{models.RoleSales, models.RoleAdmin}).
+		Order("username asc").Find(&sales)
+
+	return gin.H{
+		"clients":   clients,
+		"assets":    assets,
+		"engineers": engineers,
+		"sales":     sales,
+		"types":     models.ProjectTypes,
+	}
+}
+
+// bindProjectForm — общий разбор формы проекта для создания и редактирования.
+// Возвращает выбранные объекты защиты или текст ошибки для пользователя.
+func bindProjectForm(c *gin.Context, p *models.Project) ([]models.Asset, string) {
+	name := strings.TrimSpace(c.PostForm("name"))
+	pType := models.ProjectType(strings.TrimSpace(c.PostForm("project_type")))
+	description := strings.TrimSpace(c.PostForm("description"))
+
+	if len(name) < 3 {
+		return nil, "Название проекта должно быть не короче 3 символов"
+	}
+	if !pType.Valid() {
+		return nil, "Укажите тип проекта"
+	}
+
+	clientID, err := strconv.ParseUint(strings.TrimSpace(c.PostForm("client_id")), 10, 64)
+	if err != nil || clientID == 0 {
+		return nil, "Выберите клиента"
+	}
+	var client models.Client
+	if err := database.DB.First(&client, clientID).Error; err != nil {
+		return nil, "Клиент не найден"
+	}
+
+	engineerID, ok := parseUserRef(c.PostForm("engineer_id"), models.RoleEngineer, models.RoleAdmin)
+	if !ok {
+		return nil, "Ответственный инженер не найден"
+	}
+	salesID, ok := parseUserRef(c.PostForm("sales_id"), models.RoleSales, models.RoleAdmin)
+	if !ok {
+		return nil, "Ответственный менеджер не найден"
+	}
+
+	var dates [4]*time.Time
+	for i, field := range []string{"planned_start", "planned_end", "actual_start", "actual_end"} {
+		d, err := parseFormDate(c.PostForm(field))
+		if err != nil {
+			return nil, "Некорректный формат даты"
+		}
+		dates[i] = d
+	}
+	if dates[0] != nil && dates[1] != nil && dates[1].Before(*dates[0]) {
+		return nil, "Плановая дата окончания раньше даты начала"
+	}
+	if dates[2] != nil && dates[3] != nil && dates[3].Before(*dates[2]) {
+		return nil, "Фактическая дата окончания раньше даты начала"
+	}
+
+	// объекты защиты проекта — только объекты выбранного клиента
+	var assets []models.Asset
+	if ids := c.PostFormArray("asset_ids"); len(ids) > 0 {
+		database.DB.Where("id IN ? AND client_id = ?", ids, client.ID).Find(&assets)
+		if len(assets) != len(ids) {
+			return nil, "Выбраны объекты защиты другого клиента"
+		}
+	}
+
+	p.ClientID = client.ID
+	p.Name = name
+	p.ProjectType = pType
+	p.Description = description
+	p.EngineerID = engineerID
+	p.SalesID = salesID
+	p.PlannedStart = dates[0]
+	p.PlannedEnd = dates[1]
+	// фактические сроки правятся только при редактировании,
+	// при создании их проставляет смена статуса
+	if p.ID != 0 {
+		p.ActualStart = dates[2]
+		p.ActualEnd = dates[3]
+	}
+
+	return assets, ""
+}
+
+//
+// СПИСОК / КАРТОЧКА
+//
+
+func ListProjects(c *gin.Context) {
+	sess := sessions.Default(c)
+	roleStr, _ := sess.Get("role").(string)
+	role := models.UserRole(roleStr)
+
+	status := models.ProjectStatus(c.Query("status"))
+
+	q := database.DB.Preload("Client").Preload("Engineer").Order("created_at desc")
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+
+	var projects []models.Project
+	q.Find(&projects)
+
+	render(c, http.StatusOK, "projects_list.html", gin.H{
+		"projects": projects,
+		"statuses": models.ProjectStatuses,
+		"status":   status,
+		"role":     roleStr,
+		"IsAdmin":  role == models.RoleAdmin,
+		"IsSales":  role == models.RoleSales,
+	})
+}
+
+func ShowProjectDetail(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil || id == 0 {
+		c.String(http.StatusBadRequest, "Некорректный ID проекта")
+		return
+	}
+
+	var project models.Project
+	if err := database.DB.
+		Preload("Client").
+		Preload("Engineer").
+		Preload("Sales").
+		Preload("Assets").
+		First(&project, id).Error; err != nil {
+		c.String(http.StatusNotFound, "Проект не найден")
+		return
+	}
+
+	// история статусов берётся из журнала аудита
+	var history []models.AuditLog
+	database.DB.
+		Preload("User").
+		Where("entity = ? AND entity_id = ? AND action = ?", "project", project.ID, "status_change").
+		Order("created_at asc").
+		Find(&history)
+
+	render(c, http.StatusOK, "project_detail.html", gin.H{
+		"project":      project,
+		"history":      history,
+		"nextStatuses": project.Status.NextStatuses(),
+		"IsAdmin":      canEditClients(c),
+	})
+}
+
+//
+// СОЗДАНИЕ
+//
+
+func ShowNewProject(c *gin.Context) {
+	if !canManageClients(c) {
+		c.String(http.StatusForbidden, "Недостаточно прав")
+		return
+	}
+
+	data := projectFormData()
+	data["error"] = ""
+	// переход из карточки клиента — клиент выбран заранее
+	clientID, _ := strconv.ParseUint(c.Query("client_id"), 10, 64)
+	data["clientID"] = uint(clientID)
+	render(c, http.StatusOK, "projects_new.html", data)
+}
+
+func CreateProject(c *gin.Context) {
+	if !canManageClients(c) {
+		c.String(http.StatusForbidden, "Недостаточно прав")
+		return
+	}
+
+	project := models.Project{Status: models.ProjectDraft}
+	assets, errMsg := bindProjectForm(c, &project)
+	if errMsg != "" {
+		data := projectFormData()
+		data["error"] = errMsg
+		data["clientID"] = project.ClientID
+		render(c, http.StatusBadRequest, "projects_new.html", data)
+		return
+	}
+	project.Assets = assets
+
+	if err := database.DB.Create(&project).Error; err != nil {
+		data := projectFormData()
+		data["error"] = "Ошибка сохранения проекта в БД"
+		render(c, http.StatusInternalServerError, "projects_new.html", data)
+		return
+	}
+
+	sess := sessions.Default(c)
+	if uid, ok := sess.Get("user_id").(uint); ok {
+		database.CreateAuditLog(uid, "project", project.ID, "create", "Создан проект: "+project.Name)
+	}
+
+	c.Redirect(http.StatusFound, "/projects/"+strconv.FormatUint(uint64(project.ID), 10))
+}
+
+//
+// РЕДАКТИРОВАНИЕ — ТОЛЬКО ADMIN
+//
+
+func renderProjectEdit(c *gin.Context, status int, project models.Project, msg string) {
+	selected := make(map[uint]bool)
+	for _, a := range project.Assets {
+		selected[a.ID] = true
+	}
+
+	var engineerID, salesID uint
+	if project.EngineerID != nil {
+		engineerID = *project.EngineerID
+	}
+	if project.SalesID != nil {
+		salesID = *project.SalesID
+	}
+
+	data := projectFormData()
+	data["project"] = project
+	data["selectedAssets"] = selected
+	data["engineerID"] = engineerID
+	data["salesID"] = salesID
+	data["error"] = msg
+	render(c, status, "projects_edit.html", data)
+}
+
+func ShowEditProject(c *gin.Context) {
+	if !canEditClients(c) {
+		c.String(http.StatusForbidden, "Недостаточно прав")
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.String(http.StatusBadRequest, "Некорректный ID проекта")
+		return
+	}
+
+	var project models.Project
+	if err := database.DB.Preload("Assets").First(&project, id).Error; err != nil {
+		c.String(http.StatusNotFound, "Проект не найден")
+		return
+	}
+
+	renderProjectEdit(c, http.StatusOK, project, "")
+}
+
+func UpdateProject(c *gin.Context) {
+	if !canEditClients(c) {
+		c.String(http.StatusForbidden, "Недостаточно прав")
+		return
+	}
+
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id <= 0 {
+		c.String(http.StatusBadRequest, "Некорректный ID проекта")
+		return
+	}
+
+	var project models.Project
+	if err := database.DB.Preload("Assets").First(&project, id).Error; err != nil {
+		c.String(http.StatusNotFound, "Проект не найден")
+		return
+	}
+
+	assets, errMsg := bindProjectForm(c, &project)
+	if errMsg != "" {
+		renderProjectEdit(c, http.StatusBadRequest, project, errMsg)
+		return
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Assets").Save(&project).Error; err != nil {
+			return err
+		}
+		return tx.Model(&project).Association("Assets").Replace(assets)
+	})
+	if err != nil {
+		renderProjectEdit(c, http.StatusInternalServerError, project, "Ошибка сохранения проекта")
+		return
+	}
+
+	sess := sessions.Default(c)
+	if uid, ok := sess.Get("user_id").(uint); ok {
+		database.CreateAuditLog(uid, "project", project.ID, "update", "Изменён проект: "+project.Name)
+	}
+
+	c.Redirect(http.StatusFound, "/projects/"+idStr)
+}
+
+//
+// СМЕНА СТАТУСА — ТОЛЬКО ADMIN
+//
+
+func ChangeProjectStatus(c *gin.Context) {
+	if !canEditClients(c) {
+		c.String(http.StatusForbidden, "Недостаточно прав")
+		return
+	}
+
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id <= 0 {
+		c.String(http.StatusBadRequest, "Некорректный ID проекта")
+		return
+	}
+
+	var project models.Project
+	if err := database.DB.First(&project, id).Error; err != nil {
+		c.String(http.StatusNotFound, "Проект не найден")
+		return
+	}
+
+	next := models.ProjectStatus(strings.TrimSpace(c.PostForm("status")))
+	comment := strings.TrimSpace(c.PostForm("comment"))
+
+	if !project.Status.CanTransitionTo(next) {
+		c.String(http.StatusBadRequest, "Недопустимый переход статуса: "+
+			project.Status.Label()+" → "+next.Label())
+		return
+	}
+
+	prev := project.Status
+	project.Status = next
+
+	// фактические сроки проставляем автоматически, если их не заполнили вручную
+	now := time.Now()
+	if next == models.ProjectInProgress && project.ActualStart == nil {
+		project.ActualStart = &now
+	}
+	if next == models.ProjectClosed && project.ActualEnd == nil {
+		project.ActualEnd = &now
+	}
+
+	if err := database.DB.Save(&project).Error; err != nil {
+		c.String(http.StatusInternalServerError, "Ошибка сохранения статуса проекта")
+		return
+	}
+
+	details := "Проект " + project.Name + ": " + prev.Label() + " → " + next.Label()
+	if comment != "" {
+		details += " (" + comment + ")"
+	}
+
+	sess := sessions.Default(c)
+	if uid, ok := sess.Get("user_id").(uint); ok {
+		database.CreateAuditLog(uid, "project", project.ID, "status_change", details)
+	}
+
+	c.Redirect(http.StatusFound, "/projects/"+idStr)
+}
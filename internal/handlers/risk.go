@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"ib-integrator/internal/database"
+	"ib-integrator/internal/models"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ====== МАТРИЦА РИСКОВ ======
+
+type scaleValue struct {
+	Value int
+	Label string
+}
+
+type heatCell struct {
+	Likelihood int
+	Impact     int
+	Level      string
+	Count      int
+}
+
+type heatRow struct {
+	Likelihood int
+	Label      string
+	Cells      []heatCell
+}
+
+// heatMap — матрица для шаблона: строки по вероятности сверху вниз, колонки по ущербу
+type heatMap struct {
+	Title   string
+	Rows    []heatRow
+	Impacts []scaleValue
+	Total   int
+}
+
+type riskPoint struct {
+	Likelihood int
+	Impact     int
+}
+
+func likelihoodScale(m *models.RiskMatrix) []scaleValue {
+	values := make([]scaleValue, 0, m.LikelihoodScale)
+	for v := 1; v <= m.LikelihoodScale; v++ {
+		values = append(values, scaleValue{Value: v, Label: m.LikelihoodLabel(v)})
+	}
+	return values
+}
+
+func impactScale(m *models.RiskMatrix) []scaleValue {
+	values := make([]scaleValue, 0, m.ImpactScale)
+	for v := 1; v <= m.ImpactScale; v++ {
+		values = append(values, scaleValue{Value: v, Label: m.ImpactLabel(v)})
+	}
+	return values
+}
+
+// buildHeatMap раскладывает оценки по ячейкам матрицы; points может быть пустым
+func buildHeatMap(m *models.RiskMatrix, title string, points []riskPoint) heatMap {
+	counts := make(map[riskPoint]int)
+	total := 0
+	for _, p := range points {
+		if m.InScale(p.Likelihood, p.Impact) {
+			counts[p]++
+			total++
+		}
+	}
+
+	hm := heatMap{Title: title, Impacts: impactScale(m), Total: total}
+	for l := m.LikelihoodScale; l >= 1; l-- {
+		row := heatRow{Likelihood: l, Label: m.LikelihoodLabel(l)}
+		for i := 1; i <= m.ImpactScale; i++ {
+			row.Cells = append(row.Cells, heatCell{
+				Likelihood: l,
+				Impact:     i,
+				Level:      m.Level(l, i),
+				Count:      counts[riskPoint{l, i}],
+			})
+		}
+		hm.Rows = append(hm.Rows, row)
+	}
+	return hm
+}
+
+// assetRiskHeatMaps — исходный и остаточный риск по набору оценок
+func assetRiskHeatMaps(m *models.RiskMatrix, title string, links []models.AssetThreat) (heatMap, heatMap) {
+	inherent := make([]riskPoint, 0, len(links))
+	residual := make([]riskPoint, 0, len(links))
+	for _, l := range links {
+		inherent = append(inherent, riskPoint{l.Likelihood, l.Impact})
+		residual = append(residual, riskPoint{l.ResidualLikelihood, l.ResidualImpact})
+	}
+	return buildHeatMap(m, title+": исходный риск", inherent),
+		buildHeatMap(m, title+": остаточный риск", residual)
+}
+
+// parseRiskScore — значение шкалы из формы; пустое поле допустимо, если allowEmpty
+func parseRiskScore(raw string, scale int, allowEmpty bool) (int, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0, allowEmpty
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil || v < 1 || v > scale {
+		return 0, false
+	}
+	return v, true
+}
+
+func ShowRiskMatrix(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	m, err := database.LoadRiskMatrix()
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Матрица рисков не настроена")
+		return
+	}
+
+	renderRiskMatrix(c, http.StatusOK, role, m, "")
+}
+
+func renderRiskMatrix(c *gin.Context, status int, role models.UserRole, m *models.RiskMatrix, msg string) {
+	render(c, status, "risk_matrix.html", gin.H{
+		"role":    string(role),
+		"matrix":  m,
+		"grid":    buildHeatMap(m, "", nil),
+		"levels":  models.RiskLevels,
+		"IsAdmin": role == models.RoleAdmin,
+		"error":   msg,
+	})
+}
+
+// UpdateRiskMatrix — правка шкал и ячеек (только admin).
+// При смене размера шкал ячейки заполняются по умолчанию, уровни всех оценок пересчитываются.
+func UpdateRiskMatrix(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+	if role != models.RoleAdmin {
+		c.AbortWithStatus(http.StatusForbidden)
+		return
+	}
+
+	m, err := database.LoadRiskMatrix()
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Матрица рисков не настроена")
+		return
+	}
+
+	lScale, err1 := strconv.Atoi(c.PostForm("likelihood_scale"))
+	iScale, err2 := strconv.Atoi(c.PostForm("impact_scale"))
+	if err1 != nil || err2 != nil || lScale < 2 || lScale > 10 || iScale < 2 || iScale > 10 {
+		renderRiskMatrix(c, http.StatusBadRequest, role, m, "Размер шкалы должен быть от 2 до 10")
+		return
+	}
+
+	resized := lScale != m.LikelihoodScale || iScale != m.ImpactScale
+	if resized {
+		// нельзя сжать шкалу ниже уже выставленных оценок
+		var used struct {
+			L, RL, I, RI int
+		}
+		database.DB.Model(&models.AssetThreat{}).Select(
+			"COALESCE(MAX(likelihood), 0) AS l, COALESCE(MAX(residual_likelihood), 0) AS rl, " +
+				"COALESCE(MAX(impact), 0) AS i, COALESCE(MAX(residual_impact), 0) AS ri").
+			Scan(&used)
+		maxL, maxI := max(used.L, used.RL), max(used.I, used.RI)
+		if lScale < maxL || iScale < maxI {
+			renderRiskMatrix(c, http.StatusBadRequest, role, m, fmt.Sprintf(
+				"Есть оценки со значениями до %d×%d — шкалу нельзя сделать меньше", maxL, maxI))
+			return
+		}
+	}
+
+	cells := make([]models.RiskMatrixCell, 0, lScale*iScale)
+	for l := 1; l <= lScale; l++ {
+		for i := 1; i <= iScale; i++ {
+			level := models.DefaultRiskLevel(l, i, lScale, iScale)
+			if !resized {
+				level = c.PostForm(fmt.Sprintf("cell_%d_%d", l, i))
+				if !isRiskLevel(level) {
+					renderRiskMatrix(c, http.StatusBadRequest, role, m, "Некорректный уровень риска в матрице")
+					return
+				}
+			}
+			cells = append(cells, models.RiskMatrixCell{MatrixID: m.ID, Likelihood: l, Impact: i, Level: level})
+		}
+	}
+
+	m.LikelihoodScale = lScale
+	m.ImpactScale = iScale
+	m.LikelihoodLabels = strings.TrimSpace(c.PostForm("likelihood_labels"))
+	m.ImpactLabels = strings.TrimSpace(c.PostForm("impact_labels"))
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Cells").Save(m).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("matrix_id = ?", m.ID).Delete(&models.RiskMatrixCell{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&cells).Error; err != nil {
+			return err
+		}
+		m.Cells = cells
+		return database.RecalculateRiskLevels(tx, m)
+	})
+	if err != nil {
+		renderRiskMatrix(c, http.StatusInternalServerError, role, m, "Ошибка сохранения матрицы рисков")
+		return
+	}
+
+	sess := sessions.Default(c)
+	if uid, ok := sess.Get("user_id").(uint); ok {
+		database.CreateAuditLog(uid, "risk_matrix", m.ID, "update",
+			fmt.Sprintf("Изменена матрица рисков (%d×%d)", lScale, iScale))
+	}
+
+	c.Redirect(http.StatusFound, "/risk-matrix")
+}
+
+func isRiskLevel(level string) bool {
+	for _, l := range models.RiskLevels {
+		if l == level {
+			return true
+		}
+	}
+	return false
+}
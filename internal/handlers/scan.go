@@ -0,0 +1,309 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"ib-integrator/internal/database"
+	"ib-integrator/internal/models"
+	"ib-integrator/internal/scan"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+// ====== ИНВЕНТАРИЗАЦИЯ УЗЛОВ КЛИЕНТА И ИМПОРТ ОТЧЁТОВ СКАНЕРОВ ======
+//
+// Как и импорт БДУ: шаг 1 — загрузка отчёта и предпросмотр, шаг 2 — запись.
+// Путь к загруженному файлу и клиент, для которого он загружен, между шагами хранятся в сессии.
+
+const scanImportSessionKey = "scan_import_file"
+
+// клиент, для которого выполнен предпросмотр: применять файл можно только к нему
+const scanImportClientKey = "scan_import_client"
+
+type hostRow struct {
+	Host     models.Host
+	AssetID  uint // 0 — узел не сопоставлен с объектом
+	Findings int64
+	Open     int64 // находки, сопоставленные с неустранёнными уязвимостями объекта
+}
+
+func renderClientHosts(c *gin.Context, status int, role models.UserRole, client models.Client, data gin.H) {
+	var hosts []models.Host
+	database.DB.Preload("Asset").Preload("Services").
+		Where("client_id = ?", client.ID).
+		Order("address asc").
+		Find(&hosts)
+
+	rows := make([]hostRow, 0, len(hosts))
+	for _, h := range hosts {
+		row := hostRow{Host: h}
+		if h.AssetID != nil {
+			row.AssetID = *h.AssetID
+		}
+		database.DB.Model(&models.HostFinding{}).Where("host_id = ?", h.ID).Count(&row.Findings)
+		if h.AssetID != nil {
+			database.DB.Model(&models.HostFinding{}).
+				Joins("JOIN asset_vulnerabilities ON asset_vulnerabilities.vulnerability_id = host_findings.vulnerability_id AND asset_vulnerabilities.deleted_at IS NULL").
+				Where("host_findings.host_id = ? AND asset_vulnerabilities.asset_id = ? AND asset_vulnerabilities.status IN ?",
+					h.ID, *h.AssetID, []models.VulnerabilityStatus{models.VulnFound, models.VulnConfirmed}).
+				Count(&row.Open)
+		}
+		rows = append(rows, row)
+	}
+
+	var assets []models.Asset
+	database.DB.Where("client_id = ?", client.ID).Order("name asc").Find(&assets)
+
+	h := gin.H{
+		"role":     string(role),
+		"client":   client,
+		"hosts":    rows,
+		"assets":   assets,
+		"mappings": scan.CSVMappings(),
+		"error":    "",
+	}
+	for k, v := range data {
+		h[k] = v
+	}
+	render(c, status, "client_hosts.html", h)
+}
+
+func ShowClientHosts(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	client, ok := loadClientByParam(c)
+	if !ok {
+		return
+	}
+
+	renderClientHosts(c, http.StatusOK, role, client, nil)
+}
+
+// scanImportParams — объект для новых узлов и сопоставление колонок CSV из формы
+func scanImportParams(c *gin.Context, client models.Client) (*uint, *scan.CSVMapping, string) {
+	var assetID *uint
+	if v := c.PostForm("asset_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return nil, nil, "Некорректный объект защиты"
+		}
+		var asset models.Asset
+		if err := database.DB.Where("id = ? AND client_id = ?", id, client.ID).First(&asset).Error; err != nil {
+			return nil, nil, "Объект защиты не принадлежит клиенту"
+		}
+		assetID = &asset.ID
+	}
+
+	var mapping *scan.CSVMapping
+	if name := c.PostForm("mapping"); name != "" {
+		m, ok := scan.CSVMappingByName(name)
+		if !ok {
+			return nil, nil, "Неизвестное сопоставление колонок CSV"
+		}
+		mapping = &m
+	}
+	return assetID, mapping, ""
+}
+
+func PreviewScanImport(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	client, ok := loadClientByParam(c)
+	if !ok {
+		return
+	}
+	fail := func(status int, msg string) {
+		renderClientHosts(c, status, role, client, gin.H{"error": msg})
+	}
+
+	assetID, mapping, msg := scanImportParams(c, client)
+	if msg != "" {
+		fail(http.StatusBadRequest, msg)
+		return
+	}
+
+	fh, err := c.FormFile("file")
+	if err != nil {
+		fail(http.StatusBadRequest, "Выберите файл отчёта сканера")
+		return
+	}
+	ext := strings.ToLower(filepath.Ext(fh.Filename))
+	if ext != ".xml" && ext != ".csv" {
+		fail(http.StatusBadRequest, "Поддерживаются отчёты .xml (Nmap, OpenVAS/GVM) и .csv")
+		return
+	}
+
+	tmp, err := os.CreateTemp("", "scan-import-*"+ext)
+	if err != nil {
+		fail(http.StatusInternalServerError, "Не удалось сохранить файл")
+		return
+	}
+	tmp.Close()
+
+	if err := c.SaveUploadedFile(fh, tmp.Name()); err != nil {
+		os.Remove(tmp.Name())
+		fail(http.StatusInternalServerError, "Не удалось сохранить файл")
+		return
+	}
+
+	report, err := scan.ParseFile(tmp.Name(), mapping)
+	if err != nil {
+		os.Remove(tmp.Name())
+		fail(http.StatusBadRequest, "Ошибка разбора отчёта: "+err.Error())
+		return
+	}
+
+	plan, err := scan.BuildPlan(database.DB, report, client.ID, assetID)
+	if err != nil {
+		os.Remove(tmp.Name())
+		fail(http.StatusInternalServerError, "Ошибка сопоставления с инвентаризацией: "+err.Error())
+		return
+	}
+
+	// предыдущий незавершённый импорт больше не нужен
+	sess := sessions.Default(c)
+	if prev, ok := sess.Get(scanImportSessionKey).(string); ok {
+		removeImportFile(prev)
+	}
+	if len(plan.Hosts) == 0 {
+		os.Remove(tmp.Name())
+		sess.Delete(scanImportSessionKey)
+		sess.Delete(scanImportClientKey)
+	} else {
+		sess.Set(scanImportSessionKey, tmp.Name())
+		sess.Set(scanImportClientKey, client.ID)
+	}
+	_ = sess.Save()
+
+	var mappingName string
+	if mapping != nil {
+		mappingName = mapping.Name
+	}
+	var asset uint
+	if assetID != nil {
+		asset = *assetID
+	}
+	renderClientHosts(c, http.StatusOK, role, client, gin.H{
+		"plan":     plan,
+		"counts":   plan.Counts(),
+		"filename": fh.Filename,
+		"assetID":  asset,
+		"mapping":  mappingName,
+	})
+}
+
+func ApplyScanImport(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	client, ok := loadClientByParam(c)
+	if !ok {
+		return
+	}
+	fail := func(status int, msg string) {
+		renderClientHosts(c, status, role, client, gin.H{"error": msg})
+	}
+
+	sess := sessions.Default(c)
+	path, ok := sess.Get(scanImportSessionKey).(string)
+	if !ok || path == "" {
+		fail(http.StatusBadRequest, "Нет загруженного отчёта — загрузите файл заново")
+		return
+	}
+	// отчёт мог быть загружен для другого клиента в соседней вкладке — его не трогаем
+	if owner, _ := sess.Get(scanImportClientKey).(uint); owner != client.ID {
+		fail(http.StatusBadRequest, "Загруженный отчёт относится к другому клиенту — загрузите файл заново")
+		return
+	}
+	sess.Delete(scanImportSessionKey)
+	sess.Delete(scanImportClientKey)
+	_ = sess.Save()
+	defer removeImportFile(path)
+
+	assetID, mapping, msg := scanImportParams(c, client)
+	if msg != "" {
+		fail(http.StatusBadRequest, msg)
+		return
+	}
+
+	report, err := scan.ParseFile(path, mapping)
+	if err != nil {
+		fail(http.StatusBadRequest, "Ошибка разбора отчёта: "+err.Error())
+		return
+	}
+
+	// план строится заново: инвентаризация могла измениться с момента предпросмотра
+	plan, err := scan.BuildPlan(database.DB, report, client.ID, assetID)
+	if err != nil {
+		fail(http.StatusInternalServerError, "Ошибка сопоставления с инвентаризацией: "+err.Error())
+		return
+	}
+	if err := scan.Apply(database.DB, plan); err != nil {
+		fail(http.StatusInternalServerError, "Ошибка записи инвентаризации: "+err.Error())
+		return
+	}
+
+	if uid, ok := sess.Get("user_id").(uint); ok {
+		database.CreateAuditLog(uid, "client", client.ID, "scan_import",
+			fmt.Sprintf("Импорт отчёта сканера (%s): %s", report.Format, plan.Summary()))
+	}
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/clients/%d/hosts", client.ID))
+}
+
+// SetHostAsset — ручное сопоставление узла с объектом защиты клиента
+func SetHostAsset(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	client, ok := loadClientByParam(c)
+	if !ok {
+		return
+	}
+
+	var host models.Host
+	if err := database.DB.Where("id = ? AND client_id = ?", c.Param("host_id"), client.ID).First(&host).Error; err != nil {
+		c.String(http.StatusNotFound, "Узел не найден")
+		return
+	}
+
+	assetID, _, msg := scanImportParams(c, client)
+	if msg != "" {
+		renderClientHosts(c, http.StatusBadRequest, role, client, gin.H{"error": msg})
+		return
+	}
+
+	if err := database.DB.Model(&host).Update("asset_id", assetID).Error; err != nil {
+		renderClientHosts(c, http.StatusInternalServerError, role, client, gin.H{"error": "Ошибка сохранения узла"})
+		return
+	}
+
+	sess := sessions.Default(c)
+	if uid, ok := sess.Get("user_id").(uint); ok {
+		details := fmt.Sprintf("Узел %s: сопоставление с объектом снято", host.Address)
+		if assetID != nil {
+			var asset models.Asset
+			database.DB.First(&asset, *assetID)
+			details = fmt.Sprintf("Узел %s отнесён к объекту %s", host.Address, asset.Name)
+		}
+		database.CreateAuditLog(uid, "client", client.ID, "host_asset", details)
+	}
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/clients/%d/hosts", client.ID))
+}
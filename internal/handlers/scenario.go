@@ -0,0 +1,293 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"ib-integrator/internal/database"
+	"ib-integrator/internal/models"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ====== СЦЕНАРИИ РЕАЛИЗАЦИИ УГРОЗ (ТАКТИКИ И ТЕХНИКИ Т1–Т10) ======
+
+// пустых строк цепочки в форме сверх уже выбранных техник
+const scenarioSpareSteps = 3
+
+// scenarioForm — данные частичного шаблона "scenario_form"
+type scenarioForm struct {
+	Action        string
+	Scenario      models.ThreatScenario
+	Slots         []uint // ID техник по шагам, 0 — пустая строка
+	Tactics       []models.Tactic
+	Feasibilities []models.ScenarioFeasibility
+}
+
+func newScenarioForm(action string, s models.ThreatScenario) scenarioForm {
+	tactics, _ := database.Tactics()
+	slots := make([]uint, 0, len(s.Steps)+scenarioSpareSteps)
+	for _, st := range s.Steps {
+		slots = append(slots, st.TechniqueID)
+	}
+	for len(slots) < len(s.Steps)+scenarioSpareSteps || len(slots) < 5 {
+		slots = append(slots, 0)
+	}
+	if s.Feasibility == "" {
+		s.Feasibility = models.ScenarioNotAssessed
+	}
+	return scenarioForm{
+		Action:        action,
+		Scenario:      s,
+		Slots:         slots,
+		Tactics:       tactics,
+		Feasibilities: models.ScenarioFeasibilities,
+	}
+}
+
+// linkScenarios — сценарии угроз объекта с шагами по порядку; ключ — ID связи AssetThreat
+func linkScenarios(linkIDs []uint) map[uint][]models.ThreatScenario {
+	result := make(map[uint][]models.ThreatScenario)
+	if len(linkIDs) == 0 {
+		return result
+	}
+
+	var scenarios []models.ThreatScenario
+	database.DB.
+		Preload("Steps", func(db *gorm.DB) *gorm.DB { return db.Order("position asc") }).
+		Preload("Steps.Technique").
+		Where("asset_threat_id IN ?", linkIDs).
+		Order("id asc").
+		Find(&scenarios)
+
+	for _, s := range scenarios {
+		result[s.AssetThreatID] = append(result[s.AssetThreatID], s)
+	}
+	return result
+}
+
+// deleteThreatScenarios удаляет сценарии связи вместе с шагами
+func deleteThreatScenarios(tx *gorm.DB, linkID uint) error {
+	if err := tx.Where("scenario_id IN (?)",
+		tx.Model(&models.ThreatScenario{}).Select("id").Where("asset_threat_id = ?", linkID),
+	).Delete(&models.ScenarioStep{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("asset_threat_id = ?", linkID).Delete(&models.ThreatScenario{}).Error
+}
+
+// bindScenarioForm разбирает форму сценария; шаги — непустые technique_ids в порядке полей формы
+func bindScenarioForm(c *gin.Context, s *models.ThreatScenario) string {
+	s.Name = strings.TrimSpace(c.PostForm("name"))
+	s.Feasibility = models.ScenarioFeasibility(c.PostForm("feasibility"))
+	s.Justification = strings.TrimSpace(c.PostForm("justification"))
+
+	var ids []uint
+	for _, v := range c.PostFormArray("technique_ids") {
+		if v == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil || id == 0 {
+			return "Некорректная техника в цепочке"
+		}
+		ids = append(ids, uint(id))
+	}
+
+	var techniques []models.Technique
+	if len(ids) > 0 {
+		database.DB.Where("id IN ?", ids).Find(&techniques)
+	}
+	byID := make(map[uint]models.Technique, len(techniques))
+	for _, t := range techniques {
+		byID[t.ID] = t
+	}
+
+	s.Steps = s.Steps[:0]
+	for i, id := range ids {
+		t, ok := byID[id]
+		if !ok {
+			return "Техника не найдена в справочнике"
+		}
+		s.Steps = append(s.Steps, models.ScenarioStep{Position: i + 1, TechniqueID: id, Technique: t})
+	}
+
+	if s.Name == "" {
+		return "Укажите название сценария"
+	}
+	if len(s.Steps) == 0 {
+		return "Сценарий должен содержать хотя бы одну технику"
+	}
+	if !s.Feasibility.Valid() {
+		return "Некорректный вывод о реализуемости"
+	}
+	if s.Feasibility != models.ScenarioNotAssessed && s.Justification == "" {
+		return "Обоснуйте вывод о реализуемости сценария"
+	}
+	return ""
+}
+
+// saveThreatScenario сохраняет сценарий и заменяет его шаги
+func saveThreatScenario(s *models.ThreatScenario) error {
+	steps := s.Steps
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Steps").Save(s).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("scenario_id = ?", s.ID).Delete(&models.ScenarioStep{}).Error; err != nil {
+			return err
+		}
+		for i := range steps {
+			steps[i].ID = 0
+			steps[i].ScenarioID = s.ID
+			if err := tx.Omit("Technique").Create(&steps[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func scenarioAudit(c *gin.Context, link models.AssetThreat, action, details string) {
+	sess := sessions.Default(c)
+	if uid, ok := sess.Get("user_id").(uint); ok {
+		database.CreateAuditLog(uid, "asset", link.AssetID, action,
+			fmt.Sprintf("Объект %s, угроза %s: %s", link.Asset.Name, link.Threat.Code, details))
+	}
+}
+
+func CreateThreatScenario(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	link, ok := loadAssetThreatLink(c)
+	if !ok {
+		return
+	}
+
+	s := models.ThreatScenario{AssetThreatID: link.ID}
+	if msg := bindScenarioForm(c, &s); msg != "" {
+		matrix, err := database.LoadRiskMatrix()
+		if err != nil {
+			c.String(http.StatusInternalServerError, "Матрица рисков не настроена")
+			return
+		}
+		renderAssetThreatEdit(c, http.StatusBadRequest, role, link, matrix, msg)
+		return
+	}
+
+	if err := saveThreatScenario(&s); err != nil {
+		c.String(http.StatusInternalServerError, "Ошибка сохранения сценария")
+		return
+	}
+
+	scenarioAudit(c, link, "scenario_create", fmt.Sprintf("сценарий «%s» (%s) — %s", s.Name, s.Chain(), s.Feasibility.Label()))
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/assets/%d/threats/%d/edit", link.AssetID, link.ID))
+}
+
+func loadThreatScenario(c *gin.Context, link models.AssetThreat) (models.ThreatScenario, bool) {
+	var s models.ThreatScenario
+	if err := database.DB.
+		Preload("Steps", func(db *gorm.DB) *gorm.DB { return db.Order("position asc") }).
+		Preload("Steps.Technique").
+		Where("id = ? AND asset_threat_id = ?", c.Param("scenario_id"), link.ID).
+		First(&s).Error; err != nil {
+		c.String(http.StatusNotFound, "Сценарий не найден")
+		return models.ThreatScenario{}, false
+	}
+	return s, true
+}
+
+func renderThreatScenarioEdit(c *gin.Context, status int, role models.UserRole, link models.AssetThreat, s models.ThreatScenario, msg string) {
+	render(c, status, "threat_scenario_edit.html", gin.H{
+		"role":  string(role),
+		"link":  link,
+		"form":  newScenarioForm(fmt.Sprintf("/assets/%d/threats/%d/scenarios/%d/edit", link.AssetID, link.ID, s.ID), s),
+		"error": msg,
+	})
+}
+
+func ShowEditThreatScenario(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	link, ok := loadAssetThreatLink(c)
+	if !ok {
+		return
+	}
+	s, ok := loadThreatScenario(c, link)
+	if !ok {
+		return
+	}
+
+	renderThreatScenarioEdit(c, http.StatusOK, role, link, s, "")
+}
+
+func UpdateThreatScenario(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	link, ok := loadAssetThreatLink(c)
+	if !ok {
+		return
+	}
+	s, ok := loadThreatScenario(c, link)
+	if !ok {
+		return
+	}
+
+	if msg := bindScenarioForm(c, &s); msg != "" {
+		renderThreatScenarioEdit(c, http.StatusBadRequest, role, link, s, msg)
+		return
+	}
+
+	if err := saveThreatScenario(&s); err != nil {
+		renderThreatScenarioEdit(c, http.StatusInternalServerError, role, link, s, "Ошибка сохранения сценария")
+		return
+	}
+
+	scenarioAudit(c, link, "scenario_update", fmt.Sprintf("сценарий «%s» (%s) — %s", s.Name, s.Chain(), s.Feasibility.Label()))
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/assets/%d/threats/%d/edit", link.AssetID, link.ID))
+}
+
+func DeleteThreatScenario(c *gin.Context) {
+	if _, ok := requireRiskEditor(c); !ok {
+		return
+	}
+
+	link, ok := loadAssetThreatLink(c)
+	if !ok {
+		return
+	}
+	s, ok := loadThreatScenario(c, link)
+	if !ok {
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("scenario_id = ?", s.ID).Delete(&models.ScenarioStep{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&s).Error
+	})
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Ошибка удаления сценария")
+		return
+	}
+
+	scenarioAudit(c, link, "scenario_delete", fmt.Sprintf("удалён сценарий «%s»", s.Name))
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/assets/%d/threats/%d/edit", link.AssetID, link.ID))
+}
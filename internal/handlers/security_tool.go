@@ -0,0 +1,695 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"ib-integrator/internal/database"
+	"ib-integrator/internal/models"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ====== КАТАЛОГ СЗИ И УСТАНОВКИ СЗИ НА ОБЪЕКТАХ ======
+
+// срок в отчёте об истекающих сертификатах по умолчанию, дней
+const defaultCertificateHorizon = 90
+
+// installationRow — установка СЗИ с результатами проверок сертификата и класса
+type installationRow struct {
+	Installation models.SecurityToolInstallation
+	DaysLeft     int
+	HasExpiry    bool
+	Expired      bool
+	SupportEnded bool
+	ClassIssue   string // пусто — класс соответствует объекту или проверка не применима
+}
+
+// requiredToolClass — класс СЗИ, требуемый для объекта; 0 — объект не классифицирован
+func requiredToolClass(asset models.Asset) (int, string) {
+	b, ok := assetBaseline(asset)
+	if !ok {
+		return 0, ""
+	}
+	return b.RequiredToolClass(), b.Regulation.LevelLabel(b.Level)
+}
+
+// toolClassIssue — несоответствие класса СЗИ объекту. Проверяются сертификаты ФСТЭК:
+// классы СКЗИ ФСБ определяются по модели нарушителя, а не по классу объекта.
+func toolClassIssue(tool models.SecurityTool, required int) string {
+	if required == 0 || tool.Regulator != models.RegulatorFSTEC {
+		return ""
+	}
+	if tool.ProtectionClass == 0 {
+		return fmt.Sprintf("класс по сертификату не указан, требуется не ниже %d класса", required)
+	}
+	if tool.ProtectionClass > required {
+		return fmt.Sprintf("%d класс при требуемом не ниже %d", tool.ProtectionClass, required)
+	}
+	return ""
+}
+
+func newInstallationRow(inst models.SecurityToolInstallation, required int, now time.Time) installationRow {
+	row := installationRow{Installation: inst}
+	row.DaysLeft, row.HasExpiry = inst.Tool.CertificateDaysLeft(now)
+	row.Expired = row.HasExpiry && row.DaysLeft < 0
+	row.SupportEnded = inst.Tool.SupportEnded(now)
+	row.ClassIssue = toolClassIssue(inst.Tool, required)
+	return row
+}
+
+// assetRegistryMeasures — меры из реестра объекта: их и реализуют установленные СЗИ
+func assetRegistryMeasures(assetID uint) []models.ControlMeasure {
+	var measures []models.ControlMeasure
+	database.DB.
+		Joins("JOIN asset_measures ON asset_measures.measure_id = control_measures.id AND asset_measures.deleted_at IS NULL").
+		Where("asset_measures.asset_id = ?", assetID).
+		Order("control_measures.regulation asc, control_measures.code asc").
+		Find(&measures)
+	return measures
+}
+
+// ---------- каталог ----------
+
+func bindSecurityToolForm(c *gin.Context, tool *models.SecurityTool) string {
+	tool.Name = strings.TrimSpace(c.PostForm("name"))
+	tool.Vendor = strings.TrimSpace(c.PostForm("vendor"))
+	tool.Type = models.SecurityToolType(c.PostForm("type"))
+	tool.Regulator = models.CertRegulator(c.PostForm("regulator"))
+	tool.CertificateNumber = strings.TrimSpace(c.PostForm("certificate_number"))
+	tool.CryptoClass = strings.TrimSpace(c.PostForm("crypto_class"))
+	tool.Notes = strings.TrimSpace(c.PostForm("notes"))
+
+	if tool.Name == "" || tool.CertificateNumber == "" {
+		return "Укажите наименование СЗИ и номер сертификата"
+	}
+	if !tool.Type.Valid() {
+		return "Некорректный тип СЗИ"
+	}
+	if !tool.Regulator.Valid() {
+		return "Укажите орган, выдавший сертификат"
+	}
+
+	tool.ProtectionClass = 0
+	if s := strings.TrimSpace(c.PostForm("protection_class")); s != "" {
+		class, err := strconv.Atoi(s)
+		if err != nil || class < 1 || class > models.ToolClassLevels {
+			return fmt.Sprintf("Класс защиты — число от 1 до %d", models.ToolClassLevels)
+		}
+		tool.ProtectionClass = class
+	}
+
+	validUntil, err1 := parseFormDate(c.PostForm("certificate_valid_until"))
+	supportUntil, err2 := parseFormDate(c.PostForm("support_until"))
+	if err1 != nil || err2 != nil {
+		return "Некорректная дата"
+	}
+	tool.CertificateValidUntil = validUntil
+	tool.SupportUntil = supportUntil
+
+	var count int64
+	database.DB.Model(&models.SecurityTool{}).
+		Where("certificate_number = ? AND id <> ?", tool.CertificateNumber, tool.ID).
+		Count(&count)
+	if count > 0 {
+		return "СЗИ с сертификатом " + tool.CertificateNumber + " уже есть в каталоге"
+	}
+	return ""
+}
+
+// tool — значения формы нового СЗИ
+func renderSecurityTools(c *gin.Context, status int, role models.UserRole, tool models.SecurityTool, msg string) {
+	query := database.DB.Order("type asc, name asc")
+	filterType := c.Query("type")
+	if filterType != "" {
+		query = query.Where("type = ?", filterType)
+	}
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		like := "%" + strings.ToLower(q) + "%"
+		query = query.Where("LOWER(name) LIKE ? OR LOWER(vendor) LIKE ? OR LOWER(certificate_number) LIKE ?", like, like, like)
+	}
+	var tools []models.SecurityTool
+	query.Find(&tools)
+
+	// число установок по каждому СЗИ
+	type usage struct {
+		ToolID uint
+		Count  int
+	}
+	var usages []usage
+	database.DB.Model(&models.SecurityToolInstallation{}).
+		Select("tool_id, COUNT(*) AS count").
+		Group("tool_id").
+		Scan(&usages)
+	installed := make(map[uint]int, len(usages))
+	for _, u := range usages {
+		installed[u.ToolID] = u.Count
+	}
+
+	render(c, status, "security_tools.html", gin.H{
+		"role":       string(role),
+		"tools":      tools,
+		"installed":  installed,
+		"tool":       tool,
+		"types":      models.SecurityToolTypes,
+		"regulators": models.CertRegulators,
+		"classes":    toolClasses(),
+		"filterType": models.SecurityToolType(filterType),
+		"q":          c.Query("q"),
+		"now":        time.Now(),
+		"error":      msg,
+	})
+}
+
+func toolClasses() []int {
+	classes := make([]int, models.ToolClassLevels)
+	for i := range classes {
+		classes[i] = i + 1
+	}
+	return classes
+}
+
+func ListSecurityTools(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	renderSecurityTools(c, http.StatusOK, role, models.SecurityTool{Regulator: models.RegulatorFSTEC}, "")
+}
+
+func CreateSecurityTool(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	var tool models.SecurityTool
+	if msg := bindSecurityToolForm(c, &tool); msg != "" {
+		renderSecurityTools(c, http.StatusBadRequest, role, tool, msg)
+		return
+	}
+
+	if err := database.DB.Create(&tool).Error; err != nil {
+		renderSecurityTools(c, http.StatusInternalServerError, role, tool, "Ошибка сохранения СЗИ")
+		return
+	}
+
+	sess := sessions.Default(c)
+	if uid, ok := sess.Get("user_id").(uint); ok {
+		database.CreateAuditLog(uid, "security_tool", tool.ID, "create",
+			fmt.Sprintf("СЗИ %s (%s, сертификат %s) добавлено в каталог", tool.Title(), tool.Type.Label(), tool.CertificateNumber))
+	}
+
+	c.Redirect(http.StatusFound, "/security-tools")
+}
+
+func loadSecurityTool(c *gin.Context) (models.SecurityTool, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.String(http.StatusBadRequest, "Некорректный ID")
+		return models.SecurityTool{}, false
+	}
+
+	var tool models.SecurityTool
+	if err := database.DB.First(&tool, id).Error; err != nil {
+		c.String(http.StatusNotFound, "СЗИ не найдено")
+		return models.SecurityTool{}, false
+	}
+	return tool, true
+}
+
+func renderSecurityToolEdit(c *gin.Context, status int, role models.UserRole, tool models.SecurityTool, msg string) {
+	var installations []models.SecurityToolInstallation
+	database.DB.Preload("Asset.Client").Where("tool_id = ?", tool.ID).Order("asset_id asc").Find(&installations)
+
+	render(c, status, "security_tool_edit.html", gin.H{
+		"role":          string(role),
+		"tool":          tool,
+		"installations": installations,
+		"types":         models.SecurityToolTypes,
+		"regulators":    models.CertRegulators,
+		"classes":       toolClasses(),
+		"error":         msg,
+	})
+}
+
+func ShowEditSecurityTool(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	tool, ok := loadSecurityTool(c)
+	if !ok {
+		return
+	}
+
+	renderSecurityToolEdit(c, http.StatusOK, role, tool, "")
+}
+
+func UpdateSecurityTool(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	tool, ok := loadSecurityTool(c)
+	if !ok {
+		return
+	}
+
+	old := tool
+	if msg := bindSecurityToolForm(c, &tool); msg != "" {
+		renderSecurityToolEdit(c, http.StatusBadRequest, role, tool, msg)
+		return
+	}
+
+	if err := database.DB.Save(&tool).Error; err != nil {
+		renderSecurityToolEdit(c, http.StatusInternalServerError, role, tool, "Ошибка сохранения СЗИ")
+		return
+	}
+
+	sess := sessions.Default(c)
+	if uid, ok := sess.Get("user_id").(uint); ok {
+		details := fmt.Sprintf("СЗИ %s: обновлена карточка", tool.Title())
+		if !sameDate(old.CertificateValidUntil, tool.CertificateValidUntil) {
+			details += ", срок действия сертификата: " + formatOptionalDate(old.CertificateValidUntil) +
+				" → " + formatOptionalDate(tool.CertificateValidUntil)
+		}
+		database.CreateAuditLog(uid, "security_tool", tool.ID, "update", details)
+	}
+
+	c.Redirect(http.StatusFound, "/security-tools")
+}
+
+func sameDate(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Equal(*b)
+}
+
+func formatOptionalDate(t *time.Time) string {
+	if t == nil {
+		return "не указан"
+	}
+	return t.Format("02.01.2006")
+}
+
+func DeleteSecurityTool(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	tool, ok := loadSecurityTool(c)
+	if !ok {
+		return
+	}
+
+	var count int64
+	database.DB.Model(&models.SecurityToolInstallation{}).Where("tool_id = ?", tool.ID).Count(&count)
+	if count > 0 {
+		renderSecurityToolEdit(c, http.StatusBadRequest, role, tool, "СЗИ установлено на объектах — сначала удалите установки")
+		return
+	}
+
+	// удаляем физически: иначе уникальный индекс не даст снова завести сертификат
+	if err := database.DB.Unscoped().Delete(&tool).Error; err != nil {
+		c.String(http.StatusInternalServerError, "Ошибка удаления СЗИ")
+		return
+	}
+
+	sess := sessions.Default(c)
+	if uid, ok := sess.Get("user_id").(uint); ok {
+		database.CreateAuditLog(uid, "security_tool", tool.ID, "delete",
+			fmt.Sprintf("СЗИ %s (сертификат %s) удалено из каталога", tool.Title(), tool.CertificateNumber))
+	}
+
+	c.Redirect(http.StatusFound, "/security-tools")
+}
+
+// ---------- установки на объекте ----------
+
+func bindInstallationForm(c *gin.Context, inst *models.SecurityToolInstallation) string {
+	toolID, ok := parseOptionalID(c.PostForm("tool_id"))
+	var tool models.SecurityTool
+	if !ok || toolID == nil || database.DB.First(&tool, *toolID).Error != nil {
+		return "Выберите СЗИ из каталога"
+	}
+
+	componentID, ok := parseOptionalID(c.PostForm("component_id"))
+	if !ok {
+		return "Некорректный компонент"
+	}
+	if componentID != nil {
+		var count int64
+		database.DB.Model(&models.AssetComponent{}).Where("id = ? AND asset_id = ?", *componentID, inst.AssetID).Count(&count)
+		if count == 0 {
+			return "Компонент не относится к объекту"
+		}
+	}
+
+	quantity := 1
+	if s := strings.TrimSpace(c.PostForm("quantity")); s != "" {
+		q, err := strconv.Atoi(s)
+		if err != nil || q < 1 {
+			return "Количество — целое число не меньше 1"
+		}
+		quantity = q
+	}
+
+	installedAt, err := parseFormDate(c.PostForm("installed_at"))
+	if err != nil {
+		return "Некорректная дата установки"
+	}
+
+	// реализуемые меры — только из реестра мер объекта
+	allowed := make(map[string]models.ControlMeasure)
+	for _, m := range assetRegistryMeasures(inst.AssetID) {
+		allowed[strconv.FormatUint(uint64(m.ID), 10)] = m
+	}
+	measures := []models.ControlMeasure{}
+	for _, id := range c.PostFormArray("measure_ids") {
+		m, ok := allowed[id]
+		if !ok {
+			return "Мера не внесена в реестр мер объекта"
+		}
+		measures = append(measures, m)
+	}
+
+	inst.ToolID = tool.ID
+	inst.Tool = tool
+	inst.ComponentID = componentID
+	inst.Quantity = quantity
+	inst.Version = strings.TrimSpace(c.PostForm("version"))
+	inst.InstalledAt = installedAt
+	inst.Notes = strings.TrimSpace(c.PostForm("notes"))
+	inst.Measures = measures
+	return ""
+}
+
+func saveInstallation(inst *models.SecurityToolInstallation) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Asset", "Tool", "Component", "Measures").Save(inst).Error; err != nil {
+			return err
+		}
+		return tx.Model(inst).Association("Measures").Replace(inst.Measures)
+	})
+}
+
+// form — значения формы новой установки
+func renderAssetSecurityTools(c *gin.Context, status int, role models.UserRole, asset models.Asset, form models.SecurityToolInstallation, msg string) {
+	var installations []models.SecurityToolInstallation
+	database.DB.Preload("Tool").Preload("Component").Preload("Measures").
+		Where("asset_id = ?", asset.ID).
+		Order("id asc").
+		Find(&installations)
+
+	required, levelLabel := requiredToolClass(asset)
+	now := time.Now()
+	rows := make([]installationRow, 0, len(installations))
+	issues := 0
+	for _, inst := range installations {
+		row := newInstallationRow(inst, required, now)
+		if row.ClassIssue != "" {
+			issues++
+		}
+		rows = append(rows, row)
+	}
+
+	var tools []models.SecurityTool
+	database.DB.Order("type asc, name asc").Find(&tools)
+
+	h := gin.H{
+		"role":       string(role),
+		"asset":      asset,
+		"rows":       rows,
+		"required":   required,
+		"levelLabel": levelLabel,
+		"issues":     issues,
+		"tools":      tools,
+		"components": assetComponents(asset.ID),
+		"measures":   assetRegistryMeasures(asset.ID),
+		"error":      msg,
+	}
+	for k, v := range installationFormData(form) {
+		h[k] = v
+	}
+	render(c, status, "asset_security_tools.html", h)
+}
+
+// installationFormData — значения формы установки СЗИ для шаблона
+func installationFormData(inst models.SecurityToolInstallation) gin.H {
+	linked := make(map[uint]bool, len(inst.Measures))
+	for _, m := range inst.Measures {
+		linked[m.ID] = true
+	}
+	var componentID uint
+	if inst.ComponentID != nil {
+		componentID = *inst.ComponentID
+	}
+	return gin.H{
+		"installation": inst,
+		"componentID":  componentID,
+		"linked":       linked,
+	}
+}
+
+func ShowAssetSecurityTools(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	asset, ok := loadAssetByParam(c)
+	if !ok {
+		return
+	}
+
+	renderAssetSecurityTools(c, http.StatusOK, role, asset, models.SecurityToolInstallation{AssetID: asset.ID, Quantity: 1}, "")
+}
+
+func AddAssetSecurityTool(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	asset, ok := loadAssetByParam(c)
+	if !ok {
+		return
+	}
+
+	inst := models.SecurityToolInstallation{AssetID: asset.ID}
+	if msg := bindInstallationForm(c, &inst); msg != "" {
+		renderAssetSecurityTools(c, http.StatusBadRequest, role, asset, inst, msg)
+		return
+	}
+
+	if err := saveInstallation(&inst); err != nil {
+		renderAssetSecurityTools(c, http.StatusInternalServerError, role, asset, inst, "Ошибка сохранения установки СЗИ")
+		return
+	}
+
+	sess := sessions.Default(c)
+	if uid, ok := sess.Get("user_id").(uint); ok {
+		database.CreateAuditLog(uid, "security_tool_installation", inst.ID, "create",
+			fmt.Sprintf("Объект %s: установлено СЗИ %s (сертификат %s), %d шт.",
+				asset.Name, inst.Tool.Title(), inst.Tool.CertificateNumber, inst.Quantity))
+	}
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/assets/%d/security-tools", asset.ID))
+}
+
+func loadInstallation(c *gin.Context) (models.SecurityToolInstallation, bool) {
+	assetID, err1 := strconv.Atoi(c.Param("id"))
+	instID, err2 := strconv.Atoi(c.Param("installation_id"))
+	if err1 != nil || err2 != nil || assetID <= 0 || instID <= 0 {
+		c.String(http.StatusBadRequest, "Некорректные параметры")
+		return models.SecurityToolInstallation{}, false
+	}
+
+	var inst models.SecurityToolInstallation
+	if err := database.DB.
+		Preload("Asset.Client").
+		Preload("Tool").
+		Preload("Measures").
+		Where("id = ? AND asset_id = ?", instID, assetID).
+		First(&inst).Error; err != nil {
+		c.String(http.StatusNotFound, "Установка СЗИ не найдена")
+		return models.SecurityToolInstallation{}, false
+	}
+	return inst, true
+}
+
+func renderInstallationEdit(c *gin.Context, status int, role models.UserRole, inst models.SecurityToolInstallation, msg string) {
+	var tools []models.SecurityTool
+	database.DB.Order("type asc, name asc").Find(&tools)
+
+	h := gin.H{
+		"role":       string(role),
+		"tools":      tools,
+		"components": assetComponents(inst.AssetID),
+		"measures":   assetRegistryMeasures(inst.AssetID),
+		"error":      msg,
+	}
+	for k, v := range installationFormData(inst) {
+		h[k] = v
+	}
+	render(c, status, "asset_security_tool_edit.html", h)
+}
+
+func ShowEditAssetSecurityTool(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	inst, ok := loadInstallation(c)
+	if !ok {
+		return
+	}
+
+	renderInstallationEdit(c, http.StatusOK, role, inst, "")
+}
+
+func UpdateAssetSecurityTool(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	inst, ok := loadInstallation(c)
+	if !ok {
+		return
+	}
+
+	if msg := bindInstallationForm(c, &inst); msg != "" {
+		renderInstallationEdit(c, http.StatusBadRequest, role, inst, msg)
+		return
+	}
+
+	if err := saveInstallation(&inst); err != nil {
+		renderInstallationEdit(c, http.StatusInternalServerError, role, inst, "Ошибка сохранения установки СЗИ")
+		return
+	}
+
+	sess := sessions.Default(c)
+	if uid, ok := sess.Get("user_id").(uint); ok {
+		database.CreateAuditLog(uid, "security_tool_installation", inst.ID, "update",
+			fmt.Sprintf("Объект %s: обновлена установка СЗИ %s", inst.Asset.Name, inst.Tool.Title()))
+	}
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/assets/%d/security-tools", inst.AssetID))
+}
+
+func DeleteAssetSecurityTool(c *gin.Context) {
+	if _, ok := requireRiskEditor(c); !ok {
+		return
+	}
+
+	inst, ok := loadInstallation(c)
+	if !ok {
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&inst).Association("Measures").Clear(); err != nil {
+			return err
+		}
+		return tx.Delete(&inst).Error
+	})
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Ошибка удаления установки СЗИ")
+		return
+	}
+
+	sess := sessions.Default(c)
+	if uid, ok := sess.Get("user_id").(uint); ok {
+		database.CreateAuditLog(uid, "security_tool_installation", inst.ID, "delete",
+			fmt.Sprintf("Объект %s: удалена установка СЗИ %s", inst.Asset.Name, inst.Tool.Title()))
+	}
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/assets/%d/security-tools", inst.AssetID))
+}
+
+// ---------- отчёт ----------
+
+// classMismatch — объект, на котором установлены СЗИ ниже требуемого класса
+type classMismatch struct {
+	Asset      models.Asset
+	Required   int
+	LevelLabel string
+	Rows       []installationRow
+}
+
+// SecurityToolReport — установки, сертификат которых истёк или истекает в ближайшие N дней,
+// и объекты, требуемый класс защиты которых выше класса установленных СЗИ
+func SecurityToolReport(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	days := defaultCertificateHorizon
+	if s := c.Query("days"); s != "" {
+		d, err := strconv.Atoi(s)
+		if err != nil || d < 0 || d > 3650 {
+			c.String(http.StatusBadRequest, "Некорректный срок")
+			return
+		}
+		days = d
+	}
+
+	var installations []models.SecurityToolInstallation
+	database.DB.Preload("Asset.Client").Preload("Tool").Preload("Component").
+		Order("asset_id asc, id asc").
+		Find(&installations)
+
+	now := time.Now()
+	type required struct {
+		class int
+		label string
+	}
+	requirements := make(map[uint]required)
+	var expiring []installationRow
+	var mismatches []classMismatch
+	mismatchIndex := make(map[uint]int)
+	for _, inst := range installations {
+		req, ok := requirements[inst.AssetID]
+		if !ok {
+			req.class, req.label = requiredToolClass(inst.Asset)
+			requirements[inst.AssetID] = req
+		}
+
+		row := newInstallationRow(inst, req.class, now)
+		if row.HasExpiry && row.DaysLeft <= days {
+			expiring = append(expiring, row)
+		}
+		if row.ClassIssue != "" {
+			i, ok := mismatchIndex[inst.AssetID]
+			if !ok {
+				mismatches = append(mismatches, classMismatch{Asset: inst.Asset, Required: req.class, LevelLabel: req.label})
+				i = len(mismatches) - 1
+				mismatchIndex[inst.AssetID] = i
+			}
+			mismatches[i].Rows = append(mismatches[i].Rows, row)
+		}
+	}
+	sort.SliceStable(expiring, func(i, j int) bool { return expiring[i].DaysLeft < expiring[j].DaysLeft })
+
+	render(c, http.StatusOK, "security_tools_report.html", gin.H{
+		"role":       string(role),
+		"days":       days,
+		"expiring":   expiring,
+		"mismatches": mismatches,
+	})
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"ib-integrator/internal/bdu"
+	"ib-integrator/internal/database"
+	"ib-integrator/internal/models"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+// ====== ИМПОРТ БДУ ФСТЭК (только admin) ======
+//
+// Шаг 1: загрузка файла -> показ разницы с каталогом.
+// Шаг 2: подтверждение -> запись. Загруженный файл между шагами лежит во временном каталоге,
+// путь к нему хранится в сессии, а не в форме.
+
+const bduImportSessionKey = "bdu_import_file"
+
+func requireAdmin(c *gin.Context) bool {
+	sess := sessions.Default(c)
+	roleStr, _ := sess.Get("role").(string)
+	if models.UserRole(roleStr) != models.RoleAdmin {
+		c.AbortWithStatus(http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+func ShowThreatImport(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	render(c, http.StatusOK, "threats_import.html", gin.H{
+		"role":  string(models.RoleAdmin),
+		"error": "",
+	})
+}
+
+func renderThreatImportError(c *gin.Context, status int, msg string) {
+	render(c, status, "threats_import.html", gin.H{
+		"role":  string(models.RoleAdmin),
+		"error": msg,
+	})
+}
+
+func PreviewThreatImport(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	fh, err := c.FormFile("file")
+	if err != nil {
+		renderThreatImportError(c, http.StatusBadRequest, "Выберите файл выгрузки БДУ")
+		return
+	}
+
+	ext := strings.ToLower(filepath.Ext(fh.Filename))
+	if ext != ".xlsx" && ext != ".xml" {
+		renderThreatImportError(c, http.StatusBadRequest, "Поддерживаются только файлы .xlsx и .xml")
+		return
+	}
+
+	tmp, err := os.CreateTemp("", "bdu-import-*"+ext)
+	if err != nil {
+		renderThreatImportError(c, http.StatusInternalServerError, "Не удалось сохранить файл")
+		return
+	}
+	tmp.Close()
+
+	if err := c.SaveUploadedFile(fh, tmp.Name()); err != nil {
+		os.Remove(tmp.Name())
+		renderThreatImportError(c, http.StatusInternalServerError, "Не удалось сохранить файл")
+		return
+	}
+
+	records, err := bdu.ParseThreatsFile(tmp.Name())
+	if err != nil {
+		os.Remove(tmp.Name())
+		renderThreatImportError(c, http.StatusBadRequest, "Ошибка разбора выгрузки: "+err.Error())
+		return
+	}
+
+	plan, err := bdu.BuildPlan(database.DB, records)
+	if err != nil {
+		os.Remove(tmp.Name())
+		renderThreatImportError(c, http.StatusInternalServerError, "Ошибка сравнения с каталогом")
+		return
+	}
+
+	// предыдущий незавершённый импорт больше не нужен
+	sess := sessions.Default(c)
+	if prev, ok := sess.Get(bduImportSessionKey).(string); ok {
+		removeImportFile(prev)
+	}
+	if plan.Empty() {
+		os.Remove(tmp.Name())
+		sess.Delete(bduImportSessionKey)
+	} else {
+		sess.Set(bduImportSessionKey, tmp.Name())
+	}
+	_ = sess.Save()
+
+	render(c, http.StatusOK, "threats_import.html", gin.H{
+		"role":     string(models.RoleAdmin),
+		"error":    "",
+		"plan":     plan,
+		"filename": fh.Filename,
+		"total":    len(records),
+	})
+}
+
+func ApplyThreatImport(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	sess := sessions.Default(c)
+	path, ok := sess.Get(bduImportSessionKey).(string)
+	if !ok || path == "" {
+		renderThreatImportError(c, http.StatusBadRequest, "Нет загруженной выгрузки — загрузите файл заново")
+		return
+	}
+	sess.Delete(bduImportSessionKey)
+	_ = sess.Save()
+	defer removeImportFile(path)
+
+	records, err := bdu.ParseThreatsFile(path)
+	if err != nil {
+		renderThreatImportError(c, http.StatusBadRequest, "Ошибка разбора выгрузки: "+err.Error())
+		return
+	}
+
+	// план строится заново: каталог мог измениться с момента предпросмотра
+	plan, err := bdu.BuildPlan(database.DB, records)
+	if err != nil {
+		renderThreatImportError(c, http.StatusInternalServerError, "Ошибка сравнения с каталогом")
+		return
+	}
+
+	if err := bdu.ApplyPlan(database.DB, plan); err != nil {
+		renderThreatImportError(c, http.StatusInternalServerError, "Ошибка записи каталога: "+err.Error())
+		return
+	}
+
+	if uid, ok := sess.Get("user_id").(uint); ok {
+		database.CreateAuditLog(uid, "threat", 0, "import", "Импорт БДУ ФСТЭК: "+plan.Summary())
+	}
+
+	c.Redirect(http.StatusFound, "/threats")
+}
+
+// removeImportFile удаляет только собственные временные файлы импорта
+func removeImportFile(path string) {
+	if filepath.Dir(path) != filepath.Clean(os.TempDir()) {
+		return
+	}
+	base := filepath.Base(path)
+	if strings.HasPrefix(base, "bdu-import-") || strings.HasPrefix(base, "scan-import-") {
+		os.Remove(path)
+	}
+}
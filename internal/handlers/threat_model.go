@@ -0,0 +1,537 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"ib-integrator/internal/database"
+	"ib-integrator/internal/docx"
+	"ib-integrator/internal/models"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ====== ДОКУМЕНТ «МОДЕЛЬ УГРОЗ БЕЗОПАСНОСТИ ИНФОРМАЦИИ» ======
+
+// threatModelThreat — актуальная угроза объекта с рекомендованными и применёнными мерами
+type threatModelThreat struct {
+	Link      models.AssetThreat
+	Measures  []models.ControlMeasure
+	Scenarios []models.ThreatScenario
+}
+
+// Justification — обоснование актуальности: комментарий и обоснования вероятности и ущерба
+func (t threatModelThreat) Justification() string {
+	var parts []string
+	if s := strings.TrimSpace(t.Link.Notes); s != "" {
+		parts = append(parts, s)
+	}
+	if s := strings.TrimSpace(t.Link.LikelihoodNotes); s != "" {
+		parts = append(parts, "Вероятность: "+s)
+	}
+	if s := strings.TrimSpace(t.Link.ImpactNotes); s != "" {
+		parts = append(parts, "Ущерб: "+s)
+	}
+	return strings.Join(parts, ". ")
+}
+
+func (t threatModelThreat) MeasureList() string {
+	if len(t.Measures) == 0 {
+		return "—"
+	}
+	items := make([]string, 0, len(t.Measures))
+	for _, m := range t.Measures {
+		items = append(items, m.Code+" "+m.Name)
+	}
+	return strings.Join(items, "; ")
+}
+
+type threatModelAsset struct {
+	Asset     models.Asset
+	Baseline  string // базовый набор мер по классификации, "" — не определён
+	Inventory assetInventory
+	Intruders []models.AssetIntruder
+	Threats   []threatModelThreat
+	Excluded  []models.AssetThreat  // угрозы, признанные неактуальными, с причиной
+	Measures  []models.AssetMeasure // реестр мер объекта
+}
+
+func (a threatModelAsset) HasScenarios() bool {
+	for _, t := range a.Threats {
+		if len(t.Scenarios) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// threatModelData — данные, доступные в шаблоне документа
+type threatModelData struct {
+	Client  models.Client
+	Scope   string
+	Date    string
+	Assets  []threatModelAsset
+	Threats []models.Threat // все актуальные угрозы по коду, для приложения
+}
+
+var threatModelFuncs = template.FuncMap{
+	"inc": func(i int) int { return i + 1 },
+	// cell — значение для ячейки таблицы: без переводов строк и "|"
+	"cell": func(s string) string {
+		return strings.Join(strings.Fields(strings.ReplaceAll(s, "|", "/")), " ")
+	},
+	// components — компоненты угрозы или меры; пусто — объект целиком
+	"components": func(list []models.AssetComponent) string {
+		if len(list) == 0 {
+			return "объект в целом"
+		}
+		names := make([]string, 0, len(list))
+		for _, c := range list {
+			names = append(names, c.Name)
+		}
+		return strings.Join(names, ", ")
+	},
+	"intruderKind":  models.IntruderKindLabel,
+	"intruderLevel": models.IntruderLevelLabel,
+	"riskLabel": func(level string) string {
+		if level == "" {
+			return "не оценён"
+		}
+		return models.RiskLevelLabel(level)
+	},
+}
+
+// buildThreatModel собирает данные документа по объектам защиты
+func buildThreatModel(client models.Client, assets []models.Asset, scope string) threatModelData {
+	data := threatModelData{
+		Client: client,
+		Scope:  scope,
+		Date:   time.Now().Format("02.01.2006"),
+	}
+
+	seen := make(map[uint]bool)
+	for _, asset := range assets {
+		asset.Client = client
+		item := threatModelAsset{Asset: asset, Intruders: assetIntruders(asset.ID), Inventory: loadAssetInventory(asset.ID)}
+		if b, ok := assetBaseline(asset); ok {
+			item.Baseline = b.Label()
+		}
+
+		var links []models.AssetThreat
+		database.DB.Preload("Threat").Preload("ThreatVersion").Preload("Measures").Preload("Components").
+			Where("asset_id = ? AND excluded = ?", asset.ID, false).Order("id asc").Find(&links)
+		// в документ попадает та редакция угрозы, по которой проведена оценка
+		for i := range links {
+			if v := links[i].ThreatVersion; v != nil {
+				v.ApplyTo(&links[i].Threat)
+			}
+		}
+		database.DB.Preload("Threat").
+			Where("asset_id = ? AND excluded = ?", asset.ID, true).Order("id asc").Find(&item.Excluded)
+		threatIDs := make([]uint, 0, len(links))
+		linkIDs := make([]uint, 0, len(links))
+		for _, l := range links {
+			threatIDs = append(threatIDs, l.ThreatID)
+			linkIDs = append(linkIDs, l.ID)
+		}
+		recommended := recommendedMeasures(threatIDs)
+		scenarios := linkScenarios(linkIDs)
+
+		for _, l := range links {
+			t := threatModelThreat{Link: l, Scenarios: scenarios[l.ID]}
+			used := make(map[uint]bool)
+			for _, m := range append(append([]models.ControlMeasure{}, recommended[l.ThreatID]...), l.Measures...) {
+				if !used[m.ID] {
+					used[m.ID] = true
+					t.Measures = append(t.Measures, m)
+				}
+			}
+			item.Threats = append(item.Threats, t)
+
+			if !seen[l.ThreatID] {
+				seen[l.ThreatID] = true
+				data.Threats = append(data.Threats, l.Threat)
+			}
+		}
+
+		database.DB.
+			Preload("Measure").
+			Preload("Components").
+			Joins("JOIN control_measures ON control_measures.id = asset_measures.measure_id").
+			Where("asset_measures.asset_id = ?", asset.ID).
+			Order("control_measures.regulation asc, control_measures.id asc").
+			Find(&item.Measures)
+
+		data.Assets = append(data.Assets, item)
+	}
+
+	sort.Slice(data.Threats, func(i, j int) bool { return data.Threats[i].Code < data.Threats[j].Code })
+	return data
+}
+
+// sampleThreatModel — пример данных для проверки шаблона при сохранении:
+// у каждого списка есть элемент, чтобы выполнились тела range
+func sampleThreatModel() threatModelData {
+	threat := models.Threat{Code: "УБИ.001", Name: "Угроза", Description: "Описание"}
+	measure := models.ControlMeasure{Code: "ИАФ.1", Name: "Мера"}
+	asset := models.Asset{Name: "Система", AssetType: models.AssetISPD, Category: "УЗ-3"}
+	peer := models.Asset{Name: "Смежная система", Client: models.Client{Name: "Клиент"}}
+	segment := models.AssetSegment{Name: "ЛВС", CIDR: "10.0.0.0/24"}
+	server := models.AssetComponent{Kind: models.ComponentServer, Name: "Сервер", Quantity: 1, Segment: &segment}
+	software := models.AssetComponent{Kind: models.ComponentSoftware, Name: "СУБД", Version: "1.0", Quantity: 1, Segment: &segment, Parent: &server}
+	flow := models.DataFlow{Direction: models.FlowOutgoing, Data: "пример", Protocol: "HTTPS", Channel: "Интернет", Protection: "TLS",
+		Asset: asset, Component: &server, PeerAsset: &peer}
+	return threatModelData{
+		Client: models.Client{Name: "Клиент"},
+		Scope:  "пример",
+		Date:   time.Now().Format("02.01.2006"),
+		Assets: []threatModelAsset{{
+			Asset:    asset,
+			Baseline: "пример",
+			Inventory: assetInventory{
+				Segments:   []models.AssetSegment{segment},
+				Components: []models.AssetComponent{server, software},
+				Groups:     []inventorySegment{{Segment: &segment, Components: []models.AssetComponent{server, software}}},
+				Flows:      []models.DataFlow{flow},
+				Incoming:   []models.DataFlow{{Direction: models.FlowIncoming, Data: "пример", Asset: peer}},
+			},
+			Intruders: []models.AssetIntruder{{
+				Level: 2, Justification: "пример",
+				Intruder: models.Intruder{Code: "НР.1", Name: "Нарушитель", Kind: models.IntruderExternal},
+			}},
+			Excluded: []models.AssetThreat{{Threat: threat, Excluded: true, ExclusionReason: "пример"}},
+			Threats: []threatModelThreat{{
+				Link: models.AssetThreat{Threat: threat, RiskLevel: models.RiskHigh, Measures: []models.ControlMeasure{measure},
+					Components: []models.AssetComponent{server}},
+				Measures: []models.ControlMeasure{measure},
+				Scenarios: []models.ThreatScenario{{
+					Name: "Сценарий", Feasibility: models.ScenarioFeasible, Justification: "пример",
+					Steps: []models.ScenarioStep{{Position: 1, Technique: models.Technique{Code: "Т1.1", Name: "Техника"}}},
+				}},
+			}},
+			Measures: []models.AssetMeasure{{Measure: measure, Status: models.MeasurePlanned, Components: []models.AssetComponent{server}}},
+		}},
+		Threats: []models.Threat{threat},
+	}
+}
+
+// executeThreatModel выполняет шаблон и разбирает результат в блоки документа
+func executeThreatModel(body string, data threatModelData) ([]docx.Block, error) {
+	t, err := template.New("threat_model").Funcs(threatModelFuncs).Parse(body)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return docx.Parse(buf.String()), nil
+}
+
+// selectDocumentTemplate — шаблон из ?template=, иначе шаблон по умолчанию
+func selectDocumentTemplate(c *gin.Context, templates []models.DocumentTemplate) (models.DocumentTemplate, bool) {
+	if id, err := strconv.ParseUint(c.Query("template"), 10, 64); err == nil {
+		for _, t := range templates {
+			if uint64(t.ID) == id {
+				return t, true
+			}
+		}
+		return models.DocumentTemplate{}, false
+	}
+	for _, t := range templates {
+		if t.IsDefault {
+			return t, true
+		}
+	}
+	if len(templates) > 0 {
+		return templates[0], true
+	}
+	return models.DocumentTemplate{}, false
+}
+
+// serveThreatModel — HTML-предпросмотр документа; с ?format=docx отдаётся файл
+func serveThreatModel(c *gin.Context, role models.UserRole, data threatModelData, entity string, entityID uint, baseURL string) {
+	var templates []models.DocumentTemplate
+	database.DB.Order("id asc").Find(&templates)
+
+	tpl, ok := selectDocumentTemplate(c, templates)
+	if !ok {
+		c.String(http.StatusBadRequest, "Шаблон документа не найден")
+		return
+	}
+
+	blocks, err := executeThreatModel(tpl.Body, data)
+	status, msg := http.StatusOK, ""
+	if err != nil {
+		status, msg = http.StatusBadRequest, "Ошибка в шаблоне документа: "+err.Error()
+	}
+
+	if c.Query("format") == "docx" && err == nil {
+		var buf bytes.Buffer
+		if err := docx.Write(&buf, blocks, docx.Style{FontFamily: tpl.FontFamily, FontSize: tpl.FontSize}); err != nil {
+			c.String(http.StatusInternalServerError, "Ошибка формирования документа")
+			return
+		}
+
+		sess := sessions.Default(c)
+		if uid, ok := sess.Get("user_id").(uint); ok {
+			database.CreateAuditLog(uid, entity, entityID, "export",
+				fmt.Sprintf("Сформирована модель угроз (%s) по шаблону «%s»", data.Scope, tpl.Name))
+		}
+
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="threat-model-%s-%d.docx"`, entity, entityID))
+		c.Data(http.StatusOK, "application/vnd.openxmlformats-officedocument.wordprocessingml.document", buf.Bytes())
+		return
+	}
+
+	render(c, status, "threat_model_preview.html", gin.H{
+		"role":       string(role),
+		"client":     data.Client,
+		"scope":      data.Scope,
+		"templates":  templates,
+		"templateID": tpl.ID,
+		"template":   tpl,
+		"blocks":     blocks,
+		"baseURL":    baseURL,
+		"error":      msg,
+	})
+}
+
+func ShowClientThreatModel(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.String(http.StatusBadRequest, "Некорректный ID клиента")
+		return
+	}
+
+	var client models.Client
+	if err := database.DB.First(&client, id).Error; err != nil {
+		c.String(http.StatusNotFound, "Клиент не найден")
+		return
+	}
+
+	var assets []models.Asset
+	database.DB.Where("client_id = ?", client.ID).Order("name asc").Find(&assets)
+
+	data := buildThreatModel(client, assets, "все объекты защиты клиента")
+	serveThreatModel(c, role, data, "client", client.ID, fmt.Sprintf("/clients/%d/threat-model", client.ID))
+}
+
+func ShowAssetThreatModel(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	asset, ok := loadAssetByParam(c)
+	if !ok {
+		return
+	}
+
+	data := buildThreatModel(asset.Client, []models.Asset{asset}, fmt.Sprintf("объект защиты «%s»", asset.Name))
+	serveThreatModel(c, role, data, "asset", asset.ID, fmt.Sprintf("/assets/%d/threat-model", asset.ID))
+}
+
+// ====== ШАБЛОНЫ ДОКУМЕНТА ======
+
+func ListDocumentTemplates(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	var templates []models.DocumentTemplate
+	database.DB.Order("id asc").Find(&templates)
+
+	render(c, http.StatusOK, "document_templates.html", gin.H{
+		"role":      string(role),
+		"templates": templates,
+	})
+}
+
+func renderDocumentTemplateForm(c *gin.Context, status int, role models.UserRole, t models.DocumentTemplate, msg string) {
+	render(c, status, "document_template_edit.html", gin.H{
+		"role":     string(role),
+		"template": t,
+		"error":    msg,
+	})
+}
+
+// bindDocumentTemplateForm заполняет шаблон из формы и проверяет его на примере данных
+func bindDocumentTemplateForm(c *gin.Context, t *models.DocumentTemplate) string {
+	t.Name = strings.TrimSpace(c.PostForm("name"))
+	t.Description = strings.TrimSpace(c.PostForm("description"))
+	t.Body = c.PostForm("body")
+	t.FontFamily = strings.TrimSpace(c.PostForm("font_family"))
+	t.IsDefault = c.PostForm("is_default") != ""
+
+	if len([]rune(t.Name)) < 3 {
+		return "Название шаблона должно быть не короче 3 символов"
+	}
+	size, err := strconv.Atoi(c.PostForm("font_size"))
+	if err != nil || size < 8 || size > 28 {
+		return "Кегль должен быть от 8 до 28 пт"
+	}
+	t.FontSize = size
+
+	if strings.TrimSpace(t.Body) == "" {
+		return "Текст шаблона не может быть пустым"
+	}
+	if _, err := executeThreatModel(t.Body, sampleThreatModel()); err != nil {
+		return "Ошибка в шаблоне: " + err.Error()
+	}
+	return ""
+}
+
+// saveDocumentTemplate сохраняет шаблон; шаблон по умолчанию может быть только один
+func saveDocumentTemplate(t *models.DocumentTemplate) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if t.IsDefault {
+			if err := tx.Model(&models.DocumentTemplate{}).
+				Where("id <> ?", t.ID).
+				Update("is_default", false).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Save(t).Error
+	})
+}
+
+func ShowNewDocumentTemplate(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	renderDocumentTemplateForm(c, http.StatusOK, role, models.DocumentTemplate{
+		Body:       database.DefaultThreatModelTemplate,
+		FontFamily: "Times New Roman",
+		FontSize:   14,
+	}, "")
+}
+
+func CreateDocumentTemplate(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	var t models.DocumentTemplate
+	if msg := bindDocumentTemplateForm(c, &t); msg != "" {
+		renderDocumentTemplateForm(c, http.StatusBadRequest, role, t, msg)
+		return
+	}
+
+	if err := saveDocumentTemplate(&t); err != nil {
+		renderDocumentTemplateForm(c, http.StatusInternalServerError, role, t, "Ошибка сохранения шаблона")
+		return
+	}
+
+	sess := sessions.Default(c)
+	if uid, ok := sess.Get("user_id").(uint); ok {
+		database.CreateAuditLog(uid, "document_template", t.ID, "create", "Создан шаблон документа: "+t.Name)
+	}
+
+	c.Redirect(http.StatusFound, "/document-templates")
+}
+
+func loadDocumentTemplate(c *gin.Context) (models.DocumentTemplate, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.String(http.StatusBadRequest, "Некорректный ID шаблона")
+		return models.DocumentTemplate{}, false
+	}
+
+	var t models.DocumentTemplate
+	if err := database.DB.First(&t, id).Error; err != nil {
+		c.String(http.StatusNotFound, "Шаблон не найден")
+		return models.DocumentTemplate{}, false
+	}
+	return t, true
+}
+
+func ShowEditDocumentTemplate(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	t, ok := loadDocumentTemplate(c)
+	if !ok {
+		return
+	}
+
+	renderDocumentTemplateForm(c, http.StatusOK, role, t, "")
+}
+
+func UpdateDocumentTemplate(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	t, ok := loadDocumentTemplate(c)
+	if !ok {
+		return
+	}
+
+	if msg := bindDocumentTemplateForm(c, &t); msg != "" {
+		renderDocumentTemplateForm(c, http.StatusBadRequest, role, t, msg)
+		return
+	}
+
+	if err := saveDocumentTemplate(&t); err != nil {
+		renderDocumentTemplateForm(c, http.StatusInternalServerError, role, t, "Ошибка сохранения шаблона")
+		return
+	}
+
+	sess := sessions.Default(c)
+	if uid, ok := sess.Get("user_id").(uint); ok {
+		database.CreateAuditLog(uid, "document_template", t.ID, "update", "Изменён шаблон документа: "+t.Name)
+	}
+
+	c.Redirect(http.StatusFound, "/document-templates")
+}
+
+func DeleteDocumentTemplate(c *gin.Context) {
+	if _, ok := requireRiskEditor(c); !ok {
+		return
+	}
+
+	t, ok := loadDocumentTemplate(c)
+	if !ok {
+		return
+	}
+
+	var count int64
+	database.DB.Model(&models.DocumentTemplate{}).Count(&count)
+	if count <= 1 {
+		c.String(http.StatusBadRequest, "Нельзя удалить единственный шаблон")
+		return
+	}
+
+	if err := database.DB.Delete(&t).Error; err != nil {
+		c.String(http.StatusInternalServerError, "Ошибка удаления шаблона")
+		return
+	}
+
+	sess := sessions.Default(c)
+	if uid, ok := sess.Get("user_id").(uint); ok {
+		database.CreateAuditLog(uid, "document_template", t.ID, "delete", "Удалён шаблон документа: "+t.Name)
+	}
+
+	c.Redirect(http.StatusFound, "/document-templates")
+}
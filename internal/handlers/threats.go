@@ -1,289 +1,786 @@
-package handlers
-
-import (
-	"net/http"
-	"strconv"
-	"strings"
-
-	"ib-integrator/internal/database"
-	"ib-integrator/internal/models"
-
-	"github.com/gin-contrib/sessions"
-	"github.com/gin-gonic/gin"
-)
-
-// ====== ДОСТУП К РИСКАМ (УГРОЗАМ / МЕРАМ) ======
-
-func requireRiskEditor(c *gin.Context) (models.UserRole, bool) {
-	sess := sessions.Default(c)
-	roleStr, _ := sess.Get("role").(string)
-	role := models.UserRole(roleStr)
-
-	if role != models.RoleAdmin && role != models.RoleEngineer {
-		c.AbortWithStatus(http.StatusForbidden)
-		return role, false
-	}
-	return role, true
-}
-
-// ====== КАТАЛОГ УГРОЗ И МЕР ======
-
-func ListThreatsAndMeasures(c *gin.Context) {
-	role, ok := requireRiskEditor(c)
-	if !ok {
-		return
-	}
-
-	var threats []models.Threat
-	var measures []models.ControlMeasure
-	var links []models.ThreatMeasure
-
-	database.DB.Order("code asc").Find(&threats)
-	database.DB.Order("code asc").Find(&measures)
-	database.DB.Preload("Measure").Order("threat_id asc, measure_id asc").Find(&links)
-
-	// строим карту: threatID -> []ControlMeasure
-	rec := make(map[uint][]models.ControlMeasure)
-	for _, l := range links {
-		if l.Measure.ID == 0 {
-			continue
-		}
-		rec[l.ThreatID] = append(rec[l.ThreatID], l.Measure)
-	}
-
-	render(c, http.StatusOK, "threats_list.html", gin.H{
-		"role":        string(role),
-		"threats":     threats,
-		"measures":    measures,
-		"RecMeasures": rec,
-	})
-}
-
-
-// --- Угрозы: создание
-
-func ShowNewThreat(c *gin.Context) {
-	_, ok := requireRiskEditor(c)
-	if !ok {
-		return
-	}
-
-	render(c, http.StatusOK, "threats_new.html", gin.H{
-		"error": "",
-	})
-}
-
-func CreateThreat(c *gin.Context) {
-	_, ok := requireRiskEditor(c)
-	if !ok {
-		return
-	}
-
-	code := strings.TrimSpace(c.PostForm("code"))
-	name := strings.TrimSpace(c.PostForm("name"))
-	category := strings.TrimSpace(c.PostForm("category"))
-	desc := strings.TrimSpace(c.PostForm("description"))
-
-	if len(name) < 3 {
-		render(c, http.StatusBadRequest, "threats_new.html", gin.H{
-			"error": "Название угрозы должно быть не короче 3 символов",
-		})
-		return
-	}
-
-	th := models.Threat{
-		Code:        code,
-		Name:        name,
-		Category:    category,
-		Description: desc,
-	}
-
-	if err := database.DB.Create(&th).Error; err != nil {
-		render(c, http.StatusBadRequest, "threats_new.html", gin.H{
-			"error": "Ошибка сохранения угрозы в БД",
-		})
-		return
-	}
-
-	c.Redirect(http.StatusFound, "/threats")
-}
-
-// --- Меры: создание
-
-func ShowNewMeasure(c *gin.Context) {
-	_, ok := requireRiskEditor(c)
-	if !ok {
-		return
-	}
-
-	render(c, http.StatusOK, "measures_new.html", gin.H{
-		"error": "",
-	})
-}
-
-func CreateMeasure(c *gin.Context) {
-	_, ok := requireRiskEditor(c)
-	if !ok {
-		return
-	}
-
-	code := strings.TrimSpace(c.PostForm("code"))
-	name := strings.TrimSpace(c.PostForm("name"))
-	standard := strings.TrimSpace(c.PostForm("standard"))
-	desc := strings.TrimSpace(c.PostForm("description"))
-
-	if len(name) < 3 {
-		render(c, http.StatusBadRequest, "measures_new.html", gin.H{
-			"error": "Название меры защиты должно быть не короче 3 символов",
-		})
-		return
-	}
-
-	m := models.ControlMeasure{
-		Code:        code,
-		Name:        name,
-		Standard:    standard,
-		Description: desc,
-	}
-
-	if err := database.DB.Create(&m).Error; err != nil {
-		render(c, http.StatusBadRequest, "measures_new.html", gin.H{
-			"error": "Ошибка сохранения меры защиты в БД",
-		})
-		return
-	}
-
-	c.Redirect(http.StatusFound, "/threats")
-}
-
-// ====== УГРОЗЫ КОНКРЕТНОГО ОБЪЕКТА ЗАЩИТЫ ======
-
-func ShowAssetThreats(c *gin.Context) {
-	role, ok := requireRiskEditor(c)
-	if !ok {
-		return
-	}
-
-	idStr := c.Param("id")
-	assetID, err := strconv.Atoi(idStr)
-	if err != nil || assetID <= 0 {
-		c.String(http.StatusBadRequest, "Некорректный ID объекта защиты")
-		return
-	}
-
-	var asset models.Asset
-	if err := database.DB.Preload("Client").First(&asset, assetID).Error; err != nil {
-		c.String(http.StatusNotFound, "Объект защиты не найден")
-		return
-	}
-
-	var links []models.AssetThreat
-	database.DB.
-		Preload("Threat").
-		Where("asset_id = ?", asset.ID).
-		Order("id asc").
-		Find(&links)
-
-	// Каталог угроз, которые ещё не привязаны к этому объекту
-	var usedIDs []uint
-	for _, l := range links {
-		usedIDs = append(usedIDs, l.ThreatID)
-	}
-
-	thQuery := database.DB.Order("code asc")
-	if len(usedIDs) > 0 {
-		thQuery = thQuery.Where("id NOT IN ?", usedIDs)
-	}
-
-	var threats []models.Threat
-	thQuery.Find(&threats)
-
-	render(c, http.StatusOK, "asset_threats.html", gin.H{
-		"role":   string(role),
-		"asset":  asset,
-		"links":  links,
-		"threats": threats,
-	})
-}
-
-func AddAssetThreat(c *gin.Context) {
-	_, ok := requireRiskEditor(c)
-	if !ok {
-		return
-	}
-
-	idStr := c.Param("id")
-	assetID, err := strconv.Atoi(idStr)
-	if err != nil || assetID <= 0 {
-		c.String(http.StatusBadRequest, "Некорректный ID объекта защиты")
-		return
-	}
-
-	threatIDStr := c.PostForm("threat_id")
-	risk := strings.TrimSpace(c.PostForm("risk_level"))
-	notes := strings.TrimSpace(c.PostForm("notes"))
-
-	tid, err := strconv.Atoi(threatIDStr)
-	if err != nil || tid <= 0 {
-		c.String(http.StatusBadRequest, "Некорректный ID угрозы")
-		return
-	}
-
-	// Проверка уровня риска
-	switch risk {
-	case "low", "medium", "high":
-	default:
-		c.String(http.StatusBadRequest, "Некорректный уровень риска")
-		return
-	}
-
-	// Проверка отсутствия дубликата
-	var count int64
-	database.DB.Model(&models.AssetThreat{}).
-		Where("asset_id = ? AND threat_id = ?", assetID, tid).
-		Count(&count)
-	if count > 0 {
-		c.String(http.StatusBadRequest, "Эта угроза уже привязана к объекту")
-		return
-	}
-
-	link := models.AssetThreat{
-		AssetID:   uint(assetID),
-		ThreatID:  uint(tid),
-		RiskLevel: risk,
-		Notes:     notes,
-	}
-
-	if err := database.DB.Create(&link).Error; err != nil {
-		c.String(http.StatusInternalServerError, "Ошибка сохранения угрозы для объекта")
-		return
-	}
-
-	c.Redirect(http.StatusFound, "/assets/"+idStr+"/threats")
-}
-
-func DeleteAssetThreat(c *gin.Context) {
-	_, ok := requireRiskEditor(c)
-	if !ok {
-		return
-	}
-
-	assetIDStr := c.Param("id")
-	linkIDStr := c.Param("link_id")
-
-	assetID, err1 := strconv.Atoi(assetIDStr)
-	linkID, err2 := strconv.Atoi(linkIDStr)
-
-	if err1 != nil || err2 != nil || assetID <= 0 || linkID <= 0 {
-		c.String(http.StatusBadRequest, "Некорректные параметры")
-		return
-	}
-
-	if err := database.DB.Where("id = ? AND asset_id = ?", linkID, assetID).
-		Delete(&models.AssetThreat{}).Error; err != nil {
-		c.String(http.StatusInternalServerError, "Ошибка удаления связи угрозы")
-		return
-	}
-
-	c.Redirect(http.StatusFound, "/assets/"+assetIDStr+"/threats")
-}
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"ib-integrator/internal/catalog"
+	"ib-integrator/internal/database"
+	"ib-integrator/internal/models"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ====== ДОСТУП К РИСКАМ (УГРОЗАМ / МЕРАМ) ======
+
+func requireRiskEditor(c *gin.Context) (models.UserRole, bool) {
+	sess := sessions.Default(c)
+	roleStr, _ := sess.Get("role").(string)
+	role := models.UserRole(roleStr)
+
+	if role != models.RoleAdmin && role != models.RoleEngineer {
+		c.AbortWithStatus(http.StatusForbidden)
+		return role, false
+	}
+	return role, true
+}
+
+// ====== КАТАЛОГ УГРОЗ И МЕР ======
+
+func ListThreatsAndMeasures(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	var threats []models.Threat
+	var measures []models.ControlMeasure
+	var links []models.ThreatMeasure
+
+	database.DB.Order("code asc").Find(&threats)
+	// меры приказов ФСТЭК выводятся в каталоге базовых наборов
+	database.DB.Where("regulation = ?", "").Order("code asc").Find(&measures)
+	database.DB.Preload("Measure").Order("threat_id asc, measure_id asc").Find(&links)
+
+	// строим карту: threatID -> []ControlMeasure
+	rec := make(map[uint][]models.ControlMeasure)
+	for _, l := range links {
+		if l.Measure.ID == 0 {
+			continue
+		}
+		rec[l.ThreatID] = append(rec[l.ThreatID], l.Measure)
+	}
+
+	// записи с неопубликованным черновиком следующей версии
+	var ids []uint
+	draftThreats := make(map[uint]bool)
+	database.DB.Model(&models.ThreatVersion{}).Where("status = ?", models.CatalogDraft).Pluck("threat_id", &ids)
+	for _, id := range ids {
+		draftThreats[id] = true
+	}
+	ids = nil
+	draftMeasures := make(map[uint]bool)
+	database.DB.Model(&models.MeasureVersion{}).Where("status = ?", models.CatalogDraft).Pluck("measure_id", &ids)
+	for _, id := range ids {
+		draftMeasures[id] = true
+	}
+
+	// справочник тактик и техник для построения сценариев
+	tactics, _ := database.Tactics()
+
+	render(c, http.StatusOK, "threats_list.html", gin.H{
+		"role":          string(role),
+		"threats":       threats,
+		"measures":      measures,
+		"RecMeasures":   rec,
+		"tactics":       tactics,
+		"attack":        attackCodesByThreat(),
+		"draftThreats":  draftThreats,
+		"draftMeasures": draftMeasures,
+	})
+}
+
+
+// --- Угрозы: создание
+
+func ShowNewThreat(c *gin.Context) {
+	_, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	render(c, http.StatusOK, "threats_new.html", gin.H{
+		"error": "",
+	})
+}
+
+func CreateThreat(c *gin.Context) {
+	_, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	in := threatInput{
+		Code:        strings.TrimSpace(c.PostForm("code")),
+		Name:        strings.TrimSpace(c.PostForm("name")),
+		Category:    strings.TrimSpace(c.PostForm("category")),
+		Description: strings.TrimSpace(c.PostForm("description")),
+		Publish:     c.PostForm("publish") == "1",
+	}
+	if msg := validateThreat(in); msg != "" {
+		render(c, http.StatusBadRequest, "threats_new.html", gin.H{
+			"error": msg,
+		})
+		return
+	}
+
+	if _, err := createThreat(in, currentUserID(c)); err != nil {
+		render(c, http.StatusBadRequest, "threats_new.html", gin.H{
+			"error": "Ошибка сохранения угрозы в БД",
+		})
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/threats")
+}
+
+// threatInput — новая угроза каталога из формы или из запроса к API
+type threatInput struct {
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	Category    string `json:"category"`
+	Description string `json:"description"`
+	Publish     bool   `json:"publish"` // сразу опубликовать версию 1
+}
+
+func validateThreat(in threatInput) string {
+	if len(in.Name) < 3 {
+		return "Название угрозы должно быть не короче 3 символов"
+	}
+	if in.Code != "" {
+		var count int64
+		database.DB.Unscoped().Model(&models.Threat{}).Where("code = ?", in.Code).Count(&count)
+		if count > 0 {
+			return "Угроза с таким кодом уже есть в каталоге"
+		}
+	}
+	return ""
+}
+
+// createThreat — новая угроза заводится черновиком версии 1 и не видна при оценке объектов до публикации
+func createThreat(in threatInput, uid *uint) (models.Threat, error) {
+	th := models.Threat{
+		Code:        in.Code,
+		Name:        in.Name,
+		Category:    in.Category,
+		Description: in.Description,
+		Status:      models.CatalogDraft,
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&th).Error; err != nil {
+			return err
+		}
+		v, err := catalog.SaveThreatDraft(tx, th, models.NewThreatVersion(th), uid)
+		if err != nil || !in.Publish {
+			return err
+		}
+		return catalog.PublishThreat(tx, &th, v)
+	})
+	if err != nil {
+		return th, err
+	}
+
+	if uid != nil {
+		database.CreateAuditLog(*uid, "threat", th.ID, "create", "Добавлена угроза "+th.Code+" "+th.Name)
+	}
+	return th, nil
+}
+
+// --- Меры: создание
+
+func ShowNewMeasure(c *gin.Context) {
+	_, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	render(c, http.StatusOK, "measures_new.html", gin.H{
+		"error": "",
+	})
+}
+
+func CreateMeasure(c *gin.Context) {
+	_, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	in := measureInput{
+		Code:        strings.TrimSpace(c.PostForm("code")),
+		Name:        strings.TrimSpace(c.PostForm("name")),
+		Standard:    strings.TrimSpace(c.PostForm("standard")),
+		Description: strings.TrimSpace(c.PostForm("description")),
+		Publish:     c.PostForm("publish") == "1",
+	}
+	if msg := validateMeasure(in); msg != "" {
+		render(c, http.StatusBadRequest, "measures_new.html", gin.H{
+			"error": msg,
+		})
+		return
+	}
+
+	if _, err := createMeasure(in, currentUserID(c)); err != nil {
+		render(c, http.StatusBadRequest, "measures_new.html", gin.H{
+			"error": "Ошибка сохранения меры защиты в БД",
+		})
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/threats")
+}
+
+// measureInput — новая собственная мера каталога из формы или из запроса к API
+type measureInput struct {
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	Standard    string `json:"standard"`
+	Description string `json:"description"`
+	Publish     bool   `json:"publish"`
+}
+
+func validateMeasure(in measureInput) string {
+	if len(in.Name) < 3 {
+		return "Название меры защиты должно быть не короче 3 символов"
+	}
+	if in.Code != "" {
+		var count int64
+		database.DB.Unscoped().Model(&models.ControlMeasure{}).Where("regulation = ? AND code = ?", "", in.Code).Count(&count)
+		if count > 0 {
+			return "Мера с таким кодом уже есть в каталоге"
+		}
+	}
+	return ""
+}
+
+func createMeasure(in measureInput, uid *uint) (models.ControlMeasure, error) {
+	m := models.ControlMeasure{
+		Code:        in.Code,
+		Name:        in.Name,
+		Standard:    in.Standard,
+		Description: in.Description,
+		Status:      models.CatalogDraft,
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&m).Error; err != nil {
+			return err
+		}
+		v, err := catalog.SaveMeasureDraft(tx, m, models.NewMeasureVersion(m), uid)
+		if err != nil || !in.Publish {
+			return err
+		}
+		return catalog.PublishMeasure(tx, &m, v)
+	})
+	if err != nil {
+		return m, err
+	}
+
+	if uid != nil {
+		database.CreateAuditLog(*uid, "measure", m.ID, "create", "Добавлена мера защиты "+m.Code+" "+m.Name)
+	}
+	return m, nil
+}
+
+// ====== УГРОЗЫ КОНКРЕТНОГО ОБЪЕКТА ЗАЩИТЫ ======
+
+func ShowAssetThreats(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	idStr := c.Param("id")
+	assetID, err := strconv.Atoi(idStr)
+	if err != nil || assetID <= 0 {
+		c.String(http.StatusBadRequest, "Некорректный ID объекта защиты")
+		return
+	}
+
+	var asset models.Asset
+	if err := database.DB.Preload("Client").First(&asset, assetID).Error; err != nil {
+		c.String(http.StatusNotFound, "Объект защиты не найден")
+		return
+	}
+
+	matrix, err := database.LoadRiskMatrix()
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Матрица рисков не настроена")
+		return
+	}
+
+	var links []models.AssetThreat
+	database.DB.
+		Preload("Threat").
+		Preload("ThreatVersion").
+		Preload("Measures").
+		Preload("Components").
+		Where("asset_id = ?", asset.ID).
+		Order("id asc").
+		Find(&links)
+
+	// Каталог угроз, которые ещё не привязаны к этому объекту
+	var usedIDs []uint
+	for _, l := range links {
+		usedIDs = append(usedIDs, l.ThreatID)
+	}
+
+	// исключённые из БДУ, черновики и выведенные из каталога угрозы заново не привязываем
+	thQuery := database.DB.Where("withdrawn = ? AND status = ?", false, models.CatalogPublished).Order("code asc")
+	if len(usedIDs) > 0 {
+		thQuery = thQuery.Where("id NOT IN ?", usedIDs)
+	}
+
+	var threats []models.Threat
+	thQuery.Find(&threats)
+
+	// неактуальные угрозы в оценке риска не участвуют
+	var active, excluded []models.AssetThreat
+	for _, l := range links {
+		if l.Excluded {
+			excluded = append(excluded, l)
+		} else {
+			active = append(active, l)
+		}
+	}
+	relevance := assetThreatRelevance(assetIntruders(asset.ID), active)
+	activeIDs := make([]uint, 0, len(active))
+	for _, l := range active {
+		activeIDs = append(activeIDs, l.ID)
+	}
+
+	// тепловые карты: по объекту и по всем объектам клиента
+	var clientLinks []models.AssetThreat
+	database.DB.
+		Joins("JOIN assets ON assets.id = asset_threats.asset_id AND assets.deleted_at IS NULL").
+		Where("assets.client_id = ? AND asset_threats.excluded = ?", asset.ClientID, false).
+		Find(&clientLinks)
+
+	// покрытие угроз мерами из реестра объекта
+	coverage := assetThreatCoverage(asset.ID, active)
+	unmitigated := 0
+	for _, tc := range coverage {
+		if !tc.Mitigated() {
+			unmitigated++
+		}
+	}
+
+	assetInherent, assetResidual := assetRiskHeatMaps(matrix, "Объект", active)
+	clientInherent, clientResidual := assetRiskHeatMaps(matrix, "Клиент", clientLinks)
+
+	render(c, http.StatusOK, "asset_threats.html", gin.H{
+		"role":            string(role),
+		"asset":           asset,
+		"links":           active,
+		"excluded":        excluded,
+		"relevance":       relevance,
+		"scenarios":       linkScenarios(activeIDs),
+		"threats":         threats,
+		"matrix":          matrix,
+		"likelihoodScale": likelihoodScale(matrix),
+		"impactScale":     impactScale(matrix),
+		"heatMaps":        []heatMap{assetInherent, assetResidual, clientInherent, clientResidual},
+		"coverage":        coverage,
+		"unmitigated":     unmitigated,
+		"criticalVulns":   assetOpenCriticalVulnerabilities(asset.ID),
+		"inventory":       loadAssetInventory(asset.ID),
+	})
+}
+
+func AddAssetThreat(c *gin.Context) {
+	_, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	idStr := c.Param("id")
+	assetID, err := strconv.Atoi(idStr)
+	if err != nil || assetID <= 0 {
+		c.String(http.StatusBadRequest, "Некорректный ID объекта защиты")
+		return
+	}
+
+	tid, err := strconv.Atoi(c.PostForm("threat_id"))
+	if err != nil || tid <= 0 {
+		c.String(http.StatusBadRequest, "Некорректный ID угрозы")
+		return
+	}
+
+	matrix, err := database.LoadRiskMatrix()
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Матрица рисков не настроена")
+		return
+	}
+
+	// Вероятность и ущерб — по шкалам матрицы, уровень риска выводится из неё
+	likelihood, ok1 := parseRiskScore(c.PostForm("likelihood"), matrix.LikelihoodScale, false)
+	impact, ok2 := parseRiskScore(c.PostForm("impact"), matrix.ImpactScale, false)
+	if !ok1 || !ok2 {
+		c.String(http.StatusBadRequest, "Некорректная оценка вероятности или ущерба")
+		return
+	}
+
+	in := assetThreatInput{
+		ThreatID:        uint(tid),
+		Likelihood:      likelihood,
+		Impact:          impact,
+		LikelihoodNotes: strings.TrimSpace(c.PostForm("likelihood_notes")),
+		ImpactNotes:     strings.TrimSpace(c.PostForm("impact_notes")),
+		Notes:           strings.TrimSpace(c.PostForm("notes")),
+	}
+	uid, _ := sessions.Default(c).Get("user_id").(uint)
+	if _, msg, err := addAssetThreat(uint(assetID), in, matrix, uid); msg != "" {
+		c.String(http.StatusBadRequest, msg)
+		return
+	} else if err != nil {
+		c.String(http.StatusInternalServerError, "Ошибка сохранения угрозы для объекта")
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/assets/"+idStr+"/threats")
+}
+
+// ====== ОЦЕНКА РИСКА ПО УГРОЗЕ ОБЪЕКТА ======
+
+func loadAssetThreatLink(c *gin.Context) (models.AssetThreat, bool) {
+	assetID, err1 := strconv.Atoi(c.Param("id"))
+	linkID, err2 := strconv.Atoi(c.Param("link_id"))
+	if err1 != nil || err2 != nil || assetID <= 0 || linkID <= 0 {
+		c.String(http.StatusBadRequest, "Некорректные параметры")
+		return models.AssetThreat{}, false
+	}
+
+	var link models.AssetThreat
+	if err := database.DB.
+		Preload("Asset.Client").
+		Preload("Threat").
+		Preload("ThreatVersion").
+		Preload("Measures").
+		Preload("Components").
+		Where("id = ? AND asset_id = ?", linkID, assetID).
+		First(&link).Error; err != nil {
+		c.String(http.StatusNotFound, "Угроза объекта не найдена")
+		return models.AssetThreat{}, false
+	}
+	return link, true
+}
+
+func renderAssetThreatEdit(c *gin.Context, status int, role models.UserRole, link models.AssetThreat, matrix *models.RiskMatrix, msg string) {
+	// собственные меры, меры из реестра объекта и уже отмеченные применёнными
+	var usedIDs []uint
+	database.DB.Model(&models.AssetMeasure{}).Where("asset_id = ?", link.AssetID).Pluck("measure_id", &usedIDs)
+	for _, m := range link.Measures {
+		usedIDs = append(usedIDs, m.ID)
+	}
+	mQuery := database.DB.Order("regulation asc, code asc")
+	if len(usedIDs) > 0 {
+		mQuery = mQuery.Where("(regulation = ? AND status = ?) OR id IN ?", "", models.CatalogPublished, usedIDs)
+	} else {
+		mQuery = mQuery.Where("regulation = ? AND status = ?", "", models.CatalogPublished)
+	}
+	var measures []models.ControlMeasure
+	mQuery.Find(&measures)
+
+	// рекомендованные для угрозы меры подсвечиваются
+	var recLinks []models.ThreatMeasure
+	database.DB.Where("threat_id = ?", link.ThreatID).Find(&recLinks)
+	recommended := make(map[uint]bool)
+	for _, r := range recLinks {
+		recommended[r.MeasureID] = true
+	}
+
+	applied := make(map[uint]bool)
+	for _, m := range link.Measures {
+		applied[m.ID] = true
+	}
+
+	render(c, status, "asset_threat_edit.html", gin.H{
+		"role":            string(role),
+		"link":            link,
+		"scenarios":       linkScenarios([]uint{link.ID})[link.ID],
+		"scenarioForm":    newScenarioForm(fmt.Sprintf("/assets/%d/threats/%d/scenarios", link.AssetID, link.ID), models.ThreatScenario{}),
+		"measures":        measures,
+		"recommended":     recommended,
+		"applied":         applied,
+		"components":      assetComponents(link.AssetID),
+		"inScope":         componentSet(link.Components),
+		"likelihoodScale": likelihoodScale(matrix),
+		"impactScale":     impactScale(matrix),
+		"error":           msg,
+	})
+}
+
+func ShowEditAssetThreat(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	link, ok := loadAssetThreatLink(c)
+	if !ok {
+		return
+	}
+
+	matrix, err := database.LoadRiskMatrix()
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Матрица рисков не настроена")
+		return
+	}
+
+	renderAssetThreatEdit(c, http.StatusOK, role, link, matrix, "")
+}
+
+func UpdateAssetThreat(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	link, ok := loadAssetThreatLink(c)
+	if !ok {
+		return
+	}
+
+	matrix, err := database.LoadRiskMatrix()
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Матрица рисков не настроена")
+		return
+	}
+
+	likelihood, ok1 := parseRiskScore(c.PostForm("likelihood"), matrix.LikelihoodScale, false)
+	impact, ok2 := parseRiskScore(c.PostForm("impact"), matrix.ImpactScale, false)
+	resLikelihood, ok3 := parseRiskScore(c.PostForm("residual_likelihood"), matrix.LikelihoodScale, false)
+	resImpact, ok4 := parseRiskScore(c.PostForm("residual_impact"), matrix.ImpactScale, false)
+	if !ok1 || !ok2 || !ok3 || !ok4 {
+		renderAssetThreatEdit(c, http.StatusBadRequest, role, link, matrix, "Некорректная оценка вероятности или ущерба")
+		return
+	}
+
+	in := assetThreatInput{
+		Likelihood:         likelihood,
+		Impact:             impact,
+		LikelihoodNotes:    strings.TrimSpace(c.PostForm("likelihood_notes")),
+		ImpactNotes:        strings.TrimSpace(c.PostForm("impact_notes")),
+		ResidualLikelihood: resLikelihood,
+		ResidualImpact:     resImpact,
+		ResidualNotes:      strings.TrimSpace(c.PostForm("residual_notes")),
+		Notes:              strings.TrimSpace(c.PostForm("notes")),
+		MeasureIDs:         parseIDList(c.PostFormArray("measure_ids")),
+		ComponentIDs:       parseIDList(c.PostFormArray("component_ids")),
+	}
+	if len(in.ComponentIDs) != len(c.PostFormArray("component_ids")) {
+		renderAssetThreatEdit(c, http.StatusBadRequest, role, link, matrix, "Компонент не относится к объекту")
+		return
+	}
+
+	uid, _ := sessions.Default(c).Get("user_id").(uint)
+	if msg, err := updateAssetThreat(&link, in, matrix, uid); msg != "" {
+		renderAssetThreatEdit(c, http.StatusBadRequest, role, link, matrix, msg)
+		return
+	} else if err != nil {
+		renderAssetThreatEdit(c, http.StatusInternalServerError, role, link, matrix, "Ошибка сохранения оценки риска")
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/assets/"+c.Param("id")+"/threats")
+}
+
+func DeleteAssetThreat(c *gin.Context) {
+	_, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	assetIDStr := c.Param("id")
+	linkIDStr := c.Param("link_id")
+
+	assetID, err1 := strconv.Atoi(assetIDStr)
+	linkID, err2 := strconv.Atoi(linkIDStr)
+
+	if err1 != nil || err2 != nil || assetID <= 0 || linkID <= 0 {
+		c.String(http.StatusBadRequest, "Некорректные параметры")
+		return
+	}
+
+	var link models.AssetThreat
+	if err := database.DB.Where("id = ? AND asset_id = ?", linkID, assetID).First(&link).Error; err != nil {
+		c.String(http.StatusNotFound, "Угроза объекта не найдена")
+		return
+	}
+
+	uid, _ := sessions.Default(c).Get("user_id").(uint)
+	if err := deleteAssetThreat(link, uid); err != nil {
+		c.String(http.StatusInternalServerError, "Ошибка удаления связи угрозы")
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/assets/"+assetIDStr+"/threats")
+}
+
+// ====== ОБЩЕЕ ДЛЯ ФОРМ И API: УГРОЗЫ ОБЪЕКТА ======
+
+// assetThreatInput — оценка угрозы объекта из формы или из запроса к API.
+// ThreatID задаётся только при привязке угрозы.
+type assetThreatInput struct {
+	ThreatID           uint   `json:"threat_id"`
+	Likelihood         int    `json:"likelihood"`
+	Impact             int    `json:"impact"`
+	LikelihoodNotes    string `json:"likelihood_notes"`
+	ImpactNotes        string `json:"impact_notes"`
+	ResidualLikelihood int    `json:"residual_likelihood"`
+	ResidualImpact     int    `json:"residual_impact"`
+	ResidualNotes      string `json:"residual_notes"`
+	Notes              string `json:"notes"`
+	MeasureIDs         []uint `json:"measure_ids"`
+	ComponentIDs       []uint `json:"component_ids"`
+}
+
+// parseIDList — ID из формы; некорректные значения отбрасываются
+func parseIDList(raw []string) []uint {
+	ids := make([]uint, 0, len(raw))
+	for _, s := range raw {
+		if id, err := strconv.ParseUint(strings.TrimSpace(s), 10, 64); err == nil && id > 0 {
+			ids = append(ids, uint(id))
+		}
+	}
+	return ids
+}
+
+// addAssetThreat привязывает опубликованную угрозу к объекту; msg — ошибка в данных
+func addAssetThreat(assetID uint, in assetThreatInput, matrix *models.RiskMatrix, uid uint) (models.AssetThreat, string, error) {
+	if !matrix.InScale(in.Likelihood, in.Impact) {
+		return models.AssetThreat{}, "Некорректная оценка вероятности или ущерба", nil
+	}
+
+	var th models.Threat
+	if err := database.DB.Where("id = ? AND status = ?", in.ThreatID, models.CatalogPublished).First(&th).Error; err != nil {
+		return models.AssetThreat{}, "Угроза не найдена в действующем каталоге", nil
+	}
+	version, err := catalog.CurrentThreatVersion(database.DB, th)
+	if err != nil {
+		return models.AssetThreat{}, "", err
+	}
+
+	// Проверка отсутствия дубликата
+	var count int64
+	database.DB.Model(&models.AssetThreat{}).
+		Where("asset_id = ? AND threat_id = ?", assetID, th.ID).
+		Count(&count)
+	if count > 0 {
+		return models.AssetThreat{}, "Эта угроза уже привязана к объекту", nil
+	}
+
+	// пока меры не применены, остаточный риск равен исходному
+	level := matrix.Level(in.Likelihood, in.Impact)
+	link := models.AssetThreat{
+		AssetID:            assetID,
+		ThreatID:           th.ID,
+		ThreatVersionID:    &version.ID,
+		Likelihood:         in.Likelihood,
+		Impact:             in.Impact,
+		LikelihoodNotes:    in.LikelihoodNotes,
+		ImpactNotes:        in.ImpactNotes,
+		RiskLevel:          level,
+		ResidualLikelihood: in.Likelihood,
+		ResidualImpact:     in.Impact,
+		ResidualLevel:      level,
+		Notes:              in.Notes,
+	}
+	if err := database.DB.Create(&link).Error; err != nil {
+		return link, "", err
+	}
+
+	if uid != 0 {
+		database.CreateAuditLog(uid, "asset", assetID, "threat_add",
+			fmt.Sprintf("Привязана угроза %s, риск %s", th.Code, models.RiskLevelLabel(level)))
+	}
+	link.Threat = th
+	return link, "", nil
+}
+
+// updateAssetThreat — повторная оценка исходного и остаточного риска, применённые меры
+// и компоненты, к которым относится угроза; msg — ошибка в данных
+func updateAssetThreat(link *models.AssetThreat, in assetThreatInput, matrix *models.RiskMatrix, uid uint) (string, error) {
+	if !matrix.InScale(in.Likelihood, in.Impact) || !matrix.InScale(in.ResidualLikelihood, in.ResidualImpact) {
+		return "Некорректная оценка вероятности или ущерба", nil
+	}
+
+	// меры защиты не могут увеличивать риск
+	if in.ResidualLikelihood > in.Likelihood || in.ResidualImpact > in.Impact {
+		return "Остаточный риск не может быть выше исходного", nil
+	}
+
+	var measures []models.ControlMeasure
+	if len(in.MeasureIDs) > 0 {
+		database.DB.Where("id IN ?", in.MeasureIDs).Find(&measures)
+	}
+
+	var components []models.AssetComponent
+	if len(in.ComponentIDs) > 0 {
+		database.DB.Where("asset_id = ? AND id IN ?", link.AssetID, in.ComponentIDs).Find(&components)
+		if len(components) != len(in.ComponentIDs) {
+			return "Компонент не относится к объекту", nil
+		}
+	}
+
+	if (in.ResidualLikelihood < in.Likelihood || in.ResidualImpact < in.Impact) && len(measures) == 0 {
+		return "Снижение риска должно опираться на применённые меры защиты", nil
+	}
+
+	// повторная оценка проводится по действующей версии угрозы
+	if link.Threat.ID == 0 {
+		database.DB.First(&link.Threat, link.ThreatID)
+	}
+	version, err := catalog.CurrentThreatVersion(database.DB, link.Threat)
+	if err != nil {
+		return "", err
+	}
+
+	link.Likelihood = in.Likelihood
+	link.Impact = in.Impact
+	link.LikelihoodNotes = in.LikelihoodNotes
+	link.ImpactNotes = in.ImpactNotes
+	link.RiskLevel = matrix.Level(in.Likelihood, in.Impact)
+	link.ResidualLikelihood = in.ResidualLikelihood
+	link.ResidualImpact = in.ResidualImpact
+	link.ResidualNotes = in.ResidualNotes
+	link.ResidualLevel = matrix.Level(in.ResidualLikelihood, in.ResidualImpact)
+	link.Notes = in.Notes
+	link.ThreatVersionID = &version.ID
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Asset", "Threat", "ThreatVersion", "Measures", "Components").Save(link).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(link).Association("Components").Replace(components); err != nil {
+			return err
+		}
+		return tx.Model(link).Association("Measures").Replace(measures)
+	})
+	if err != nil {
+		return "", err
+	}
+	link.Measures = measures
+	link.Components = components
+
+	if uid != 0 {
+		database.CreateAuditLog(uid, "asset", link.AssetID, "threat_assess",
+			fmt.Sprintf("Оценка угрозы %s: риск %s, остаточный %s", link.Threat.Code,
+				models.RiskLevelLabel(link.RiskLevel), models.RiskLevelLabel(link.ResidualLevel)))
+	}
+	return "", nil
+}
+
+// deleteAssetThreat — вместе со связью удаляются отметки о применённых мерах и сценарии реализации
+func deleteAssetThreat(link models.AssetThreat, uid uint) error {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := deleteThreatScenarios(tx, link.ID); err != nil {
+			return err
+		}
+		return tx.Select("Measures", "Components").Delete(&link).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	if uid != 0 {
+		var th models.Threat
+		database.DB.Unscoped().First(&th, link.ThreatID)
+		database.CreateAuditLog(uid, "asset", link.AssetID, "threat_remove", "Отвязана угроза "+th.Code)
+	}
+	return nil
+}
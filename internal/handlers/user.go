@@ -0,0 +1,449 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"ib-integrator/internal/database"
+	"ib-integrator/internal/lockout"
+	"ib-integrator/internal/models"
+	"ib-integrator/internal/passpolicy"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ====== ПОЛЬЗОВАТЕЛИ (только админ) ======
+
+type userStatusCount struct {
+	Status models.UserStatus
+	Count  int64
+}
+
+func renderUsers(c *gin.Context, status int, extra gin.H) {
+	filterStatus := models.UserStatus(c.Query("status"))
+	q := strings.TrimSpace(c.Query("q"))
+
+	query := database.DB.Model(&models.User{})
+	if filterStatus != "" {
+		query = query.Where("status = ?", filterStatus)
+	}
+	if q != "" {
+		query = query.Where("LOWER(username) LIKE ?", "%"+strings.ToLower(q)+"%")
+	}
+	var users []models.User
+	// заявки на доступ — первыми
+	query.Order(fmt.Sprintf("CASE WHEN status = '%s' THEN 0 ELSE 1 END", models.UserPending)).
+		Order("username").Find(&users)
+
+	counts := make([]userStatusCount, 0, len(models.UserStatuses))
+	for _, s := range models.UserStatuses {
+		var n int64
+		database.DB.Model(&models.User{}).Where("status = ?", s).Count(&n)
+		counts = append(counts, userStatusCount{Status: s, Count: n})
+	}
+
+	// логины, заблокированные после неудачных попыток входа
+	names := make([]string, 0, len(users))
+	for _, u := range users {
+		names = append(names, u.Username)
+	}
+	lockedUntil := lockout.LockedAccounts(names)
+	locks := map[uint]string{}
+	for _, u := range users {
+		if until, ok := lockedUntil[u.Username]; ok {
+			locks[u.ID] = until.Format("02.01.2006 15:04:05")
+		}
+	}
+
+	uid, _ := sessions.Default(c).Get("user_id").(uint)
+	data := gin.H{
+		"role":         string(models.RoleAdmin),
+		"users":        users,
+		"counts":       counts,
+		"roles":        models.UserRoles,
+		"filterStatus": string(filterStatus),
+		"q":            q,
+		"selfID":       uid,
+		"locks":        locks,
+		"mode":         database.RegistrationMode(),
+		"modes":        []models.RegistrationMode{models.RegistrationOpen, models.RegistrationRequest},
+	}
+	for k, v := range extra {
+		data[k] = v
+	}
+	render(c, status, "users.html", data)
+}
+
+func ListUsers(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+	renderUsers(c, http.StatusOK, nil)
+}
+
+// temporaryPassword — пароль, который администратор передаёт пользователю; при первом входе
+// его придётся сменить. Содержит буквы обоих регистров, цифры и знаки.
+func temporaryPassword() (string, error) {
+	classes := []string{"ABCDEFGHJKLMNPQRSTUVWXYZ", "abcdefghijkmnpqrstuvwxyz", "23456789", "!#%*+-=?@"}
+	all := strings.Join(classes, "")
+	pick := func(set string) (byte, error) {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(set))))
+		if err != nil {
+			return 0, err
+		}
+		return set[n.Int64()], nil
+	}
+
+	buf := make([]byte, 0, 14)
+	for _, set := range classes {
+		ch, err := pick(set)
+		if err != nil {
+			return "", err
+		}
+		buf = append(buf, ch)
+	}
+	for len(buf) < cap(buf) {
+		ch, err := pick(all)
+		if err != nil {
+			return "", err
+		}
+		buf = append(buf, ch)
+	}
+	// перемешиваем, чтобы символы обязательных классов не стояли в начале
+	for i := len(buf) - 1; i > 0; i-- {
+		j, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return "", err
+		}
+		buf[i], buf[j.Int64()] = buf[j.Int64()], buf[i]
+	}
+	return string(buf), nil
+}
+
+// loadManagedUser — пользователь из :id; себя администратор не отключает и не понижает,
+// поэтому selfAllowed=false отсекает действия над собственной учётной записью
+func loadManagedUser(c *gin.Context, selfAllowed bool) (models.User, uint, bool) {
+	var user models.User
+	uid, _ := sessions.Default(c).Get("user_id").(uint)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.String(http.StatusBadRequest, "Некорректный ID пользователя")
+		return user, uid, false
+	}
+	if err := database.DB.First(&user, id).Error; err != nil {
+		c.String(http.StatusNotFound, "Пользователь не найден")
+		return user, uid, false
+	}
+	if !selfAllowed && user.ID == uid {
+		renderUsers(c, http.StatusBadRequest, gin.H{"error": "Это действие нельзя выполнить над собственной учётной записью"})
+		return user, uid, false
+	}
+	return user, uid, true
+}
+
+// lastActiveAdmin — user единственный активный администратор (его нельзя отключить или понизить)
+func lastActiveAdmin(user models.User) bool {
+	if user.Role != models.RoleAdmin || !user.Active() || user.IsService {
+		return false
+	}
+	var n int64
+	database.DB.Model(&models.User{}).
+		Where("role = ? AND status = ? AND is_service = ? AND id <> ?", models.RoleAdmin, models.UserActive, false, user.ID).
+		Count(&n)
+	return n == 0
+}
+
+type newUserForm struct {
+	Username string `form:"username"`
+	Role     string `form:"role"`
+}
+
+// CreateUser — учётная запись сотрудника с временным паролем, который показывается один раз
+func CreateUser(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+	uid, _ := sessions.Default(c).Get("user_id").(uint)
+
+	var form newUserForm
+	_ = c.ShouldBind(&form)
+	form.Username = strings.TrimSpace(form.Username)
+	role := models.UserRole(form.Role)
+
+	var msg string
+	switch {
+	case len(form.Username) < 3 || len(form.Username) > 50:
+		msg = "Логин — от 3 до 50 символов"
+	case !role.Valid():
+		msg = "Неверная роль"
+	}
+	if msg == "" {
+		var n int64
+		database.DB.Unscoped().Model(&models.User{}).Where("username = ?", form.Username).Count(&n)
+		if n > 0 {
+			msg = "Пользователь с таким логином уже существует"
+		}
+	}
+	if msg != "" {
+		renderUsers(c, http.StatusBadRequest, gin.H{"error": msg, "form": form})
+		return
+	}
+
+	password, err := temporaryPassword()
+	if err != nil {
+		renderUsers(c, http.StatusInternalServerError, gin.H{"error": "Ошибка создания пароля"})
+		return
+	}
+	hash, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	user := models.User{
+		Username:           form.Username,
+		PasswordHash:       string(hash),
+		Role:               role,
+		Status:             models.UserActive,
+		MustChangePassword: true,
+	}
+	if err := database.DB.Create(&user).Error; err != nil {
+		renderUsers(c, http.StatusInternalServerError, gin.H{"error": "Ошибка сохранения пользователя"})
+		return
+	}
+	database.CreateAuditLog(uid, "user", user.ID, "create",
+		fmt.Sprintf("Создан пользователь %s, роль %s", user.Username, user.Role))
+
+	renderUsers(c, http.StatusOK, gin.H{"newPassword": password, "newPasswordFor": user.Username})
+}
+
+// ChangeUserRole — смена роли; действует сразу, в том числе для открытых сессий
+func ChangeUserRole(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+	user, uid, ok := loadManagedUser(c, false)
+	if !ok {
+		return
+	}
+
+	role := models.UserRole(c.PostForm("role"))
+	switch {
+	case !role.Valid():
+		renderUsers(c, http.StatusBadRequest, gin.H{"error": "Неверная роль"})
+		return
+	case role == user.Role:
+		c.Redirect(http.StatusFound, "/users")
+		return
+	case lastActiveAdmin(user):
+		renderUsers(c, http.StatusBadRequest, gin.H{"error": "Нельзя понизить единственного активного администратора"})
+		return
+	}
+
+	old := user.Role
+	if err := database.DB.Model(&user).Update("role", role).Error; err != nil {
+		renderUsers(c, http.StatusInternalServerError, gin.H{"error": "Ошибка сохранения роли"})
+		return
+	}
+	database.CreateAuditLog(uid, "user", user.ID, "role_change",
+		fmt.Sprintf("Роль пользователя %s: %s → %s", user.Username, old, role))
+
+	c.Redirect(http.StatusFound, "/users")
+}
+
+// DeactivateUser — отключение: вход, открытые сессии и токены API перестают действовать
+func DeactivateUser(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+	user, uid, ok := loadManagedUser(c, false)
+	if !ok {
+		return
+	}
+	if user.Status == models.UserDisabled || user.Status == models.UserRejected {
+		c.Redirect(http.StatusFound, "/users")
+		return
+	}
+	if lastActiveAdmin(user) {
+		renderUsers(c, http.StatusBadRequest, gin.H{"error": "Нельзя отключить единственного активного администратора"})
+		return
+	}
+
+	if err := database.DB.Model(&user).Update("status", models.UserDisabled).Error; err != nil {
+		renderUsers(c, http.StatusInternalServerError, gin.H{"error": "Ошибка сохранения"})
+		return
+	}
+	database.CreateAuditLog(uid, "user", user.ID, "deactivate", "Отключён пользователь "+user.Username)
+
+	c.Redirect(http.StatusFound, "/users")
+}
+
+func ActivateUser(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+	user, uid, ok := loadManagedUser(c, false)
+	if !ok {
+		return
+	}
+	if user.Status != models.UserDisabled {
+		renderUsers(c, http.StatusBadRequest, gin.H{"error": "Включить можно только отключённую учётную запись"})
+		return
+	}
+
+	if err := database.DB.Model(&user).Update("status", models.UserActive).Error; err != nil {
+		renderUsers(c, http.StatusInternalServerError, gin.H{"error": "Ошибка сохранения"})
+		return
+	}
+	database.CreateAuditLog(uid, "user", user.ID, "activate", "Включён пользователь "+user.Username)
+
+	c.Redirect(http.StatusFound, "/users")
+}
+
+// ResetUserPassword — новый временный пароль; при следующем входе пользователь обязан его сменить
+func ResetUserPassword(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+	user, uid, ok := loadManagedUser(c, true)
+	if !ok {
+		return
+	}
+	if user.IsService {
+		renderUsers(c, http.StatusBadRequest, gin.H{"error": "У сервисной учётной записи нет пароля — используйте токены API"})
+		return
+	}
+
+	password, err := temporaryPassword()
+	if err != nil {
+		renderUsers(c, http.StatusInternalServerError, gin.H{"error": "Ошибка создания пароля"})
+		return
+	}
+	hash, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	// прежний пароль — в историю, чтобы к нему нельзя было вернуться после сброса
+	if err := passpolicy.Remember(user); err != nil {
+		renderUsers(c, http.StatusInternalServerError, gin.H{"error": "Ошибка сохранения пароля"})
+		return
+	}
+	if err := database.DB.Model(&user).Updates(map[string]interface{}{
+		"password_hash":        string(hash),
+		"must_change_password": true,
+	}).Error; err != nil {
+		renderUsers(c, http.StatusInternalServerError, gin.H{"error": "Ошибка сохранения пароля"})
+		return
+	}
+	database.CreateAuditLog(uid, "user", user.ID, "password_reset", "Сброшен пароль пользователя "+user.Username)
+
+	renderUsers(c, http.StatusOK, gin.H{"newPassword": password, "newPasswordFor": user.Username})
+}
+
+// ApproveUser — одобрение заявки на доступ; администратор может выдать другую роль
+func ApproveUser(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+	user, uid, ok := loadManagedUser(c, false)
+	if !ok {
+		return
+	}
+	if user.Status != models.UserPending {
+		renderUsers(c, http.StatusBadRequest, gin.H{"error": "Заявка уже рассмотрена"})
+		return
+	}
+
+	role := user.Role
+	if v := models.UserRole(c.PostForm("role")); v != "" {
+		if !v.Valid() {
+			renderUsers(c, http.StatusBadRequest, gin.H{"error": "Неверная роль"})
+			return
+		}
+		role = v
+	}
+
+	if err := database.DB.Model(&user).Updates(map[string]interface{}{
+		"status": models.UserActive,
+		"role":   role,
+	}).Error; err != nil {
+		renderUsers(c, http.StatusInternalServerError, gin.H{"error": "Ошибка сохранения"})
+		return
+	}
+	details := fmt.Sprintf("Одобрена заявка на доступ %s, роль %s", user.Username, role)
+	if role != user.Role {
+		details += fmt.Sprintf(" (запрошена %s)", user.Role)
+	}
+	database.CreateAuditLog(uid, "user", user.ID, "approve", details)
+
+	c.Redirect(http.StatusFound, "/users")
+}
+
+// RejectUser — отклонение заявки. Учётная запись остаётся (на неё ссылается журнал аудита),
+// но войти в неё нельзя; с тем же логином можно подать новую заявку.
+func RejectUser(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+	user, uid, ok := loadManagedUser(c, false)
+	if !ok {
+		return
+	}
+	if user.Status != models.UserPending {
+		renderUsers(c, http.StatusBadRequest, gin.H{"error": "Заявка уже рассмотрена"})
+		return
+	}
+
+	if err := database.DB.Model(&user).Update("status", models.UserRejected).Error; err != nil {
+		renderUsers(c, http.StatusInternalServerError, gin.H{"error": "Ошибка сохранения"})
+		return
+	}
+	database.CreateAuditLog(uid, "user", user.ID, "reject", "Отклонена заявка на доступ "+user.Username)
+
+	c.Redirect(http.StatusFound, "/users")
+}
+
+// SetRegistrationMode — открытая регистрация или заявки с одобрением администратором
+func SetRegistrationMode(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+	uid, _ := sessions.Default(c).Get("user_id").(uint)
+
+	mode := models.RegistrationMode(c.PostForm("mode"))
+	if !mode.Valid() {
+		renderUsers(c, http.StatusBadRequest, gin.H{"error": "Неизвестный режим регистрации"})
+		return
+	}
+	old := database.RegistrationMode()
+	if mode == old {
+		c.Redirect(http.StatusFound, "/users")
+		return
+	}
+	if err := database.SetSetting(models.SettingRegistrationMode, string(mode)); err != nil {
+		renderUsers(c, http.StatusInternalServerError, gin.H{"error": "Ошибка сохранения настройки"})
+		return
+	}
+	database.CreateAuditLog(uid, "settings", 0, "registration_mode",
+		fmt.Sprintf("Режим регистрации: %s → %s", old.Label(), mode.Label()))
+
+	c.Redirect(http.StatusFound, "/users")
+}
+
+// UnlockUser — снять блокировку входа после неудачных попыток
+func UnlockUser(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+	user, uid, ok := loadManagedUser(c, false)
+	if !ok {
+		return
+	}
+	if err := lockout.Unlock(user.Username); err != nil {
+		renderUsers(c, http.StatusInternalServerError, gin.H{"error": "Ошибка снятия блокировки"})
+		return
+	}
+	database.CreateRequestAuditLog(uid, models.AuthEntity, user.ID, models.AuthUnlock,
+		"Снята блокировка входа "+user.Username, c.ClientIP(), c.Request.UserAgent())
+
+	c.Redirect(http.StatusFound, "/users")
+}
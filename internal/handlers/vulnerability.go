@@ -0,0 +1,458 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"ib-integrator/internal/database"
+	"ib-integrator/internal/models"
+	"ib-integrator/internal/vuln"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ====== КАТАЛОГ УЯЗВИМОСТЕЙ И РЕЕСТР УЯЗВИМОСТЕЙ ОБЪЕКТА ======
+
+var vulnerabilitySeverities = []string{"critical", "high", "medium", "low", "none"}
+
+type severityCount struct {
+	Severity string
+	Count    int64
+}
+
+func (s severityCount) Label() string { return models.SeverityLabel(s.Severity) }
+
+func renderVulnerabilities(c *gin.Context, status int, role models.UserRole, result *vuln.Result, msg string) {
+	q := strings.TrimSpace(c.Query("q"))
+	severity := c.Query("severity")
+
+	query := database.DB.Model(&models.Vulnerability{})
+	if q != "" {
+		like := "%" + strings.ToLower(q) + "%"
+		query = query.Where("LOWER(code) LIKE ? OR LOWER(cve) LIKE ? OR LOWER(name) LIKE ? OR LOWER(vendor) LIKE ? OR LOWER(product) LIKE ?",
+			like, like, like, like, like)
+	}
+	if severity != "" {
+		query = query.Where("severity = ?", severity)
+	}
+
+	var total int64
+	query.Count(&total)
+	var vulns []models.Vulnerability
+	query.Order("published desc, code desc").Limit(200).Find(&vulns)
+
+	counts := make([]severityCount, 0, len(vulnerabilitySeverities))
+	for _, s := range vulnerabilitySeverities {
+		sc := severityCount{Severity: s}
+		database.DB.Model(&models.Vulnerability{}).Where("severity = ?", s).Count(&sc.Count)
+		counts = append(counts, sc)
+	}
+
+	render(c, status, "vulnerabilities.html", gin.H{
+		"role":     string(role),
+		"vulns":    vulns,
+		"total":    total,
+		"q":        q,
+		"severity": severity,
+		"counts":   counts,
+		"result":   result,
+		"error":    msg,
+	})
+}
+
+func ListVulnerabilities(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	renderVulnerabilities(c, http.StatusOK, role, nil, "")
+}
+
+// ImportVulnerabilities загружает vulnlist БДУ (.xlsx/.xml) или фид NVD JSON 2.0 (только admin)
+func ImportVulnerabilities(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	fh, err := c.FormFile("file")
+	if err != nil {
+		renderVulnerabilities(c, http.StatusBadRequest, models.RoleAdmin, nil, "Выберите файл выгрузки")
+		return
+	}
+	ext := strings.ToLower(filepath.Ext(fh.Filename))
+	if ext != ".xlsx" && ext != ".xml" && ext != ".json" {
+		renderVulnerabilities(c, http.StatusBadRequest, models.RoleAdmin, nil,
+			"Поддерживаются vulnlist.xlsx, XML-выгрузка БДУ и фиды NVD JSON 2.0 (.json)")
+		return
+	}
+
+	tmp, err := os.CreateTemp("", "vuln-import-*"+ext)
+	if err != nil {
+		renderVulnerabilities(c, http.StatusInternalServerError, models.RoleAdmin, nil, "Не удалось сохранить файл")
+		return
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	if err := c.SaveUploadedFile(fh, tmp.Name()); err != nil {
+		renderVulnerabilities(c, http.StatusInternalServerError, models.RoleAdmin, nil, "Не удалось сохранить файл")
+		return
+	}
+
+	records, err := vuln.ParseFile(tmp.Name())
+	if err != nil {
+		renderVulnerabilities(c, http.StatusBadRequest, models.RoleAdmin, nil, "Ошибка разбора выгрузки: "+err.Error())
+		return
+	}
+
+	res, err := vuln.Import(database.DB, records)
+	if err != nil {
+		renderVulnerabilities(c, http.StatusInternalServerError, models.RoleAdmin, nil, "Ошибка загрузки: "+err.Error())
+		return
+	}
+
+	sess := sessions.Default(c)
+	if uid, ok := sess.Get("user_id").(uint); ok {
+		database.CreateAuditLog(uid, "vulnerability", 0, "import",
+			fmt.Sprintf("Загружен %s: добавлено %d, обновлено %d, без изменений %d, отброшено векторов CVSS %d",
+				fh.Filename, res.Added, res.Updated, res.Unchanged, len(res.Warnings)))
+	}
+
+	renderVulnerabilities(c, http.StatusOK, models.RoleAdmin, &res, "")
+}
+
+func ShowVulnerability(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.String(http.StatusBadRequest, "Некорректный ID уязвимости")
+		return
+	}
+	var v models.Vulnerability
+	if err := database.DB.First(&v, id).Error; err != nil {
+		c.String(http.StatusNotFound, "Уязвимость не найдена")
+		return
+	}
+
+	var entries []models.AssetVulnerability
+	database.DB.Preload("Asset.Client").Preload("Threats").
+		Joins("JOIN assets ON assets.id = asset_vulnerabilities.asset_id AND assets.deleted_at IS NULL").
+		Where("asset_vulnerabilities.vulnerability_id = ?", v.ID).
+		Order("asset_vulnerabilities.id asc").
+		Find(&entries)
+
+	render(c, http.StatusOK, "vulnerability_detail.html", gin.H{
+		"role":    string(role),
+		"vuln":    v,
+		"entries": entries,
+	})
+}
+
+// --- реестр уязвимостей объекта ---
+
+// assetOpenCriticalVulnerabilities — неустранённые критические уязвимости объекта
+func assetOpenCriticalVulnerabilities(assetID uint) []models.AssetVulnerability {
+	var entries []models.AssetVulnerability
+	database.DB.Preload("Vulnerability").Preload("Threats").
+		Joins("JOIN vulnerabilities ON vulnerabilities.id = asset_vulnerabilities.vulnerability_id").
+		Where("asset_vulnerabilities.asset_id = ? AND asset_vulnerabilities.status IN ? AND vulnerabilities.severity = ?",
+			assetID, []models.VulnerabilityStatus{models.VulnFound, models.VulnConfirmed}, "critical").
+		Order("vulnerabilities.code asc").
+		Find(&entries)
+	return entries
+}
+
+// assetLinkedThreats — угрозы объекта, с которыми можно связать уязвимость
+func assetLinkedThreats(assetID uint) []models.Threat {
+	var threats []models.Threat
+	database.DB.
+		Joins("JOIN asset_threats ON asset_threats.threat_id = threats.id").
+		Where("asset_threats.asset_id = ?", assetID).
+		Order("threats.code asc").
+		Find(&threats)
+	return threats
+}
+
+// findVulnerability — по коду каталога (BDU:…, CVE-…) или по CVE, указанному в записи БДУ
+func findVulnerability(code string) (models.Vulnerability, bool) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return models.Vulnerability{}, false
+	}
+	var v models.Vulnerability
+	if err := database.DB.Where("code = ?", code).First(&v).Error; err == nil {
+		return v, true
+	}
+	if strings.HasPrefix(code, "CVE-") {
+		if err := database.DB.Scopes(models.HasCVE(code)).Order("id asc").First(&v).Error; err == nil {
+			return v, true
+		}
+	}
+	return models.Vulnerability{}, false
+}
+
+// bindAssetVulnerabilityForm заполняет статус, место обнаружения и связанные угрозы из формы
+func bindAssetVulnerabilityForm(c *gin.Context, entry *models.AssetVulnerability) string {
+	status := models.VulnerabilityStatus(c.PostForm("status"))
+	if !status.Valid() {
+		return "Некорректный статус уязвимости"
+	}
+
+	justification := strings.TrimSpace(c.PostForm("justification"))
+	if status == models.VulnAccepted && justification == "" {
+		return "Для принятого риска укажите обоснование"
+	}
+
+	// связать можно только с угрозами, заведёнными для объекта
+	allowed := make(map[string]models.Threat)
+	for _, th := range assetLinkedThreats(entry.AssetID) {
+		allowed[strconv.FormatUint(uint64(th.ID), 10)] = th
+	}
+	threats := []models.Threat{}
+	for _, id := range c.PostFormArray("threat_ids") {
+		th, ok := allowed[id]
+		if !ok {
+			return "Угроза не заведена для объекта"
+		}
+		threats = append(threats, th)
+	}
+
+	if status == models.VulnRemediated && entry.Status != models.VulnRemediated {
+		now := time.Now()
+		entry.RemediatedAt = &now
+	} else if status != models.VulnRemediated {
+		entry.RemediatedAt = nil
+	}
+	entry.Status = status
+	entry.Justification = justification
+	entry.Component = strings.TrimSpace(c.PostForm("component"))
+	entry.Notes = strings.TrimSpace(c.PostForm("notes"))
+	entry.Threats = threats
+	return ""
+}
+
+func saveAssetVulnerability(entry *models.AssetVulnerability) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Asset", "Vulnerability", "Threats").Save(entry).Error; err != nil {
+			return err
+		}
+		return tx.Model(entry).Association("Threats").Replace(entry.Threats)
+	})
+}
+
+func renderAssetVulnerabilities(c *gin.Context, status int, role models.UserRole, asset models.Asset, msg string) {
+	var entries []models.AssetVulnerability
+	database.DB.
+		Preload("Vulnerability").
+		Preload("Threats").
+		Joins("JOIN vulnerabilities ON vulnerabilities.id = asset_vulnerabilities.vulnerability_id").
+		Where("asset_vulnerabilities.asset_id = ?", asset.ID).
+		Order("asset_vulnerabilities.status asc, vulnerabilities.code asc").
+		Find(&entries)
+
+	counts := make(map[models.VulnerabilityStatus]int)
+	for _, e := range entries {
+		counts[e.Status]++
+	}
+
+	render(c, status, "asset_vulnerabilities.html", gin.H{
+		"role":     string(role),
+		"asset":    asset,
+		"entries":  entries,
+		"counts":   counts,
+		"threats":  assetLinkedThreats(asset.ID),
+		"statuses": models.VulnerabilityStatuses,
+		"error":    msg,
+	})
+}
+
+func ShowAssetVulnerabilities(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	asset, ok := loadAssetByParam(c)
+	if !ok {
+		return
+	}
+
+	renderAssetVulnerabilities(c, http.StatusOK, role, asset, "")
+}
+
+func AddAssetVulnerability(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	asset, ok := loadAssetByParam(c)
+	if !ok {
+		return
+	}
+
+	v, found := findVulnerability(c.PostForm("code"))
+	if !found {
+		renderAssetVulnerabilities(c, http.StatusBadRequest, role, asset, "Уязвимость не найдена в каталоге — загрузите выгрузку БДУ или фид NVD")
+		return
+	}
+
+	var count int64
+	database.DB.Model(&models.AssetVulnerability{}).
+		Where("asset_id = ? AND vulnerability_id = ?", asset.ID, v.ID).
+		Count(&count)
+	if count > 0 {
+		renderAssetVulnerabilities(c, http.StatusBadRequest, role, asset, "Уязвимость "+v.Code+" уже есть в реестре объекта")
+		return
+	}
+
+	entry := models.AssetVulnerability{AssetID: asset.ID, VulnerabilityID: v.ID}
+	if msg := bindAssetVulnerabilityForm(c, &entry); msg != "" {
+		renderAssetVulnerabilities(c, http.StatusBadRequest, role, asset, msg)
+		return
+	}
+
+	if err := saveAssetVulnerability(&entry); err != nil {
+		renderAssetVulnerabilities(c, http.StatusInternalServerError, role, asset, "Ошибка сохранения уязвимости в реестре")
+		return
+	}
+
+	sess := sessions.Default(c)
+	if uid, ok := sess.Get("user_id").(uint); ok {
+		database.CreateAuditLog(uid, "asset_vulnerability", entry.ID, "create",
+			fmt.Sprintf("Объект %s: уязвимость %s добавлена в реестр (%s)", asset.Name, v.Code, entry.Status.Label()))
+	}
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/assets/%d/vulnerabilities", asset.ID))
+}
+
+func loadAssetVulnerability(c *gin.Context) (models.AssetVulnerability, bool) {
+	assetID, err1 := strconv.Atoi(c.Param("id"))
+	entryID, err2 := strconv.Atoi(c.Param("entry_id"))
+	if err1 != nil || err2 != nil || assetID <= 0 || entryID <= 0 {
+		c.String(http.StatusBadRequest, "Некорректные параметры")
+		return models.AssetVulnerability{}, false
+	}
+
+	var entry models.AssetVulnerability
+	if err := database.DB.
+		Preload("Asset.Client").
+		Preload("Vulnerability").
+		Preload("Threats").
+		Where("id = ? AND asset_id = ?", entryID, assetID).
+		First(&entry).Error; err != nil {
+		c.String(http.StatusNotFound, "Запись реестра уязвимостей не найдена")
+		return models.AssetVulnerability{}, false
+	}
+	return entry, true
+}
+
+func renderAssetVulnerabilityEdit(c *gin.Context, status int, role models.UserRole, entry models.AssetVulnerability, msg string) {
+	linked := make(map[uint]bool, len(entry.Threats))
+	for _, th := range entry.Threats {
+		linked[th.ID] = true
+	}
+
+	render(c, status, "asset_vulnerability_edit.html", gin.H{
+		"role":     string(role),
+		"entry":    entry,
+		"threats":  assetLinkedThreats(entry.AssetID),
+		"linked":   linked,
+		"statuses": models.VulnerabilityStatuses,
+		"error":    msg,
+	})
+}
+
+func ShowEditAssetVulnerability(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	entry, ok := loadAssetVulnerability(c)
+	if !ok {
+		return
+	}
+
+	renderAssetVulnerabilityEdit(c, http.StatusOK, role, entry, "")
+}
+
+func UpdateAssetVulnerability(c *gin.Context) {
+	role, ok := requireRiskEditor(c)
+	if !ok {
+		return
+	}
+
+	entry, ok := loadAssetVulnerability(c)
+	if !ok {
+		return
+	}
+
+	oldStatus := entry.Status
+	if msg := bindAssetVulnerabilityForm(c, &entry); msg != "" {
+		renderAssetVulnerabilityEdit(c, http.StatusBadRequest, role, entry, msg)
+		return
+	}
+
+	if err := saveAssetVulnerability(&entry); err != nil {
+		renderAssetVulnerabilityEdit(c, http.StatusInternalServerError, role, entry, "Ошибка сохранения записи реестра")
+		return
+	}
+
+	sess := sessions.Default(c)
+	if uid, ok := sess.Get("user_id").(uint); ok {
+		action := "update"
+		details := fmt.Sprintf("Объект %s: обновлена уязвимость %s", entry.Asset.Name, entry.Vulnerability.Code)
+		if oldStatus != entry.Status {
+			action = "status_change"
+			details = fmt.Sprintf("Объект %s: уязвимость %s: %s → %s",
+				entry.Asset.Name, entry.Vulnerability.Code, oldStatus.Label(), entry.Status.Label())
+		}
+		database.CreateAuditLog(uid, "asset_vulnerability", entry.ID, action, details)
+	}
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/assets/%d/vulnerabilities", entry.AssetID))
+}
+
+func DeleteAssetVulnerability(c *gin.Context) {
+	if _, ok := requireRiskEditor(c); !ok {
+		return
+	}
+
+	entry, ok := loadAssetVulnerability(c)
+	if !ok {
+		return
+	}
+
+	// удаляем физически: иначе уникальный индекс не даст снова внести уязвимость в реестр
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entry).Association("Threats").Clear(); err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&entry).Error
+	})
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Ошибка удаления записи реестра")
+		return
+	}
+
+	sess := sessions.Default(c)
+	if uid, ok := sess.Get("user_id").(uint); ok {
+		database.CreateAuditLog(uid, "asset_vulnerability", entry.ID, "delete",
+			fmt.Sprintf("Объект %s: уязвимость %s удалена из реестра", entry.Asset.Name, entry.Vulnerability.Code))
+	}
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("/assets/%d/vulnerabilities", entry.AssetID))
+}
@@ -0,0 +1,169 @@
+// Package lockout блокирует вход после серии неудачных попыток — отдельно по логину
+// и по адресу клиента. Каждая следующая блокировка вдвое дольше предыдущей.
+package lockout
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"ib-integrator/internal/database"
+	"ib-integrator/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Policy — пороги и длительность блокировок
+type Policy struct {
+	MaxFailures   int           // неудачных попыток на учётную запись до блокировки; 0 — не блокировать
+	IPMaxFailures int           // неудачных попыток с одного адреса до блокировки; 0 — не блокировать
+	Window        time.Duration // попытки старше этого срока не считаются
+	Base          time.Duration // первая блокировка
+	Max           time.Duration // предел блокировки
+}
+
+// DefaultPolicy действует, пока не вызван Configure
+var DefaultPolicy = Policy{
+	MaxFailures:   5,
+	IPMaxFailures: 20,
+	Window:        15 * time.Minute,
+	Base:          time.Minute,
+	Max:           time.Hour,
+}
+
+// если неудачных попыток не было сутки, счёт блокировок начинается заново
+const quietPeriod = 24 * time.Hour
+
+var current = DefaultPolicy
+
+func Configure(p Policy) {
+	current = p
+}
+
+func AccountKey(username string) string {
+	login := []rune(strings.ToLower(strings.TrimSpace(username)))
+	if len(login) > 100 {
+		login = login[:100]
+	}
+	return "account:" + string(login)
+}
+
+func IPKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Duration — длительность блокировки номер n+1 (n — сколько блокировок уже было)
+func Duration(n int) time.Duration {
+	d := current.Base
+	for i := 0; i < n && d < current.Max; i++ {
+		d *= 2
+	}
+	if d > current.Max {
+		d = current.Max
+	}
+	return d
+}
+
+func find(key string) (models.LoginLock, error) {
+	var l models.LoginLock
+	err := database.DB.Where(&models.LoginLock{Key: key}).First(&l).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.LoginLock{Key: key}, nil
+	}
+	return l, err
+}
+
+// Check — действующая блокировка логина или адреса; если заблокированы оба,
+// возвращается та, что закончится позже
+func Check(username, ip string) (models.LoginLock, bool) {
+	now := time.Now()
+	var found models.LoginLock
+	locked := false
+	for _, key := range []string{AccountKey(username), IPKey(ip)} {
+		l, err := find(key)
+		if err != nil || !l.Locked(now) {
+			continue
+		}
+		if !locked || l.LockedUntil.After(*found.LockedUntil) {
+			found = l
+		}
+		locked = true
+	}
+	return found, locked
+}
+
+// Fail учитывает неудачную попытку входа и возвращает блокировки, наложенные ею
+func Fail(username, ip string) []models.LoginLock {
+	var out []models.LoginLock
+	if l, ok := fail(AccountKey(username), current.MaxFailures); ok {
+		out = append(out, l)
+	}
+	if l, ok := fail(IPKey(ip), current.IPMaxFailures); ok {
+		out = append(out, l)
+	}
+	return out
+}
+
+func fail(key string, threshold int) (models.LoginLock, bool) {
+	if threshold <= 0 {
+		return models.LoginLock{}, false
+	}
+	l, err := find(key)
+	if err != nil {
+		return l, false
+	}
+
+	now := time.Now()
+	switch {
+	case now.Sub(l.LastFailureAt) > quietPeriod:
+		l.Failures, l.Lockouts = 0, 0
+	case now.Sub(l.LastFailureAt) > current.Window:
+		l.Failures = 0
+	}
+	l.Failures++
+	l.LastFailureAt = now
+
+	locked := false
+	if l.Failures >= threshold {
+		until := now.Add(Duration(l.Lockouts))
+		l.LockedUntil = &until
+		l.Lockouts++
+		l.Failures = 0
+		locked = true
+	}
+	if err := database.DB.Save(&l).Error; err != nil {
+		return l, false
+	}
+	return l, locked
+}
+
+// Success — успешный вход: счётчики учётной записи сбрасываются. Счётчик адреса
+// остаётся, иначе перебор по многим логинам сбрасывался бы входом в свою учётную запись.
+func Success(username string) {
+	_ = Unlock(username)
+}
+
+// LockedAccounts — до какого времени заблокированы логины из списка (только действующие блокировки)
+func LockedAccounts(usernames []string) map[string]time.Time {
+	out := map[string]time.Time{}
+	if len(usernames) == 0 {
+		return out
+	}
+	byKey := make(map[string]string, len(usernames))
+	keys := make([]string, 0, len(usernames))
+	for _, u := range usernames {
+		byKey[AccountKey(u)] = u
+		keys = append(keys, AccountKey(u))
+	}
+	var locks []models.LoginLock
+	database.DB.Where(map[string]interface{}{"key": keys}).Where("locked_until > ?", time.Now()).Find(&locks)
+	for _, l := range locks {
+		out[byKey[l.Key]] = *l.LockedUntil
+	}
+	return out
+}
+
+// Unlock снимает блокировку логина (например, по решению администратора)
+func Unlock(username string) error {
+	return database.DB.Where(&models.LoginLock{Key: AccountKey(username)}).Delete(&models.LoginLock{}).Error
+}
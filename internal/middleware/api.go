@@ -0,0 +1,157 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"ib-integrator/internal/apitoken"
+	"ib-integrator/internal/database"
+	"ib-integrator/internal/models"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+// ключи контекста запроса к API: кто выполняет запрос
+const (
+	ContextUserID  = "api_user_id"
+	ContextRole    = "api_role"
+	ContextTokenID = "api_token_id" // только при входе по токену
+)
+
+// APIErrorBody — тело ответа об ошибке API: {"error": {"status": 404, "code": "not_found", "message": "..."}}
+type APIErrorBody struct {
+	Error APIError `json:"error"`
+}
+
+type APIError struct {
+	Status  int    `json:"status"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// коды ошибок API
+const (
+	ErrBadRequest   = "bad_request"
+	ErrUnauthorized = "unauthorized"
+	ErrForbidden    = "forbidden"
+	ErrNotFound     = "not_found"
+	ErrConflict     = "conflict"
+	ErrValidation   = "validation_failed"
+	ErrInternal     = "internal_error"
+)
+
+// AbortAPI — ответ об ошибке в едином для API формате
+func AbortAPI(c *gin.Context, status int, code, message string) {
+	c.AbortWithStatusJSON(status, APIErrorBody{Error: APIError{Status: status, Code: code, Message: message}})
+}
+
+// RequireAPIAuth — аутентификация запросов к /api. В отличие от RequireAuth не перенаправляет
+// на /login, а отвечает 401 в JSON. Принимает сессию браузера или токен в заголовке
+// "Authorization: Bearer ...". Пользователь и роль кладутся в контекст запроса.
+func RequireAPIAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if header := c.GetHeader("Authorization"); header != "" {
+			bearerAuth(c, header)
+			return
+		}
+
+		sess := sessions.Default(c)
+		uid, ok := sess.Get("user_id").(uint)
+		if !ok || uid == 0 {
+			AbortAPI(c, http.StatusUnauthorized, ErrUnauthorized, "Требуется вход в систему")
+			return
+		}
+		// как и RequireAuth: отключённые и не сменившие выданный пароль — без доступа,
+		// роль — текущая из базы, а не из сессии
+		user, _ := c.Get("CurrentUser")
+		current, ok := user.(models.User)
+		if !ok || !current.Active() {
+			AbortAPI(c, http.StatusUnauthorized, ErrUnauthorized, "Учётная запись отключена")
+			return
+		}
+		if current.MustChangePassword {
+			AbortAPI(c, http.StatusForbidden, ErrForbidden, "Требуется сменить пароль")
+			return
+		}
+
+		c.Set(ContextUserID, uid)
+		c.Set(ContextRole, current.Role)
+		c.Next()
+	}
+}
+
+// bearerAuth — вход по токену API. Запрос выполняется от имени владельца токена
+// с ролью токена; каждое использование токена пишется в журнал аудита.
+func bearerAuth(c *gin.Context, header string) {
+	scheme, plain, _ := strings.Cut(header, " ")
+	if !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(plain) == "" {
+		AbortAPI(c, http.StatusUnauthorized, ErrUnauthorized, "Ожидается заголовок Authorization: Bearer <токен>")
+		return
+	}
+
+	token, err := apitoken.Authenticate(strings.TrimSpace(plain), c.ClientIP())
+	if err != nil {
+		AbortAPI(c, http.StatusUnauthorized, ErrUnauthorized, err.Error())
+		return
+	}
+
+	// как и при входе через сессию: пользователь, не сменивший выданный пароль, без доступа
+	if token.User.MustChangePassword && !token.User.IsService {
+		auditTokenUse(c, token, http.StatusForbidden)
+		AbortAPI(c, http.StatusForbidden, ErrForbidden, "Требуется сменить пароль")
+		return
+	}
+
+	// токен только на чтение не пускаем к изменяющим методам
+	if token.Scope != models.TokenScopeWrite {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+		default:
+			auditTokenUse(c, token, http.StatusForbidden)
+			AbortAPI(c, http.StatusForbidden, ErrForbidden, "Токен выдан только на чтение")
+			return
+		}
+	}
+
+	c.Set(ContextUserID, token.UserID)
+	c.Set(ContextRole, token.Role)
+	c.Set(ContextTokenID, token.ID)
+	c.Next()
+
+	auditTokenUse(c, token, c.Writer.Status())
+}
+
+func auditTokenUse(c *gin.Context, token models.APIToken, status int) {
+	details := fmt.Sprintf("%s %s → %d, токен «%s» (%s…)",
+		c.Request.Method, c.Request.URL.RequestURI(), status, token.Name, token.Prefix)
+	database.CreateRequestAuditLog(token.UserID, "api_token", token.ID, "use", details, c.ClientIP(), c.Request.UserAgent())
+}
+
+// RequireAPIRole — как RequireRole, но для API: роль берётся из контекста, отказ — 403 в JSON
+func RequireAPIRole(roles ...models.UserRole) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		current := APIRole(c)
+		for _, r := range roles {
+			if r == current {
+				c.Next()
+				return
+			}
+		}
+		AbortAPI(c, http.StatusForbidden, ErrForbidden, "Недостаточно прав")
+	}
+}
+
+// APIUserID — пользователь, выполняющий запрос к API
+func APIUserID(c *gin.Context) uint {
+	uid, _ := c.Get(ContextUserID)
+	id, _ := uid.(uint)
+	return id
+}
+
+func APIRole(c *gin.Context) models.UserRole {
+	role, _ := c.Get(ContextRole)
+	r, _ := role.(models.UserRole)
+	return r
+}
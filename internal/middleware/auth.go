@@ -1,50 +1,77 @@
-package middleware
-
-import (
-	"net/http"
-
-	"ib-integrator/internal/models"
-
-	"github.com/gin-contrib/sessions"
-	"github.com/gin-gonic/gin"
-)
-
-// RequireAuth — проверяет, что пользователь залогинен (есть user_id в сессии).
-// Если нет — редиректит на /login.
-func RequireAuth() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		sess := sessions.Default(c)
-
-		if uid, ok := sess.Get("user_id").(uint); !ok || uid == 0 {
-			c.Redirect(http.StatusFound, "/login")
-			c.Abort()
-			return
-		}
-
-		c.Next()
-	}
-}
-
-// RequireRole — пускает только пользователей с одной из указанных ролей.
-func RequireRole(roles ...models.UserRole) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		sess := sessions.Default(c)
-		roleStr, _ := sess.Get("role").(string)
-		current := models.UserRole(roleStr)
-
-		allowed := false
-		for _, r := range roles {
-			if r == current {
-				allowed = true
-				break
-			}
-		}
-
-		if !allowed {
-			c.AbortWithStatus(http.StatusForbidden)
-			return
-		}
-
-		c.Next()
-	}
-}
+package middleware
+
+import (
+	"net/http"
+
+	"ib-integrator/internal/models"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+// RequireAuth — проверяет, что пользователь залогинен (есть user_id в сессии).
+// Если нет — редиректит на /login.
+// Учётная запись перепроверяется на каждом запросе: отключённого пользователя выкидывает
+// из сессии, смена роли администратором действует сразу, а после сброса пароля
+// пользователь не попадёт никуда, кроме страницы смены пароля.
+func RequireAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sess := sessions.Default(c)
+
+		if uid, ok := sess.Get("user_id").(uint); !ok || uid == 0 {
+			c.Redirect(http.StatusFound, "/login")
+			c.Abort()
+			return
+		}
+
+		user, ok := c.Get("CurrentUser")
+		current, _ := user.(models.User)
+		if !ok || !current.Active() || current.IsService {
+			sess.Clear()
+			_ = sess.Save()
+			c.Redirect(http.StatusFound, "/login")
+			c.Abort()
+			return
+		}
+
+		if role, _ := sess.Get("role").(string); role != string(current.Role) {
+			sess.Set("role", string(current.Role))
+			_ = sess.Save()
+		}
+
+		if current.MustChangePassword && c.Request.URL.Path != PasswordChangePath {
+			c.Redirect(http.StatusFound, PasswordChangePath)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// PasswordChangePath — страница смены собственного пароля
+const PasswordChangePath = "/account/password"
+
+// RequireRole — пускает только пользователей с одной из указанных ролей.
+func RequireRole(roles ...models.UserRole) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sess := sessions.Default(c)
+		roleStr, _ := sess.Get("role").(string)
+		current := models.UserRole(roleStr)
+
+		allowed := false
+		for _, r := range roles {
+			if r == current {
+				allowed = true
+				break
+			}
+		}
+
+		if !allowed {
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ====== ТОКЕНЫ ДОСТУПА К API ======
+
+// TokenScope — что разрешено делать с токеном
+type TokenScope string
+
+const (
+	TokenScopeRead  TokenScope = "read"  // только чтение (GET)
+	TokenScopeWrite TokenScope = "write" // чтение и изменение
+)
+
+func (s TokenScope) Label() string {
+	switch s {
+	case TokenScopeRead:
+		return "только чтение"
+	case TokenScopeWrite:
+		return "чтение и запись"
+	}
+	return string(s)
+}
+
+func (s TokenScope) Valid() bool {
+	return s == TokenScopeRead || s == TokenScopeWrite
+}
+
+// APIToken — персональный токен пользователя или токен сервисной учётной записи.
+// Сам токен показывается один раз при выпуске, в базе хранится только его SHA-256.
+type APIToken struct {
+	gorm.Model
+
+	UserID uint `gorm:"index;not null"`
+	User   User
+
+	Name      string `gorm:"size:100;not null"`
+	Prefix    string `gorm:"size:16;not null"` // начало токена, чтобы узнать его в списке
+	TokenHash string `gorm:"size:64;uniqueIndex;not null"`
+
+	// роль, с которой работает токен (не выше роли владельца), и чтение/запись
+	Role  UserRole   `gorm:"type:varchar(20);not null"`
+	Scope TokenScope `gorm:"type:varchar(10);not null"`
+
+	ExpiresAt  *time.Time // nil — бессрочный (только для сервисных учётных записей)
+	LastUsedAt *time.Time
+	LastUsedIP string `gorm:"size:64"`
+
+	CreatedByID uint
+	RevokedAt   *time.Time
+	RevokedByID *uint
+}
+
+// Expired — истёк ли срок действия токена на момент now
+func (t APIToken) Expired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+// Active — токен не отозван и не истёк
+func (t APIToken) Active(now time.Time) bool {
+	return t.RevokedAt == nil && !t.Expired(now)
+}
+
+// StatusLabel — состояние токена для списка
+func (t APIToken) StatusLabel(now time.Time) string {
+	switch {
+	case t.RevokedAt != nil:
+		return "отозван"
+	case t.Expired(now):
+		return "истёк"
+	}
+	return "действует"
+}
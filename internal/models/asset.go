@@ -1,23 +1,39 @@
-package models
-
-import "gorm.io/gorm"
-
-type AssetType string
-
-const (
-	AssetISPD   AssetType = "ispdn"
-	AssetGIS    AssetType = "gis"
-	AssetASUTP  AssetType = "asutp"
-	AssetCorpIT AssetType = "corp_net"
-)
-
-type Asset struct {
-	gorm.Model
-	ClientID uint
-	Client   Client
-
-	Name        string    `gorm:"size:255;not null"`
-	AssetType   AssetType `gorm:"type:varchar(50);not null"`
-	Category    string    `gorm:"size:100"` // класс ИСПДн, УЗ ГИС и т.п.
-	Description string    `gorm:"type:text"`
-}
+package models
+
+import "gorm.io/gorm"
+
+type AssetType string
+
+const (
+	AssetISPD   AssetType = "ispdn"
+	AssetGIS    AssetType = "gis"
+	AssetASUTP  AssetType = "asutp"
+	AssetCorpIT AssetType = "corp_net"
+)
+
+var AssetTypes = []AssetType{AssetISPD, AssetGIS, AssetASUTP, AssetCorpIT}
+
+func (t AssetType) Label() string {
+	switch t {
+	case AssetISPD:
+		return "ИСПДн"
+	case AssetGIS:
+		return "ГИС"
+	case AssetASUTP:
+		return "АСУ ТП"
+	case AssetCorpIT:
+		return "Корпоративная сеть"
+	}
+	return string(t)
+}
+
+type Asset struct {
+	gorm.Model
+	ClientID uint
+	Client   Client
+
+	Name        string    `gorm:"size:255;not null"`
+	AssetType   AssetType `gorm:"type:varchar(50);not null"`
+	Category    string    `gorm:"size:100"` // УЗ ИСПДн (рассчитывается по анкете), класс ГИС и т.п.
+	Description string    `gorm:"type:text"`
+}
@@ -0,0 +1,180 @@
+package models
+
+import "gorm.io/gorm"
+
+// ====== СОСТАВ ОБЪЕКТА ЗАЩИТЫ: СЕГМЕНТЫ, КОМПОНЕНТЫ, ПОТОКИ ДАННЫХ ======
+
+type ComponentKind string
+
+const (
+	ComponentServer      ComponentKind = "server"
+	ComponentWorkstation ComponentKind = "workstation"
+	ComponentNetwork     ComponentKind = "network"
+	ComponentMobile      ComponentKind = "mobile"
+	ComponentSoftware    ComponentKind = "software"
+	ComponentOther       ComponentKind = "other"
+)
+
+var ComponentKinds = []ComponentKind{
+	ComponentServer, ComponentWorkstation, ComponentNetwork, ComponentMobile, ComponentSoftware, ComponentOther,
+}
+
+func (k ComponentKind) Label() string {
+	switch k {
+	case ComponentServer:
+		return "Сервер"
+	case ComponentWorkstation:
+		return "АРМ"
+	case ComponentNetwork:
+		return "Сетевое оборудование"
+	case ComponentMobile:
+		return "Мобильное устройство"
+	case ComponentSoftware:
+		return "Программное обеспечение"
+	case ComponentOther:
+		return "Прочее"
+	}
+	return string(k)
+}
+
+func (k ComponentKind) Valid() bool {
+	for _, v := range ComponentKinds {
+		if v == k {
+			return true
+		}
+	}
+	return false
+}
+
+// AssetSegment — сегмент сети объекта (ЛВС, ДМЗ, технологический сегмент)
+type AssetSegment struct {
+	gorm.Model
+	AssetID     uint   `gorm:"index"`
+	Name        string `gorm:"size:255;not null"`
+	CIDR        string `gorm:"size:64"` // адресное пространство, через запятую
+	Description string `gorm:"type:text"`
+}
+
+// AssetComponent — сервер, АРМ, сетевое устройство или ПО в составе объекта.
+// ПО может быть установлено на другой компонент (ParentID).
+type AssetComponent struct {
+	gorm.Model
+	AssetID   uint  `gorm:"index"`
+	SegmentID *uint // сегмент сети; пусто — вне сегментов
+	ParentID  *uint // компонент, на котором установлено ПО
+	HostID    *uint // узел из инвентаризации сканирования
+
+	Kind        ComponentKind `gorm:"type:varchar(30);not null"`
+	Name        string        `gorm:"size:255;not null"`
+	Vendor      string        `gorm:"size:255"`
+	Version     string        `gorm:"size:100"`
+	Quantity    int           `gorm:"not null;default:1"` // для типовых АРМ — количество
+	Description string        `gorm:"type:text"`
+
+	Segment *AssetSegment
+	Parent  *AssetComponent
+	Host    *Host
+}
+
+// Title — наименование с производителем и версией
+func (c AssetComponent) Title() string {
+	s := c.Name
+	if c.Vendor != "" {
+		s = c.Vendor + " " + s
+	}
+	if c.Version != "" {
+		s += " " + c.Version
+	}
+	return s
+}
+
+type FlowDirection string
+
+const (
+	FlowOutgoing      FlowDirection = "out"
+	FlowIncoming      FlowDirection = "in"
+	FlowBidirectional FlowDirection = "both"
+)
+
+var FlowDirections = []FlowDirection{FlowOutgoing, FlowIncoming, FlowBidirectional}
+
+func (d FlowDirection) Label() string {
+	switch d {
+	case FlowOutgoing:
+		return "передача"
+	case FlowIncoming:
+		return "получение"
+	case FlowBidirectional:
+		return "обмен"
+	}
+	return string(d)
+}
+
+// Arrow — направление потока относительно объекта-владельца
+func (d FlowDirection) Arrow() string {
+	switch d {
+	case FlowOutgoing:
+		return "→"
+	case FlowIncoming:
+		return "←"
+	}
+	return "↔"
+}
+
+// Reverse — направление того же потока со стороны смежной системы
+func (d FlowDirection) Reverse() FlowDirection {
+	switch d {
+	case FlowOutgoing:
+		return FlowIncoming
+	case FlowIncoming:
+		return FlowOutgoing
+	}
+	return d
+}
+
+func (d FlowDirection) Valid() bool {
+	for _, v := range FlowDirections {
+		if v == d {
+			return true
+		}
+	}
+	return false
+}
+
+// DataFlow — информационное взаимодействие объекта со смежной системой:
+// другим объектом защиты (в т.ч. другого клиента) или внешней системой вне реестра
+type DataFlow struct {
+	gorm.Model
+	AssetID     uint  `gorm:"index"`
+	ComponentID *uint // компонент объекта, через который идёт обмен
+
+	PeerAssetID    *uint  `gorm:"index"`
+	ExternalSystem string `gorm:"size:255"` // наименование внешней системы, если смежная система не в реестре
+	ExternalOwner  string `gorm:"size:255"` // её владелец (оператор)
+
+	Direction  FlowDirection `gorm:"type:varchar(10);not null"`
+	Data       string        `gorm:"type:text"` // состав передаваемой информации
+	Protocol   string        `gorm:"size:100"`  // протокол / интерфейс
+	Channel    string        `gorm:"size:255"`  // канал связи: ЛВС, Интернет, выделенный канал
+	Protection string        `gorm:"type:text"` // защита канала: VPN, TLS, СКЗИ
+
+	Asset     Asset
+	Component *AssetComponent
+	PeerAsset *Asset
+}
+
+// PeerName — смежная система для отображения
+func (f DataFlow) PeerName() string {
+	if f.PeerAsset != nil {
+		return f.PeerAsset.Name
+	}
+	if f.ExternalOwner != "" {
+		return f.ExternalSystem + " (" + f.ExternalOwner + ")"
+	}
+	return f.ExternalSystem
+}
+
+// External — смежная система вне реестра или объект другого клиента
+func (f DataFlow) External() bool {
+	return f.PeerAsset == nil || f.PeerAsset.ClientID != f.Asset.ClientID
+}
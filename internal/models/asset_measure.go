@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type MeasureStatus string
+
+const (
+	MeasurePlanned       MeasureStatus = "planned"
+	MeasureInProgress    MeasureStatus = "in_progress"
+	MeasureImplemented   MeasureStatus = "implemented"
+	MeasureNotApplicable MeasureStatus = "not_applicable"
+)
+
+var MeasureStatuses = []MeasureStatus{
+	MeasurePlanned, MeasureInProgress, MeasureImplemented, MeasureNotApplicable,
+}
+
+func (s MeasureStatus) Label() string {
+	switch s {
+	case MeasurePlanned:
+		return "Запланирована"
+	case MeasureInProgress:
+		return "Внедряется"
+	case MeasureImplemented:
+		return "Внедрена"
+	case MeasureNotApplicable:
+		return "Не применима"
+	}
+	return string(s)
+}
+
+func (s MeasureStatus) Valid() bool {
+	for _, v := range MeasureStatuses {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// AssetMeasure — реестр мер защиты объекта: внедрена ли мера на конкретном объекте
+type AssetMeasure struct {
+	gorm.Model
+
+	AssetID   uint `gorm:"uniqueIndex:idx_asset_measure"`
+	MeasureID uint `gorm:"uniqueIndex:idx_asset_measure"`
+
+	Status        MeasureStatus `gorm:"type:varchar(30);not null;default:planned"`
+	Justification string        `gorm:"type:text"` // обязательно для "не применима"
+	OwnerID       *uint         // ответственный за внедрение
+	DueDate       *time.Time    // срок внедрения
+	Evidence      string        `gorm:"type:text"` // подтверждение: акт, скриншот настроек, номер заявки
+
+	// компоненты, на которых мера внедряется; пусто — объект целиком
+	Components []AssetComponent `gorm:"many2many:asset_measure_components;"`
+
+	Asset   Asset
+	Measure ControlMeasure
+	Owner   *User
+}
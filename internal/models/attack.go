@@ -0,0 +1,85 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// AttackRelease — загруженная версия MITRE ATT&CK Enterprise. Версии хранятся рядом:
+// при загрузке новой старые техники не изменяются, и сопоставления с ними остаются в силе.
+type AttackRelease struct {
+	ID         uint   `gorm:"primaryKey"`
+	Version    string `gorm:"size:32;uniqueIndex"` // 15.1
+	Name       string `gorm:"size:255"`            // название коллекции из бандла
+	ImportedAt time.Time
+
+	Techniques []AttackTechnique `gorm:"foreignKey:ReleaseID"`
+}
+
+func (AttackRelease) TableName() string {
+	return "attack_releases"
+}
+
+// AttackTechnique — техника (или подтехника) ATT&CK конкретной версии
+type AttackTechnique struct {
+	ID          uint   `gorm:"primaryKey"`
+	ReleaseID   uint   `gorm:"uniqueIndex:idx_attack_release_technique"`
+	ExternalID  string `gorm:"size:16;uniqueIndex:idx_attack_release_technique"` // T1566.001
+	STIXID      string `gorm:"size:64"`
+	Name        string `gorm:"size:255;not null"`
+	Tactics     string `gorm:"size:255"` // фазы kill chain через запятую: initial-access,execution
+	Platforms   string `gorm:"size:255"`
+	Description string `gorm:"type:text"`
+
+	IsSubtechnique bool
+	Deprecated     bool // отозвана или помечена устаревшей в этой версии
+
+	Release AttackRelease
+}
+
+func (AttackTechnique) TableName() string {
+	return "attack_techniques"
+}
+
+func (t AttackTechnique) TacticList() []string {
+	if t.Tactics == "" {
+		return nil
+	}
+	return strings.Split(t.Tactics, ",")
+}
+
+// ThreatAttackTechnique — сопоставление угрозы каталога с техникой ATT&CK.
+// Ссылается на технику конкретной версии; для другой версии техника ищется по ExternalID.
+type ThreatAttackTechnique struct {
+	ID          uint `gorm:"primaryKey"`
+	ThreatID    uint `gorm:"uniqueIndex:idx_threat_attack"`
+	TechniqueID uint `gorm:"uniqueIndex:idx_threat_attack"`
+
+	Threat    Threat
+	Technique AttackTechnique
+}
+
+func (ThreatAttackTechnique) TableName() string {
+	return "threat_attack_techniques"
+}
+
+// AttackTactics — тактики ATT&CK Enterprise в порядке матрицы: shortname → название
+var AttackTactics = []struct {
+	Shortname string
+	Name      string
+}{
+	{"reconnaissance", "Reconnaissance"},
+	{"resource-development", "Resource Development"},
+	{"initial-access", "Initial Access"},
+	{"execution", "Execution"},
+	{"persistence", "Persistence"},
+	{"privilege-escalation", "Privilege Escalation"},
+	{"defense-evasion", "Defense Evasion"},
+	{"credential-access", "Credential Access"},
+	{"discovery", "Discovery"},
+	{"lateral-movement", "Lateral Movement"},
+	{"collection", "Collection"},
+	{"command-and-control", "Command and Control"},
+	{"exfiltration", "Exfiltration"},
+	{"impact", "Impact"},
+}
@@ -1,16 +1,20 @@
-package models
-
-import "time"
-
-type AuditLog struct {
-	ID        uint      `gorm:"primaryKey"`
-	CreatedAt time.Time
-
-	UserID uint
-	User   User
-
-	Entity   string `gorm:"size:50;not null"` // "client", "project", "asset"
-	EntityID uint
-	Action   string `gorm:"size:50;not null"` // "create", "status_change" и т.п.
-	Details  string `gorm:"type:text"`
-}
+package models
+
+import "time"
+
+type AuditLog struct {
+	ID        uint      `gorm:"primaryKey"`
+	CreatedAt time.Time
+
+	UserID uint // 0 (в базе NULL) — пользователь неизвестен, например неудачный вход
+	User   User
+
+	Entity   string `gorm:"size:50;not null"` // "client", "project", "asset"
+	EntityID uint
+	Action   string `gorm:"size:50;not null"` // "create", "status_change" и т.п.
+	Details  string `gorm:"type:text"`
+
+	// откуда пришёл запрос — для событий входа и работы через API
+	IP        string `gorm:"size:64"`
+	UserAgent string `gorm:"size:255"`
+}
@@ -0,0 +1,111 @@
+package models
+
+import "fmt"
+
+// Приказы ФСТЭК России с каталогами мер и базовыми наборами
+const (
+	Regulation21  = "21"  // ИСПДн
+	Regulation17  = "17"  // ГИС
+	Regulation31  = "31"  // АСУ ТП
+	Regulation239 = "239" // значимые объекты КИИ
+)
+
+// Regulation — приказ ФСТЭК: базовый набор мер зависит от уровня (УЗ, класса или категории).
+// Levels — число уровней, 1 — самый высокий.
+type Regulation struct {
+	Code   string
+	Title  string
+	Levels int
+}
+
+var Regulations = []Regulation{
+	{Regulation21, "Приказ ФСТЭК России №21 (ИСПДн)", 4},
+	{Regulation17, "Приказ ФСТЭК России №17 (ГИС)", 3},
+	{Regulation31, "Приказ ФСТЭК России №31 (АСУ ТП)", 3},
+	{Regulation239, "Приказ ФСТЭК России №239 (значимые объекты КИИ)", 3},
+}
+
+func FindRegulation(code string) (Regulation, bool) {
+	for _, r := range Regulations {
+		if r.Code == code {
+			return r, true
+		}
+	}
+	return Regulation{}, false
+}
+
+func (r Regulation) LevelLabel(level int) string {
+	switch r.Code {
+	case Regulation21:
+		return ISPDnLevelLabel(level)
+	case Regulation17, Regulation31:
+		return GISClassLabel(level)
+	case Regulation239:
+		return KIICategoryLabel(level)
+	}
+	return fmt.Sprint(level)
+}
+
+// LevelNumbers — 1..Levels, для выбора уровня в форме
+func (r Regulation) LevelNumbers() []int {
+	levels := make([]int, r.Levels)
+	for i := range levels {
+		levels[i] = i + 1
+	}
+	return levels
+}
+
+func (r Regulation) ValidLevel(level int) bool {
+	return level >= 1 && level <= r.Levels
+}
+
+// BaselineMeasure — мера приказа входит в базовый набор для уровня Level
+type BaselineMeasure struct {
+	ID        uint `gorm:"primaryKey"`
+	MeasureID uint `gorm:"uniqueIndex:idx_baseline_measure"`
+	Level     int  `gorm:"uniqueIndex:idx_baseline_measure"`
+
+	Measure ControlMeasure
+}
+
+func (BaselineMeasure) TableName() string {
+	return "baseline_measures"
+}
+
+// AssetBaseline — базовый набор мер, положенный объекту по результату классификации
+type AssetBaseline struct {
+	Regulation Regulation
+	Level      int
+	ThreatType int // ИСПДн: тип актуальных угроз из анкеты; 0 — неизвестен
+}
+
+func (b AssetBaseline) Label() string {
+	return fmt.Sprintf("приказ ФСТЭК №%s, базовый набор мер для %s",
+		b.Regulation.Code, b.Regulation.LevelLabel(b.Level))
+}
+
+// RequiredToolClass — класс СЗИ (уровень доверия), не ниже которого должны быть средства защиты:
+// ИСПДн — п. 12 приказа №21, ГИС — п. 26 приказа №17, АСУ ТП — п. 25 приказа №31, КИИ — п. 29 приказа №239.
+// Для ИСПДн 2 и 3 УЗ класс зависит от типа угроз: 2 УЗ — 4 класс при угрозах 2-го типа (и тем
+// более 1-го), 5 при угрозах 3-го типа; 3 УЗ — 5 класс при угрозах 2-го типа, 6 при угрозах 3-го.
+// Если тип неизвестен, требование не занижается.
+func (b AssetBaseline) RequiredToolClass() int {
+	if b.Regulation.Code == Regulation21 {
+		switch b.Level {
+		case 1:
+			return 4
+		case 2:
+			if b.ThreatType == 3 {
+				return 5
+			}
+			return 4
+		case 3:
+			if b.ThreatType == 3 {
+				return 6
+			}
+			return 5
+		}
+		return 6
+	}
+	return b.Level + 3
+}
@@ -0,0 +1,33 @@
+package models
+
+import "testing"
+
+// Классы СЗИ для ИСПДн по п. 12 приказа ФСТЭК №21
+func TestRequiredToolClassISPDn(t *testing.T) {
+	r, ok := FindRegulation(Regulation21)
+	if !ok {
+		t.Fatal("нет приказа №21")
+	}
+	tests := []struct {
+		level      int
+		threatType int
+		class      int
+	}{
+		{1, 1, 4},
+		{1, 2, 4},
+		{2, 1, 4},
+		{2, 2, 4},
+		{2, 3, 5},
+		{2, 0, 4},
+		{3, 2, 5},
+		{3, 3, 6},
+		{3, 0, 5},
+		{4, 3, 6},
+	}
+	for _, tt := range tests {
+		b := AssetBaseline{Regulation: r, Level: tt.level, ThreatType: tt.threatType}
+		if got := b.RequiredToolClass(); got != tt.class {
+			t.Errorf("УЗ-%d, угрозы %d-го типа: класс %d, ожидается %d", tt.level, tt.threatType, got, tt.class)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package models
+
+import "time"
+
+// CatalogStatus — статус записи каталога угроз/мер и её версии
+type CatalogStatus string
+
+const (
+	CatalogDraft      CatalogStatus = "draft"      // черновик: не виден при оценке объектов
+	CatalogPublished  CatalogStatus = "published"  // действует
+	CatalogDeprecated CatalogStatus = "deprecated" // выведена из каталога или заменена новой версией
+)
+
+var CatalogStatuses = []CatalogStatus{CatalogDraft, CatalogPublished, CatalogDeprecated}
+
+func (s CatalogStatus) Label() string {
+	switch s {
+	case CatalogDraft:
+		return "Черновик"
+	case CatalogPublished:
+		return "Действует"
+	case CatalogDeprecated:
+		return "Выведена"
+	}
+	return string(s)
+}
+
+func (s CatalogStatus) Valid() bool {
+	for _, v := range CatalogStatuses {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// CatalogFieldChange — изменение одного поля записи каталога между версиями
+type CatalogFieldChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// ThreatVersion — снимок полей угрозы каталога. Опубликованные версии не меняются:
+// правка создаёт черновик следующей версии, оценки объектов ссылаются на оценённую версию.
+type ThreatVersion struct {
+	ID       uint          `gorm:"primaryKey"`
+	ThreatID uint          `gorm:"uniqueIndex:idx_threat_version"`
+	Version  int           `gorm:"uniqueIndex:idx_threat_version"`
+	Status   CatalogStatus `gorm:"size:16;not null;default:draft"`
+
+	Name            string `gorm:"size:255;not null"`
+	Category        string `gorm:"size:64"`
+	Description     string `gorm:"type:text"`
+	Source          string `gorm:"type:text"`
+	ViolatorType    string `gorm:"size:64"`
+	Object          string `gorm:"type:text"`
+	Confidentiality bool
+	Integrity       bool
+	Availability    bool
+
+	Comment       string     `gorm:"type:text"` // что и почему изменено
+	EffectiveFrom *time.Time // дата публикации
+	EffectiveTo   *time.Time // дата замены следующей версией или вывода из каталога
+	AuthorID      *uint      // пусто — версия создана импортом или миграцией
+	CreatedAt     time.Time
+
+	Threat Threat
+	Author *User
+}
+
+func (ThreatVersion) TableName() string {
+	return "threat_versions"
+}
+
+// NewThreatVersion — снимок текущих полей угрозы
+func NewThreatVersion(th Threat) ThreatVersion {
+	return ThreatVersion{
+		ThreatID:        th.ID,
+		Name:            th.Name,
+		Category:        th.Category,
+		Description:     th.Description,
+		Source:          th.Source,
+		ViolatorType:    th.ViolatorType,
+		Object:          th.Object,
+		Confidentiality: th.Confidentiality,
+		Integrity:       th.Integrity,
+		Availability:    th.Availability,
+	}
+}
+
+// ApplyTo переносит поля версии в действующую запись каталога
+func (v ThreatVersion) ApplyTo(th *Threat) {
+	th.Name = v.Name
+	th.Category = v.Category
+	th.Description = v.Description
+	th.Source = v.Source
+	th.ViolatorType = v.ViolatorType
+	th.Object = v.Object
+	th.Confidentiality = v.Confidentiality
+	th.Integrity = v.Integrity
+	th.Availability = v.Availability
+	th.Version = v.Version
+}
+
+// Changes — поля, отличающиеся от версии prev
+func (v ThreatVersion) Changes(prev ThreatVersion) []CatalogFieldChange {
+	var changes []CatalogFieldChange
+	str := func(field, old, val string) {
+		if old != val {
+			changes = append(changes, CatalogFieldChange{Field: field, Old: old, New: val})
+		}
+	}
+	str("Наименование", prev.Name, v.Name)
+	str("Категория", prev.Category, v.Category)
+	str("Описание", prev.Description, v.Description)
+	str("Источник угрозы", prev.Source, v.Source)
+	str("Тип нарушителя", prev.ViolatorType, v.ViolatorType)
+	str("Объект воздействия", prev.Object, v.Object)
+	str("Конфиденциальность", yesNo(prev.Confidentiality), yesNo(v.Confidentiality))
+	str("Целостность", yesNo(prev.Integrity), yesNo(v.Integrity))
+	str("Доступность", yesNo(prev.Availability), yesNo(v.Availability))
+	return changes
+}
+
+// MeasureVersion — снимок полей меры каталога, устроен так же, как ThreatVersion
+type MeasureVersion struct {
+	ID        uint          `gorm:"primaryKey"`
+	MeasureID uint          `gorm:"uniqueIndex:idx_measure_version"`
+	Version   int           `gorm:"uniqueIndex:idx_measure_version"`
+	Status    CatalogStatus `gorm:"size:16;not null;default:draft"`
+
+	Name        string `gorm:"size:255;not null"`
+	GroupCode   string `gorm:"size:16"`
+	Standard    string `gorm:"size:128"`
+	Description string `gorm:"type:text"`
+
+	Comment       string `gorm:"type:text"`
+	EffectiveFrom *time.Time
+	EffectiveTo   *time.Time
+	AuthorID      *uint
+	CreatedAt     time.Time
+
+	Measure ControlMeasure
+	Author  *User
+}
+
+func (MeasureVersion) TableName() string {
+	return "measure_versions"
+}
+
+func NewMeasureVersion(m ControlMeasure) MeasureVersion {
+	return MeasureVersion{
+		MeasureID:   m.ID,
+		Name:        m.Name,
+		GroupCode:   m.GroupCode,
+		Standard:    m.Standard,
+		Description: m.Description,
+	}
+}
+
+func (v MeasureVersion) ApplyTo(m *ControlMeasure) {
+	m.Name = v.Name
+	m.GroupCode = v.GroupCode
+	m.Standard = v.Standard
+	m.Description = v.Description
+	m.Version = v.Version
+}
+
+func (v MeasureVersion) Changes(prev MeasureVersion) []CatalogFieldChange {
+	var changes []CatalogFieldChange
+	str := func(field, old, val string) {
+		if old != val {
+			changes = append(changes, CatalogFieldChange{Field: field, Old: old, New: val})
+		}
+	}
+	str("Наименование", prev.Name, v.Name)
+	str("Группа мер", prev.GroupCode, v.GroupCode)
+	str("Стандарт / норматив", prev.Standard, v.Standard)
+	str("Описание", prev.Description, v.Description)
+	return changes
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "да"
+	}
+	return "нет"
+}
+
+// AssessedVersion — версия угрозы, по которой проведена оценка; 0 — неизвестна
+func (l AssetThreat) AssessedVersion() int {
+	if l.ThreatVersion == nil {
+		return 0
+	}
+	return l.ThreatVersion.Version
+}
+
+// Outdated — после оценки опубликована новая версия угрозы
+func (l AssetThreat) Outdated() bool {
+	return l.ThreatVersion != nil && l.ThreatVersion.Version < l.Threat.Version
+}
@@ -0,0 +1,40 @@
+package models
+
+// MaskEmail — e-mail контактного лица в списках: видны первые два символа и домен
+func MaskEmail(email string) string {
+	runes := []rune(email)
+	atIdx := -1
+	for i, r := range runes {
+		if r == '@' {
+			atIdx = i
+			break
+		}
+	}
+	if atIdx <= 0 {
+		return "***"
+	}
+	prefix := string(runes[:atIdx])
+	domain := string(runes[atIdx:])
+	if len(prefix) <= 2 {
+		return prefix + "***" + domain
+	}
+	return string(runes[0:2]) + "***" + domain
+}
+
+// MaskPhone — телефон в списках: видны две последние цифры
+func MaskPhone(phone string) string {
+	runes := []rune(phone)
+	n := len(runes)
+	if n <= 4 {
+		return "***"
+	}
+	masked := make([]rune, n)
+	for i := range runes {
+		if i >= n-2 {
+			masked[i] = runes[i]
+		} else {
+			masked[i] = '*'
+		}
+	}
+	return string(masked)
+}
@@ -0,0 +1,130 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ====== DFD ОБЪЕКТА: ДИАГРАММА ПОТОКОВ ДАННЫХ ДЛЯ АНАЛИЗА STRIDE ======
+
+type DFDElementKind string
+
+const (
+	DFDProcess   DFDElementKind = "process"
+	DFDDataStore DFDElementKind = "data_store"
+	DFDExternal  DFDElementKind = "external_entity"
+)
+
+var DFDElementKinds = []DFDElementKind{DFDProcess, DFDDataStore, DFDExternal}
+
+func (k DFDElementKind) Label() string {
+	switch k {
+	case DFDProcess:
+		return "Процесс"
+	case DFDDataStore:
+		return "Хранилище данных"
+	case DFDExternal:
+		return "Внешняя сущность"
+	}
+	return string(k)
+}
+
+func (k DFDElementKind) Valid() bool {
+	for _, v := range DFDElementKinds {
+		if v == k {
+			return true
+		}
+	}
+	return false
+}
+
+// DFDBoundary — граница доверия: элементы внутри неё доверяют друг другу
+type DFDBoundary struct {
+	gorm.Model
+	AssetID     uint   `gorm:"uniqueIndex:idx_dfd_boundary"`
+	Name        string `gorm:"size:255;not null;uniqueIndex:idx_dfd_boundary"`
+	Description string `gorm:"type:text"`
+}
+
+// DFDElement — процесс, хранилище или внешняя сущность диаграммы
+type DFDElement struct {
+	gorm.Model
+	AssetID     uint           `gorm:"uniqueIndex:idx_dfd_element"`
+	Name        string         `gorm:"size:255;not null;uniqueIndex:idx_dfd_element"`
+	Kind        DFDElementKind `gorm:"type:varchar(20);not null"`
+	BoundaryID  *uint          // пусто — вне границ доверия
+	ComponentID *uint          // компонент из состава объекта
+	Description string         `gorm:"type:text"`
+
+	Boundary  *DFDBoundary
+	Component *AssetComponent
+}
+
+// DFDFlow — поток данных между элементами диаграммы
+type DFDFlow struct {
+	gorm.Model
+	AssetID       uint `gorm:"index"`
+	SourceID      uint
+	TargetID      uint
+	Name          string `gorm:"size:255;not null"` // передаваемые данные
+	Protocol      string `gorm:"size:100"`
+	Encrypted     bool   // канал защищён шифрованием
+	Authenticated bool   // источник аутентифицируется получателем
+
+	Source DFDElement
+	Target DFDElement
+}
+
+// Crosses — поток пересекает границу доверия
+func (f DFDFlow) Crosses() bool {
+	return !sameBoundary(f.Source.BoundaryID, f.Target.BoundaryID)
+}
+
+func sameBoundary(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+type SuggestionStatus string
+
+const (
+	SuggestionPending  SuggestionStatus = "pending"
+	SuggestionAccepted SuggestionStatus = "accepted"
+	SuggestionRejected SuggestionStatus = "rejected"
+)
+
+func (s SuggestionStatus) Label() string {
+	switch s {
+	case SuggestionPending:
+		return "На рассмотрении"
+	case SuggestionAccepted:
+		return "Принята"
+	case SuggestionRejected:
+		return "Отклонена"
+	}
+	return string(s)
+}
+
+// DFDSuggestion — угроза STRIDE, предложенная по диаграмме; после одобрения инженером
+// становится угрозой объекта (AssetThreat). Key — правило и элемент, по нему решение
+// сохраняется при повторном анализе и переимпорте диаграммы.
+type DFDSuggestion struct {
+	gorm.Model
+	AssetID     uint   `gorm:"uniqueIndex:idx_dfd_suggestion"`
+	Key         string `gorm:"column:rule_key;size:600;uniqueIndex:idx_dfd_suggestion"`
+	ThreatID    uint
+	Target      string `gorm:"size:600"` // элемент или поток диаграммы
+	Reason      string `gorm:"type:text"`
+	ComponentID *uint  // компонент объекта, к которому отнесётся угроза
+
+	Status      SuggestionStatus `gorm:"type:varchar(16);not null;default:pending"`
+	DecidedByID *uint
+	DecidedAt   *time.Time
+
+	Threat    Threat
+	Component *AssetComponent
+	DecidedBy *User
+}
@@ -0,0 +1,21 @@
+package models
+
+import "gorm.io/gorm"
+
+// DocumentTemplate — редактируемый шаблон документа «Модель угроз безопасности информации».
+// Body — text/template, результат которого разбирается как разметка docx.Parse.
+type DocumentTemplate struct {
+	gorm.Model
+	Name        string `gorm:"size:255;not null"`
+	Description string `gorm:"type:text"` // для какого заказчика / требований оформления
+	Body        string `gorm:"type:text;not null"`
+
+	FontFamily string `gorm:"size:64"`
+	FontSize   int    // кегль основного текста, пт
+
+	IsDefault bool `gorm:"default:false"` // предлагается первым при формировании документа
+}
+
+func (DocumentTemplate) TableName() string {
+	return "document_templates"
+}
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Framework — стандарт с каталогом требований: ISO/IEC 27001, ГОСТ Р 57580.1, NIST CSF
+type Framework struct {
+	ID     uint   `gorm:"primaryKey"`
+	Code   string `gorm:"size:32;uniqueIndex"` // iso27001, gost57580, nist-csf
+	Name   string `gorm:"size:255;not null"`
+	Levels string `gorm:"size:255"` // уровни защиты «номер=название» через запятую; пусто — уровней нет
+
+	Requirements []FrameworkRequirement
+}
+
+func (Framework) TableName() string {
+	return "frameworks"
+}
+
+// FrameworkLevel — уровень защиты стандарта, например 2 = стандартный по ГОСТ Р 57580.1
+type FrameworkLevel struct {
+	Number int
+	Label  string
+}
+
+func (f Framework) LevelList() []FrameworkLevel {
+	if f.Levels == "" {
+		return nil
+	}
+	var levels []FrameworkLevel
+	for _, part := range strings.Split(f.Levels, ",") {
+		num, label, _ := strings.Cut(part, "=")
+		n, err := strconv.Atoi(strings.TrimSpace(num))
+		if err != nil {
+			continue
+		}
+		levels = append(levels, FrameworkLevel{Number: n, Label: strings.TrimSpace(label)})
+	}
+	return levels
+}
+
+func (f Framework) ValidLevel(level int) bool {
+	for _, l := range f.LevelList() {
+		if l.Number == level {
+			return true
+		}
+	}
+	return false
+}
+
+func (f Framework) LevelLabel(level int) string {
+	for _, l := range f.LevelList() {
+		if l.Number == level {
+			return l.Label
+		}
+	}
+	return ""
+}
+
+// FrameworkRequirement — требование (мера, категория) стандарта
+type FrameworkRequirement struct {
+	ID          uint   `gorm:"primaryKey"`
+	FrameworkID uint   `gorm:"uniqueIndex:idx_framework_requirement"`
+	Code        string `gorm:"size:32;uniqueIndex:idx_framework_requirement"` // A.8.15, УЗП.1, PR.AA
+	Section     string `gorm:"size:255"`                                      // раздел / группа мер
+	Name        string `gorm:"type:text;not null"`
+	Levels      string `gorm:"size:32"` // уровни, на которых требование применяется, через запятую; пусто — на всех
+
+	Framework Framework
+}
+
+func (FrameworkRequirement) TableName() string {
+	return "framework_requirements"
+}
+
+// AppliesAt — применяется ли требование на уровне защиты; level=0 — уровень не выбран
+func (r FrameworkRequirement) AppliesAt(level int) bool {
+	if level == 0 || r.Levels == "" {
+		return true
+	}
+	for _, l := range strings.Split(r.Levels, ",") {
+		if n, err := strconv.Atoi(strings.TrimSpace(l)); err == nil && n == level {
+			return true
+		}
+	}
+	return false
+}
+
+// RequirementMeasure — сопоставление требования стандарта с мерой каталога.
+// Мера, внедрённая один раз, засчитывается во все сопоставленные требования.
+type RequirementMeasure struct {
+	ID            uint `gorm:"primaryKey"`
+	RequirementID uint `gorm:"uniqueIndex:idx_requirement_measure"`
+	MeasureID     uint `gorm:"uniqueIndex:idx_requirement_measure"`
+
+	Requirement FrameworkRequirement
+	Measure     ControlMeasure
+}
+
+func (RequirementMeasure) TableName() string {
+	return "requirement_measures"
+}
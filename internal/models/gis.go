@@ -0,0 +1,162 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Степень возможного ущерба от нарушения свойства безопасности информации (приказ ФСТЭК №17, п. 14.2)
+const (
+	DamageHigh   = "high"
+	DamageMedium = "medium"
+	DamageLow    = "low"
+)
+
+var DamageLevels = []string{DamageHigh, DamageMedium, DamageLow}
+
+func DamageLabel(level string) string {
+	switch level {
+	case DamageHigh:
+		return "высокая"
+	case DamageMedium:
+		return "средняя"
+	case DamageLow:
+		return "низкая"
+	}
+	return level
+}
+
+// Масштаб ГИС
+const (
+	GISScaleFederal  = "federal"
+	GISScaleRegional = "regional"
+	GISScaleObject   = "object"
+)
+
+var GISScales = []string{GISScaleFederal, GISScaleRegional, GISScaleObject}
+
+func GISScaleLabel(scale string) string {
+	switch scale {
+	case GISScaleFederal:
+		return "федеральный"
+	case GISScaleRegional:
+		return "региональный"
+	case GISScaleObject:
+		return "объектовый"
+	}
+	return scale
+}
+
+// GISClassification — исходные данные акта классификации ГИС и рассчитанный класс (приказ ФСТЭК №17).
+// Класс дублируется в Asset.Category в виде "К1".."К3".
+type GISClassification struct {
+	gorm.Model
+	AssetID uint `gorm:"uniqueIndex"`
+
+	ConfidentialityDamage string `gorm:"size:16"`
+	IntegrityDamage       string `gorm:"size:16"`
+	AvailabilityDamage    string `gorm:"size:16"`
+	Scale                 string `gorm:"size:16"`
+
+	// реквизиты акта классификации
+	ActNumber        string `gorm:"size:50"`
+	ActDate          *time.Time
+	InformationTypes string `gorm:"type:text"` // виды обрабатываемой информации
+	Commission       string `gorm:"type:text"` // состав комиссии, по строке на члена
+
+	Significance int    // уровень значимости информации: 1..3
+	Class        int    // класс защищённости: 1..3
+	Trail        string `gorm:"type:text"` // ход расчёта, по строке на шаг
+
+	// обоснование, если класс в карточке объекта изменён вручную
+	OverrideJustification string `gorm:"type:text"`
+}
+
+func (GISClassification) TableName() string {
+	return "gis_classifications"
+}
+
+func isDamageLevel(level string) bool {
+	for _, l := range DamageLevels {
+		if l == level {
+			return true
+		}
+	}
+	return false
+}
+
+func (g GISClassification) Validate() error {
+	if !isDamageLevel(g.ConfidentialityDamage) || !isDamageLevel(g.IntegrityDamage) || !isDamageLevel(g.AvailabilityDamage) {
+		return errors.New("укажите степень ущерба для конфиденциальности, целостности и доступности")
+	}
+	switch g.Scale {
+	case GISScaleFederal, GISScaleRegional, GISScaleObject:
+	default:
+		return errors.New("укажите масштаб информационной системы")
+	}
+	return nil
+}
+
+// Calculate определяет уровень значимости информации (п. 14.2) и класс ГИС (п. 14.3).
+// УЗ — по наибольшей степени ущерба из трёх свойств безопасности.
+func (g GISClassification) Calculate() (int, int, []string) {
+	trail := []string{
+		"Степень ущерба: конфиденциальность — " + DamageLabel(g.ConfidentialityDamage) +
+			", целостность — " + DamageLabel(g.IntegrityDamage) +
+			", доступность — " + DamageLabel(g.AvailabilityDamage),
+	}
+
+	significance := 3
+	for _, d := range []string{g.ConfidentialityDamage, g.IntegrityDamage, g.AvailabilityDamage} {
+		switch {
+		case d == DamageHigh:
+			significance = 1
+		case d == DamageMedium && significance > 2:
+			significance = 2
+		}
+	}
+	trail = append(trail, fmt.Sprintf("Уровень значимости информации: УЗ%d", significance))
+
+	class := 3
+	switch significance {
+	case 1:
+		class = 1
+	case 2:
+		class = 2
+		if g.Scale == GISScaleFederal {
+			class = 1
+		}
+	case 3:
+		if g.Scale == GISScaleFederal {
+			class = 2
+		}
+	}
+	trail = append(trail, "Масштаб системы: "+GISScaleLabel(g.Scale))
+	trail = append(trail, fmt.Sprintf("Итог: %s", GISClassLabel(class)))
+	return significance, class, trail
+}
+
+func GISClassLabel(class int) string {
+	return fmt.Sprintf("К%d", class)
+}
+
+func (g GISClassification) TrailLines() []string {
+	if g.Trail == "" {
+		return nil
+	}
+	return strings.Split(g.Trail, "\n")
+}
+
+func (g GISClassification) CommissionLines() []string {
+	var lines []string
+	for _, l := range strings.Split(strings.ReplaceAll(g.Commission, "\r\n", "\n"), "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			lines = append(lines, l)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Host — узел из инвентаризации клиента, заводится и обновляется импортом отчётов сканеров
+type Host struct {
+	gorm.Model
+	ClientID uint   `gorm:"uniqueIndex:idx_client_host"`
+	Address  string `gorm:"size:64;uniqueIndex:idx_client_host"` // IP-адрес
+	AssetID  *uint  // объект защиты, в состав которого входит узел; пусто — не сопоставлен
+
+	Hostname   string `gorm:"size:255"`
+	MAC        string `gorm:"size:32"`
+	OS         string `gorm:"size:255"`
+	LastScanAt *time.Time
+	LastSource string `gorm:"size:32"` // nmap / openvas / сопоставление CSV
+
+	Client   Client
+	Asset    *Asset
+	Services []HostService
+	Findings []HostFinding
+}
+
+// HostService — открытый порт узла
+type HostService struct {
+	ID       uint   `gorm:"primaryKey"`
+	HostID   uint   `gorm:"uniqueIndex:idx_host_service"`
+	Port     int    `gorm:"uniqueIndex:idx_host_service"`
+	Protocol string `gorm:"size:8;uniqueIndex:idx_host_service"`
+	Service  string `gorm:"size:64"`
+	Product  string `gorm:"size:255"` // продукт и версия по данным сканера
+
+	LastSeenAt time.Time
+}
+
+// HostFinding — уязвимость, найденная сканером на узле. С каталогом уязвимостей
+// связывается по BDU/CVE; найденные в каталоге попадают в реестр уязвимостей объекта.
+type HostFinding struct {
+	ID     uint   `gorm:"primaryKey"`
+	HostID uint   `gorm:"uniqueIndex:idx_host_finding"`
+	Key    string `gorm:"column:check_key;size:255;uniqueIndex:idx_host_finding"` // идентификатор проверки сканера
+	Port   string `gorm:"size:16;uniqueIndex:idx_host_finding"`
+
+	Name     string `gorm:"type:text"`
+	CVE      string `gorm:"type:text"`
+	BDU      string `gorm:"size:255"`
+	Score    float64
+	Severity string `gorm:"size:16"`
+	Solution string `gorm:"type:text"`
+	Source   string `gorm:"size:32"`
+
+	VulnerabilityID *uint
+	FirstSeenAt     time.Time
+	LastSeenAt      time.Time
+
+	Host          Host
+	Vulnerability *Vulnerability
+}
+
+func (f HostFinding) SeverityLabel() string {
+	return SeverityLabel(f.Severity)
+}
@@ -0,0 +1,148 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Вид нарушителя по Методике оценки угроз ФСТЭК 2021 г.
+const (
+	IntruderExternal = "external"
+	IntruderInternal = "internal"
+)
+
+var IntruderKinds = []string{IntruderExternal, IntruderInternal}
+
+func IntruderKindLabel(kind string) string {
+	switch kind {
+	case IntruderExternal:
+		return "внешний"
+	case IntruderInternal:
+		return "внутренний"
+	}
+	return kind
+}
+
+// Уровни возможностей нарушителя Н1–Н4
+var IntruderLevels = []int{1, 2, 3, 4}
+
+func IntruderLevelLabel(level int) string {
+	switch level {
+	case 1:
+		return "Н1 — базовые возможности"
+	case 2:
+		return "Н2 — базовые повышенные возможности"
+	case 3:
+		return "Н3 — средние возможности"
+	case 4:
+		return "Н4 — высокие возможности"
+	}
+	return fmt.Sprintf("Н%d", level)
+}
+
+// Intruder — вид нарушителя из каталога: типовые возможности и мотивация
+type Intruder struct {
+	gorm.Model
+	Code       string `gorm:"size:16;uniqueIndex"`
+	Name       string `gorm:"size:255;not null"`
+	Kind       string `gorm:"size:16;not null"` // external / internal
+	Level      int    // типовой уровень возможностей 1..4
+	Motivation string `gorm:"type:text"` // возможные цели (мотивация)
+}
+
+func (Intruder) TableName() string {
+	return "intruders"
+}
+
+func (i Intruder) Validate() error {
+	if len([]rune(strings.TrimSpace(i.Name))) < 3 {
+		return errors.New("название нарушителя должно быть не короче 3 символов")
+	}
+	if i.Kind != IntruderExternal && i.Kind != IntruderInternal {
+		return errors.New("укажите вид нарушителя")
+	}
+	if i.Level < 1 || i.Level > 4 {
+		return errors.New("укажите уровень возможностей Н1–Н4")
+	}
+	return nil
+}
+
+// AssetIntruder — нарушитель, признанный актуальным для объекта защиты.
+// Level может отличаться от типового уровня каталога, Justification обязателен.
+type AssetIntruder struct {
+	ID         uint `gorm:"primaryKey"`
+	AssetID    uint `gorm:"uniqueIndex:idx_asset_intruder"`
+	IntruderID uint `gorm:"uniqueIndex:idx_asset_intruder"`
+
+	Level         int
+	Motivation    string `gorm:"type:text"`
+	Justification string `gorm:"type:text"`
+
+	Intruder Intruder
+}
+
+func (AssetIntruder) TableName() string {
+	return "asset_intruders"
+}
+
+// IntruderRequirement — нарушитель, способный реализовать угрозу: вид и минимальный уровень
+type IntruderRequirement struct {
+	Kind  string
+	Level int
+}
+
+func (r IntruderRequirement) Label() string {
+	return fmt.Sprintf("%s, не ниже Н%d", IntruderKindLabel(r.Kind), r.Level)
+}
+
+var intruderLevelRe = regexp.MustCompile(`н([1-4])`)
+
+// IntruderRequirements разбирает поле «Источник угрозы» БДУ, например
+// "Внешний нарушитель с низким потенциалом; Внутренний нарушитель со средним потенциалом".
+// Потенциал БДУ сопоставляется уровням Методики: низкий — Н1, средний — Н2, высокий — Н3.
+// Пустой результат — источник не указан, угрозу может реализовать любой нарушитель.
+func (t Threat) IntruderRequirements() []IntruderRequirement {
+	var reqs []IntruderRequirement
+	for _, part := range strings.FieldsFunc(strings.ToLower(t.Source), func(r rune) bool {
+		return r == ';' || r == ',' || r == '\n'
+	}) {
+		var kind string
+		switch {
+		case strings.Contains(part, "внешн"):
+			kind = IntruderExternal
+		case strings.Contains(part, "внутрен"):
+			kind = IntruderInternal
+		default:
+			continue
+		}
+
+		level := 1
+		switch {
+		case intruderLevelRe.MatchString(part):
+			level = int(intruderLevelRe.FindStringSubmatch(part)[1][0] - '0')
+		case strings.Contains(part, "высок"):
+			level = 3
+		case strings.Contains(part, "средн"):
+			level = 2
+		}
+		reqs = append(reqs, IntruderRequirement{Kind: kind, Level: level})
+	}
+	return reqs
+}
+
+// CanRealise — может ли нарушитель из профиля объекта реализовать угрозу с такими требованиями
+func (ai AssetIntruder) CanRealise(reqs []IntruderRequirement) bool {
+	if len(reqs) == 0 {
+		return true
+	}
+	for _, r := range reqs {
+		if ai.Intruder.Kind == r.Kind && ai.Level >= r.Level {
+			return true
+		}
+	}
+	return false
+}
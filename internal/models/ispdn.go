@@ -0,0 +1,170 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Категории ПДн по ПП РФ №1119
+const (
+	PDSpecial   = "special"
+	PDBiometric = "biometric"
+	PDPublic    = "public"
+	PDOther     = "other"
+)
+
+var PDCategories = []string{PDSpecial, PDBiometric, PDPublic, PDOther}
+
+func PDCategoryLabel(cat string) string {
+	switch cat {
+	case PDSpecial:
+		return "специальные"
+	case PDBiometric:
+		return "биометрические"
+	case PDPublic:
+		return "общедоступные"
+	case PDOther:
+		return "иные"
+	}
+	return cat
+}
+
+// ISPDnAssessment — анкета ИСПДн и результат определения уровня защищённости (ПП РФ №1119).
+// Результат дублируется в Asset.Category в виде "УЗ-N".
+type ISPDnAssessment struct {
+	gorm.Model
+	AssetID uint `gorm:"uniqueIndex"`
+
+	Special   bool // специальные категории ПДн
+	Biometric bool // биометрические ПДн
+	Public    bool // общедоступные ПДн
+	Other     bool // иные категории ПДн
+
+	EmployeesOnly bool // обрабатываются ПДн только сотрудников оператора
+	Over100k      bool // более 100 000 субъектов
+	ThreatType    int  // тип актуальных угроз: 1, 2 или 3
+
+	Level int    // рассчитанный УЗ: 1..4
+	Trail string `gorm:"type:text"` // ход расчёта, по строке на шаг
+}
+
+func (ISPDnAssessment) TableName() string {
+	return "ispdn_assessments"
+}
+
+// Categories — отмеченные в анкете категории ПДн
+func (a ISPDnAssessment) Categories() []string {
+	var cats []string
+	for _, c := range []struct {
+		on  bool
+		cat string
+	}{{a.Special, PDSpecial}, {a.Biometric, PDBiometric}, {a.Public, PDPublic}, {a.Other, PDOther}} {
+		if c.on {
+			cats = append(cats, c.cat)
+		}
+	}
+	return cats
+}
+
+// Validate — анкета заполнена достаточно для расчёта
+func (a ISPDnAssessment) Validate() error {
+	if len(a.Categories()) == 0 {
+		return errors.New("отметьте хотя бы одну категорию ПДн")
+	}
+	if a.ThreatType < 1 || a.ThreatType > 3 {
+		return errors.New("укажите тип актуальных угроз (1, 2 или 3)")
+	}
+	return nil
+}
+
+// Calculate определяет УЗ по пп. 9–12 ПП №1119. При нескольких категориях ПДн
+// итоговый уровень — наиболее высокий (наименьший номер) из уровней по каждой категории.
+func (a ISPDnAssessment) Calculate() (int, []string) {
+	// "много субъектов" в ПП — более 100 000 субъектов, не являющихся сотрудниками оператора
+	large := a.Over100k && !a.EmployeesOnly
+
+	var trail []string
+	if a.EmployeesOnly {
+		trail = append(trail, "Обрабатываются ПДн только сотрудников оператора")
+	} else if a.Over100k {
+		trail = append(trail, "Обрабатываются ПДн более 100 000 субъектов, не являющихся сотрудниками")
+	} else {
+		trail = append(trail, "Обрабатываются ПДн менее 100 000 субъектов, не являющихся сотрудниками")
+	}
+	trail = append(trail, fmt.Sprintf("Актуальны угрозы %d-го типа", a.ThreatType))
+
+	result := 4
+	for _, cat := range a.Categories() {
+		level, rule := ispdnLevel(cat, a.ThreatType, large)
+		trail = append(trail, fmt.Sprintf("Категория «%s»: УЗ-%d (%s)", PDCategoryLabel(cat), level, rule))
+		if level < result {
+			result = level
+		}
+	}
+	trail = append(trail, fmt.Sprintf("Итог: %s", ISPDnLevelLabel(result)))
+	return result, trail
+}
+
+// ispdnLevel — УЗ для одной категории ПДн и ссылка на подпункт ПП №1119
+func ispdnLevel(cat string, threatType int, large bool) (int, string) {
+	switch threatType {
+	case 1:
+		if cat == PDPublic {
+			return 2, "п. 10 «а»"
+		}
+		return 1, "п. 9 «а»"
+	case 2:
+		switch cat {
+		case PDSpecial:
+			if large {
+				return 1, "п. 9 «б»"
+			}
+			return 2, "п. 10 «б»"
+		case PDBiometric:
+			return 2, "п. 10 «в»"
+		case PDPublic:
+			if large {
+				return 2, "п. 10 «г»"
+			}
+			return 3, "п. 11 «а»"
+		}
+		// иные
+		if large {
+			return 2, "п. 10 «д»"
+		}
+		return 3, "п. 11 «б»"
+	}
+
+	// угрозы 3-го типа
+	switch cat {
+	case PDSpecial:
+		if large {
+			return 2, "п. 10 «е»"
+		}
+		return 3, "п. 11 «в»"
+	case PDBiometric:
+		return 3, "п. 11 «г»"
+	case PDPublic:
+		return 4, "п. 12 «а»"
+	}
+	// иные
+	if large {
+		return 3, "п. 11 «д»"
+	}
+	return 4, "п. 12 «б»"
+}
+
+func ISPDnLevelLabel(level int) string {
+	return fmt.Sprintf("УЗ-%d", level)
+}
+
+// TrailLines — ход расчёта построчно для шаблона
+func (a ISPDnAssessment) TrailLines() []string {
+	if a.Trail == "" {
+		return nil
+	}
+	return strings.Split(a.Trail, "\n")
+}
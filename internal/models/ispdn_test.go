@@ -0,0 +1,76 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+// Уровни защищённости и подпункты пп. 9–12 ПП №1119 для всех сочетаний категории ПДн,
+// типа актуальных угроз и объёма (large — более 100 000 субъектов, не являющихся сотрудниками)
+func TestISPDnLevel(t *testing.T) {
+	tests := []struct {
+		cat        string
+		threatType int
+		large      bool
+		level      int
+		rule       string
+	}{
+		{PDSpecial, 1, false, 1, "п. 9 «а»"},
+		{PDSpecial, 1, true, 1, "п. 9 «а»"},
+		{PDBiometric, 1, false, 1, "п. 9 «а»"},
+		{PDBiometric, 1, true, 1, "п. 9 «а»"},
+		{PDOther, 1, false, 1, "п. 9 «а»"},
+		{PDOther, 1, true, 1, "п. 9 «а»"},
+		{PDPublic, 1, false, 2, "п. 10 «а»"},
+		{PDPublic, 1, true, 2, "п. 10 «а»"},
+
+		{PDSpecial, 2, true, 1, "п. 9 «б»"},
+		{PDSpecial, 2, false, 2, "п. 10 «б»"},
+		{PDBiometric, 2, false, 2, "п. 10 «в»"},
+		{PDBiometric, 2, true, 2, "п. 10 «в»"},
+		{PDPublic, 2, true, 2, "п. 10 «г»"},
+		{PDPublic, 2, false, 3, "п. 11 «а»"},
+		{PDOther, 2, true, 2, "п. 10 «д»"},
+		{PDOther, 2, false, 3, "п. 11 «б»"},
+
+		{PDSpecial, 3, true, 2, "п. 10 «е»"},
+		{PDSpecial, 3, false, 3, "п. 11 «в»"},
+		{PDBiometric, 3, false, 3, "п. 11 «г»"},
+		{PDBiometric, 3, true, 3, "п. 11 «г»"},
+		{PDOther, 3, true, 3, "п. 11 «д»"},
+		{PDOther, 3, false, 4, "п. 12 «б»"},
+		{PDPublic, 3, false, 4, "п. 12 «а»"},
+		{PDPublic, 3, true, 4, "п. 12 «а»"},
+	}
+	for _, tt := range tests {
+		level, rule := ispdnLevel(tt.cat, tt.threatType, tt.large)
+		if level != tt.level || rule != tt.rule {
+			t.Errorf("%s, угрозы %d-го типа, large=%v: УЗ-%d (%s), ожидался УЗ-%d (%s)",
+				tt.cat, tt.threatType, tt.large, level, rule, tt.level, tt.rule)
+		}
+	}
+}
+
+func TestISPDnCalculate(t *testing.T) {
+	tests := []struct {
+		name  string
+		a     ISPDnAssessment
+		level int
+	}{
+		// ПДн только сотрудников — не «много субъектов», даже если их больше 100 000
+		{"employees only", ISPDnAssessment{Public: true, EmployeesOnly: true, Over100k: true, ThreatType: 2}, 3},
+		{"public over 100k", ISPDnAssessment{Public: true, Over100k: true, ThreatType: 2}, 2},
+		// при нескольких категориях — наивысший уровень
+		{"several categories", ISPDnAssessment{Public: true, Special: true, Over100k: true, ThreatType: 2}, 1},
+		{"public only", ISPDnAssessment{Public: true, ThreatType: 3}, 4},
+	}
+	for _, tt := range tests {
+		level, trail := tt.a.Calculate()
+		if level != tt.level {
+			t.Errorf("%s: УЗ-%d, ожидался УЗ-%d", tt.name, level, tt.level)
+		}
+		if last := trail[len(trail)-1]; !strings.HasSuffix(last, ISPDnLevelLabel(tt.level)) {
+			t.Errorf("%s: итог в ходе расчёта %q", tt.name, last)
+		}
+	}
+}
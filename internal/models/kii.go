@@ -0,0 +1,196 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Группы показателей значимости по ПП РФ №127
+const (
+	KIISocial     = "social"
+	KIIPolitical  = "political"
+	KIIEconomic   = "economic"
+	KIIEcological = "ecological"
+	KIIDefence    = "defence"
+)
+
+var KIIGroups = []string{KIISocial, KIIPolitical, KIIEconomic, KIIEcological, KIIDefence}
+
+func KIIGroupLabel(group string) string {
+	switch group {
+	case KIISocial:
+		return "Социальная значимость"
+	case KIIPolitical:
+		return "Политическая значимость"
+	case KIIEconomic:
+		return "Экономическая значимость"
+	case KIIEcological:
+		return "Экологическая значимость"
+	case KIIDefence:
+		return "Значимость для обороны, безопасности и правопорядка"
+	}
+	return group
+}
+
+// KIIIndicator — показатель значимости из перечня ПП №127.
+// Thresholds — минимальные значения для 3, 2 и 1 категории; ниже порога 3 категории показатель не значим.
+type KIIIndicator struct {
+	Code       string
+	Group      string
+	Name       string
+	Unit       string
+	Thresholds [3]float64
+}
+
+// Уровни для показателей, которые оцениваются не числом, а масштабом последствий
+const (
+	KIIScaleMunicipal = 1
+	KIIScaleRegional  = 2
+	KIIScaleFederal   = 3
+)
+
+var KIIIndicators = []KIIIndicator{
+	{"1.1", KIISocial, "Причинение ущерба жизни и здоровью людей", "человек", [3]float64{1, 51, 501}},
+	{"1.2", KIISocial, "Прекращение или нарушение функционирования объектов обеспечения жизнедеятельности населения", "человек", [3]float64{2000, 1000000, 2000000}},
+	{"1.3", KIISocial, "Прекращение или нарушение функционирования объектов транспортной инфраструктуры", "человек", [3]float64{2000, 1000000, 2000000}},
+	{"1.4", KIISocial, "Прекращение или нарушение функционирования сети связи", "человек", [3]float64{2000, 1000000, 2000000}},
+	{"2.1", KIIPolitical, "Прекращение или нарушение функционирования органа государственной власти или местного самоуправления",
+		"масштаб: 1 — муниципальный, 2 — региональный, 3 — федеральный", [3]float64{KIIScaleMunicipal, KIIScaleRegional, KIIScaleFederal}},
+	{"3.1", KIIEconomic, "Ущерб субъекту КИИ и (или) бюджетам Российской Федерации", "% от годового дохода (бюджета)", [3]float64{0.001, 0.05, 0.1}},
+	{"4.1", KIIEcological, "Вредные воздействия на окружающую среду", "человек, проживающих на территории", [3]float64{2000, 1000000, 2000000}},
+	{"5.1", KIIDefence, "Прекращение или нарушение проведения мероприятий по обеспечению обороны, безопасности и правопорядка",
+		"масштаб: 1 — муниципальный, 2 — региональный, 3 — федеральный", [3]float64{KIIScaleMunicipal, KIIScaleRegional, KIIScaleFederal}},
+}
+
+func FindKIIIndicator(code string) (KIIIndicator, bool) {
+	for _, ind := range KIIIndicators {
+		if ind.Code == code {
+			return ind, true
+		}
+	}
+	return KIIIndicator{}, false
+}
+
+// Category — категория по значению показателя: 1..3 или 0, если порог не достигнут
+func (ind KIIIndicator) Category(value float64) int {
+	for i := 2; i >= 0; i-- {
+		if value >= ind.Thresholds[i] {
+			return 3 - i
+		}
+	}
+	return 0
+}
+
+// KIIObject — объект КИИ в реестре клиента: результат категорирования по 187-ФЗ и ПП №127.
+// Категория дублируется в Asset.Category.
+type KIIObject struct {
+	gorm.Model
+	ClientID uint
+	AssetID  uint `gorm:"uniqueIndex"`
+
+	CriticalProcesses string `gorm:"type:text"` // критические процессы, по строке на процесс
+
+	Category int    // 1..3; 0 — объект не является значимым
+	Trail    string `gorm:"type:text"`
+
+	DecisionNumber string `gorm:"size:50"` // номер акта (протокола) комиссии по категорированию
+	DecisionDate   *time.Time
+
+	Asset      Asset
+	Indicators []KIIIndicatorValue `gorm:"foreignKey:ObjectID"`
+}
+
+func (KIIObject) TableName() string {
+	return "kii_objects"
+}
+
+// KIIIndicatorValue — значение показателя значимости для объекта КИИ
+type KIIIndicatorValue struct {
+	ID       uint   `gorm:"primaryKey"`
+	ObjectID uint   `gorm:"uniqueIndex:idx_kii_indicator"`
+	Code     string `gorm:"size:16;uniqueIndex:idx_kii_indicator"`
+
+	Value         float64
+	Justification string `gorm:"type:text"` // расчёт или источник значения
+}
+
+func (KIIIndicatorValue) TableName() string {
+	return "kii_indicator_values"
+}
+
+// Calculate — итоговая категория: наивысшая (наименьший номер) по всем показателям
+func (o KIIObject) Calculate() (int, []string) {
+	values := make(map[string]float64, len(o.Indicators))
+	for _, v := range o.Indicators {
+		values[v.Code] = v.Value
+	}
+
+	var trail []string
+	result := 0
+	for _, ind := range KIIIndicators {
+		v, ok := values[ind.Code]
+		if !ok {
+			continue
+		}
+		cat := ind.Category(v)
+		trail = append(trail, fmt.Sprintf("Показатель %s «%s»: %s (%s) — %s",
+			ind.Code, ind.Name, formatKIIValue(v), ind.Unit, KIICategoryLabel(cat)))
+		if cat > 0 && (result == 0 || cat < result) {
+			result = cat
+		}
+	}
+	trail = append(trail, "Итог: "+KIICategoryLabel(result))
+	return result, trail
+}
+
+func formatKIIValue(v float64) string {
+	return strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.3f", v), "0"), ".")
+}
+
+func KIICategoryLabel(category int) string {
+	if category == 0 {
+		return "не значимый"
+	}
+	return fmt.Sprintf("%d категория значимости", category)
+}
+
+// AssetCategory — значение для Asset.Category
+func (o KIIObject) AssetCategory() string {
+	if o.Category == 0 {
+		return "КИИ: не значимый"
+	}
+	return fmt.Sprintf("КИИ: %d категория", o.Category)
+}
+
+// BaselineLabel — какой базовый набор мер по приказу ФСТЭК №239 положен объекту
+func (o KIIObject) BaselineLabel() string {
+	if o.Category == 0 {
+		return ""
+	}
+	return fmt.Sprintf("приказ ФСТЭК №239, базовый набор мер для %d категории", o.Category)
+}
+
+func (o KIIObject) ProcessLines() []string {
+	var lines []string
+	for _, l := range strings.Split(strings.ReplaceAll(o.CriticalProcesses, "\r\n", "\n"), "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			lines = append(lines, l)
+		}
+	}
+	return lines
+}
+
+func (o KIIObject) TrailLines() []string {
+	if o.Trail == "" {
+		return nil
+	}
+	return strings.Split(o.Trail, "\n")
+}
+
+// IsKIICandidate — объекты, которые могут быть объектами КИИ и подлежат категорированию
+func (t AssetType) IsKIICandidate() bool {
+	return t == AssetASUTP || t == AssetCorpIT
+}
@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+// PasswordHistory — прежние пароли пользователя (только bcrypt-хеши), чтобы их нельзя
+// было выбрать снова
+type PasswordHistory struct {
+	ID           uint `gorm:"primaryKey"`
+	UserID       uint `gorm:"index;not null"`
+	PasswordHash string
+	CreatedAt    time.Time
+}
+
+// LoginLock — счётчик неудачных входов и блокировка по логину или по адресу
+type LoginLock struct {
+	Key           string `gorm:"primaryKey;size:150"` // "account:<логин>" или "ip:<адрес>"
+	Failures      int    // неудачных попыток подряд
+	Lockouts      int    // сколько раз уже блокировали — от этого зависит длительность
+	LastFailureAt time.Time
+	LockedUntil   *time.Time
+	UpdatedAt     time.Time
+}
+
+// Locked — действует ли блокировка в момент now
+func (l LoginLock) Locked(now time.Time) bool {
+	return l.LockedUntil != nil && now.Before(*l.LockedUntil)
+}
+
+// события входа в журнале аудита (entity "auth")
+const (
+	AuthEntity       = "auth"
+	AuthLoginSuccess = "login_success" // успешный вход
+	AuthLoginFailed  = "login_failed"  // неверный логин или пароль
+	AuthLoginDenied  = "login_denied"  // пароль верный, но учётная запись не активна
+	AuthLockout      = "lockout"       // после очередной неудачи вход заблокирован
+	AuthLoginBlocked = "login_blocked" // попытка входа во время блокировки
+	AuthUnlock       = "unlock"        // администратор снял блокировку
+)
+
+var AuthActions = []string{AuthLoginSuccess, AuthLoginFailed, AuthLoginDenied, AuthLockout, AuthLoginBlocked, AuthUnlock}
+
+// AuthActionLabel — подпись события входа для журнала
+func AuthActionLabel(action string) string {
+	switch action {
+	case AuthLoginSuccess:
+		return "успешный вход"
+	case AuthLoginFailed:
+		return "неудачный вход"
+	case AuthLoginDenied:
+		return "вход запрещён"
+	case AuthLockout:
+		return "блокировка входа"
+	case AuthLoginBlocked:
+		return "попытка при блокировке"
+	case AuthUnlock:
+		return "блокировка снята"
+	}
+	return action
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ProjectType string
+
+const (
+	ProjectAudit   ProjectType = "audit"
+	ProjectSZI     ProjectType = "szi_deploy"
+	ProjectPentest ProjectType = "pentest"
+	ProjectSupport ProjectType = "support"
+)
+
+// ProjectTypes — порядок вывода типов в формах
+var ProjectTypes = []ProjectType{ProjectAudit, ProjectSZI, ProjectPentest, ProjectSupport}
+
+func (t ProjectType) Label() string {
+	switch t {
+	case ProjectAudit:
+		return "Аудит ИБ"
+	case ProjectSZI:
+		return "Внедрение СЗИ"
+	case ProjectPentest:
+		return "Пентест"
+	case ProjectSupport:
+		return "Сопровождение"
+	}
+	return string(t)
+}
+
+func (t ProjectType) Valid() bool {
+	for _, v := range ProjectTypes {
+		if v == t {
+			return true
+		}
+	}
+	return false
+}
+
+type ProjectStatus string
+
+const (
+	ProjectDraft      ProjectStatus = "draft"
+	ProjectContracted ProjectStatus = "contracted"
+	ProjectInProgress ProjectStatus = "in_progress"
+	ProjectAcceptance ProjectStatus = "acceptance"
+	ProjectClosed     ProjectStatus = "closed"
+)
+
+// ProjectStatuses — жизненный цикл проекта по порядку
+var ProjectStatuses = []ProjectStatus{
+	ProjectDraft, ProjectContracted, ProjectInProgress, ProjectAcceptance, ProjectClosed,
+}
+
+// допустимые переходы статусов: только вперёд по шагу,
+// плюс возврат с приёмки в работу (замечания заказчика)
+var projectTransitions = map[ProjectStatus][]ProjectStatus{
+	ProjectDraft:      {ProjectContracted},
+	ProjectContracted: {ProjectInProgress},
+	ProjectInProgress: {ProjectAcceptance},
+	ProjectAcceptance: {ProjectClosed, ProjectInProgress},
+	ProjectClosed:     {},
+}
+
+func (s ProjectStatus) Label() string {
+	switch s {
+	case ProjectDraft:
+		return "Черновик"
+	case ProjectContracted:
+		return "Договор заключён"
+	case ProjectInProgress:
+		return "В работе"
+	case ProjectAcceptance:
+		return "Приёмка"
+	case ProjectClosed:
+		return "Закрыт"
+	}
+	return string(s)
+}
+
+// NextStatuses — куда проект может перейти из текущего статуса
+func (s ProjectStatus) NextStatuses() []ProjectStatus {
+	return projectTransitions[s]
+}
+
+func (s ProjectStatus) CanTransitionTo(next ProjectStatus) bool {
+	for _, v := range projectTransitions[s] {
+		if v == next {
+			return true
+		}
+	}
+	return false
+}
+
+// Проект интегратора по клиенту: аудит, внедрение СЗИ, пентест, сопровождение
+type Project struct {
+	gorm.Model
+	ClientID uint
+	Client   Client
+
+	Name        string        `gorm:"size:255;not null"`
+	ProjectType ProjectType   `gorm:"type:varchar(30);not null"`
+	Status      ProjectStatus `gorm:"type:varchar(30);not null;default:draft"`
+	Description string        `gorm:"type:text"`
+
+	// ответственные
+	EngineerID *uint
+	Engineer   *User
+	SalesID    *uint
+	Sales      *User
+
+	// плановые и фактические сроки
+	PlannedStart *time.Time
+	PlannedEnd   *time.Time
+	ActualStart  *time.Time
+	ActualEnd    *time.Time
+
+	Assets []Asset `gorm:"many2many:project_assets;"`
+}
@@ -0,0 +1,124 @@
+package models
+
+import "strings"
+
+// Уровни риска. Значения совпадают с прежним свободным полем AssetThreat.RiskLevel.
+const (
+	RiskLow    = "low"
+	RiskMedium = "medium"
+	RiskHigh   = "high"
+)
+
+// RiskLevels — порядок уровней от меньшего к большему
+var RiskLevels = []string{RiskLow, RiskMedium, RiskHigh}
+
+func RiskLevelLabel(level string) string {
+	switch level {
+	case RiskLow:
+		return "низкий"
+	case RiskMedium:
+		return "средний"
+	case RiskHigh:
+		return "высокий"
+	}
+	return level
+}
+
+// RiskMatrix — настраиваемая матрица "вероятность × ущерб".
+// В системе используется одна матрица (первая запись), её правит админ.
+type RiskMatrix struct {
+	ID uint `gorm:"primaryKey"`
+
+	LikelihoodScale  int    `gorm:"not null"`  // размер шкалы вероятности, например 5 (значения 1..5)
+	ImpactScale      int    `gorm:"not null"`  // размер шкалы ущерба
+	LikelihoodLabels string `gorm:"type:text"` // подписи значений шкалы, по одной на строку
+	ImpactLabels     string `gorm:"type:text"`
+
+	Cells []RiskMatrixCell `gorm:"foreignKey:MatrixID"`
+}
+
+// RiskMatrixCell — уровень риска для пары (вероятность, ущерб)
+type RiskMatrixCell struct {
+	ID       uint `gorm:"primaryKey"`
+	MatrixID uint `gorm:"uniqueIndex:idx_risk_cell"`
+
+	Likelihood int    `gorm:"uniqueIndex:idx_risk_cell"`
+	Impact     int    `gorm:"uniqueIndex:idx_risk_cell"`
+	Level      string `gorm:"size:16;not null"`
+}
+
+// Level — уровень риска по матрице; 0 или выход за шкалу дают пустую строку
+func (m *RiskMatrix) Level(likelihood, impact int) string {
+	if !m.InScale(likelihood, impact) {
+		return ""
+	}
+	for _, cell := range m.Cells {
+		if cell.Likelihood == likelihood && cell.Impact == impact {
+			return cell.Level
+		}
+	}
+	return DefaultRiskLevel(likelihood, impact, m.LikelihoodScale, m.ImpactScale)
+}
+
+func (m *RiskMatrix) InScale(likelihood, impact int) bool {
+	return likelihood >= 1 && likelihood <= m.LikelihoodScale &&
+		impact >= 1 && impact <= m.ImpactScale
+}
+
+// Representative — типовая оценка для уровня риска: ячейка на диагонали матрицы
+// (медианная среди подходящих). Используется при переносе старых оценок low/medium/high.
+func (m *RiskMatrix) Representative(level string) (int, int, bool) {
+	n := m.LikelihoodScale
+	if m.ImpactScale < n {
+		n = m.ImpactScale
+	}
+
+	var diag []int
+	for k := 1; k <= n; k++ {
+		if m.Level(k, k) == level {
+			diag = append(diag, k)
+		}
+	}
+	if len(diag) > 0 {
+		k := diag[len(diag)/2]
+		return k, k, true
+	}
+
+	for l := 1; l <= m.LikelihoodScale; l++ {
+		for i := 1; i <= m.ImpactScale; i++ {
+			if m.Level(l, i) == level {
+				return l, i, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+func (m *RiskMatrix) LikelihoodLabel(v int) string {
+	return scaleLabel(m.LikelihoodLabels, v)
+}
+
+func (m *RiskMatrix) ImpactLabel(v int) string {
+	return scaleLabel(m.ImpactLabels, v)
+}
+
+func scaleLabel(labels string, v int) string {
+	lines := strings.Split(strings.ReplaceAll(labels, "\r\n", "\n"), "\n")
+	if v >= 1 && v <= len(lines) && strings.TrimSpace(lines[v-1]) != "" {
+		return strings.TrimSpace(lines[v-1])
+	}
+	return ""
+}
+
+// DefaultRiskLevel — уровень по доле произведения от максимума шкалы:
+// для 5×5 это 1–4 низкий, 5–12 средний, 15–25 высокий.
+func DefaultRiskLevel(likelihood, impact, lScale, iScale int) string {
+	ratio := float64(likelihood*impact) / float64(lScale*iScale)
+	switch {
+	case ratio < 0.2:
+		return RiskLow
+	case ratio < 0.5:
+		return RiskMedium
+	}
+	return RiskHigh
+}
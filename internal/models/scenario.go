@@ -0,0 +1,103 @@
+package models
+
+import "strings"
+
+// Tactic — тактика реализации угроз Т1–Т10 из приложения 11 к Методике ФСТЭК 2021 г.
+type Tactic struct {
+	ID   uint   `gorm:"primaryKey"`
+	Code string `gorm:"size:8;uniqueIndex"` // Т1..Т10
+	Name string `gorm:"type:text;not null"`
+
+	Techniques []Technique
+}
+
+func (Tactic) TableName() string {
+	return "tactics"
+}
+
+// Technique — техника тактики, например Т2.8 (социальная инженерия)
+type Technique struct {
+	ID       uint   `gorm:"primaryKey"`
+	TacticID uint   `gorm:"index"`
+	Code     string `gorm:"size:8;uniqueIndex"` // Т2.8
+	Name     string `gorm:"type:text;not null"`
+
+	Tactic Tactic
+}
+
+func (Technique) TableName() string {
+	return "techniques"
+}
+
+type ScenarioFeasibility string
+
+const (
+	ScenarioNotAssessed ScenarioFeasibility = "not_assessed"
+	ScenarioFeasible    ScenarioFeasibility = "feasible"
+	ScenarioInfeasible  ScenarioFeasibility = "infeasible"
+)
+
+var ScenarioFeasibilities = []ScenarioFeasibility{
+	ScenarioNotAssessed, ScenarioFeasible, ScenarioInfeasible,
+}
+
+func (f ScenarioFeasibility) Label() string {
+	switch f {
+	case ScenarioNotAssessed:
+		return "Не оценён"
+	case ScenarioFeasible:
+		return "Реализуем"
+	case ScenarioInfeasible:
+		return "Не реализуем"
+	}
+	return string(f)
+}
+
+func (f ScenarioFeasibility) Valid() bool {
+	for _, v := range ScenarioFeasibilities {
+		if v == f {
+			return true
+		}
+	}
+	return false
+}
+
+// ThreatScenario — сценарий реализации угрозы объекта: упорядоченная цепочка техник
+// и вывод о возможности его реализации с обоснованием
+type ThreatScenario struct {
+	ID            uint   `gorm:"primaryKey"`
+	AssetThreatID uint   `gorm:"index"`
+	Name          string `gorm:"size:255;not null"`
+
+	Feasibility   ScenarioFeasibility `gorm:"type:varchar(20);not null;default:not_assessed"`
+	Justification string              `gorm:"type:text"` // обязательно для вывода «реализуем» / «не реализуем»
+
+	Steps []ScenarioStep `gorm:"foreignKey:ScenarioID"`
+}
+
+func (ThreatScenario) TableName() string {
+	return "threat_scenarios"
+}
+
+// Chain — цепочка кодов техник в порядке шагов: "Т1.1 → Т2.8 → Т10.14"
+func (s ThreatScenario) Chain() string {
+	codes := make([]string, 0, len(s.Steps))
+	for _, st := range s.Steps {
+		codes = append(codes, st.Technique.Code)
+	}
+	return strings.Join(codes, " → ")
+}
+
+// ScenarioStep — шаг сценария; Position задаёт порядок техник в цепочке
+type ScenarioStep struct {
+	ID          uint `gorm:"primaryKey"`
+	ScenarioID  uint `gorm:"uniqueIndex:idx_scenario_step"`
+	Position    int  `gorm:"uniqueIndex:idx_scenario_step"`
+	TechniqueID uint
+
+	Technique Technique
+}
+
+func (ScenarioStep) TableName() string {
+	return "scenario_steps"
+}
@@ -0,0 +1,226 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ====== СЗИ: СЕРТИФИЦИРОВАННЫЕ СРЕДСТВА ЗАЩИТЫ ИНФОРМАЦИИ ======
+
+type SecurityToolType string
+
+const (
+	ToolFirewall    SecurityToolType = "fw"     // межсетевой экран
+	ToolIDS         SecurityToolType = "ids"    // система обнаружения вторжений
+	ToolAntivirus   SecurityToolType = "av"     // средство антивирусной защиты
+	ToolTrustedBoot SecurityToolType = "tdz"    // средство доверенной загрузки
+	ToolCrypto      SecurityToolType = "crypto" // средство криптографической защиты
+)
+
+var SecurityToolTypes = []SecurityToolType{ToolFirewall, ToolIDS, ToolAntivirus, ToolTrustedBoot, ToolCrypto}
+
+func (t SecurityToolType) Label() string {
+	switch t {
+	case ToolFirewall:
+		return "МЭ"
+	case ToolIDS:
+		return "СОВ"
+	case ToolAntivirus:
+		return "САВЗ"
+	case ToolTrustedBoot:
+		return "СДЗ"
+	case ToolCrypto:
+		return "СКЗИ"
+	}
+	return string(t)
+}
+
+func (t SecurityToolType) Valid() bool {
+	for _, v := range SecurityToolTypes {
+		if v == t {
+			return true
+		}
+	}
+	return false
+}
+
+// CertRegulator — орган, выдавший сертификат
+type CertRegulator string
+
+const (
+	RegulatorFSTEC CertRegulator = "fstec"
+	RegulatorFSB   CertRegulator = "fsb"
+)
+
+var CertRegulators = []CertRegulator{RegulatorFSTEC, RegulatorFSB}
+
+func (r CertRegulator) Label() string {
+	switch r {
+	case RegulatorFSTEC:
+		return "ФСТЭК России"
+	case RegulatorFSB:
+		return "ФСБ России"
+	}
+	return string(r)
+}
+
+func (r CertRegulator) Valid() bool {
+	return r == RegulatorFSTEC || r == RegulatorFSB
+}
+
+// ToolClassLevels — классы защиты (уровни доверия) ФСТЭК: 1 — самый высокий, 6 — самый низкий
+const ToolClassLevels = 6
+
+// SecurityTool — СЗИ из каталога: продукт и его сертификат
+type SecurityTool struct {
+	gorm.Model
+	Name      string           `gorm:"size:255;not null"`
+	Vendor    string           `gorm:"size:255"`
+	Type      SecurityToolType `gorm:"type:varchar(16);not null"`
+	Regulator CertRegulator    `gorm:"type:varchar(16);not null"`
+
+	CertificateNumber string `gorm:"size:64;not null;uniqueIndex"`
+	// класс защиты / уровень доверия по сертификату ФСТЭК: 1..6; 0 — не указан
+	ProtectionClass int
+	// класс СКЗИ по сертификату ФСБ: КС1, КС2, КС3, КВ, КА
+	CryptoClass string `gorm:"size:8"`
+
+	CertificateValidUntil *time.Time
+	SupportUntil          *time.Time // окончание технической поддержки производителем
+	Notes                 string     `gorm:"type:text"`
+
+	// состояние сертификата по последней загрузке госреестра ФСТЭК
+	CertificateStatus CertificateStatus `gorm:"type:varchar(16);not null;default:valid"`
+	RegisterCheckedAt *time.Time
+}
+
+func (t SecurityTool) Title() string {
+	if t.Vendor == "" {
+		return t.Name
+	}
+	return t.Vendor + " " + t.Name
+}
+
+func (t SecurityTool) ClassLabel() string {
+	switch {
+	case t.Regulator == RegulatorFSB && t.CryptoClass != "":
+		return t.CryptoClass
+	case t.ProtectionClass > 0:
+		return fmt.Sprintf("%d класс", t.ProtectionClass)
+	}
+	return "—"
+}
+
+// CertificateDaysLeft — дней до окончания сертификата; отрицательное — сертификат истёк.
+// ok = false, если срок действия не указан.
+func (t SecurityTool) CertificateDaysLeft(now time.Time) (int, bool) {
+	if t.CertificateValidUntil == nil {
+		return 0, false
+	}
+	return daysBetween(now, *t.CertificateValidUntil), true
+}
+
+func (t SecurityTool) CertificateExpired(now time.Time) bool {
+	days, ok := t.CertificateDaysLeft(now)
+	return ok && days < 0
+}
+
+func (t SecurityTool) SupportEnded(now time.Time) bool {
+	return t.SupportUntil != nil && daysBetween(now, *t.SupportUntil) < 0
+}
+
+// daysBetween — целых суток от даты from до даты to (по календарю, без учёта времени)
+func daysBetween(from, to time.Time) int {
+	y1, m1, d1 := from.Date()
+	y2, m2, d2 := to.Date()
+	a := time.Date(y1, m1, d1, 0, 0, 0, 0, time.UTC)
+	b := time.Date(y2, m2, d2, 0, 0, 0, 0, time.UTC)
+	return int(b.Sub(a).Hours() / 24)
+}
+
+// SecurityToolInstallation — СЗИ, установленное на объекте защиты, и меры, которые оно реализует
+type SecurityToolInstallation struct {
+	gorm.Model
+	AssetID     uint   `gorm:"index"`
+	ToolID      uint   `gorm:"index"`
+	ComponentID *uint  // компонент объекта, на котором установлено; пусто — объект целиком
+	Quantity    int    `gorm:"not null;default:1"` // число экземпляров (лицензий)
+	Version     string `gorm:"size:64"`
+	InstalledAt *time.Time
+	Notes       string `gorm:"type:text"`
+
+	Measures []ControlMeasure `gorm:"many2many:security_tool_installation_measures;"`
+
+	Asset     Asset
+	Tool      SecurityTool
+	Component *AssetComponent
+}
+
+// ====== ГОСРЕЕСТР СЕРТИФИЦИРОВАННЫХ СЗИ ФСТЭК ======
+
+type CertificateStatus string
+
+const (
+	CertificateValid      CertificateStatus = "valid"
+	CertificateSuspended  CertificateStatus = "suspended"  // действие приостановлено
+	CertificateExpired    CertificateStatus = "expired"    // срок действия истёк
+	CertificateTerminated CertificateStatus = "terminated" // действие прекращено
+)
+
+func (s CertificateStatus) Label() string {
+	switch s {
+	case CertificateValid:
+		return "Действует"
+	case CertificateSuspended:
+		return "Приостановлен"
+	case CertificateExpired:
+		return "Истёк"
+	case CertificateTerminated:
+		return "Прекращён"
+	}
+	return string(s)
+}
+
+// Problem — сертификатом пользоваться нельзя
+func (s CertificateStatus) Problem() bool {
+	return s == CertificateSuspended || s == CertificateExpired || s == CertificateTerminated
+}
+
+// RegisterCertificate — запись госреестра сертифицированных СЗИ ФСТЭК России
+type RegisterCertificate struct {
+	gorm.Model
+	Number          string `gorm:"size:64;not null;uniqueIndex"`
+	Product         string `gorm:"type:text"` // наименование средства (шифр)
+	Applicant       string `gorm:"size:500"`
+	Documents       string `gorm:"type:text"` // документы, требованиям которых соответствует средство
+	ProtectionClass int    // класс защиты / уровень доверия из перечня документов; 0 — не удалось определить
+	ValidUntil      *time.Time
+	SupportUntil    *time.Time
+	Status          CertificateStatus `gorm:"type:varchar(16);not null;default:valid"`
+	StatusNote      string            `gorm:"type:text"`
+	ImportedAt      time.Time
+}
+
+// CertificateAlert — уведомление о приостановленном, прекращённом или истёкшем сертификате
+// СЗИ, установленного на объекте клиента. Показывается инженерам на главной странице, пока
+// не будет принято в работу.
+type CertificateAlert struct {
+	gorm.Model
+	ClientID          uint `gorm:"index"`
+	AssetID           uint
+	InstallationID    uint `gorm:"index"`
+	ToolID            uint
+	CertificateNumber string            `gorm:"size:64"`
+	Status            CertificateStatus `gorm:"type:varchar(16);not null"`
+	Message           string            `gorm:"type:text"`
+
+	AcknowledgedByID *uint
+	AcknowledgedAt   *time.Time
+
+	Client         Client
+	Asset          Asset
+	Tool           SecurityTool
+	AcknowledgedBy *User
+}
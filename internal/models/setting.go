@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Setting — настройка системы, которую администратор меняет из интерфейса
+type Setting struct {
+	Key       string `gorm:"primaryKey;size:100"`
+	Value     string `gorm:"type:text"`
+	UpdatedAt time.Time
+}
+
+const SettingRegistrationMode = "registration_mode"
+
+// RegistrationMode — как работает самостоятельная регистрация
+type RegistrationMode string
+
+const (
+	RegistrationOpen    RegistrationMode = "open"    // учётная запись активна сразу
+	RegistrationRequest RegistrationMode = "request" // заявка на доступ, вход после одобрения администратором
+)
+
+func (m RegistrationMode) Label() string {
+	switch m {
+	case RegistrationOpen:
+		return "открытая регистрация"
+	case RegistrationRequest:
+		return "по заявке с одобрением администратора"
+	}
+	return string(m)
+}
+
+func (m RegistrationMode) Valid() bool {
+	return m == RegistrationOpen || m == RegistrationRequest
+}
@@ -1,35 +1,76 @@
-package models
-
-import "gorm.io/gorm"
-
-// Каталог угроз (по STRIDE / ФСТЭК / своей классификации)
-type Threat struct {
-	gorm.Model
-	Code        string `gorm:"size:32;uniqueIndex"` // Например: T1, STRIDE-S, УБИ.001
-	Name        string `gorm:"size:255;not null"`   // Краткое название угрозы
-	Category    string `gorm:"size:64"`             // STRIDE, Техногенная, Несанкционированный доступ и т.п.
-	Description string `gorm:"type:text"`           // Подробное описание
-}
-
-// Каталог мер / контролей / мероприятий по ИБ
-type ControlMeasure struct {
-	gorm.Model
-	Code        string `gorm:"size:32;uniqueIndex"`
-	Name        string `gorm:"size:255;not null"` // Например: Настройка МЭ, Внедрение СКЗИ
-	Standard    string `gorm:"size:128"`          // Ссылка на ФСТЭК, ГОСТ, ISO и т.п.
-	Description string `gorm:"type:text"`
-}
-
-// Связь "угроза для конкретного объекта защиты"
-type AssetThreat struct {
-	ID uint `gorm:"primaryKey"`
-
-	AssetID  uint
-	ThreatID uint
-
-	RiskLevel string `gorm:"size:16"`   // low / medium / high
-	Notes     string `gorm:"type:text"` // комментарии по риску / обоснование
-
-	Asset  Asset
-	Threat Threat
-}
+package models
+
+import "gorm.io/gorm"
+
+// Каталог угроз (по STRIDE / ФСТЭК / своей классификации)
+type Threat struct {
+	gorm.Model
+	Code        string `gorm:"size:32;uniqueIndex"` // Например: T1, STRIDE-S, УБИ.001
+	Name        string `gorm:"size:255;not null"`   // Краткое название угрозы
+	Category    string `gorm:"size:64"`             // STRIDE, Техногенная, Несанкционированный доступ и т.п.
+	Description string `gorm:"type:text"`           // Подробное описание
+
+	// Поля из БДУ ФСТЭК (заполняются импортом thrlist)
+	Source          string `gorm:"type:text"` // Источник угрозы (характеристика и потенциал нарушителя)
+	ViolatorType    string `gorm:"size:64"`   // внешний / внутренний / внешний, внутренний
+	Object          string `gorm:"type:text"` // Объект воздействия
+	Confidentiality bool   // нарушение конфиденциальности
+	Integrity       bool   // нарушение целостности
+	Availability    bool   // нарушение доступности
+	Withdrawn       bool   `gorm:"default:false"` // исключена из БДУ — связи с объектами сохраняются
+
+	// версионирование каталога: поля выше — действующая (опубликованная) версия
+	Status  CatalogStatus `gorm:"size:16;not null;default:published"`
+	Version int           `gorm:"not null;default:1"`
+}
+
+// Каталог мер / контролей / мероприятий по ИБ
+type ControlMeasure struct {
+	gorm.Model
+	Regulation  string `gorm:"size:16;not null;default:'';uniqueIndex:idx_measure_regulation_code"` // приказ ФСТЭК из каталога Regulations; пусто — собственная мера
+	Code        string `gorm:"size:32;uniqueIndex:idx_measure_regulation_code"`
+	GroupCode   string `gorm:"size:16"`           // группа мер приказа: ИАФ, УПД, ЗИС...
+	Name        string `gorm:"size:255;not null"` // Например: Настройка МЭ, Внедрение СКЗИ
+	Standard    string `gorm:"size:128"`          // Ссылка на ФСТЭК, ГОСТ, ISO и т.п.
+	Description string `gorm:"type:text"`
+
+	Status  CatalogStatus `gorm:"size:16;not null;default:published"`
+	Version int           `gorm:"not null;default:1"`
+}
+
+// Связь "угроза для конкретного объекта защиты"
+type AssetThreat struct {
+	ID uint `gorm:"primaryKey"`
+
+	AssetID  uint
+	ThreatID uint
+	// версия угрозы каталога, по которой проведена оценка
+	ThreatVersionID *uint
+
+	// Исходный риск: вероятность и ущерб по шкалам RiskMatrix, уровень выводится из матрицы
+	Likelihood      int
+	Impact          int
+	LikelihoodNotes string `gorm:"type:text"` // обоснование вероятности
+	ImpactNotes     string `gorm:"type:text"` // обоснование ущерба
+	RiskLevel       string `gorm:"size:16"`   // low / medium / high
+
+	// Остаточный риск после применённых мер защиты
+	ResidualLikelihood int
+	ResidualImpact     int
+	ResidualNotes      string           `gorm:"type:text"` // обоснование снижения риска
+	ResidualLevel      string           `gorm:"size:16"`
+	Measures           []ControlMeasure `gorm:"many2many:asset_threat_measures;"`
+
+	// компоненты объекта, к которым относится угроза; пусто — объект целиком
+	Components []AssetComponent `gorm:"many2many:asset_threat_components;"`
+
+	Notes string `gorm:"type:text"` // комментарии по риску / обоснование
+
+	// угроза признана неактуальной для объекта (например, нет нарушителя с нужными возможностями)
+	Excluded        bool   `gorm:"default:false"`
+	ExclusionReason string `gorm:"type:text"`
+
+	Asset         Asset
+	Threat        Threat
+	ThreatVersion *ThreatVersion
+}
@@ -1,19 +1,78 @@
-package models
-
-import "gorm.io/gorm"
-
-type UserRole string
-
-const (
-	RoleAdmin    UserRole = "admin"
-	RoleSales    UserRole = "sales"
-	RoleEngineer UserRole = "engineer"
-	RoleViewer   UserRole = "viewer"
-)
-
-type User struct {
-	gorm.Model
-	Username     string   `gorm:"uniqueIndex;size:50;not null"`
-	PasswordHash string   `gorm:"not null"`
-	Role         UserRole `gorm:"type:varchar(20);not null"`
-}
+package models
+
+import "gorm.io/gorm"
+
+type UserRole string
+
+const (
+	RoleAdmin    UserRole = "admin"
+	RoleSales    UserRole = "sales"
+	RoleEngineer UserRole = "engineer"
+	RoleViewer   UserRole = "viewer"
+)
+
+// UserStatus — состояние учётной записи
+type UserStatus string
+
+const (
+	UserActive   UserStatus = "active"
+	UserPending  UserStatus = "pending"  // заявка на доступ ждёт одобрения администратора
+	UserDisabled UserStatus = "disabled" // отключена администратором
+	UserRejected UserStatus = "rejected" // заявка отклонена; с тем же логином можно подать новую
+)
+
+var UserStatuses = []UserStatus{UserActive, UserPending, UserDisabled, UserRejected}
+
+func (s UserStatus) Label() string {
+	switch s {
+	case UserActive:
+		return "активна"
+	case UserPending:
+		return "ожидает одобрения"
+	case UserDisabled:
+		return "отключена"
+	case UserRejected:
+		return "заявка отклонена"
+	}
+	return string(s)
+}
+
+var UserRoles = []UserRole{RoleAdmin, RoleEngineer, RoleSales, RoleViewer}
+
+func (r UserRole) Valid() bool {
+	for _, v := range UserRoles {
+		if v == r {
+			return true
+		}
+	}
+	return false
+}
+
+type User struct {
+	gorm.Model
+	Username     string   `gorm:"uniqueIndex;size:50;not null"`
+	PasswordHash string   `gorm:"not null"`
+	Role         UserRole `gorm:"type:varchar(20);not null"`
+
+	// сервисная учётная запись: заводится администратором для интеграций,
+	// входа по паролю нет — только токены API
+	IsService   bool   `gorm:"not null;default:false"`
+	Description string `gorm:"size:255"`
+
+	Status UserStatus `gorm:"type:varchar(20);not null;default:active"`
+	// пароль задан администратором — при входе пользователь обязан сменить его
+	MustChangePassword bool `gorm:"not null;default:false"`
+}
+
+func (u User) Active() bool {
+	return u.Status == UserActive
+}
+
+// CanDelegate — можно ли выдать токен с ролью role от имени пользователя с ролью owner:
+// администратор выдаёт любую роль, остальные — свою или только просмотр
+func CanDelegate(owner, role UserRole) bool {
+	if !role.Valid() {
+		return false
+	}
+	return owner == RoleAdmin || role == owner || role == RoleViewer
+}
@@ -0,0 +1,135 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Vulnerability — уязвимость из БДУ ФСТЭК (BDU:2023-01234) или NVD (CVE-2023-1234)
+type Vulnerability struct {
+	gorm.Model
+	Code        string `gorm:"size:32;uniqueIndex"`
+	CVE         string `gorm:"size:255"` // идентификаторы CVE через запятую (у записей БДУ)
+	Name        string `gorm:"type:text;not null"`
+	Description string `gorm:"type:text"`
+	Vendor      string `gorm:"type:text"`
+	Product     string `gorm:"type:text"` // название и версии уязвимого ПО
+	Remediation string `gorm:"type:text"` // возможные меры по устранению
+	Source      string `gorm:"size:16"`   // bdu / nvd
+	Published   *time.Time
+
+	CVSS3Vector string  `gorm:"size:128"`
+	CVSS3Score  float64 // рассчитывается по вектору
+	CVSS4Vector string  `gorm:"size:255"`
+	CVSS4Score  float64 // рассчитывается по вектору
+	Severity    string  `gorm:"size:16;index"` // none / low / medium / high / critical по наибольшей оценке
+}
+
+// Score — наибольшая из оценок CVSS
+func (v Vulnerability) Score() float64 {
+	if v.CVSS4Score > v.CVSS3Score {
+		return v.CVSS4Score
+	}
+	return v.CVSS3Score
+}
+
+func (v Vulnerability) SeverityLabel() string {
+	return SeverityLabel(v.Severity)
+}
+
+// CVEList — идентификаторы CVE записи
+func (v Vulnerability) CVEList() []string {
+	var ids []string
+	for _, s := range strings.Split(v.CVE, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			ids = append(ids, s)
+		}
+	}
+	return ids
+}
+
+// HasCVE — условие запроса «id есть в списке CVE записи». Сравнивается идентификатор
+// целиком: CVE-2021-1234 не должен находить запись с CVE-2021-12345.
+func HasCVE(id string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("(',' || REPLACE(cve, ' ', '') || ',') LIKE ?", "%,"+strings.TrimSpace(id)+",%")
+	}
+}
+
+// SeverityLabel — качественная оценка CVSS по-русски
+func SeverityLabel(s string) string {
+	switch s {
+	case "critical":
+		return "Критический"
+	case "high":
+		return "Высокий"
+	case "medium":
+		return "Средний"
+	case "low":
+		return "Низкий"
+	case "none":
+		return "Нет"
+	}
+	return "Не оценена"
+}
+
+type VulnerabilityStatus string
+
+const (
+	VulnFound      VulnerabilityStatus = "found"      // обнаружена (сканирование, анализ версий ПО)
+	VulnConfirmed  VulnerabilityStatus = "confirmed"  // подтверждена на объекте
+	VulnRemediated VulnerabilityStatus = "remediated" // устранена
+	VulnAccepted   VulnerabilityStatus = "accepted"   // риск принят
+)
+
+var VulnerabilityStatuses = []VulnerabilityStatus{
+	VulnFound, VulnConfirmed, VulnRemediated, VulnAccepted,
+}
+
+func (s VulnerabilityStatus) Label() string {
+	switch s {
+	case VulnFound:
+		return "Обнаружена"
+	case VulnConfirmed:
+		return "Подтверждена"
+	case VulnRemediated:
+		return "Устранена"
+	case VulnAccepted:
+		return "Риск принят"
+	}
+	return string(s)
+}
+
+func (s VulnerabilityStatus) Valid() bool {
+	for _, v := range VulnerabilityStatuses {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Open — уязвимость не устранена и риск не принят
+func (s VulnerabilityStatus) Open() bool {
+	return s == VulnFound || s == VulnConfirmed
+}
+
+// AssetVulnerability — реестр уязвимостей объекта со связью с угрозами, которые они делают возможными
+type AssetVulnerability struct {
+	gorm.Model
+
+	AssetID         uint `gorm:"uniqueIndex:idx_asset_vulnerability"`
+	VulnerabilityID uint `gorm:"uniqueIndex:idx_asset_vulnerability"`
+
+	Status        VulnerabilityStatus `gorm:"type:varchar(16);not null;default:found"`
+	Component     string              `gorm:"size:255"`  // где обнаружена: узел, ПО, версия
+	Justification string              `gorm:"type:text"` // обязательно для "риск принят"
+	Notes         string              `gorm:"type:text"`
+	RemediatedAt  *time.Time
+
+	Asset         Asset
+	Vulnerability Vulnerability
+	Threats       []Threat `gorm:"many2many:asset_vulnerability_threats;"`
+}
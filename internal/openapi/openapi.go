@@ -0,0 +1,480 @@
+// Package openapi собирает описание JSON API в формате OpenAPI 3.1 из таблицы маршрутов
+// и описаний операций. Схемы тел запросов и ответов строятся по Go-структурам (теги json),
+// поэтому спецификация не может разойтись с тем, что API реально принимает и отдаёт.
+package openapi
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+const Version = "3.1.0"
+
+// ---------- документ ----------
+
+type Document struct {
+	OpenAPI    string                `json:"openapi"`
+	Info       Info                  `json:"info"`
+	Servers    []Server              `json:"servers,omitempty"`
+	Tags       []Tag                 `json:"tags,omitempty"`
+	Paths      map[string]PathItem   `json:"paths"`
+	Components Components            `json:"components"`
+	Security   []map[string][]string `json:"security,omitempty"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type Server struct {
+	URL string `json:"url"`
+}
+
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem — операции пути по HTTP-методу в нижнем регистре
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Tags        []string             `json:"tags,omitempty"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Parameters  []Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+	Roles       []string             `json:"x-roles,omitempty"` // роли, которым доступна операция
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Headers     map[string]Header    `json:"headers,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Header struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	Scheme      string `json:"scheme,omitempty"`
+	In          string `json:"in,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// Schema — подмножество JSON Schema 2020-12, которого хватает для нашего API.
+// Type — строка или список (["integer", "null"] для необязательных значений).
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 interface{}        `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"`
+}
+
+func refTo(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// ---------- описание операций ----------
+
+// Route — маршрут API: метод, путь в нотации gin (/clients/:id), имя обработчика и роли
+type Route struct {
+	Method  string
+	Path    string
+	Handler string
+	Roles   []string
+}
+
+// Op — то, чего нельзя узнать из маршрута: назначение операции, фильтры, типы тела и ответа.
+// Body и Response — значения (обычно нулевые) структур, по которым строятся схемы.
+type Op struct {
+	Tag         string
+	Summary     string
+	Description string
+	Query       []Param  // фильтры списка
+	Sort        []string // поля сортировки; непустой Sort делает операцию постраничным списком
+	Body        interface{}
+	Response    interface{} // nil — ответ 204 без тела
+	Created     bool        // 201 с заголовком Location вместо 200
+	Conflict    string      // когда операция отвечает 409
+}
+
+// Param — параметр строки запроса
+type Param struct {
+	Name        string
+	Type        string // string, integer, boolean; по умолчанию string
+	Format      string
+	Description string
+	Enum        []string
+}
+
+// Key — ключ операции в таблице описаний: "GET /api/v1/clients/:id"
+func Key(method, path string) string {
+	return method + " " + path
+}
+
+// ---------- сборка ----------
+
+// Builder собирает документ; схемы структур складываются в components.schemas
+type Builder struct {
+	doc      *Document
+	enums    map[reflect.Type][]string
+	required map[reflect.Type][]string
+	names    map[reflect.Type]string
+	// Error — схема тела ошибки, общая для всех ответов 4xx/5xx
+	errorRef *Schema
+	metaRef  *Schema
+}
+
+func NewBuilder(info Info, basePath string) *Builder {
+	return &Builder{
+		doc: &Document{
+			OpenAPI: Version,
+			Info:    info,
+			Servers: []Server{{URL: basePath}},
+			Paths:   map[string]PathItem{},
+			Components: Components{
+				Schemas:         map[string]*Schema{},
+				SecuritySchemes: map[string]SecurityScheme{},
+			},
+		},
+		enums:    map[reflect.Type][]string{},
+		required: map[reflect.Type][]string{},
+		names:    map[reflect.Type]string{},
+	}
+}
+
+// Enum — допустимые значения строкового типа (например, models.AssetType)
+func (b *Builder) Enum(sample interface{}, values ...string) {
+	b.enums[reflect.TypeOf(sample)] = values
+}
+
+// Required — обязательные поля тела запроса. Для структур ответа обязательны все поля
+// без omitempty, но тела запросов принимают и неполные данные, поэтому для них
+// обязательные поля перечисляются явно.
+func (b *Builder) Required(sample interface{}, fields ...string) {
+	b.required[reflect.TypeOf(sample)] = fields
+}
+
+func (b *Builder) Tag(name, description string) {
+	b.doc.Tags = append(b.doc.Tags, Tag{Name: name, Description: description})
+}
+
+// Security — схема аутентификации, которая принимается для всех операций
+func (b *Builder) Security(name string, scheme SecurityScheme) {
+	b.doc.Components.SecuritySchemes[name] = scheme
+	b.doc.Security = append(b.doc.Security, map[string][]string{name: {}})
+}
+
+// Errors и Meta — образцы тела ошибки и метаданных постраничного списка
+func (b *Builder) Errors(sample interface{}) { b.errorRef = b.Schema(sample) }
+func (b *Builder) Meta(sample interface{})   { b.metaRef = b.Schema(sample) }
+
+// Build добавляет в документ маршруты routes с путями относительно basePath.
+// Возвращает расхождения: маршруты без описания и описания без маршрута.
+func (b *Builder) Build(basePath string, routes []Route, ops map[string]Op) (*Document, []string) {
+	var problems []string
+	seen := map[string]bool{}
+
+	for _, r := range routes {
+		key := Key(r.Method, r.Path)
+		seen[key] = true
+		op, ok := ops[key]
+		if !ok {
+			problems = append(problems, "маршрут без описания в спецификации: "+key)
+			continue
+		}
+
+		path := strings.TrimPrefix(r.Path, basePath)
+		openPath, pathParams := convertPath(path)
+		item := b.doc.Paths[openPath]
+		if item == nil {
+			item = PathItem{}
+			b.doc.Paths[openPath] = item
+		}
+		item[strings.ToLower(r.Method)] = b.operation(r, op, pathParams)
+	}
+
+	for key := range ops {
+		if !seen[key] {
+			problems = append(problems, "описание операции без маршрута: "+key)
+		}
+	}
+	sort.Strings(problems)
+	return b.doc, problems
+}
+
+func (b *Builder) operation(r Route, op Op, pathParams []string) *Operation {
+	o := &Operation{
+		OperationID: operationID(r.Handler),
+		Tags:        []string{op.Tag},
+		Summary:     op.Summary,
+		Description: op.Description,
+		Responses:   map[string]*Response{},
+		Roles:       r.Roles,
+	}
+	if len(r.Roles) > 0 {
+		roles := "Доступно ролям: " + strings.Join(r.Roles, ", ") + "."
+		if o.Description != "" {
+			o.Description += "\n\n"
+		}
+		o.Description += roles
+	}
+
+	for _, p := range pathParams {
+		min := 1.0
+		o.Parameters = append(o.Parameters, Parameter{
+			Name: p, In: "path", Required: true, Description: "Идентификатор записи",
+			Schema: &Schema{Type: "integer", Minimum: &min},
+		})
+	}
+
+	list := len(op.Sort) > 0
+	if list {
+		min := 1.0
+		o.Parameters = append(o.Parameters,
+			Parameter{Name: "page", In: "query", Description: "Номер страницы, с 1", Schema: &Schema{Type: "integer", Minimum: &min}},
+			Parameter{Name: "per_page", In: "query", Description: "Записей на странице, по умолчанию 50, не больше 200", Schema: &Schema{Type: "integer", Minimum: &min}},
+			Parameter{Name: "sort", In: "query", Description: "Поле сортировки; с минусом — по убыванию", Schema: &Schema{Type: "string", Enum: sortEnum(op.Sort)}},
+		)
+	}
+	for _, q := range op.Query {
+		typ := q.Type
+		if typ == "" {
+			typ = "string"
+		}
+		o.Parameters = append(o.Parameters, Parameter{
+			Name: q.Name, In: "query", Description: q.Description,
+			Schema: &Schema{Type: typ, Format: q.Format, Enum: q.Enum},
+		})
+	}
+
+	if op.Body != nil {
+		o.RequestBody = &RequestBody{Required: true, Content: jsonContent(b.Schema(op.Body))}
+		o.Responses["422"] = b.errorResponse("Данные не прошли проверку")
+	}
+
+	switch {
+	case op.Response == nil:
+		o.Responses["204"] = &Response{Description: "Выполнено, тела ответа нет"}
+	case list:
+		o.Responses["200"] = &Response{Description: "Страница списка", Content: jsonContent(&Schema{
+			Type:       "object",
+			Properties: map[string]*Schema{"data": {Type: "array", Items: b.Schema(op.Response)}, "meta": b.metaRef},
+			Required:   []string{"data", "meta"},
+		})}
+	default:
+		envelope := jsonContent(&Schema{
+			Type:       "object",
+			Properties: map[string]*Schema{"data": b.Schema(op.Response)},
+			Required:   []string{"data"},
+		})
+		if op.Created {
+			o.Responses["201"] = &Response{
+				Description: "Запись создана",
+				Headers:     map[string]Header{"Location": {Description: "Адрес новой записи", Schema: &Schema{Type: "string"}}},
+				Content:     envelope,
+			}
+		} else {
+			o.Responses["200"] = &Response{Description: "Запись", Content: envelope}
+		}
+	}
+
+	if list || op.Body != nil || len(pathParams) > 0 {
+		o.Responses["400"] = b.errorResponse("Некорректный запрос")
+	}
+	if len(pathParams) > 0 {
+		o.Responses["404"] = b.errorResponse("Запись не найдена")
+	}
+	if op.Conflict != "" {
+		o.Responses["409"] = b.errorResponse(op.Conflict)
+	}
+	o.Responses["401"] = b.errorResponse("Нет сессии или токен недействителен")
+	o.Responses["403"] = b.errorResponse("Недостаточно прав")
+	o.Responses["500"] = b.errorResponse("Внутренняя ошибка")
+	return o
+}
+
+func (b *Builder) errorResponse(description string) *Response {
+	return &Response{Description: description, Content: jsonContent(b.errorRef)}
+}
+
+func jsonContent(s *Schema) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: s}}
+}
+
+// ---------- схемы по Go-типам ----------
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Schema возвращает схему для значения sample; именованные структуры попадают
+// в components.schemas и подставляются ссылкой
+func (b *Builder) Schema(sample interface{}) *Schema {
+	return b.schemaOf(reflect.TypeOf(sample))
+}
+
+func (b *Builder) schemaOf(t reflect.Type) *Schema {
+	if t.Kind() == reflect.Ptr {
+		s := b.schemaOf(t.Elem())
+		if s.Ref != "" {
+			return &Schema{Ref: s.Ref, Description: "может быть null"}
+		}
+		if typ, ok := s.Type.(string); ok {
+			s.Type = []string{typ, "null"}
+		}
+		return s
+	}
+	if values, ok := b.enums[t]; ok {
+		return &Schema{Type: "string", Enum: values}
+	}
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Schema{Type: "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		min := 0.0
+		return &Schema{Type: "integer", Minimum: &min}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: b.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: b.schemaOf(t.Elem())}
+	case reflect.Struct:
+		return b.structSchema(t)
+	}
+	return &Schema{}
+}
+
+func (b *Builder) structSchema(t reflect.Type) *Schema {
+	name, known := b.names[t]
+	if known {
+		return refTo(name)
+	}
+	name = componentName(t)
+	if name == "" {
+		return b.objectSchema(t)
+	}
+	b.names[t] = name
+	// заглушка до построения — на случай рекурсивных типов
+	b.doc.Components.Schemas[name] = &Schema{}
+	*b.doc.Components.Schemas[name] = *b.objectSchema(t)
+	return refTo(name)
+}
+
+func (b *Builder) objectSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}, AdditionalProperties: false}
+	required, explicit := b.required[t]
+	if explicit {
+		s.Required = required
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = f.Name
+		}
+		s.Properties[name] = b.schemaOf(f.Type)
+		if !explicit && !strings.Contains(opts, "omitempty") && f.Type.Kind() != reflect.Ptr {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return s
+}
+
+// componentName — имя схемы по имени типа: apiClient → Client, clientInput → ClientInput
+func componentName(t reflect.Type) string {
+	name := t.Name()
+	if name == "" {
+		return ""
+	}
+	if rest := strings.TrimPrefix(name, "api"); rest != name && rest != "" && strings.ToUpper(rest[:1]) == rest[:1] {
+		name = rest
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// convertPath переводит путь gin (/assets/:id) в шаблон OpenAPI (/assets/{id})
+func convertPath(path string) (string, []string) {
+	parts := strings.Split(path, "/")
+	var params []string
+	for i, p := range parts {
+		if strings.HasPrefix(p, ":") || strings.HasPrefix(p, "*") {
+			params = append(params, p[1:])
+			parts[i] = "{" + p[1:] + "}"
+		}
+	}
+	return strings.Join(parts, "/"), params
+}
+
+// operationID — из имени обработчика: ib-integrator/internal/handlers.APIListClients → listClients
+func operationID(handler string) string {
+	name := handler[strings.LastIndex(handler, ".")+1:]
+	name = strings.TrimPrefix(name, "API")
+	if name == "" {
+		return handler
+	}
+	return strings.ToLower(name[:1]) + name[1:]
+}
+
+func sortEnum(fields []string) []string {
+	out := make([]string, 0, 2*len(fields))
+	sorted := append([]string(nil), fields...)
+	sort.Strings(sorted)
+	for _, f := range sorted {
+		out = append(out, f, "-"+f)
+	}
+	return out
+}
@@ -0,0 +1,9 @@
+package openapi
+
+import _ "embed"
+
+// ViewerHTML — страница просмотра спецификации без внешних зависимостей (сервер
+// может стоять в закрытом контуре): загружает /api/openapi.json и выводит операции по разделам
+//
+//go:embed viewer.html
+var ViewerHTML []byte
@@ -0,0 +1,218 @@
+// Package passpolicy — парольная политика: длина, виды символов, запрет утёкших
+// и недавно использованных паролей.
+package passpolicy
+
+import (
+	"bufio"
+	"crypto/sha1"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+
+	"ib-integrator/internal/database"
+	"ib-integrator/internal/models"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Policy — требования к новому паролю
+type Policy struct {
+	MinLength    int    // минимальная длина в символах
+	MinClasses   int    // сколько видов символов из четырёх: строчные, заглавные, цифры, знаки
+	History      int    // сколько последних паролей, включая текущий, нельзя выбрать снова
+	BreachedFile string // необязательный список утёкших паролей
+}
+
+// DefaultPolicy действует, пока не вызван Configure
+var DefaultPolicy = Policy{MinLength: 8, MinClasses: 3, History: 5}
+
+//go:embed common.txt
+var commonPasswords string
+
+var (
+	current  = DefaultPolicy
+	breached = map[[sha1.Size]byte]struct{}{}
+)
+
+func init() {
+	loadBreached(strings.NewReader(commonPasswords))
+}
+
+// Configure задаёт политику и загружает список утёкших паролей из файла.
+// Строка файла — пароль открытым текстом или его SHA-1 в hex (формат
+// Pwned Passwords: "ХЕШ:количество"); пустые строки и "#" пропускаются.
+func Configure(p Policy) error {
+	current = p
+	if p.BreachedFile == "" {
+		return nil
+	}
+	f, err := os.Open(p.BreachedFile)
+	if err != nil {
+		return fmt.Errorf("breached passwords list: %w", err)
+	}
+	defer f.Close()
+	return loadBreached(f)
+}
+
+// Current — действующая политика
+func Current() Policy {
+	return current
+}
+
+func loadBreached(r io.Reader) error {
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if sum, ok := parseSHA1(line); ok {
+			breached[sum] = struct{}{}
+			continue
+		}
+		breached[sha1.Sum([]byte(strings.ToLower(line)))] = struct{}{}
+	}
+	return sc.Err()
+}
+
+// parseSHA1 разбирает строку "ХЕШ" или "ХЕШ:количество"
+func parseSHA1(line string) ([sha1.Size]byte, bool) {
+	var sum [sha1.Size]byte
+	if i := strings.IndexByte(line, ':'); i >= 0 {
+		line = line[:i]
+	}
+	if len(line) != hex.EncodedLen(sha1.Size) {
+		return sum, false
+	}
+	if _, err := hex.Decode(sum[:], []byte(line)); err != nil {
+		return sum, false
+	}
+	return sum, true
+}
+
+// Breached — встречается ли пароль в списке утёкших. Открытым текстом список
+// хранится без учёта регистра, поэтому проверяем и пароль как есть, и в нижнем регистре.
+func Breached(password string) bool {
+	if _, ok := breached[sha1.Sum([]byte(password))]; ok {
+		return true
+	}
+	_, ok := breached[sha1.Sum([]byte(strings.ToLower(password)))]
+	return ok
+}
+
+// classes — сколько видов символов в пароле
+func classes(password string) int {
+	var lower, upper, digit, other bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+	n := 0
+	for _, ok := range []bool{lower, upper, digit, other} {
+		if ok {
+			n++
+		}
+	}
+	return n
+}
+
+// Requirements — требования политики одной строкой, для подсказки в формах
+func Requirements() string {
+	p := current
+	parts := []string{fmt.Sprintf("не короче %d символов", p.MinLength)}
+	if p.MinClasses > 1 {
+		parts = append(parts, fmt.Sprintf("символы не менее %d видов из четырёх: строчные и заглавные буквы, цифры, знаки", p.MinClasses))
+	}
+	parts = append(parts, "без логина и без паролей из утечек")
+	if p.History > 0 {
+		parts = append(parts, fmt.Sprintf("не совпадает с %d последними паролями", p.History))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Validate проверяет новый пароль пользователя user. Для существующей учётной
+// записи (user.ID != 0) проверяется и история паролей. Возвращает текст ошибки или "".
+func Validate(password string, user models.User) string {
+	p := current
+	if len([]rune(password)) < p.MinLength {
+		return fmt.Sprintf("Пароль должен быть не короче %d символов", p.MinLength)
+	}
+	if classes(password) < p.MinClasses {
+		return fmt.Sprintf("Пароль должен содержать символы не менее %d видов из четырёх: строчные и заглавные буквы, цифры, знаки", p.MinClasses)
+	}
+	if containsLogin(password, user.Username) {
+		return "Пароль не должен содержать логин"
+	}
+	if Breached(password) {
+		return "Этот пароль встречается в утечках — выберите другой"
+	}
+	if user.ID != 0 && Reused(user, password) {
+		return fmt.Sprintf("Пароль совпадает с одним из %d последних — выберите новый", p.History)
+	}
+	return ""
+}
+
+// containsLogin — есть ли в пароле логин (для адреса почты — его часть до @)
+func containsLogin(password, username string) bool {
+	login := strings.ToLower(strings.TrimSpace(username))
+	if i := strings.IndexByte(login, '@'); i > 0 {
+		login = login[:i]
+	}
+	if len([]rune(login)) < 3 {
+		return false
+	}
+	return strings.Contains(strings.ToLower(password), login)
+}
+
+// Reused — совпадает ли пароль с текущим или с одним из последних в истории
+func Reused(user models.User, password string) bool {
+	if current.History <= 0 {
+		return false
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) == nil {
+		return true
+	}
+	var history []models.PasswordHistory
+	database.DB.Where("user_id = ?", user.ID).Order("created_at desc, id desc").
+		Limit(current.History - 1).Find(&history)
+	for _, h := range history {
+		if bcrypt.CompareHashAndPassword([]byte(h.PasswordHash), []byte(password)) == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Remember сохраняет прежний хеш пароля в историю перед сменой пароля
+// и удаляет записи сверх нужного политике количества.
+func Remember(user models.User) error {
+	if user.PasswordHash == "" || user.IsService {
+		return nil
+	}
+	if err := database.DB.Create(&models.PasswordHistory{UserID: user.ID, PasswordHash: user.PasswordHash}).Error; err != nil {
+		return err
+	}
+	keep := current.History - 1
+	if keep < 0 {
+		keep = 0
+	}
+	var ids []uint
+	database.DB.Model(&models.PasswordHistory{}).Where("user_id = ?", user.ID).
+		Order("created_at desc, id desc").Pluck("id", &ids)
+	if len(ids) <= keep {
+		return nil
+	}
+	return database.DB.Delete(&models.PasswordHistory{}, ids[keep:]).Error
+}
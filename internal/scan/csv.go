@@ -0,0 +1,259 @@
+package scan
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"ib-integrator/internal/textenc"
+)
+
+var (
+	cveRe = regexp.MustCompile(`CVE-\d{4}-\d{4,}`)
+	bduRe = regexp.MustCompile(`BDU:\d{4}-\d{5}`)
+)
+
+// поля отчёта, которые можно сопоставить колонкам CSV
+const (
+	FieldAddress  = "address"
+	FieldHostname = "hostname"
+	FieldMAC      = "mac"
+	FieldOS       = "os"
+	FieldPort     = "port"
+	FieldProtocol = "protocol"
+	FieldService  = "service"
+	FieldKey      = "key"
+	FieldName     = "name"
+	FieldCVE      = "cve"
+	FieldBDU      = "bdu"
+	FieldScore    = "score"
+	FieldSolution = "solution"
+)
+
+// порядок важен: заголовок достаётся первому подходящему полю
+var csvFields = []string{
+	FieldAddress, FieldHostname, FieldMAC, FieldOS, FieldPort, FieldProtocol, FieldService,
+	FieldCVE, FieldBDU, FieldScore, FieldSolution, FieldKey, FieldName,
+}
+
+// CSVMapping — сопоставление колонок CSV-выгрузки сканера полям отчёта.
+// Варианты заголовка сравниваются без учёта регистра как подстрока; вариант с "=" в начале —
+// только точное совпадение (для коротких заголовков вроде "ОС").
+type CSVMapping struct {
+	Name    string              `json:"name"`
+	Title   string              `json:"title"`
+	Comma   string              `json:"comma"` // пусто — ";" или "," по строке заголовков
+	Columns map[string][]string `json:"columns"`
+}
+
+var csvMappings = map[string]CSVMapping{
+	"redcheck": {
+		Name:  "redcheck",
+		Title: "RedCheck (отчёт по уязвимостям, CSV)",
+		Columns: map[string][]string{
+			FieldAddress:  {"ip-адрес", "ip адрес", "=адрес", "=ip"},
+			FieldHostname: {"имя хоста", "имя узла", "=хост", "dns"},
+			FieldOS:       {"операционная система", "=ос"},
+			FieldPort:     {"=порт"},
+			FieldProtocol: {"протокол"},
+			FieldService:  {"=сервис", "=служба"},
+			FieldKey:      {"идентификатор уязвимости", "=id"},
+			FieldName:     {"название уязвимости", "наименование уязвимости", "=уязвимость"},
+			FieldCVE:      {"cve"},
+			FieldBDU:      {"бду", "bdu", "фстэк"},
+			FieldScore:    {"cvss"},
+			FieldSolution: {"рекомендации", "устранени"},
+		},
+	},
+	"maxpatrol": {
+		Name:  "maxpatrol",
+		Title: "MaxPatrol 8 / VM (выгрузка уязвимостей, CSV)",
+		Columns: map[string][]string{
+			FieldAddress:  {"ip-адрес", "=ip", "=узел"},
+			FieldHostname: {"fqdn", "имя узла", "netbios"},
+			FieldMAC:      {"mac"},
+			FieldOS:       {"=ос", "операционная система"},
+			FieldPort:     {"=порт"},
+			FieldProtocol: {"протокол"},
+			FieldService:  {"=сервис", "=служба"},
+			FieldKey:      {"=id", "идентификатор"},
+			FieldName:     {"=уязвимость", "название"},
+			FieldCVE:      {"cve"},
+			FieldBDU:      {"bdu", "бду"},
+			FieldScore:    {"cvss", "=уровень опасности (балл)"},
+			FieldSolution: {"рекомендации", "решение"},
+		},
+	},
+}
+
+// RegisterCSVMapping добавляет сопоставление (или заменяет встроенное с тем же именем)
+func RegisterCSVMapping(m CSVMapping) error {
+	if m.Name == "" {
+		return errors.New("у сопоставления нет имени")
+	}
+	if len(m.Columns[FieldAddress]) == 0 {
+		return errors.New("в сопоставлении нет колонки адреса узла")
+	}
+	if m.Title == "" {
+		m.Title = m.Name
+	}
+	csvMappings[m.Name] = m
+	return nil
+}
+
+// LoadCSVMapping читает сопоставление из JSON-файла и регистрирует его
+func LoadCSVMapping(filename string) (CSVMapping, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return CSVMapping{}, err
+	}
+	var m CSVMapping
+	if err := json.Unmarshal(data, &m); err != nil {
+		return CSVMapping{}, fmt.Errorf("разбор сопоставления: %w", err)
+	}
+	return m, RegisterCSVMapping(m)
+}
+
+func CSVMappingByName(name string) (CSVMapping, bool) {
+	m, ok := csvMappings[name]
+	return m, ok
+}
+
+// CSVMappings — зарегистрированные сопоставления по имени
+func CSVMappings() []CSVMapping {
+	list := make([]CSVMapping, 0, len(csvMappings))
+	for _, m := range csvMappings {
+		list = append(list, m)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// ParseCSV разбирает CSV-выгрузку: строка — находка (или просто порт) на узле
+func ParseCSV(data []byte, m CSVMapping) (*Report, error) {
+	// отечественные сканеры нередко выгружают CSV в windows-1251
+	data = textenc.ToUTF8(data)
+
+	comma := ';'
+	if m.Comma != "" {
+		comma, _ = utf8.DecodeRuneInString(m.Comma)
+	} else if first, _, _ := bytes.Cut(data, []byte("\n")); bytes.Count(first, []byte(",")) > bytes.Count(first, []byte(";")) {
+		comma = ','
+	}
+
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comma = comma
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("разбор csv: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("пустой файл")
+	}
+
+	cols := mapColumns(rows[0], m)
+	if _, ok := cols[FieldAddress]; !ok {
+		return nil, fmt.Errorf("в заголовке нет колонки адреса узла (сопоставление %s)", m.Name)
+	}
+	get := func(row []string, field string) string {
+		i, ok := cols[field]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	var hosts []HostRecord
+	for _, row := range rows[1:] {
+		addr := get(row, FieldAddress)
+		if addr == "" {
+			continue
+		}
+		rec := HostRecord{
+			Address:  addr,
+			Hostname: get(row, FieldHostname),
+			MAC:      strings.ToUpper(get(row, FieldMAC)),
+			OS:       get(row, FieldOS),
+		}
+
+		port, proto := parsePort(get(row, FieldPort))
+		if p := strings.ToLower(get(row, FieldProtocol)); p != "" {
+			proto = p
+		}
+		if port > 0 {
+			rec.Services = append(rec.Services, ServiceRecord{Port: port, Protocol: proto, Service: get(row, FieldService)})
+		}
+
+		name := get(row, FieldName)
+		cve := joinUnique(cveRe.FindAllString(get(row, FieldCVE), -1))
+		bdu := joinUnique(bduRe.FindAllString(get(row, FieldBDU), -1))
+		if name != "" || cve != "" || bdu != "" {
+			score, _ := strconv.ParseFloat(strings.Replace(get(row, FieldScore), ",", ".", 1), 64)
+			key := get(row, FieldKey)
+			for _, k := range []string{bdu, cve, name} {
+				if key == "" && k != "" {
+					key, _, _ = strings.Cut(k, ",")
+				}
+			}
+			if name == "" {
+				name = key
+			}
+			rec.Findings = append(rec.Findings, FindingRecord{
+				Key:      key,
+				Port:     portLabel(port, proto),
+				Name:     name,
+				CVE:      cve,
+				BDU:      bdu,
+				Score:    score,
+				Solution: get(row, FieldSolution),
+			})
+		}
+		hosts = append(hosts, rec)
+	}
+
+	return &Report{Format: m.Name, Hosts: mergeHosts(hosts)}, nil
+}
+
+// mapColumns — индексы колонок по полям: сначала точные совпадения, затем подстроки
+func mapColumns(header []string, m CSVMapping) map[string]int {
+	cols := make(map[string]int)
+	used := make(map[int]bool)
+	for _, exact := range []bool{true, false} {
+		for _, field := range csvFields {
+			if _, ok := cols[field]; ok {
+				continue
+			}
+			for _, alias := range m.Columns[field] {
+				want := strings.ToLower(alias)
+				exactOnly := strings.HasPrefix(want, "=")
+				want = strings.TrimPrefix(want, "=")
+				if exactOnly && !exact {
+					continue
+				}
+				for i, h := range header {
+					title := strings.ToLower(strings.TrimSpace(h))
+					match := title == want || (!exact && strings.Contains(title, want))
+					if !used[i] && match {
+						cols[field] = i
+						used[i] = true
+						break
+					}
+				}
+				if _, ok := cols[field]; ok {
+					break
+				}
+			}
+		}
+	}
+	return cols
+}
@@ -0,0 +1,122 @@
+package scan
+
+import (
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type nmapRun struct {
+	Hosts []struct {
+		Status struct {
+			State string `xml:"state,attr"`
+		} `xml:"status"`
+		Addresses []struct {
+			Addr string `xml:"addr,attr"`
+			Type string `xml:"addrtype,attr"`
+		} `xml:"address"`
+		Hostnames []struct {
+			Name string `xml:"name,attr"`
+		} `xml:"hostnames>hostname"`
+		Ports []struct {
+			Protocol string `xml:"protocol,attr"`
+			PortID   int    `xml:"portid,attr"`
+			State    struct {
+				State string `xml:"state,attr"`
+			} `xml:"state"`
+			Service struct {
+				Name    string `xml:"name,attr"`
+				Product string `xml:"product,attr"`
+				Version string `xml:"version,attr"`
+			} `xml:"service"`
+			Scripts []nmapScript `xml:"script"`
+		} `xml:"ports>port"`
+		OSMatches []struct {
+			Name string `xml:"name,attr"`
+		} `xml:"os>osmatch"`
+	} `xml:"host"`
+}
+
+type nmapScript struct {
+	ID     string `xml:"id,attr"`
+	Output string `xml:"output,attr"`
+}
+
+// строка вывода NSE-скриптов vulners / vulscan: "CVE-2023-38408	9.8	https://vulners.com/..."
+var nmapCVELine = regexp.MustCompile(`(CVE-\d{4}-\d{4,})\s+(\d+(?:\.\d+)?)?`)
+
+// ParseNmap разбирает XML-отчёт Nmap (-oX). Уязвимости берутся из вывода скриптов vulners/vulscan.
+func ParseNmap(data []byte) (*Report, error) {
+	var run nmapRun
+	if err := xml.Unmarshal(data, &run); err != nil {
+		return nil, fmt.Errorf("разбор xml: %w", err)
+	}
+
+	report := &Report{Format: FormatNmap}
+	for _, h := range run.Hosts {
+		if h.Status.State != "" && h.Status.State != "up" {
+			continue
+		}
+
+		var rec HostRecord
+		for _, a := range h.Addresses {
+			switch a.Type {
+			case "ipv4", "ipv6":
+				if rec.Address == "" {
+					rec.Address = a.Addr
+				}
+			case "mac":
+				rec.MAC = strings.ToUpper(a.Addr)
+			}
+		}
+		if rec.Address == "" {
+			continue
+		}
+		if len(h.Hostnames) > 0 {
+			rec.Hostname = h.Hostnames[0].Name
+		}
+		if len(h.OSMatches) > 0 {
+			rec.OS = h.OSMatches[0].Name
+		}
+
+		for _, p := range h.Ports {
+			if p.State.State != "open" {
+				continue
+			}
+			rec.Services = append(rec.Services, ServiceRecord{
+				Port:     p.PortID,
+				Protocol: p.Protocol,
+				Service:  p.Service.Name,
+				Product:  p.Service.Product,
+				Version:  p.Service.Version,
+			})
+
+			port := portLabel(p.PortID, p.Protocol)
+			product := strings.TrimSpace(p.Service.Product + " " + p.Service.Version)
+			for _, s := range p.Scripts {
+				if s.ID != "vulners" && s.ID != "vulscan" {
+					continue
+				}
+				for _, m := range nmapCVELine.FindAllStringSubmatch(s.Output, -1) {
+					score, _ := strconv.ParseFloat(m[2], 64)
+					name := m[1]
+					if product != "" {
+						name += " в " + product
+					}
+					rec.Findings = addFinding(rec.Findings, FindingRecord{
+						Key:   m[1],
+						Port:  port,
+						Name:  name,
+						CVE:   m[1],
+						Score: score,
+					})
+				}
+			}
+		}
+		report.Hosts = append(report.Hosts, rec)
+	}
+	report.Hosts = mergeHosts(report.Hosts)
+	return report, nil
+}
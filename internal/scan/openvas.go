@@ -0,0 +1,167 @@
+package scan
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type gvmResult struct {
+	Name string `xml:"name"`
+	Host struct {
+		IP       string `xml:",chardata"`
+		Hostname string `xml:"hostname"`
+	} `xml:"host"`
+	Port string `xml:"port"`
+	NVT  struct {
+		OID        string `xml:"oid,attr"`
+		Name       string `xml:"name"`
+		CVE        string `xml:"cve"` // старые версии GVM: "CVE-..., CVE-..."
+		Solution   string `xml:"solution"`
+		Severities []struct {
+			Value string `xml:"value"`
+		} `xml:"severities>severity"`
+		Refs []struct {
+			Type string `xml:"type,attr"`
+			ID   string `xml:"id,attr"`
+		} `xml:"refs>ref"`
+	} `xml:"nvt"`
+	Severity string `xml:"severity"`
+}
+
+type gvmHost struct {
+	IP      string `xml:"ip"`
+	Details []struct {
+		Name  string `xml:"name"`
+		Value string `xml:"value"`
+	} `xml:"detail"`
+}
+
+// ParseOpenVAS разбирает XML-отчёт OpenVAS/GVM (get_reports, формат XML).
+// Результаты с нулевой опасностью (журнальные) дают только сведения о портах.
+func ParseOpenVAS(data []byte) (*Report, error) {
+	var hosts []HostRecord
+	details := make(map[string]gvmHost)
+
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("разбор xml: %w", err)
+		}
+		se, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch se.Name.Local {
+		case "result":
+			var r gvmResult
+			if err := dec.DecodeElement(&r, &se); err != nil {
+				return nil, fmt.Errorf("разбор результата: %w", err)
+			}
+			if rec, ok := gvmResultHost(r); ok {
+				hosts = append(hosts, rec)
+			}
+		case "host":
+			// сведения об узле на уровне отчёта: <host><ip>…</ip><detail>…</detail></host>
+			var h gvmHost
+			if err := dec.DecodeElement(&h, &se); err != nil {
+				return nil, fmt.Errorf("разбор узла: %w", err)
+			}
+			if ip := strings.TrimSpace(h.IP); ip != "" {
+				details[ip] = h
+			}
+		}
+	}
+
+	for ip, h := range details {
+		rec := HostRecord{Address: ip}
+		for _, d := range h.Details {
+			switch d.Name {
+			case "hostname":
+				rec.Hostname = strings.TrimSpace(d.Value)
+			case "best_os_txt":
+				rec.OS = strings.TrimSpace(d.Value)
+			case "MAC":
+				rec.MAC = strings.ToUpper(strings.TrimSpace(d.Value))
+			}
+		}
+		hosts = append(hosts, rec)
+	}
+
+	return &Report{Format: FormatOpenVAS, Hosts: mergeHosts(hosts)}, nil
+}
+
+func gvmResultHost(r gvmResult) (HostRecord, bool) {
+	ip := strings.TrimSpace(r.Host.IP)
+	if ip == "" {
+		return HostRecord{}, false
+	}
+	rec := HostRecord{Address: ip, Hostname: strings.TrimSpace(r.Host.Hostname)}
+
+	port, proto := parsePort(r.Port)
+	if port > 0 {
+		rec.Services = append(rec.Services, ServiceRecord{Port: port, Protocol: proto})
+	}
+
+	score, _ := strconv.ParseFloat(strings.TrimSpace(r.Severity), 64)
+	if score <= 0 {
+		return rec, true
+	}
+
+	cves := strings.Split(r.NVT.CVE, ",")
+	var bdus []string
+	for _, ref := range r.NVT.Refs {
+		switch strings.ToLower(ref.Type) {
+		case "cve":
+			cves = append(cves, ref.ID)
+		case "bdu":
+			bdus = append(bdus, ref.ID)
+		}
+	}
+	var vector string
+	for _, s := range r.NVT.Severities {
+		if strings.HasPrefix(s.Value, "CVSS:3") {
+			vector = s.Value
+			break
+		}
+	}
+
+	name := strings.TrimSpace(r.Name)
+	if name == "" {
+		name = strings.TrimSpace(r.NVT.Name)
+	}
+	key := r.NVT.OID
+	if key == "" {
+		key = name
+	}
+	rec.Findings = append(rec.Findings, FindingRecord{
+		Key:        key,
+		Port:       portLabel(port, proto),
+		Name:       name,
+		CVE:        joinUnique(filterCVE(cves)),
+		BDU:        joinUnique(bdus),
+		Score:      score,
+		CVSSVector: vector,
+		Solution:   strings.TrimSpace(r.NVT.Solution),
+	})
+	return rec, true
+}
+
+func filterCVE(ids []string) []string {
+	var out []string
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if strings.HasPrefix(id, "CVE-") {
+			out = append(out, id)
+		}
+	}
+	return out
+}
@@ -0,0 +1,380 @@
+package scan
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ib-integrator/internal/cvss"
+	"ib-integrator/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Change — изменение поля узла инвентаризации
+type Change struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// FindingPlan — находка сканера и уязвимость каталога, с которой она сопоставлена
+type FindingPlan struct {
+	Record          FindingRecord
+	Severity        string
+	Known           bool  // уже была в инвентаризации узла
+	VulnerabilityID *uint // пусто — в каталоге не найдена
+	VulnCode        string
+}
+
+// RegisterItem — изменение реестра уязвимостей объекта
+type RegisterItem struct {
+	AssetID         uint
+	VulnerabilityID uint
+	Code            string
+	Component       string
+	Reopen          bool // уязвимость была отмечена устранённой, а сканер нашёл её снова
+}
+
+// HostPlan — что импорт сделает с одним узлом
+type HostPlan struct {
+	Record      HostRecord
+	Host        models.Host // ID == 0 — новый узел
+	AssetID     *uint
+	AssetName   string
+	Changes     []Change
+	NewServices int
+	Findings    []FindingPlan
+	Register    []RegisterItem
+}
+
+func (h HostPlan) New() bool     { return h.Host.ID == 0 }
+func (h HostPlan) Matched() bool { return h.AssetID != nil }
+
+func (h HostPlan) NewFindings() int {
+	n := 0
+	for _, f := range h.Findings {
+		if !f.Known {
+			n++
+		}
+	}
+	return n
+}
+
+// Plan — предпросмотр импорта отчёта; до применения ничего не пишется
+type Plan struct {
+	Format   string
+	ClientID uint
+	Hosts    []HostPlan
+}
+
+type PlanCounts struct {
+	NewHosts, UpdatedHosts, Unmatched int
+	Findings, NewFindings, Matched    int
+	Registered, Reopened              int
+}
+
+func (p *Plan) Counts() PlanCounts {
+	var c PlanCounts
+	for _, h := range p.Hosts {
+		switch {
+		case h.New():
+			c.NewHosts++
+		case len(h.Changes) > 0 || h.NewServices > 0 || h.NewFindings() > 0:
+			c.UpdatedHosts++
+		}
+		if !h.Matched() {
+			c.Unmatched++
+		}
+		for _, f := range h.Findings {
+			c.Findings++
+			if !f.Known {
+				c.NewFindings++
+			}
+			if f.VulnerabilityID != nil {
+				c.Matched++
+			}
+		}
+		for _, r := range h.Register {
+			if r.Reopen {
+				c.Reopened++
+			} else {
+				c.Registered++
+			}
+		}
+	}
+	return c
+}
+
+func (p *Plan) Summary() string {
+	c := p.Counts()
+	return fmt.Sprintf("узлов %d (новых %d, обновлённых %d, без объекта %d), находок %d (новых %d, в каталоге %d), в реестр объектов внесено %d, открыто повторно %d",
+		len(p.Hosts), c.NewHosts, c.UpdatedHosts, c.Unmatched, c.Findings, c.NewFindings, c.Matched, c.Registered, c.Reopened)
+}
+
+// BuildPlan сопоставляет узлы отчёта с инвентаризацией клиента: по IP-адресу, затем по имени узла.
+// Новые и ещё не сопоставленные узлы относятся к объекту assetID (если задан).
+func BuildPlan(db *gorm.DB, report *Report, clientID uint, assetID *uint) (*Plan, error) {
+	var assets []models.Asset
+	if err := db.Where("client_id = ?", clientID).Find(&assets).Error; err != nil {
+		return nil, err
+	}
+	assetNames := make(map[uint]string, len(assets))
+	for _, a := range assets {
+		assetNames[a.ID] = a.Name
+	}
+	if assetID != nil {
+		if _, ok := assetNames[*assetID]; !ok {
+			return nil, errors.New("объект защиты не принадлежит клиенту")
+		}
+	}
+
+	var hosts []models.Host
+	if err := db.Preload("Services").Preload("Findings").Where("client_id = ?", clientID).Find(&hosts).Error; err != nil {
+		return nil, err
+	}
+	byAddress := make(map[string]models.Host, len(hosts))
+	byName := make(map[string]models.Host, len(hosts))
+	for _, h := range hosts {
+		byAddress[h.Address] = h
+		if h.Hostname != "" {
+			byName[strings.ToLower(h.Hostname)] = h
+		}
+	}
+
+	m := newMatcher(db)
+	plan := &Plan{Format: report.Format, ClientID: clientID}
+	registered := make(map[[2]uint]bool) // объект+уязвимость уже в плане
+
+	for _, rec := range report.Hosts {
+		hp := HostPlan{Record: rec, AssetID: assetID}
+		host, ok := byAddress[rec.Address]
+		if !ok && rec.Hostname != "" {
+			host, ok = byName[strings.ToLower(rec.Hostname)]
+		}
+		if ok {
+			hp.Host = host
+			if host.AssetID != nil {
+				hp.AssetID = host.AssetID
+			}
+			hp.Changes = hostChanges(host, rec)
+		}
+		if hp.AssetID != nil {
+			hp.AssetName = assetNames[*hp.AssetID]
+		}
+
+		known := make(map[string]bool)
+		for _, s := range hp.Host.Services {
+			known[fmt.Sprintf("%d/%s", s.Port, s.Protocol)] = true
+		}
+		for _, s := range rec.Services {
+			if !known[fmt.Sprintf("%d/%s", s.Port, s.Protocol)] {
+				hp.NewServices++
+			}
+		}
+
+		seen := make(map[string]bool)
+		for _, f := range hp.Host.Findings {
+			seen[f.Key+"|"+f.Port] = true
+		}
+		for _, f := range rec.Findings {
+			fp := FindingPlan{Record: f, Severity: findingSeverity(f), Known: seen[f.Key+"|"+f.Port]}
+			v, found, err := m.match(f)
+			if err != nil {
+				return nil, err
+			}
+			if found {
+				id := v.ID
+				fp.VulnerabilityID, fp.VulnCode = &id, v.Code
+			}
+			hp.Findings = append(hp.Findings, fp)
+
+			if !found || hp.AssetID == nil || registered[[2]uint{*hp.AssetID, v.ID}] {
+				continue
+			}
+			registered[[2]uint{*hp.AssetID, v.ID}] = true
+			var entry models.AssetVulnerability
+			err = db.Where("asset_id = ? AND vulnerability_id = ?", *hp.AssetID, v.ID).First(&entry).Error
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				hp.Register = append(hp.Register, RegisterItem{AssetID: *hp.AssetID, VulnerabilityID: v.ID, Code: v.Code, Component: hostLabel(rec)})
+			case err != nil:
+				return nil, err
+			case entry.Status == models.VulnRemediated:
+				hp.Register = append(hp.Register, RegisterItem{AssetID: *hp.AssetID, VulnerabilityID: v.ID, Code: v.Code, Component: hostLabel(rec), Reopen: true})
+			}
+		}
+
+		plan.Hosts = append(plan.Hosts, hp)
+	}
+	return plan, nil
+}
+
+// Apply записывает план: узлы, порты, находки и реестры уязвимостей объектов
+func Apply(db *gorm.DB, plan *Plan) error {
+	now := time.Now()
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, hp := range plan.Hosts {
+			host := hp.Host
+			if host.ID == 0 {
+				host = models.Host{ClientID: plan.ClientID}
+			}
+			rec := hp.Record
+			host.Address = rec.Address
+			for _, ch := range []struct {
+				dst *string
+				val string
+			}{{&host.Hostname, rec.Hostname}, {&host.MAC, rec.MAC}, {&host.OS, rec.OS}} {
+				if ch.val != "" {
+					*ch.dst = ch.val
+				}
+			}
+			host.AssetID = hp.AssetID
+			host.LastScanAt = &now
+			host.LastSource = plan.Format
+			if err := tx.Omit("Client", "Asset", "Services", "Findings").Save(&host).Error; err != nil {
+				return fmt.Errorf("узел %s: %w", rec.Address, err)
+			}
+
+			for _, s := range rec.Services {
+				var svc models.HostService
+				err := tx.Where("host_id = ? AND port = ? AND protocol = ?", host.ID, s.Port, s.Protocol).First(&svc).Error
+				if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+					return err
+				}
+				svc.HostID, svc.Port, svc.Protocol = host.ID, s.Port, s.Protocol
+				if s.Service != "" {
+					svc.Service = s.Service
+				}
+				if product := strings.TrimSpace(s.Product + " " + s.Version); product != "" {
+					svc.Product = product
+				}
+				svc.LastSeenAt = now
+				if err := tx.Save(&svc).Error; err != nil {
+					return err
+				}
+			}
+
+			for _, fp := range hp.Findings {
+				f := fp.Record
+				var finding models.HostFinding
+				err := tx.Where("host_id = ? AND check_key = ? AND port = ?", host.ID, f.Key, f.Port).First(&finding).Error
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					finding = models.HostFinding{HostID: host.ID, Key: f.Key, Port: f.Port, FirstSeenAt: now}
+				} else if err != nil {
+					return err
+				}
+				finding.Name, finding.CVE, finding.BDU = f.Name, f.CVE, f.BDU
+				finding.Score, finding.Severity = f.Score, fp.Severity
+				finding.Solution, finding.Source = f.Solution, plan.Format
+				finding.VulnerabilityID = fp.VulnerabilityID
+				finding.LastSeenAt = now
+				if err := tx.Omit("Host", "Vulnerability").Save(&finding).Error; err != nil {
+					return err
+				}
+			}
+
+			for _, r := range hp.Register {
+				if r.Reopen {
+					err := tx.Model(&models.AssetVulnerability{}).
+						Where("asset_id = ? AND vulnerability_id = ?", r.AssetID, r.VulnerabilityID).
+						Updates(map[string]interface{}{"status": models.VulnFound, "remediated_at": nil}).Error
+					if err != nil {
+						return err
+					}
+					continue
+				}
+				entry := models.AssetVulnerability{
+					AssetID:         r.AssetID,
+					VulnerabilityID: r.VulnerabilityID,
+					Status:          models.VulnFound,
+					Component:       r.Component,
+					Notes:           "Обнаружена сканером (" + plan.Format + ")",
+				}
+				if err := tx.Omit("Asset", "Vulnerability", "Threats").Create(&entry).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func hostChanges(h models.Host, rec HostRecord) []Change {
+	var changes []Change
+	str := func(field, old, val string) {
+		if val != "" && old != val {
+			changes = append(changes, Change{Field: field, Old: old, New: val})
+		}
+	}
+	str("IP-адрес", h.Address, rec.Address)
+	str("Имя узла", h.Hostname, rec.Hostname)
+	str("MAC", h.MAC, rec.MAC)
+	str("ОС", h.OS, rec.OS)
+	return changes
+}
+
+func hostLabel(rec HostRecord) string {
+	if rec.Hostname != "" {
+		return rec.Address + " (" + rec.Hostname + ")"
+	}
+	return rec.Address
+}
+
+// findingSeverity — по оценке сканера, при её отсутствии — по вектору CVSS
+func findingSeverity(f FindingRecord) string {
+	score := f.Score
+	if score == 0 && f.CVSSVector != "" {
+		if v, err := cvss.Parse(f.CVSSVector); err == nil {
+			score, _ = v.BaseScore()
+		}
+	}
+	if score == 0 {
+		return ""
+	}
+	return cvss.Severity(score)
+}
+
+// matcher ищет уязвимость каталога по кодам БДУ, затем по CVE; результаты кэшируются
+type matcher struct {
+	db    *gorm.DB
+	cache map[string]*models.Vulnerability
+}
+
+func newMatcher(db *gorm.DB) *matcher {
+	return &matcher{db: db, cache: make(map[string]*models.Vulnerability)}
+}
+
+func (m *matcher) match(f FindingRecord) (models.Vulnerability, bool, error) {
+	var ids []string
+	for _, list := range []string{f.BDU, f.CVE} {
+		for _, id := range strings.Split(list, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	for _, id := range ids {
+		v, cached := m.cache[id]
+		if !cached {
+			var found models.Vulnerability
+			err := m.db.Where("code = ?", id).First(&found).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) && strings.HasPrefix(id, "CVE-") {
+				err = m.db.Scopes(models.HasCVE(id)).Order("id asc").First(&found).Error
+			}
+			switch {
+			case err == nil:
+				v = &found
+			case !errors.Is(err, gorm.ErrRecordNotFound):
+				return models.Vulnerability{}, false, err
+			}
+			m.cache[id] = v
+		}
+		if v != nil {
+			return *v, true, nil
+		}
+	}
+	return models.Vulnerability{}, false, nil
+}
@@ -0,0 +1,188 @@
+// Package scan — разбор отчётов сканеров (Nmap XML, OpenVAS/GVM XML, CSV отечественных сканеров)
+// и загрузка узлов и найденных уязвимостей в инвентаризацию клиента.
+package scan
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	FormatNmap    = "nmap"
+	FormatOpenVAS = "openvas"
+)
+
+// Report — узлы из одного отчёта сканера
+type Report struct {
+	Format string // nmap / openvas / имя CSV-сопоставления
+	Hosts  []HostRecord
+}
+
+// HostRecord — узел и всё, что сканер нашёл на нём
+type HostRecord struct {
+	Address  string
+	Hostname string
+	MAC      string
+	OS       string
+	Services []ServiceRecord
+	Findings []FindingRecord
+}
+
+type ServiceRecord struct {
+	Port     int
+	Protocol string
+	Service  string
+	Product  string
+	Version  string
+}
+
+// FindingRecord — уязвимость, найденная сканером на узле
+type FindingRecord struct {
+	Key        string // идентификатор проверки сканера (OID NVT, CVE, код БДУ)
+	Port       string // 443/tcp; пусто — уязвимость узла в целом
+	Name       string
+	CVE        string // идентификаторы CVE через запятую
+	BDU        string // идентификаторы БДУ через запятую
+	Score      float64
+	CVSSVector string
+	Solution   string
+}
+
+// ParseFile определяет формат отчёта: .csv разбирается по сопоставлению mapping,
+// XML — по корневому элементу (nmaprun — Nmap, report — OpenVAS/GVM)
+func ParseFile(filename string, mapping *CSVMapping) (*Report, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		if mapping == nil {
+			return nil, errors.New("для CSV выберите сопоставление колонок сканера")
+		}
+		return ParseCSV(data, *mapping)
+	case ".xml":
+		root, err := rootElement(data)
+		if err != nil {
+			return nil, err
+		}
+		switch root {
+		case "nmaprun":
+			return ParseNmap(data)
+		case "report", "get_reports_response":
+			return ParseOpenVAS(data)
+		}
+		return nil, fmt.Errorf("неизвестный формат XML (корневой элемент %q)", root)
+	}
+	return nil, errors.New("поддерживаются отчёты .xml (Nmap, OpenVAS/GVM) и .csv")
+}
+
+func rootElement(data []byte) (string, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return "", errors.New("пустой XML")
+		}
+		if err != nil {
+			return "", fmt.Errorf("разбор xml: %w", err)
+		}
+		if se, ok := tok.(xml.StartElement); ok {
+			return se.Name.Local, nil
+		}
+	}
+}
+
+// mergeHosts объединяет записи одного узла (CSV и OpenVAS дают строку на каждую находку)
+func mergeHosts(hosts []HostRecord) []HostRecord {
+	var merged []HostRecord
+	index := make(map[string]int)
+	for _, h := range hosts {
+		i, ok := index[h.Address]
+		if !ok {
+			index[h.Address] = len(merged)
+			merged = append(merged, h)
+			continue
+		}
+		m := &merged[i]
+		if m.Hostname == "" {
+			m.Hostname = h.Hostname
+		}
+		if m.MAC == "" {
+			m.MAC = h.MAC
+		}
+		if m.OS == "" {
+			m.OS = h.OS
+		}
+		for _, s := range h.Services {
+			m.Services = addService(m.Services, s)
+		}
+		for _, f := range h.Findings {
+			m.Findings = addFinding(m.Findings, f)
+		}
+	}
+	return merged
+}
+
+func addService(list []ServiceRecord, s ServiceRecord) []ServiceRecord {
+	for i, cur := range list {
+		if cur.Port == s.Port && cur.Protocol == s.Protocol {
+			if cur.Service == "" {
+				list[i].Service = s.Service
+			}
+			return list
+		}
+	}
+	return append(list, s)
+}
+
+func addFinding(list []FindingRecord, f FindingRecord) []FindingRecord {
+	for _, cur := range list {
+		if cur.Key == f.Key && cur.Port == f.Port {
+			return list
+		}
+	}
+	return append(list, f)
+}
+
+// parsePort разбирает "443/tcp", "443" и "general/tcp" (порт 0)
+func parsePort(s string) (int, string) {
+	s = strings.TrimSpace(s)
+	num, proto, _ := strings.Cut(s, "/")
+	port, err := strconv.Atoi(strings.TrimSpace(num))
+	if err != nil || port <= 0 || port > 65535 {
+		return 0, strings.ToLower(strings.TrimSpace(proto))
+	}
+	proto = strings.ToLower(strings.TrimSpace(proto))
+	if proto == "" {
+		proto = "tcp"
+	}
+	return port, proto
+}
+
+func portLabel(port int, proto string) string {
+	if port == 0 {
+		return ""
+	}
+	return strconv.Itoa(port) + "/" + proto
+}
+
+func joinUnique(ids []string) string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, id := range ids {
+		if id = strings.TrimSpace(id); id != "" && !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return strings.Join(out, ", ")
+}
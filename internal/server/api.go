@@ -0,0 +1,138 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"ib-integrator/internal/handlers"
+	"ib-integrator/internal/middleware"
+	"ib-integrator/internal/models"
+	"ib-integrator/internal/openapi"
+
+	"github.com/gin-gonic/gin"
+)
+
+const apiV1Prefix = "/api/v1"
+
+// apiRoute — строка таблицы маршрутов JSON API: по ней регистрируются обработчики
+// и строится спецификация OpenAPI (роли попадают в описание операций)
+type apiRoute struct {
+	method  string
+	path    string
+	roles   []models.UserRole
+	handler gin.HandlerFunc
+}
+
+// apiV1Routes — JSON API для внутренних сервисов и скриптов. Права те же, что у HTML-страниц.
+func apiV1Routes() []apiRoute {
+	all := []models.UserRole{models.RoleAdmin, models.RoleEngineer, models.RoleSales, models.RoleViewer}
+	adminSales := []models.UserRole{models.RoleAdmin, models.RoleSales}
+	admin := []models.UserRole{models.RoleAdmin}
+	riskEditors := []models.UserRole{models.RoleAdmin, models.RoleEngineer}
+
+	return []apiRoute{
+		// клиенты: создают admin и sales, меняют и удаляют — только admin
+		{"GET", "/clients", all, handlers.APIListClients},
+		{"POST", "/clients", adminSales, handlers.APICreateClient},
+		{"GET", "/clients/:id", all, handlers.APIGetClient},
+		{"PUT", "/clients/:id", admin, handlers.APIUpdateClient},
+		{"DELETE", "/clients/:id", admin, handlers.APIDeleteClient},
+
+		// объекты защиты — права как у клиентов
+		{"GET", "/assets", all, handlers.APIListAssets},
+		{"POST", "/assets", adminSales, handlers.APICreateAsset},
+		{"GET", "/assets/:id", all, handlers.APIGetAsset},
+		{"PUT", "/assets/:id", admin, handlers.APIUpdateAsset},
+		{"DELETE", "/assets/:id", admin, handlers.APIDeleteAsset},
+
+		// угрозы объекта и оценка риска (admin + engineer)
+		{"GET", "/assets/:id/threats", riskEditors, handlers.APIListAssetThreats},
+		{"POST", "/assets/:id/threats", riskEditors, handlers.APIAddAssetThreat},
+		{"GET", "/assets/:id/threats/:link_id", riskEditors, handlers.APIGetAssetThreat},
+		{"PUT", "/assets/:id/threats/:link_id", riskEditors, handlers.APIUpdateAssetThreat},
+		{"DELETE", "/assets/:id/threats/:link_id", riskEditors, handlers.APIDeleteAssetThreat},
+
+		// каталог угроз и мер (admin + engineer)
+		{"GET", "/threats", riskEditors, handlers.APIListThreats},
+		{"POST", "/threats", riskEditors, handlers.APICreateThreat},
+		{"GET", "/threats/:id", riskEditors, handlers.APIGetThreat},
+		{"PUT", "/threats/:id", riskEditors, handlers.APIUpdateThreat},
+		{"DELETE", "/threats/:id", riskEditors, handlers.APIDeleteThreat},
+
+		{"GET", "/measures", riskEditors, handlers.APIListMeasures},
+		{"POST", "/measures", riskEditors, handlers.APICreateMeasure},
+		{"GET", "/measures/:id", riskEditors, handlers.APIGetMeasure},
+		{"PUT", "/measures/:id", riskEditors, handlers.APIUpdateMeasure},
+		{"DELETE", "/measures/:id", riskEditors, handlers.APIDeleteMeasure},
+
+		// журнал аудита — admin и viewer
+		{"GET", "/audit-logs", []models.UserRole{models.RoleAdmin, models.RoleViewer}, handlers.APIListAuditLogs},
+	}
+}
+
+func registerAPIv1(r *gin.Engine) {
+	api := r.Group(apiV1Prefix)
+	api.Use(middleware.RequireAPIAuth())
+
+	for _, route := range apiV1Routes() {
+		api.Handle(route.method, route.path, middleware.RequireAPIRole(route.roles...), route.handler)
+	}
+
+	// спецификация и её просмотр открыты без входа: секретов в них нет,
+	// а интеграторам контракт нужен до получения токена
+	r.GET("/api/openapi.json", serveOpenAPI(r))
+	r.GET("/api/docs", func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", openapi.ViewerHTML)
+	})
+
+	// неизвестный путь под /api — ошибка в формате API, а не HTML-страница
+	r.NoRoute(func(c *gin.Context) {
+		if strings.HasPrefix(c.Request.URL.Path, "/api/") {
+			middleware.AbortAPI(c, http.StatusNotFound, middleware.ErrNotFound, "Метод API не найден")
+			return
+		}
+		c.String(http.StatusNotFound, "404 page not found")
+	})
+}
+
+// openAPIRoutes — маршруты /api/v1, реально зарегистрированные в r, с ролями из таблицы
+func openAPIRoutes(r *gin.Engine) []openapi.Route {
+	roles := map[string][]string{}
+	for _, route := range apiV1Routes() {
+		names := make([]string, 0, len(route.roles))
+		for _, role := range route.roles {
+			names = append(names, string(role))
+		}
+		roles[openapi.Key(route.method, apiV1Prefix+route.path)] = names
+	}
+
+	var out []openapi.Route
+	for _, info := range r.Routes() {
+		if !strings.HasPrefix(info.Path, apiV1Prefix+"/") {
+			continue
+		}
+		out = append(out, openapi.Route{
+			Method:  info.Method,
+			Path:    info.Path,
+			Handler: info.Handler,
+			Roles:   roles[openapi.Key(info.Method, info.Path)],
+		})
+	}
+	return out
+}
+
+// serveOpenAPI отдаёт спецификацию; собирается один раз при первом запросе,
+// когда все маршруты уже зарегистрированы
+func serveOpenAPI(r *gin.Engine) gin.HandlerFunc {
+	var (
+		once sync.Once
+		doc  *openapi.Document
+	)
+	return func(c *gin.Context) {
+		once.Do(func() {
+			doc, _ = handlers.APISpec(openAPIRoutes(r))
+		})
+		c.JSON(http.StatusOK, doc)
+	}
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"ib-integrator/internal/config"
+	"ib-integrator/internal/handlers"
+	"ib-integrator/internal/openapi"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newTestRouter — роутер как в main; шаблоны грузятся относительно корня репозитория
+func newTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir("../.."); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	return NewRouter(&config.Config{SessionSecret: "test-secret"})
+}
+
+// Каждый маршрут /api/v1 описан в спецификации, и каждое описание соответствует маршруту
+func TestOpenAPIMatchesRoutes(t *testing.T) {
+	r := newTestRouter(t)
+
+	routes := openAPIRoutes(r)
+	if len(routes) != len(apiV1Routes()) {
+		t.Errorf("в роутере %d маршрутов /api/v1, в таблице apiV1Routes — %d", len(routes), len(apiV1Routes()))
+	}
+
+	doc, problems := handlers.APISpec(routes)
+	for _, p := range problems {
+		t.Error(p)
+	}
+
+	ops := 0
+	for path, item := range doc.Paths {
+		for method, op := range item {
+			ops++
+			if strings.Contains(path, ":") {
+				t.Errorf("%s %s: путь не переведён в шаблон OpenAPI", method, path)
+			}
+			if len(op.Roles) == 0 {
+				t.Errorf("%s %s: не указаны роли", method, path)
+			}
+			if op.Summary == "" {
+				t.Errorf("%s %s: нет summary", method, path)
+			}
+		}
+	}
+	if ops != len(routes) {
+		t.Errorf("в спецификации %d операций, маршрутов — %d", ops, len(routes))
+	}
+}
+
+// Маршрут, добавленный мимо описаний, должен считаться расхождением
+func TestOpenAPIDetectsUndocumentedRoute(t *testing.T) {
+	r := newTestRouter(t)
+	r.GET(apiV1Prefix+"/undocumented", func(c *gin.Context) {})
+
+	_, problems := handlers.APISpec(openAPIRoutes(r))
+	if len(problems) != 1 || !strings.Contains(problems[0], "GET /api/v1/undocumented") {
+		t.Fatalf("ожидалось одно расхождение для /api/v1/undocumented, получено: %v", problems)
+	}
+
+	_, problems = handlers.APISpec(nil)
+	if len(problems) != len(apiV1Routes()) {
+		t.Fatalf("без маршрутов каждое описание — расхождение: ожидалось %d, получено %d", len(apiV1Routes()), len(problems))
+	}
+}
+
+// Отдаваемый документ — OpenAPI 3.1, все ссылки на схемы разрешаются
+func TestOpenAPIServed(t *testing.T) {
+	r := newTestRouter(t)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /api/openapi.json: %d", w.Code)
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc["openapi"] != openapi.Version {
+		t.Errorf("openapi = %v, ожидалось %s", doc["openapi"], openapi.Version)
+	}
+	schemas, _ := doc["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			if ref, ok := v["$ref"].(string); ok {
+				name := strings.TrimPrefix(ref, "#/components/schemas/")
+				if _, ok := schemas[name]; !ok {
+					t.Errorf("ссылка на несуществующую схему %s", ref)
+				}
+			}
+			for _, child := range v {
+				walk(child)
+			}
+		case []interface{}:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(doc)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/docs", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "/api/openapi.json") {
+		t.Fatalf("GET /api/docs: %d", w.Code)
+	}
+}
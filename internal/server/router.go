@@ -1,181 +1,744 @@
-package server
-
-import (
-	"html/template"
-	"net/http"
-
-	"ib-integrator/internal/config"
-	"ib-integrator/internal/handlers"
-	"ib-integrator/internal/middleware"
-	"ib-integrator/internal/models"
-
-	"github.com/gin-contrib/sessions"
-	"github.com/gin-contrib/sessions/cookie"
-	"github.com/gin-gonic/gin"
-)
-
-func maskEmail(email string) string {
-	runes := []rune(email)
-	atIdx := -1
-	for i, r := range runes {
-		if r == '@' {
-			atIdx = i
-			break
-		}
-	}
-	if atIdx <= 0 {
-		return "***"
-	}
-	prefix := string(runes[:atIdx])
-	domain := string(runes[atIdx:])
-	if len(prefix) <= 2 {
-		return prefix + "***" + domain
-	}
-	return string(runes[0:2]) + "***" + domain
-}
-
-func maskPhone(phone string) string {
-	runes := []rune(phone)
-	n := len(runes)
-	if n <= 4 {
-		return "***"
-	}
-	masked := make([]rune, n)
-	for i := range runes {
-		if i >= n-2 {
-			masked[i] = runes[i]
-		} else {
-			masked[i] = '*'
-		}
-	}
-	return string(masked)
-}
-
-func NewRouter(cfg *config.Config) *gin.Engine {
-	r := gin.Default()
-
-	r.Static("/static", "./web/static")
-
-	r.SetFuncMap(template.FuncMap{
-		"eq":        func(a, b interface{}) bool { return a == b },
-		"maskEmail": maskEmail,
-		"maskPhone": maskPhone,
-	})
-	r.LoadHTMLGlob("web/templates/*.html")
-
-	store := cookie.NewStore([]byte(cfg.SessionSecret))
-	r.Use(sessions.Sessions("ib_session", store))
-
-	r.Use(middleware.InjectUser())
-
-	// ГЛАВНАЯ
-	r.GET("/", handlers.IndexPage)
-
-	// AUTH
-	r.GET("/register", handlers.ShowRegister)
-	r.POST("/register", handlers.Register)
-	r.GET("/login", handlers.ShowLogin)
-	r.POST("/login", handlers.Login)
-	r.GET("/logout", handlers.Logout)
-
-	auth := r.Group("/")
-	auth.Use(middleware.RequireAuth())
-
-	// КЛИЕНТЫ
-	auth.GET("/clients", handlers.ListClients)
-	auth.GET("/clients/new",
-		middleware.RequireRole(models.RoleAdmin, models.RoleSales),
-		handlers.ShowNewClient,
-	)
-	auth.POST("/clients/new",
-		middleware.RequireRole(models.RoleAdmin, models.RoleSales),
-		handlers.CreateClient,
-	)
-	auth.GET("/clients/:id", handlers.ShowClientDetail)
-
-	// редактирование клиентов — только админ
-	auth.GET("/clients/:id/edit",
-		middleware.RequireRole(models.RoleAdmin),
-		handlers.ShowEditClient,
-	)
-	auth.POST("/clients/:id/edit",
-		middleware.RequireRole(models.RoleAdmin),
-		handlers.UpdateClient,
-	)
-
-
-	// ОБЪЕКТЫ ЗАЩИТЫ
-	auth.GET("/assets", handlers.ListAssets)
-
-	auth.GET("/assets/new",
-		middleware.RequireRole(models.RoleAdmin, models.RoleSales),
-		handlers.ShowNewAsset,
-	)
-	auth.POST("/assets/new",
-		middleware.RequireRole(models.RoleAdmin, models.RoleSales),
-		handlers.CreateAsset,
-	)
-
-	// редактирование объектов защиты — только админ
-	auth.GET("/assets/:id/edit",
-		middleware.RequireRole(models.RoleAdmin),
-		handlers.ShowEditAsset,
-	)
-	auth.POST("/assets/:id/edit",
-		middleware.RequireRole(models.RoleAdmin),
-		handlers.UpdateAsset,
-	)
-
-
-	// ====== УГРОЗЫ И МЕРЫ ЗАЩИТЫ ======
-	// каталог (admin + engineer)
-	auth.GET("/threats",
-		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
-		handlers.ListThreatsAndMeasures,
-	)
-
-	auth.GET("/threats/new",
-		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
-		handlers.ShowNewThreat,
-	)
-	auth.POST("/threats/new",
-		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
-		handlers.CreateThreat,
-	)
-
-	auth.GET("/measures/new",
-		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
-		handlers.ShowNewMeasure,
-	)
-	auth.POST("/measures/new",
-		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
-		handlers.CreateMeasure,
-	)
-
-	// угрозы конкретного объекта защиты
-	auth.GET("/assets/:id/threats",
-		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
-		handlers.ShowAssetThreats,
-	)
-	auth.POST("/assets/:id/threats/add",
-		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
-		handlers.AddAssetThreat,
-	)
-	auth.POST("/assets/:id/threats/:link_id/delete",
-		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
-		handlers.DeleteAssetThreat,
-	)
-
-	// АУДИТ
-	auth.GET("/audit",
-		middleware.RequireRole(models.RoleAdmin, models.RoleViewer),
-		handlers.ListAuditLogs,
-	)
-
-	// HEALTHCHECK
-	r.GET("/health", func(c *gin.Context) {
-		c.String(http.StatusOK, "ok")
-	})
-
-	return r
-}
+package server
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+
+	"ib-integrator/internal/config"
+	"ib-integrator/internal/handlers"
+	"ib-integrator/internal/middleware"
+	"ib-integrator/internal/models"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+)
+
+func NewRouter(cfg *config.Config) *gin.Engine {
+	r := gin.Default()
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatalf("invalid TRUSTED_PROXIES: %v", err)
+	}
+
+	r.Static("/static", "./web/static")
+
+	r.SetFuncMap(template.FuncMap{
+		"eq":                 func(a, b interface{}) bool { return a == b },
+		"inc":                func(i int) int { return i + 1 },
+		"maskEmail":          models.MaskEmail,
+		"maskPhone":          models.MaskPhone,
+		"riskLabel":          models.RiskLevelLabel,
+		"pdCategoryLabel":    models.PDCategoryLabel,
+		"damageLabel":        models.DamageLabel,
+		"gisScaleLabel":      models.GISScaleLabel,
+		"kiiCategoryLabel":   models.KIICategoryLabel,
+		"intruderKindLabel":  models.IntruderKindLabel,
+		"intruderLevelLabel": models.IntruderLevelLabel,
+		"authActionLabel":    models.AuthActionLabel,
+	})
+	r.LoadHTMLGlob("web/templates/*.html")
+
+	store := cookie.NewStore([]byte(cfg.SessionSecret))
+	r.Use(sessions.Sessions("ib_session", store))
+
+	r.Use(middleware.InjectUser())
+
+	// ГЛАВНАЯ
+	r.GET("/", handlers.IndexPage)
+
+	// AUTH
+	r.GET("/register", handlers.ShowRegister)
+	r.POST("/register", handlers.Register)
+	r.GET("/login", handlers.ShowLogin)
+	r.POST("/login", handlers.Login)
+	r.GET("/logout", handlers.Logout)
+
+	auth := r.Group("/")
+	auth.Use(middleware.RequireAuth())
+
+	// смена собственного пароля (после сброса администратором — обязательна)
+	auth.GET(middleware.PasswordChangePath, handlers.ShowChangePassword)
+	auth.POST(middleware.PasswordChangePath, handlers.ChangePassword)
+
+	// КЛИЕНТЫ
+	auth.GET("/clients", handlers.ListClients)
+	auth.GET("/clients/new",
+		middleware.RequireRole(models.RoleAdmin, models.RoleSales),
+		handlers.ShowNewClient,
+	)
+	auth.POST("/clients/new",
+		middleware.RequireRole(models.RoleAdmin, models.RoleSales),
+		handlers.CreateClient,
+	)
+	auth.GET("/clients/:id", handlers.ShowClientDetail)
+
+	// редактирование клиентов — только админ
+	auth.GET("/clients/:id/edit",
+		middleware.RequireRole(models.RoleAdmin),
+		handlers.ShowEditClient,
+	)
+	auth.POST("/clients/:id/edit",
+		middleware.RequireRole(models.RoleAdmin),
+		handlers.UpdateClient,
+	)
+
+
+	// ПРОЕКТЫ — права как у клиентов
+	auth.GET("/projects", handlers.ListProjects)
+	auth.GET("/projects/new",
+		middleware.RequireRole(models.RoleAdmin, models.RoleSales),
+		handlers.ShowNewProject,
+	)
+	auth.POST("/projects/new",
+		middleware.RequireRole(models.RoleAdmin, models.RoleSales),
+		handlers.CreateProject,
+	)
+	auth.GET("/projects/:id", handlers.ShowProjectDetail)
+
+	// редактирование и смена статуса проектов — только админ
+	auth.GET("/projects/:id/edit",
+		middleware.RequireRole(models.RoleAdmin),
+		handlers.ShowEditProject,
+	)
+	auth.POST("/projects/:id/edit",
+		middleware.RequireRole(models.RoleAdmin),
+		handlers.UpdateProject,
+	)
+	auth.POST("/projects/:id/status",
+		middleware.RequireRole(models.RoleAdmin),
+		handlers.ChangeProjectStatus,
+	)
+
+	// ОБЪЕКТЫ ЗАЩИТЫ
+	auth.GET("/assets", handlers.ListAssets)
+
+	auth.GET("/assets/new",
+		middleware.RequireRole(models.RoleAdmin, models.RoleSales),
+		handlers.ShowNewAsset,
+	)
+	auth.POST("/assets/new",
+		middleware.RequireRole(models.RoleAdmin, models.RoleSales),
+		handlers.CreateAsset,
+	)
+
+	// редактирование объектов защиты — только админ
+	auth.GET("/assets/:id/edit",
+		middleware.RequireRole(models.RoleAdmin),
+		handlers.ShowEditAsset,
+	)
+	auth.POST("/assets/:id/edit",
+		middleware.RequireRole(models.RoleAdmin),
+		handlers.UpdateAsset,
+	)
+
+
+	// ====== УГРОЗЫ И МЕРЫ ЗАЩИТЫ ======
+	// каталог (admin + engineer)
+	auth.GET("/threats",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ListThreatsAndMeasures,
+	)
+
+	auth.GET("/threats/new",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ShowNewThreat,
+	)
+	auth.POST("/threats/new",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.CreateThreat,
+	)
+
+	// импорт БДУ ФСТЭК — только админ
+	auth.GET("/threats/import",
+		middleware.RequireRole(models.RoleAdmin),
+		handlers.ShowThreatImport,
+	)
+	auth.POST("/threats/import",
+		middleware.RequireRole(models.RoleAdmin),
+		handlers.PreviewThreatImport,
+	)
+	auth.POST("/threats/import/apply",
+		middleware.RequireRole(models.RoleAdmin),
+		handlers.ApplyThreatImport,
+	)
+
+	auth.GET("/measures/new",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ShowNewMeasure,
+	)
+	auth.POST("/measures/new",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.CreateMeasure,
+	)
+
+	// версии записей каталога: черновик, публикация, вывод из каталога, история
+	auth.GET("/threats/:id/edit",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ShowEditThreat,
+	)
+	auth.POST("/threats/:id/edit",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.UpdateThreat,
+	)
+	auth.POST("/threats/:id/publish",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.PublishThreat,
+	)
+	auth.POST("/threats/:id/deprecate",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.DeprecateThreat,
+	)
+	auth.POST("/threats/:id/draft/delete",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.DeleteThreatDraft,
+	)
+	auth.GET("/threats/:id/history",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ShowThreatHistory,
+	)
+
+	auth.GET("/measures/:id/edit",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ShowEditMeasure,
+	)
+	auth.POST("/measures/:id/edit",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.UpdateMeasure,
+	)
+	auth.POST("/measures/:id/publish",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.PublishMeasure,
+	)
+	auth.POST("/measures/:id/deprecate",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.DeprecateMeasure,
+	)
+	auth.POST("/measures/:id/draft/delete",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.DeleteMeasureDraft,
+	)
+	auth.GET("/measures/:id/history",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ShowMeasureHistory,
+	)
+
+	// матрица рисков: просмотр — admin + engineer, правка — только админ
+	auth.GET("/risk-matrix",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ShowRiskMatrix,
+	)
+	auth.POST("/risk-matrix",
+		middleware.RequireRole(models.RoleAdmin),
+		handlers.UpdateRiskMatrix,
+	)
+
+	// MITRE ATT&CK: версии, сопоставление угроз с техниками, покрытие мерами клиента
+	auth.GET("/attack",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ListAttackReleases,
+	)
+	auth.POST("/attack/import",
+		middleware.RequireRole(models.RoleAdmin),
+		handlers.ImportAttack,
+	)
+	auth.GET("/threats/:id/attack",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ShowThreatAttack,
+	)
+	auth.POST("/threats/:id/attack",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.AddThreatAttack,
+	)
+	auth.POST("/threats/:id/attack/:mapping_id/delete",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.DeleteThreatAttack,
+	)
+	auth.GET("/clients/:id/attack",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ShowClientAttackCoverage,
+	)
+
+	// требования стандартов, сопоставление с мерами и соответствие клиента
+	auth.GET("/frameworks",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ListFrameworks,
+	)
+	auth.GET("/frameworks/:id",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ShowFramework,
+	)
+	auth.POST("/frameworks/:id/requirements/:req_id/measures",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.AddRequirementMeasure,
+	)
+	auth.POST("/frameworks/:id/requirements/:req_id/measures/:measure_id/delete",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.DeleteRequirementMeasure,
+	)
+	auth.GET("/clients/:id/compliance",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ShowClientCompliance,
+	)
+
+	// каталог уязвимостей: просмотр — admin + engineer, загрузка выгрузок — только админ
+	auth.GET("/vulnerabilities",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ListVulnerabilities,
+	)
+	auth.POST("/vulnerabilities/import",
+		middleware.RequireRole(models.RoleAdmin),
+		handlers.ImportVulnerabilities,
+	)
+	auth.GET("/vulnerabilities/:id",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ShowVulnerability,
+	)
+
+	// реестр уязвимостей объекта
+	auth.GET("/assets/:id/vulnerabilities",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ShowAssetVulnerabilities,
+	)
+	auth.POST("/assets/:id/vulnerabilities",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.AddAssetVulnerability,
+	)
+	auth.GET("/assets/:id/vulnerabilities/:entry_id/edit",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ShowEditAssetVulnerability,
+	)
+	auth.POST("/assets/:id/vulnerabilities/:entry_id/edit",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.UpdateAssetVulnerability,
+	)
+	auth.POST("/assets/:id/vulnerabilities/:entry_id/delete",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.DeleteAssetVulnerability,
+	)
+
+	// инвентаризация узлов клиента и импорт отчётов сканеров (предпросмотр → запись)
+	auth.GET("/clients/:id/hosts",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ShowClientHosts,
+	)
+	auth.POST("/clients/:id/hosts/import",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.PreviewScanImport,
+	)
+	auth.POST("/clients/:id/hosts/import/apply",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ApplyScanImport,
+	)
+	auth.POST("/clients/:id/hosts/:host_id/asset",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.SetHostAsset,
+	)
+
+	// состав объекта: сегменты сети, компоненты, потоки данных со смежными системами
+	auth.GET("/assets/:id/components",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ShowAssetComponents,
+	)
+	auth.POST("/assets/:id/components",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.AddAssetComponent,
+	)
+	auth.GET("/assets/:id/components/:component_id/edit",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ShowEditAssetComponent,
+	)
+	auth.POST("/assets/:id/components/:component_id/edit",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.UpdateAssetComponent,
+	)
+	auth.POST("/assets/:id/components/:component_id/delete",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.DeleteAssetComponent,
+	)
+	auth.POST("/assets/:id/segments",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.AddAssetSegment,
+	)
+	auth.POST("/assets/:id/segments/:segment_id/delete",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.DeleteAssetSegment,
+	)
+	auth.POST("/assets/:id/flows",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.AddDataFlow,
+	)
+	auth.POST("/assets/:id/flows/:flow_id/delete",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.DeleteDataFlow,
+	)
+
+	// DFD объекта и подбор угроз STRIDE
+	auth.GET("/assets/:id/dfd",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ShowAssetDFD,
+	)
+	auth.POST("/assets/:id/dfd/boundaries",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.AddDFDBoundary,
+	)
+	auth.POST("/assets/:id/dfd/boundaries/:boundary_id/delete",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.DeleteDFDBoundary,
+	)
+	auth.POST("/assets/:id/dfd/elements",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.AddDFDElement,
+	)
+	auth.POST("/assets/:id/dfd/elements/:element_id/delete",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.DeleteDFDElement,
+	)
+	auth.POST("/assets/:id/dfd/flows",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.AddDFDFlow,
+	)
+	auth.POST("/assets/:id/dfd/flows/:flow_id/delete",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.DeleteDFDFlow,
+	)
+	auth.GET("/assets/:id/dfd/export",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ExportAssetDFD,
+	)
+	auth.POST("/assets/:id/dfd/import",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ImportAssetDFD,
+	)
+	auth.POST("/assets/:id/dfd/analyze",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.AnalyzeAssetDFD,
+	)
+	auth.POST("/assets/:id/dfd/suggestions",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.DecideDFDSuggestions,
+	)
+
+	// каталог СЗИ, установки на объектах и отчёт по сертификатам
+	auth.GET("/security-tools",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ListSecurityTools,
+	)
+	auth.POST("/security-tools",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.CreateSecurityTool,
+	)
+	auth.GET("/security-tools/report",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.SecurityToolReport,
+	)
+	auth.GET("/security-tools/register",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ShowCertRegister,
+	)
+	auth.POST("/security-tools/register/import",
+		middleware.RequireRole(models.RoleAdmin),
+		handlers.ImportCertRegister,
+	)
+	auth.POST("/certificate-alerts/:id/ack",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.AcknowledgeCertificateAlert,
+	)
+	auth.GET("/security-tools/:id/edit",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ShowEditSecurityTool,
+	)
+	auth.POST("/security-tools/:id/edit",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.UpdateSecurityTool,
+	)
+	auth.POST("/security-tools/:id/delete",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.DeleteSecurityTool,
+	)
+	auth.GET("/assets/:id/security-tools",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ShowAssetSecurityTools,
+	)
+	auth.POST("/assets/:id/security-tools",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.AddAssetSecurityTool,
+	)
+	auth.GET("/assets/:id/security-tools/:installation_id/edit",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ShowEditAssetSecurityTool,
+	)
+	auth.POST("/assets/:id/security-tools/:installation_id/edit",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.UpdateAssetSecurityTool,
+	)
+	auth.POST("/assets/:id/security-tools/:installation_id/delete",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.DeleteAssetSecurityTool,
+	)
+
+	// угрозы конкретного объекта защиты
+	auth.GET("/assets/:id/threats",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ShowAssetThreats,
+	)
+	auth.POST("/assets/:id/threats/add",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.AddAssetThreat,
+	)
+	auth.GET("/assets/:id/threats/:link_id/edit",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ShowEditAssetThreat,
+	)
+	auth.POST("/assets/:id/threats/:link_id/edit",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.UpdateAssetThreat,
+	)
+	auth.POST("/assets/:id/threats/:link_id/delete",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.DeleteAssetThreat,
+	)
+	auth.POST("/assets/:id/threats/:link_id/exclude",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ExcludeAssetThreat,
+	)
+	auth.POST("/assets/:id/threats/:link_id/include",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.IncludeAssetThreat,
+	)
+
+	// сценарии реализации угрозы объекта
+	auth.POST("/assets/:id/threats/:link_id/scenarios",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.CreateThreatScenario,
+	)
+	auth.GET("/assets/:id/threats/:link_id/scenarios/:scenario_id/edit",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ShowEditThreatScenario,
+	)
+	auth.POST("/assets/:id/threats/:link_id/scenarios/:scenario_id/edit",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.UpdateThreatScenario,
+	)
+	auth.POST("/assets/:id/threats/:link_id/scenarios/:scenario_id/delete",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.DeleteThreatScenario,
+	)
+
+	// каталог нарушителей и профиль нарушителей объекта (методика ФСТЭК 2021)
+	auth.GET("/intruders",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ListIntruders,
+	)
+	auth.POST("/intruders/new",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.CreateIntruder,
+	)
+	auth.GET("/intruders/:id/edit",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ShowEditIntruder,
+	)
+	auth.POST("/intruders/:id/edit",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.UpdateIntruder,
+	)
+	auth.GET("/assets/:id/intruders",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ShowAssetIntruders,
+	)
+	auth.POST("/assets/:id/intruders",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.SaveAssetIntruder,
+	)
+	auth.POST("/assets/:id/intruders/:entry_id/delete",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.DeleteAssetIntruder,
+	)
+
+	// уровень защищённости ИСПДн (ПП №1119)
+	auth.GET("/assets/:id/ispdn",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ShowISPDnAssessment,
+	)
+	auth.POST("/assets/:id/ispdn",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.SaveISPDnAssessment,
+	)
+
+	// класс защищённости ГИС (приказ №17)
+	auth.GET("/assets/:id/gis",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ShowGISClassification,
+	)
+	auth.POST("/assets/:id/gis",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.SaveGISClassification,
+	)
+	auth.GET("/assets/:id/gis/act",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ExportGISAct,
+	)
+
+	// категорирование КИИ (187-ФЗ, ПП №127)
+	auth.GET("/clients/:id/kii",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ListClientKII,
+	)
+	auth.GET("/assets/:id/kii",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ShowKIIObject,
+	)
+	auth.POST("/assets/:id/kii",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.SaveKIIObject,
+	)
+
+	// реестр внедрения мер на объекте
+	auth.GET("/assets/:id/measures",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ShowAssetMeasures,
+	)
+	auth.POST("/assets/:id/measures/add",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.AddAssetMeasure,
+	)
+	auth.POST("/assets/:id/measures/suggested",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.AcceptMeasureSuggestions,
+	)
+	auth.GET("/assets/:id/measures/:entry_id/edit",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ShowEditAssetMeasure,
+	)
+	auth.POST("/assets/:id/measures/:entry_id/edit",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.UpdateAssetMeasure,
+	)
+	auth.POST("/assets/:id/measures/:entry_id/delete",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.DeleteAssetMeasure,
+	)
+
+	// базовые наборы мер приказов ФСТЭК и анализ соответствия объекта
+	auth.GET("/baselines",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ListBaselines,
+	)
+	auth.GET("/assets/:id/gap",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ShowAssetGap,
+	)
+	auth.POST("/assets/:id/gap/add",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.AddGapMeasures,
+	)
+
+	// документ «Модель угроз» и его шаблоны
+	auth.GET("/clients/:id/threat-model",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ShowClientThreatModel,
+	)
+	auth.GET("/assets/:id/threat-model",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ShowAssetThreatModel,
+	)
+	auth.GET("/document-templates",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ListDocumentTemplates,
+	)
+	auth.GET("/document-templates/new",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ShowNewDocumentTemplate,
+	)
+	auth.POST("/document-templates/new",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.CreateDocumentTemplate,
+	)
+	auth.GET("/document-templates/:id/edit",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.ShowEditDocumentTemplate,
+	)
+	auth.POST("/document-templates/:id/edit",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.UpdateDocumentTemplate,
+	)
+	auth.POST("/document-templates/:id/delete",
+		middleware.RequireRole(models.RoleAdmin, models.RoleEngineer),
+		handlers.DeleteDocumentTemplate,
+	)
+
+	// АУДИТ
+	auth.GET("/audit",
+		middleware.RequireRole(models.RoleAdmin, models.RoleViewer),
+		handlers.ListAuditLogs,
+	)
+
+	// ПОЛЬЗОВАТЕЛИ — только админ
+	auth.GET("/users",
+		middleware.RequireRole(models.RoleAdmin),
+		handlers.ListUsers,
+	)
+	auth.POST("/users",
+		middleware.RequireRole(models.RoleAdmin),
+		handlers.CreateUser,
+	)
+	auth.POST("/users/registration-mode",
+		middleware.RequireRole(models.RoleAdmin),
+		handlers.SetRegistrationMode,
+	)
+	auth.POST("/users/:id/role",
+		middleware.RequireRole(models.RoleAdmin),
+		handlers.ChangeUserRole,
+	)
+	auth.POST("/users/:id/deactivate",
+		middleware.RequireRole(models.RoleAdmin),
+		handlers.DeactivateUser,
+	)
+	auth.POST("/users/:id/activate",
+		middleware.RequireRole(models.RoleAdmin),
+		handlers.ActivateUser,
+	)
+	auth.POST("/users/:id/reset-password",
+		middleware.RequireRole(models.RoleAdmin),
+		handlers.ResetUserPassword,
+	)
+	auth.POST("/users/:id/approve",
+		middleware.RequireRole(models.RoleAdmin),
+		handlers.ApproveUser,
+	)
+	auth.POST("/users/:id/reject",
+		middleware.RequireRole(models.RoleAdmin),
+		handlers.RejectUser,
+	)
+	auth.POST("/users/:id/unlock",
+		middleware.RequireRole(models.RoleAdmin),
+		handlers.UnlockUser,
+	)
+
+	// ТОКЕНЫ API: персональные — у каждого пользователя, сервисные учётные записи — админ
+	auth.GET("/tokens", handlers.ShowTokens)
+	auth.POST("/tokens", handlers.CreateToken)
+	auth.POST("/tokens/:id/revoke", handlers.RevokeToken)
+	auth.GET("/service-accounts",
+		middleware.RequireRole(models.RoleAdmin),
+		handlers.ShowServiceAccounts,
+	)
+	auth.POST("/service-accounts",
+		middleware.RequireRole(models.RoleAdmin),
+		handlers.CreateServiceAccount,
+	)
+	auth.POST("/service-accounts/:id/tokens",
+		middleware.RequireRole(models.RoleAdmin),
+		handlers.IssueServiceToken,
+	)
+
+	// JSON API
+	registerAPIv1(r)
+
+	// HEALTHCHECK
+	r.GET("/health", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	return r
+}
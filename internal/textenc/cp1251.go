@@ -0,0 +1,42 @@
+// Package textenc — перекодировка выгрузок, которые отечественные системы
+// (сканеры, реестры ФСТЭК) нередко сохраняют в windows-1251.
+package textenc
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+// ToUTF8 убирает BOM; данные не в UTF-8 считаются выгрузкой в windows-1251
+func ToUTF8(data []byte) []byte {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if utf8.Valid(data) {
+		return data
+	}
+	return DecodeCP1251(data)
+}
+
+// DecodeCP1251 перекодирует windows-1251 в UTF-8
+func DecodeCP1251(data []byte) []byte {
+	var buf bytes.Buffer
+	buf.Grow(len(data) * 2)
+	for _, b := range data {
+		switch {
+		case b < 0x80:
+			buf.WriteByte(b)
+		case b >= 0xC0:
+			buf.WriteRune(rune(b-0xC0) + 'А')
+		default:
+			buf.WriteRune(cp1251High[b-0x80])
+		}
+	}
+	return buf.Bytes()
+}
+
+// символы 0x80–0xBF кодировки windows-1251
+var cp1251High = [64]rune{
+	'Ђ', 'Ѓ', '‚', 'ѓ', '„', '…', '†', '‡', '€', '‰', 'Љ', '‹', 'Њ', 'Ќ', 'Ћ', 'Џ',
+	'ђ', '‘', '’', '“', '”', '•', '–', '—', '\uFFFD', '™', 'љ', '›', 'њ', 'ќ', 'ћ', 'џ',
+	'\u00A0', 'Ў', 'ў', 'Ј', '¤', 'Ґ', '¦', '§', 'Ё', '©', 'Є', '«', '¬', '\u00AD', '®', 'Ї',
+	'°', '±', 'І', 'і', 'ґ', 'µ', '¶', '·', 'ё', '№', 'є', '»', 'ј', 'Ѕ', 'ѕ', 'ї',
+}
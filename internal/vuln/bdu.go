@@ -0,0 +1,296 @@
+// Package vuln — каталог уязвимостей: разбор выгрузки vulnlist БДУ ФСТЭК и фидов NVD JSON 2.0,
+// расчёт оценок CVSS и загрузка в каталог.
+package vuln
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"ib-integrator/internal/xlsx"
+)
+
+const (
+	SourceBDU = "bdu"
+	SourceNVD = "nvd"
+)
+
+// Record — одна уязвимость из выгрузки
+type Record struct {
+	Code        string // BDU:2023-01234 или CVE-2023-1234
+	CVE         string // идентификаторы CVE через запятую
+	Name        string
+	Description string
+	Vendor      string
+	Product     string
+	Remediation string
+	Source      string
+	Published   *time.Time
+
+	CVSS3Vector string
+	CVSS4Vector string
+}
+
+// ParseFile определяет формат по расширению: .json — NVD, .xlsx/.xml — БДУ
+func ParseFile(filename string) ([]Record, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		return ParseNVD(data)
+	case ".xlsx":
+		rows, err := xlsx.ReadFile(filename)
+		if err != nil {
+			return nil, fmt.Errorf("чтение xlsx: %w", err)
+		}
+		return parseBDURows(rows)
+	case ".xml":
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		return parseBDUXML(data)
+	}
+	return nil, errors.New("поддерживаются файлы vulnlist.xlsx, XML-выгрузка БДУ и фиды NVD .json")
+}
+
+var (
+	bduCodeRe = regexp.MustCompile(`^(?:BDU:)?(\d{4}-\d{5})$`)
+	cveRe     = regexp.MustCompile(`CVE-\d{4}-\d{4,}`)
+)
+
+// NormalizeBDUCode приводит идентификатор к виду BDU:2023-01234
+func NormalizeBDUCode(raw string) (string, error) {
+	m := bduCodeRe.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(raw)))
+	if m == nil {
+		return "", fmt.Errorf("некорректный идентификатор уязвимости %q", raw)
+	}
+	return "BDU:" + m[1], nil
+}
+
+// cveIDs — идентификаторы CVE из текста ("CVE-2021-44228, GHSA-...")
+func cveIDs(s string) string {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, id := range cveRe.FindAllString(s, -1) {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return strings.Join(ids, ", ")
+}
+
+// bduVector — вектор CVSS 3.0 из ячейки БДУ; вектор без префикса дополняется им
+func bduVector(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.HasPrefix(s, "CVSS:") {
+		return s
+	}
+	return "CVSS:3.0/" + s
+}
+
+func parseBDUDate(s string) *time.Time {
+	t, err := time.Parse("02.01.2006", strings.TrimSpace(s))
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// колонки vulnlist.xlsx ищутся по подстроке в заголовке, как в thrlist
+const (
+	colID = iota
+	colName
+	colDescription
+	colVendor
+	colProduct
+	colVersion
+	colDate
+	colCVSS3
+	colRemediation
+	colOther
+	colCount
+)
+
+var headerKeys = []struct {
+	col int
+	key string
+}{
+	// порядок важен: "идентификаторы других систем" не должно попасть в колонку идентификатора
+	{colOther, "других систем"},
+	{colID, "идентификатор"},
+	{colName, "наименование уязвимости"},
+	{colDescription, "описание уязвимости"},
+	{colVendor, "вендор"},
+	{colProduct, "название по"},
+	{colVersion, "версия по"},
+	{colDate, "дата выявления"},
+	{colCVSS3, "cvss 3"},
+	{colRemediation, "меры по устранению"},
+}
+
+func parseBDURows(rows [][]string) ([]Record, error) {
+	header := -1
+	var cols [colCount]int
+	for i := range cols {
+		cols[i] = -1
+	}
+
+	// первая строка официальной выгрузки — общий заголовок
+	for i, row := range rows {
+		for _, cell := range row {
+			if strings.Contains(strings.ToLower(cell), "наименование уязвимости") {
+				header = i
+				break
+			}
+		}
+		if header >= 0 {
+			break
+		}
+	}
+	if header < 0 {
+		return nil, errors.New("не найдена строка заголовков (колонка «Наименование уязвимости»)")
+	}
+
+	for j, cell := range rows[header] {
+		title := strings.ToLower(cell)
+		for _, hk := range headerKeys {
+			if cols[hk.col] < 0 && strings.Contains(title, hk.key) {
+				cols[hk.col] = j
+				break
+			}
+		}
+	}
+	if cols[colID] < 0 || cols[colName] < 0 {
+		return nil, errors.New("в выгрузке нет колонок идентификатора и наименования уязвимости")
+	}
+
+	get := func(row []string, col int) string {
+		if col < 0 || col >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[col])
+	}
+
+	var records []Record
+	for i := header + 1; i < len(rows); i++ {
+		row := rows[i]
+		rawID := get(row, cols[colID])
+		if rawID == "" {
+			continue
+		}
+		code, err := NormalizeBDUCode(rawID)
+		if err != nil {
+			return nil, fmt.Errorf("строка %d: %w", i+1, err)
+		}
+
+		product := get(row, cols[colProduct])
+		if version := get(row, cols[colVersion]); version != "" {
+			product = strings.TrimSpace(product + " " + version)
+		}
+		records = append(records, Record{
+			Code:        code,
+			CVE:         cveIDs(get(row, cols[colOther])),
+			Name:        get(row, cols[colName]),
+			Description: get(row, cols[colDescription]),
+			Vendor:      get(row, cols[colVendor]),
+			Product:     product,
+			Remediation: get(row, cols[colRemediation]),
+			Source:      SourceBDU,
+			Published:   parseBDUDate(get(row, cols[colDate])),
+			CVSS3Vector: bduVector(get(row, cols[colCVSS3])),
+		})
+	}
+
+	return records, checkDuplicates(records)
+}
+
+type xmlVulns struct {
+	Items []struct {
+		ID          string `xml:"identifier"`
+		Name        string `xml:"name"`
+		Description string `xml:"description"`
+		Software    []struct {
+			Vendor  string `xml:"vendor"`
+			Name    string `xml:"name"`
+			Version string `xml:"version"`
+		} `xml:"vulnerable_software>soft"`
+		IdentifyDate string `xml:"identify_date"`
+		CVSS3        string `xml:"cvss3>vector"`
+		Solution     string `xml:"solution"`
+		Identifiers  []struct {
+			Type  string `xml:"type,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"identifiers>identifier"`
+	} `xml:"vul"`
+}
+
+func parseBDUXML(data []byte) ([]Record, error) {
+	var doc xmlVulns
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("разбор xml: %w", err)
+	}
+
+	records := make([]Record, 0, len(doc.Items))
+	for i, it := range doc.Items {
+		code, err := NormalizeBDUCode(it.ID)
+		if err != nil {
+			return nil, fmt.Errorf("уязвимость #%d: %w", i+1, err)
+		}
+
+		var vendors, products, other []string
+		for _, s := range it.Software {
+			vendors = appendUnique(vendors, strings.TrimSpace(s.Vendor))
+			products = appendUnique(products, strings.TrimSpace(s.Name+" "+s.Version))
+		}
+		for _, id := range it.Identifiers {
+			other = append(other, id.Value)
+		}
+
+		records = append(records, Record{
+			Code:        code,
+			CVE:         cveIDs(strings.Join(other, " ")),
+			Name:        strings.TrimSpace(it.Name),
+			Description: strings.TrimSpace(it.Description),
+			Vendor:      strings.Join(vendors, ", "),
+			Product:     strings.Join(products, ", "),
+			Remediation: strings.TrimSpace(it.Solution),
+			Source:      SourceBDU,
+			Published:   parseBDUDate(it.IdentifyDate),
+			CVSS3Vector: bduVector(it.CVSS3),
+		})
+	}
+
+	return records, checkDuplicates(records)
+}
+
+func appendUnique(list []string, s string) []string {
+	if s == "" {
+		return list
+	}
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
+
+func checkDuplicates(records []Record) error {
+	seen := make(map[string]bool, len(records))
+	for _, r := range records {
+		if seen[r.Code] {
+			return fmt.Errorf("уязвимость %s встречается в выгрузке несколько раз", r.Code)
+		}
+		seen[r.Code] = true
+	}
+	return nil
+}
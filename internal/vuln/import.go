@@ -0,0 +1,132 @@
+package vuln
+
+import (
+	"fmt"
+
+	"ib-integrator/internal/cvss"
+	"ib-integrator/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Result — итоги загрузки в каталог
+type Result struct {
+	Added     int
+	Updated   int
+	Unchanged int
+	Warnings  []string // отброшенные некорректные векторы CVSS
+}
+
+// Import добавляет новые уязвимости и обновляет существующие по коду.
+// Записи реестров объектов ссылаются на уязвимость по ID и не затрагиваются.
+func Import(db *gorm.DB, records []Record) (Result, error) {
+	var res Result
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for start := 0; start < len(records); start += 500 {
+			end := start + 500
+			if end > len(records) {
+				end = len(records)
+			}
+			batch := records[start:end]
+
+			codes := make([]string, 0, len(batch))
+			for _, r := range batch {
+				codes = append(codes, r.Code)
+			}
+			var existing []models.Vulnerability
+			if err := tx.Where("code IN ?", codes).Find(&existing).Error; err != nil {
+				return err
+			}
+			byCode := make(map[string]models.Vulnerability, len(existing))
+			for _, v := range existing {
+				byCode[v.Code] = v
+			}
+
+			for _, r := range batch {
+				v, warnings := toModel(r)
+				res.Warnings = append(res.Warnings, warnings...)
+
+				old, ok := byCode[r.Code]
+				if !ok {
+					if err := tx.Create(&v).Error; err != nil {
+						return fmt.Errorf("%s: %w", r.Code, err)
+					}
+					res.Added++
+					continue
+				}
+				if sameContent(old, v) {
+					res.Unchanged++
+					continue
+				}
+				v.ID, v.CreatedAt = old.ID, old.CreatedAt
+				if err := tx.Save(&v).Error; err != nil {
+					return fmt.Errorf("%s: %w", r.Code, err)
+				}
+				res.Updated++
+			}
+		}
+		return nil
+	})
+	return res, err
+}
+
+// toModel переносит запись в модель и считает оценки: v3.x — по вектору,
+// v4.0 — из источника после проверки вектора
+func toModel(r Record) (models.Vulnerability, []string) {
+	v := models.Vulnerability{
+		Code:        r.Code,
+		CVE:         r.CVE,
+		Name:        r.Name,
+		Description: r.Description,
+		Vendor:      r.Vendor,
+		Product:     r.Product,
+		Remediation: r.Remediation,
+		Source:      r.Source,
+		Published:   r.Published,
+	}
+	if v.Name == "" {
+		v.Name = r.Code
+	}
+
+	var warnings []string
+	if r.CVSS3Vector != "" {
+		vec, err := cvss.Parse(r.CVSS3Vector)
+		if err == nil && vec.Version == cvss.V40 {
+			err = fmt.Errorf("ожидается вектор v3.x")
+		}
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s: CVSS %q: %v", r.Code, r.CVSS3Vector, err))
+		} else {
+			v.CVSS3Vector = vec.String()
+			v.CVSS3Score, _ = vec.BaseScore()
+		}
+	}
+	if r.CVSS4Vector != "" {
+		vec, err := cvss.Parse(r.CVSS4Vector)
+		if err == nil && vec.Version != cvss.V40 {
+			err = fmt.Errorf("ожидается вектор v4.0")
+		}
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s: CVSS %q: %v", r.Code, r.CVSS4Vector, err))
+		} else {
+			v.CVSS4Vector = vec.String()
+			v.CVSS4Score, _ = vec.BaseScore()
+		}
+	}
+
+	if v.CVSS3Vector != "" || v.CVSS4Vector != "" {
+		v.Severity = cvss.Severity(v.Score())
+	}
+	return v, warnings
+}
+
+func sameContent(a, b models.Vulnerability) bool {
+	samePublished := (a.Published == nil) == (b.Published == nil) &&
+		(a.Published == nil || a.Published.Equal(*b.Published))
+	return a.CVE == b.CVE && a.Name == b.Name && a.Description == b.Description &&
+		a.Vendor == b.Vendor && a.Product == b.Product && a.Remediation == b.Remediation &&
+		a.Source == b.Source && samePublished &&
+		a.CVSS3Vector == b.CVSS3Vector && a.CVSS3Score == b.CVSS3Score &&
+		a.CVSS4Vector == b.CVSS4Vector && a.CVSS4Score == b.CVSS4Score &&
+		a.Severity == b.Severity
+}
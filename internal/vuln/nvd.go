@@ -0,0 +1,154 @@
+package vuln
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// фид NVD CVE JSON 2.0 (nvdcve-2.0-2024.json и ответ API /rest/json/cves/2.0)
+type nvdFeed struct {
+	Format          string `json:"format"`
+	Vulnerabilities []struct {
+		CVE struct {
+			ID           string `json:"id"`
+			Published    string `json:"published"`
+			VulnStatus   string `json:"vulnStatus"`
+			Descriptions []struct {
+				Lang  string `json:"lang"`
+				Value string `json:"value"`
+			} `json:"descriptions"`
+			Metrics struct {
+				V40 []nvdMetric `json:"cvssMetricV40"`
+				V31 []nvdMetric `json:"cvssMetricV31"`
+				V30 []nvdMetric `json:"cvssMetricV30"`
+			} `json:"metrics"`
+			Configurations []struct {
+				Nodes []struct {
+					CPEMatch []struct {
+						Vulnerable bool   `json:"vulnerable"`
+						Criteria   string `json:"criteria"`
+					} `json:"cpeMatch"`
+				} `json:"nodes"`
+			} `json:"configurations"`
+		} `json:"cve"`
+	} `json:"vulnerabilities"`
+}
+
+type nvdMetric struct {
+	Type     string `json:"type"` // Primary — оценка NVD, Secondary — оценка CNA
+	CVSSData struct {
+		VectorString string  `json:"vectorString"`
+		BaseScore    float64 `json:"baseScore"`
+	} `json:"cvssData"`
+}
+
+// ParseNVD разбирает фид NVD JSON 2.0. Отозванные записи (Rejected) пропускаются.
+func ParseNVD(data []byte) ([]Record, error) {
+	var feed nvdFeed
+	if err := json.Unmarshal(data, &feed); err != nil {
+		return nil, fmt.Errorf("разбор json: %w", err)
+	}
+	if feed.Format != "" && feed.Format != "NVD_CVE" {
+		return nil, fmt.Errorf("неизвестный формат фида %q", feed.Format)
+	}
+	if feed.Vulnerabilities == nil {
+		return nil, errors.New("в файле нет массива vulnerabilities — ожидается фид NVD JSON 2.0")
+	}
+
+	records := make([]Record, 0, len(feed.Vulnerabilities))
+	for i, item := range feed.Vulnerabilities {
+		cve := item.CVE
+		if !cveRe.MatchString(cve.ID) {
+			return nil, fmt.Errorf("запись #%d: некорректный идентификатор %q", i+1, cve.ID)
+		}
+		if strings.EqualFold(cve.VulnStatus, "Rejected") {
+			continue
+		}
+
+		var desc string
+		for _, d := range cve.Descriptions {
+			if d.Lang == "en" || desc == "" {
+				desc = strings.TrimSpace(d.Value)
+			}
+		}
+
+		r := Record{
+			Code:        cve.ID,
+			CVE:         cve.ID,
+			Name:        nvdName(cve.ID, desc),
+			Description: desc,
+			Source:      SourceNVD,
+			Published:   parseNVDTime(cve.Published),
+		}
+
+		if m, ok := primaryMetric(cve.Metrics.V40); ok {
+			r.CVSS4Vector = m.CVSSData.VectorString
+		}
+		if m, ok := primaryMetric(cve.Metrics.V31); ok {
+			r.CVSS3Vector = m.CVSSData.VectorString
+		} else if m, ok := primaryMetric(cve.Metrics.V30); ok {
+			r.CVSS3Vector = m.CVSSData.VectorString
+		}
+
+		// производитель и продукт — из CPE уязвимых конфигураций
+		var vendors, products []string
+		for _, conf := range cve.Configurations {
+			for _, node := range conf.Nodes {
+				for _, m := range node.CPEMatch {
+					parts := strings.Split(m.Criteria, ":")
+					if !m.Vulnerable || len(parts) < 6 {
+						continue
+					}
+					vendors = appendUnique(vendors, parts[3])
+					products = appendUnique(products, parts[4])
+				}
+			}
+		}
+		r.Vendor = strings.Join(vendors, ", ")
+		r.Product = strings.Join(products, ", ")
+
+		records = append(records, r)
+	}
+
+	return records, checkDuplicates(records)
+}
+
+// primaryMetric — оценка NVD, при её отсутствии — первая из указанных
+func primaryMetric(metrics []nvdMetric) (nvdMetric, bool) {
+	for _, m := range metrics {
+		if m.Type == "Primary" {
+			return m, true
+		}
+	}
+	if len(metrics) > 0 {
+		return metrics[0], true
+	}
+	return nvdMetric{}, false
+}
+
+// nvdName — в NVD нет отдельного наименования, берётся начало описания
+func nvdName(id, desc string) string {
+	name := desc
+	if i := strings.Index(name, ". "); i > 0 {
+		name = name[:i]
+	}
+	if r := []rune(name); len(r) > 200 {
+		name = string(r[:200]) + "…"
+	}
+	if name == "" {
+		return id
+	}
+	return name
+}
+
+func parseNVDTime(s string) *time.Time {
+	for _, layout := range []string{"2006-01-02T15:04:05.000", "2006-01-02T15:04:05", time.RFC3339} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
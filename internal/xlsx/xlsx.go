@@ -0,0 +1,197 @@
+// Package xlsx — минимальное чтение таблиц .xlsx без сторонних зависимостей.
+// Нужен для импорта выгрузок ФСТЭК (БДУ, реестр СЗИ), которые публикуются в Excel.
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+type sharedStrings struct {
+	Items []struct {
+		Text string `xml:"t"`
+		Runs []struct {
+			Text string `xml:"t"`
+		} `xml:"r"`
+	} `xml:"si"`
+}
+
+type worksheet struct {
+	Rows []struct {
+		Cells []struct {
+			Ref    string `xml:"r,attr"`
+			Type   string `xml:"t,attr"`
+			Value  string `xml:"v"`
+			Inline struct {
+				Text string `xml:"t"`
+				Runs []struct {
+					Text string `xml:"t"`
+				} `xml:"r"`
+			} `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+type workbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type relationships struct {
+	Items []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// ReadFile возвращает строки первого листа книги.
+// Пустые ячейки внутри строки заполняются пустыми строками, чтобы индексы совпадали с колонками.
+func ReadFile(filename string) ([][]string, error) {
+	zr, err := zip.OpenReader(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	return Read(&zr.Reader)
+}
+
+// Read — то же, что ReadFile, но для уже открытого архива
+func Read(zr *zip.Reader) ([][]string, error) {
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	var shared sharedStrings
+	if f, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := decode(f, &shared); err != nil {
+			return nil, fmt.Errorf("sharedStrings: %w", err)
+		}
+	}
+	strs := make([]string, len(shared.Items))
+	for i, si := range shared.Items {
+		strs[i] = joinRuns(si.Text, si.Runs)
+	}
+
+	sheetPath, err := firstSheetPath(files)
+	if err != nil {
+		return nil, err
+	}
+
+	var ws worksheet
+	if err := decode(files[sheetPath], &ws); err != nil {
+		return nil, fmt.Errorf("%s: %w", sheetPath, err)
+	}
+
+	rows := make([][]string, 0, len(ws.Rows))
+	for _, r := range ws.Rows {
+		var row []string
+		for i, c := range r.Cells {
+			// без ссылки или с некорректной ссылкой — по порядку ячейки в строке
+			col := i
+			if idx := columnIndex(c.Ref); idx >= 0 {
+				col = idx
+			}
+			for len(row) <= col {
+				row = append(row, "")
+			}
+
+			switch c.Type {
+			case "s":
+				idx, err := strconv.Atoi(strings.TrimSpace(c.Value))
+				if err == nil && idx >= 0 && idx < len(strs) {
+					row[col] = strs[idx]
+				}
+			case "inlineStr":
+				row[col] = joinRuns(c.Inline.Text, c.Inline.Runs)
+			default:
+				row[col] = c.Value
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// firstSheetPath — путь к первому листу по workbook.xml, с запасным вариантом sheet1.xml
+func firstSheetPath(files map[string]*zip.File) (string, error) {
+	const fallback = "xl/worksheets/sheet1.xml"
+
+	wbFile, ok := files["xl/workbook.xml"]
+	relsFile, relsOK := files["xl/_rels/workbook.xml.rels"]
+	if ok && relsOK {
+		var wb workbook
+		var rels relationships
+		if decode(wbFile, &wb) == nil && decode(relsFile, &rels) == nil && len(wb.Sheets) > 0 {
+			for _, r := range rels.Items {
+				if r.ID != wb.Sheets[0].RID {
+					continue
+				}
+				target := strings.TrimPrefix(r.Target, "/")
+				if !strings.HasPrefix(target, "xl/") {
+					target = path.Join("xl", target)
+				}
+				if _, ok := files[target]; ok {
+					return target, nil
+				}
+			}
+		}
+	}
+
+	if _, ok := files[fallback]; ok {
+		return fallback, nil
+	}
+	return "", errors.New("в книге не найден ни один лист")
+}
+
+func decode(f *zip.File, v interface{}) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	return xml.NewDecoder(io.LimitReader(rc, 512<<20)).Decode(v)
+}
+
+func joinRuns(text string, runs []struct {
+	Text string `xml:"t"`
+}) string {
+	if len(runs) == 0 {
+		return text
+	}
+	var sb strings.Builder
+	sb.WriteString(text)
+	for _, r := range runs {
+		sb.WriteString(r.Text)
+	}
+	return sb.String()
+}
+
+// maxColumns — столбцов на листе Excel (A…XFD)
+const maxColumns = 16384
+
+// columnIndex — "C12" -> 2; -1, если ссылка не начинается с буквы столбца или выходит за XFD
+func columnIndex(ref string) int {
+	n := 0
+	for _, ch := range ref {
+		if ch < 'A' || ch > 'Z' {
+			break
+		}
+		n = n*26 + int(ch-'A'+1)
+		if n > maxColumns {
+			return -1
+		}
+	}
+	return n - 1
+}